@@ -0,0 +1,188 @@
+package achievements
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/sirupsen/logrus"
+)
+
+// ProgressFromScore Builds the progress of a user from a newly submitted score
+func ProgressFromScore(user *db.User, score *db.RedisScore) (*Progress, error) {
+	progress := &Progress{
+		UserId:    user.Id,
+		PlayCount: getTotalPlayCount(user),
+		InClan:    score.User.ClanId > 0,
+	}
+
+	if score.Score.Failed {
+		return progress, nil
+	}
+
+	dbScore, err := db.GetScoreById(score.Score.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	progress.MaxCombo = dbScore.MaxCombo
+	progress.Grades = []string{dbScore.Grade}
+
+	if score.Map.RankedStatus == enums.RankedStatusRanked {
+		progress.MaxPassedDifficulty = score.Map.DifficultyRating
+	}
+
+	firstPlaces, err := db.GetUserFirstPlaces(user.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	progress.FirstPlaces = len(firstPlaces)
+	return progress, nil
+}
+
+// ProgressFromHistory Builds the progress of a user from all of their previous scores
+func ProgressFromHistory(user *db.User) (*Progress, error) {
+	progress := &Progress{
+		UserId:    user.Id,
+		PlayCount: getTotalPlayCount(user),
+		InClan:    user.ClanId != nil,
+	}
+
+	if user.StatsKeys4 != nil {
+		progress.MaxCombo = max(progress.MaxCombo, user.StatsKeys4.MaxCombo)
+	}
+
+	if user.StatsKeys7 != nil {
+		progress.MaxCombo = max(progress.MaxCombo, user.StatsKeys7.MaxCombo)
+	}
+
+	var err error
+
+	if progress.MaxPassedDifficulty, err = db.GetUserHighestPassedDifficulty(user.Id); err != nil {
+		return nil, err
+	}
+
+	if progress.Grades, err = db.GetUserAchievedGrades(user.Id); err != nil {
+		return nil, err
+	}
+
+	firstPlaces, err := db.GetUserFirstPlaces(user.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	progress.FirstPlaces = len(firstPlaces)
+	return progress, nil
+}
+
+// Unlock Unlocks every achievement that the user has met the rules of and hasn't unlocked yet.
+// If notify is set, the user receives a notification and an activity feed entry for each achievement.
+func Unlock(progress *Progress, notify bool) ([]*db.Achievement, error) {
+	met := Evaluate(progress, Definitions)
+
+	if len(met) == 0 {
+		return nil, nil
+	}
+
+	achievements, err := db.GetAchievements()
+
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked, err := db.GetUserUnlockedAchievementIds(progress.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var newlyUnlocked []*db.Achievement
+
+	for _, name := range met {
+		achievement := findAchievement(achievements, name)
+
+		if achievement == nil {
+			logrus.Debugf("Achievement %v is not in the database, skipping", name)
+			continue
+		}
+
+		if unlocked[achievement.Id] {
+			continue
+		}
+
+		userAchievement := &db.UserAchievement{UserId: progress.UserId, AchievementId: achievement.Id}
+
+		inserted, err := userAchievement.Insert()
+
+		if err != nil {
+			return newlyUnlocked, err
+		}
+
+		unlocked[achievement.Id] = true
+
+		// Another score of the user unlocked it first
+		if !inserted {
+			continue
+		}
+
+		newlyUnlocked = append(newlyUnlocked, achievement)
+
+		if !notify {
+			continue
+		}
+
+		if err := db.NewAchievementUnlockedNotification(progress.UserId, achievement).Insert(); err != nil {
+			return newlyUnlocked, err
+		}
+
+		if err := db.AddUserActivity(progress.UserId, db.UserActivityUnlockedAchievement, achievement.Name, -1); err != nil {
+			return newlyUnlocked, err
+		}
+	}
+
+	return newlyUnlocked, nil
+}
+
+// Backfill Unlocks the achievements of a user's previous scores without notifying them, and marks them as checked
+func Backfill(user *db.User) ([]*db.Achievement, error) {
+	progress, err := ProgressFromHistory(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked, err := Unlock(progress, false)
+
+	if err != nil {
+		return unlocked, err
+	}
+
+	return unlocked, db.UpdateUserCheckedPreviousAchievements(user.Id)
+}
+
+// Returns the total amount of plays of a user across all game modes
+func getTotalPlayCount(user *db.User) int {
+	count := 0
+
+	if user.StatsKeys4 != nil {
+		count += user.StatsKeys4.PlayCount
+	}
+
+	if user.StatsKeys7 != nil {
+		count += user.StatsKeys7.PlayCount
+	}
+
+	return count
+}
+
+func findAchievement(achievements []*db.Achievement, steamAPIName string) *db.Achievement {
+	for _, achievement := range achievements {
+		if achievement.SteamAPIName == steamAPIName {
+			return achievement
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package achievements
+
+import "slices"
+
+// Progress Everything a user has done that achievements are unlocked with.
+// It is either built from a single new score, or from all of a user's previous scores when backfilling.
+type Progress struct {
+	UserId              int
+	PlayCount           int
+	MaxCombo            int
+	MaxPassedDifficulty float64
+	Grades              []string
+	FirstPlaces         int
+	InClan              bool
+}
+
+// Rule A condition that has to be met for an achievement to be unlocked
+type Rule interface {
+	Met(p *Progress) bool
+}
+
+// PlayCountRule Play a total amount of maps across all game modes
+type PlayCountRule struct {
+	Count int
+}
+
+func (r PlayCountRule) Met(p *Progress) bool {
+	return p.PlayCount >= r.Count
+}
+
+// GradeRule Achieve any of the grades on a passed score
+type GradeRule struct {
+	Grades []string
+}
+
+func (r GradeRule) Met(p *Progress) bool {
+	for _, grade := range p.Grades {
+		if slices.Contains(r.Grades, grade) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ComboRule Reach a combo on a single score
+type ComboRule struct {
+	Combo int
+}
+
+func (r ComboRule) Met(p *Progress) bool {
+	return p.MaxCombo >= r.Combo
+}
+
+// DifficultyRule Pass a ranked map with at least a given difficulty rating
+type DifficultyRule struct {
+	Rating float64
+}
+
+func (r DifficultyRule) Met(p *Progress) bool {
+	return p.MaxPassedDifficulty >= r.Rating
+}
+
+// FirstPlaceRule Hold a given amount of first place scores
+type FirstPlaceRule struct {
+	Count int
+}
+
+func (r FirstPlaceRule) Met(p *Progress) bool {
+	return p.FirstPlaces >= r.Count
+}
+
+// ClanRule Play while being a member of a clan
+type ClanRule struct{}
+
+func (r ClanRule) Met(p *Progress) bool {
+	return p.InClan
+}
+
+// Definition Links an achievement in the database (by its Steam API name) to the rule that unlocks it
+type Definition struct {
+	SteamAPIName string
+	Rule         Rule
+}
+
+// Definitions Every achievement that is evaluated by the server.
+// Achievements in the database without a definition here are never unlocked by the server.
+// Each definition is seeded into the achievements table by a migration, so new ones need a migration too.
+var Definitions = []Definition{
+	{SteamAPIName: "PLAY_COUNT_1", Rule: PlayCountRule{Count: 1}},
+	{SteamAPIName: "PLAY_COUNT_100", Rule: PlayCountRule{Count: 100}},
+	{SteamAPIName: "PLAY_COUNT_1000", Rule: PlayCountRule{Count: 1000}},
+	{SteamAPIName: "PLAY_COUNT_10000", Rule: PlayCountRule{Count: 10000}},
+	{SteamAPIName: "GRADE_S", Rule: GradeRule{Grades: []string{"S", "SS", "X"}}},
+	{SteamAPIName: "GRADE_SS", Rule: GradeRule{Grades: []string{"SS", "X"}}},
+	{SteamAPIName: "GRADE_X", Rule: GradeRule{Grades: []string{"X"}}},
+	{SteamAPIName: "COMBO_500", Rule: ComboRule{Combo: 500}},
+	{SteamAPIName: "COMBO_1000", Rule: ComboRule{Combo: 1000}},
+	{SteamAPIName: "COMBO_2500", Rule: ComboRule{Combo: 2500}},
+	{SteamAPIName: "DIFFICULTY_10", Rule: DifficultyRule{Rating: 10}},
+	{SteamAPIName: "DIFFICULTY_20", Rule: DifficultyRule{Rating: 20}},
+	{SteamAPIName: "DIFFICULTY_30", Rule: DifficultyRule{Rating: 30}},
+	{SteamAPIName: "DIFFICULTY_40", Rule: DifficultyRule{Rating: 40}},
+	{SteamAPIName: "FIRST_PLACE_1", Rule: FirstPlaceRule{Count: 1}},
+	{SteamAPIName: "FIRST_PLACE_100", Rule: FirstPlaceRule{Count: 100}},
+	{SteamAPIName: "CLAN_MEMBER", Rule: ClanRule{}},
+}
+
+// Evaluate Returns the Steam API names of every achievement whose rule is met
+func Evaluate(p *Progress, definitions []Definition) []string {
+	var met []string
+
+	for _, definition := range definitions {
+		if definition.Rule.Met(p) {
+			met = append(met, definition.SteamAPIName)
+		}
+	}
+
+	return met
+}
@@ -0,0 +1,40 @@
+package achievements
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	progress := &Progress{
+		PlayCount:           150,
+		MaxCombo:            1200,
+		MaxPassedDifficulty: 21.5,
+		Grades:              []string{"A", "SS"},
+		FirstPlaces:         1,
+	}
+
+	met := Evaluate(progress, Definitions)
+
+	expected := []string{
+		"PLAY_COUNT_1", "PLAY_COUNT_100",
+		"GRADE_S", "GRADE_SS",
+		"COMBO_500", "COMBO_1000",
+		"DIFFICULTY_10", "DIFFICULTY_20",
+		"FIRST_PLACE_1",
+	}
+
+	if !slices.Equal(met, expected) {
+		t.Fatalf("expected %v, got %v", expected, met)
+	}
+}
+
+func TestEvaluateNoProgress(t *testing.T) {
+	if met := Evaluate(&Progress{}, Definitions); len(met) != 0 {
+		t.Fatalf("expected no achievements, got %v", met)
+	}
+
+	if met := Evaluate(&Progress{InClan: true}, Definitions); !slices.Equal(met, []string{"CLAN_MEMBER"}) {
+		t.Fatalf("expected only the clan achievement, got %v", met)
+	}
+}
@@ -0,0 +1,279 @@
+package anticheat
+
+import (
+	"fmt"
+	"github.com/Quaver/api2/config"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"github.com/Quaver/api2/replay"
+	"math"
+	"sort"
+)
+
+const (
+	// The minimum amount of hits/key presses needed before the statistical checks are run,
+	// so short maps and early quits don't get flagged.
+	minimumSampleSize int = 50
+
+	// Hits within this amount of milliseconds of an object count as an exact hit
+	exactHitTolerance float64 = 1
+
+	// Key presses that are held longer than this are long notes and don't count towards press durations
+	maxTapPressDuration float64 = 500
+
+	// The maximum distance in milliseconds between two key presses for them to count as identical
+	replayPressTolerance float64 = 1
+)
+
+type FlagType string
+
+const (
+	FlagLowUnstableRate         FlagType = "LowUnstableRate"
+	FlagExactHits               FlagType = "ExactHits"
+	FlagShortKeyPresses         FlagType = "ShortKeyPresses"
+	FlagConsistentKeyPresses    FlagType = "ConsistentKeyPresses"
+	FlagReplaySimilarToExisting FlagType = "ReplaySimilarToExisting"
+)
+
+type Flag struct {
+	Type    FlagType `json:"type"`
+	Message string   `json:"message"`
+}
+
+// ComparisonReplay An existing replay on the same map that a new replay is compared against
+type ComparisonReplay struct {
+	ScoreId int
+	Frames  []replay.Frame
+}
+
+type Result struct {
+	HitCount                  int     `json:"hit_count"`
+	MeanHitError              float64 `json:"mean_hit_error"`
+	UnstableRate              float64 `json:"unstable_rate"`
+	ExactHitRatio             float64 `json:"exact_hit_ratio"`
+	KeyPressCount             int     `json:"key_press_count"`
+	KeyPressDuration          float64 `json:"key_press_duration"`
+	KeyPressDurationDeviation float64 `json:"key_press_duration_deviation"`
+	ReplaySimilarity          float64 `json:"replay_similarity"`
+	SimilarScoreId            int     `json:"similar_score_id,omitempty"`
+	Flags                     []Flag  `json:"flags"`
+}
+
+// IsSuspicious Returns if the analysis flagged anything
+func (r *Result) IsSuspicious() bool {
+	return len(r.Flags) > 0
+}
+
+// Analyze Plays a replay on its map and checks the hit errors, key presses and similarity to other replays
+// against the configured thresholds. All times are converted to real time, so rates don't affect the checks.
+func Analyze(q *qua.Qua, frames []replay.Frame, mods enums.Mods, others []ComparisonReplay,
+	thresholds config.AntiCheat) *Result {
+	rate := float64(enums.GetRateFromMods(mods))
+	result := &Result{Flags: []Flag{}}
+
+	player := replay.NewVirtualPlayer(q, frames, mods)
+	player.PlayAll()
+
+	analyzeHitErrors(result, player.Hits, rate)
+	analyzeKeyPresses(result, frames, rate)
+	analyzeSimilarity(result, frames, others)
+
+	addFlags(result, thresholds)
+	return result
+}
+
+// Calculates the mean, unstable rate & exact hit ratio of the key press hit errors
+func analyzeHitErrors(result *Result, hits []replay.HitStat, rate float64) {
+	var errors []float64
+
+	for _, hit := range hits {
+		if hit.IsRelease || hit.Judgement == replay.JudgementMiss {
+			continue
+		}
+
+		errors = append(errors, float64(hit.Deviation)/rate)
+	}
+
+	result.HitCount = len(errors)
+
+	if len(errors) == 0 {
+		return
+	}
+
+	mean, deviation := meanAndStandardDeviation(errors)
+	exactHits := 0
+
+	for _, hitError := range errors {
+		if math.Abs(hitError) <= exactHitTolerance {
+			exactHits++
+		}
+	}
+
+	result.MeanHitError = mean
+	result.UnstableRate = deviation * 10
+	result.ExactHitRatio = float64(exactHits) / float64(len(errors))
+}
+
+// Calculates how long taps are held down for
+func analyzeKeyPresses(result *Result, frames []replay.Frame, rate float64) {
+	var durations []float64
+
+	for _, press := range getKeyPresses(frames) {
+		duration := (press.Release - press.Time) / rate
+
+		if press.Release < 0 || duration > maxTapPressDuration {
+			continue
+		}
+
+		durations = append(durations, duration)
+	}
+
+	result.KeyPressCount = len(durations)
+
+	if len(durations) == 0 {
+		return
+	}
+
+	result.KeyPressDuration, result.KeyPressDurationDeviation = meanAndStandardDeviation(durations)
+}
+
+// Finds the existing replay with the highest share of identical key presses
+func analyzeSimilarity(result *Result, frames []replay.Frame, others []ComparisonReplay) {
+	presses := getKeyPresses(frames)
+
+	for _, other := range others {
+		similarity := calculateSimilarity(presses, getKeyPresses(other.Frames))
+
+		if similarity > result.ReplaySimilarity {
+			result.ReplaySimilarity = similarity
+			result.SimilarScoreId = other.ScoreId
+		}
+	}
+}
+
+// Compares the analysis with the thresholds. A threshold of 0 disables its check.
+func addFlags(result *Result, thresholds config.AntiCheat) {
+	if result.HitCount >= minimumSampleSize {
+		if thresholds.MinUnstableRate > 0 && result.UnstableRate < thresholds.MinUnstableRate {
+			result.Flags = append(result.Flags, Flag{
+				Type:    FlagLowUnstableRate,
+				Message: fmt.Sprintf("Unstable rate of %.2f is below %.2f", result.UnstableRate, thresholds.MinUnstableRate),
+			})
+		}
+
+		if thresholds.MaxExactHitRatio > 0 && result.ExactHitRatio > thresholds.MaxExactHitRatio {
+			result.Flags = append(result.Flags, Flag{
+				Type: FlagExactHits,
+				Message: fmt.Sprintf("%.2f%% of hits are within %vms of the object",
+					result.ExactHitRatio*100, exactHitTolerance),
+			})
+		}
+	}
+
+	if result.KeyPressCount >= minimumSampleSize {
+		if thresholds.MinKeyPressDuration > 0 && result.KeyPressDuration < thresholds.MinKeyPressDuration {
+			result.Flags = append(result.Flags, Flag{
+				Type: FlagShortKeyPresses,
+				Message: fmt.Sprintf("Average key press duration of %.2fms is below %.2fms",
+					result.KeyPressDuration, thresholds.MinKeyPressDuration),
+			})
+		}
+
+		if thresholds.MinKeyPressDurationDeviation > 0 &&
+			result.KeyPressDurationDeviation < thresholds.MinKeyPressDurationDeviation {
+			result.Flags = append(result.Flags, Flag{
+				Type: FlagConsistentKeyPresses,
+				Message: fmt.Sprintf("Key press duration deviation of %.2fms is below %.2fms",
+					result.KeyPressDurationDeviation, thresholds.MinKeyPressDurationDeviation),
+			})
+		}
+	}
+
+	if thresholds.MaxReplaySimilarity > 0 && result.ReplaySimilarity > thresholds.MaxReplaySimilarity {
+		result.Flags = append(result.Flags, Flag{
+			Type: FlagReplaySimilarToExisting,
+			Message: fmt.Sprintf("%.2f%% of key presses are identical to score #%v",
+				result.ReplaySimilarity*100, result.SimilarScoreId),
+		})
+	}
+}
+
+// A single key press in a replay. Release is -1 if the key is never released.
+type keyPress struct {
+	Lane    int
+	Time    float64
+	Release float64
+}
+
+// Returns every key press in the frames, ordered by time
+func getKeyPresses(frames []replay.Frame) []keyPress {
+	var presses []keyPress
+	held := map[int]int{}
+	var previous replay.KeyPressState
+
+	for _, frame := range frames {
+		for lane := 1; lane <= 10; lane++ {
+			wasHeld := previous.IsLaneHeld(lane)
+			isHeld := frame.Keys.IsLaneHeld(lane)
+
+			switch {
+			case isHeld && !wasHeld:
+				held[lane] = len(presses)
+				presses = append(presses, keyPress{Lane: lane, Time: float64(frame.Time), Release: -1})
+			case !isHeld && wasHeld:
+				presses[held[lane]].Release = float64(frame.Time)
+			}
+		}
+
+		previous = frame.Keys
+	}
+
+	return presses
+}
+
+// Returns the share of key presses that happen in the same lane at the same time in both replays
+func calculateSimilarity(a []keyPress, b []keyPress) float64 {
+	if len(a) < minimumSampleSize || len(b) < minimumSampleSize {
+		return 0
+	}
+
+	lanes := map[int][]float64{}
+
+	for _, press := range b {
+		lanes[press.Lane] = append(lanes[press.Lane], press.Time)
+	}
+
+	for _, times := range lanes {
+		sort.Float64s(times)
+	}
+
+	matches := 0
+
+	for _, press := range a {
+		times := lanes[press.Lane]
+		index := sort.SearchFloat64s(times, press.Time-replayPressTolerance)
+
+		if index < len(times) && times[index] <= press.Time+replayPressTolerance {
+			matches++
+		}
+	}
+
+	return float64(matches) / float64(max(len(a), len(b)))
+}
+
+func meanAndStandardDeviation(values []float64) (float64, float64) {
+	sum := 0.0
+
+	for _, value := range values {
+		sum += value
+	}
+
+	mean := sum / float64(len(values))
+	variance := 0.0
+
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
@@ -0,0 +1,132 @@
+package anticheat
+
+import (
+	"github.com/Quaver/api2/config"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"github.com/Quaver/api2/replay"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+var testThresholds = config.AntiCheat{
+	Enabled:                      true,
+	MinUnstableRate:              30,
+	MaxExactHitRatio:             0.5,
+	MinKeyPressDuration:          20,
+	MinKeyPressDurationDeviation: 2,
+	MaxReplaySimilarity:          0.8,
+}
+
+func testMap() *qua.Qua {
+	q := &qua.Qua{Mode: enums.GameModeKeys4}
+
+	for i := 0; i < 200; i++ {
+		q.HitObjects = append(q.HitObjects, qua.HitObject{StartTime: 1000 + i*150, Lane: i%4 + 1})
+	}
+
+	return q
+}
+
+// Creates frames that press every object with the given offset and hold duration
+func testFrames(q *qua.Qua, offset func(i int) float32, duration func(i int) float32) []replay.Frame {
+	var frames []replay.Frame
+
+	for i, hitObject := range q.HitObjects {
+		press := float32(hitObject.StartTime) + offset(i)
+
+		frames = append(frames,
+			replay.Frame{Time: press, Keys: replay.KeyPressStateFromLanes(hitObject.Lane)},
+			replay.Frame{Time: press + duration(i), Keys: 0},
+		)
+	}
+
+	return frames
+}
+
+func flagTypes(result *Result) []FlagType {
+	var types []FlagType
+
+	for _, flag := range result.Flags {
+		types = append(types, flag.Type)
+	}
+
+	return types
+}
+
+func TestAnalyzeHumanReplay(t *testing.T) {
+	q := testMap()
+	random := rand.New(rand.NewSource(1))
+
+	frames := testFrames(q,
+		func(int) float32 { return float32(random.NormFloat64() * 12) },
+		func(int) float32 { return 60 + float32(random.NormFloat64()*15) })
+
+	result := Analyze(q, frames, 0, nil, testThresholds)
+
+	if result.IsSuspicious() {
+		t.Fatalf("expected no flags, got %+v", result.Flags)
+	}
+
+	if result.UnstableRate < 80 || result.UnstableRate > 160 {
+		t.Errorf("unexpected unstable rate: %v", result.UnstableRate)
+	}
+}
+
+func TestAnalyzeRelaxReplay(t *testing.T) {
+	q := testMap()
+
+	frames := testFrames(q,
+		func(int) float32 { return 0 },
+		func(int) float32 { return 10 })
+
+	result := Analyze(q, frames, 0, nil, testThresholds)
+	types := flagTypes(result)
+
+	for _, expected := range []FlagType{FlagLowUnstableRate, FlagExactHits, FlagShortKeyPresses, FlagConsistentKeyPresses} {
+		if !slices.Contains(types, expected) {
+			t.Errorf("expected flag %v, got %v", expected, types)
+		}
+	}
+}
+
+func TestAnalyzeRateNormalization(t *testing.T) {
+	q := testMap()
+	random := rand.New(rand.NewSource(1))
+
+	// At 2.0x, the player's presses are twice as long in song time as they are in real time
+	frames := testFrames(q,
+		func(int) float32 { return float32(random.NormFloat64() * 24) },
+		func(int) float32 { return 30 })
+
+	result := Analyze(q, frames, enums.ModSpeed20X, nil, testThresholds)
+
+	if result.KeyPressDuration < 14.9 || result.KeyPressDuration > 15.1 {
+		t.Errorf("expected a key press duration of 15ms, got %v", result.KeyPressDuration)
+	}
+}
+
+func TestAnalyzeSimilarReplay(t *testing.T) {
+	q := testMap()
+	random := rand.New(rand.NewSource(1))
+
+	frames := testFrames(q,
+		func(int) float32 { return float32(random.NormFloat64() * 12) },
+		func(int) float32 { return 60 + float32(random.NormFloat64()*15) })
+
+	different := testFrames(q,
+		func(int) float32 { return float32(random.NormFloat64() * 12) },
+		func(int) float32 { return 60 })
+
+	others := []ComparisonReplay{{ScoreId: 1, Frames: different}, {ScoreId: 2, Frames: frames}}
+	result := Analyze(q, frames, 0, others, testThresholds)
+
+	if result.SimilarScoreId != 2 || result.ReplaySimilarity != 1 {
+		t.Fatalf("expected score 2 to be identical, got #%v (%v)", result.SimilarScoreId, result.ReplaySimilarity)
+	}
+
+	if !slices.Contains(flagTypes(result), FlagReplaySimilarToExisting) {
+		t.Errorf("expected a similar replay flag, got %+v", result.Flags)
+	}
+}
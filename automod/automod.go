@@ -0,0 +1,182 @@
+package automod
+
+import (
+	"archive/zip"
+	"fmt"
+	"github.com/Quaver/api2/qua"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type IssueLevel int
+
+const (
+	IssueLevelWarning IssueLevel = iota
+	IssueLevelCritical
+)
+
+type IssueType string
+
+const (
+	IssueNoTimingPoints           IssueType = "NoTimingPoints"
+	IssueInvalidTimingPoint       IssueType = "InvalidTimingPoint"
+	IssueObjectBeforeTimingPoint  IssueType = "ObjectBeforeTimingPoint"
+	IssueOverlappingObjects       IssueType = "OverlappingObjects"
+	IssueShortLongNote            IssueType = "ShortLongNote"
+	IssueInvalidLane              IssueType = "InvalidLane"
+	IssueEmptyLane                IssueType = "EmptyLane"
+	IssueExcessiveBreakTime       IssueType = "ExcessiveBreakTime"
+	IssueShortMapLength           IssueType = "ShortMapLength"
+	IssuePreviewTimePastEnd       IssueType = "PreviewTimePastEnd"
+	IssueMissingAudioFile         IssueType = "MissingAudioFile"
+	IssueMissingBackgroundFile    IssueType = "MissingBackgroundFile"
+	IssueDifficultyNameConflict   IssueType = "DifficultyNameConflict"
+	IssueMismatchingMetadata      IssueType = "MismatchingMetadata"
+	IssueMismatchingAudioFile     IssueType = "MismatchingAudioFile"
+	IssueInvalidScrollVelocity    IssueType = "InvalidScrollVelocity"
+	IssueTimingPointAfterLastNote IssueType = "TimingPointAfterLastNote"
+)
+
+type Issue struct {
+	Type      IssueType  `json:"type"`
+	Level     IssueLevel `json:"level"`
+	Timestamp *int       `json:"timestamp"`
+	Text      string     `json:"text"`
+}
+
+type MapResult struct {
+	MapId          int      `json:"map_id"`
+	DifficultyName string   `json:"difficulty_name"`
+	Issues         []*Issue `json:"issues"`
+}
+
+type Result struct {
+	HasIssues    bool         `json:"has_issues"`
+	Maps         []*MapResult `json:"maps"`
+	MapsetIssues []*Issue     `json:"mapset_issues"`
+}
+
+// Map A .qua file and the other files that are in the same mapset archive
+type Map struct {
+	Qua   *qua.Qua
+	Files []string
+}
+
+// RunOnArchive Runs AutoMod on a mapset archive (.qp) on disk
+func RunOnArchive(archivePath string) (*Result, error) {
+	reader, err := zip.OpenReader(archivePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+	return RunOnZip(&reader.Reader)
+}
+
+// RunOnZip Runs AutoMod on every .qua file inside a mapset archive
+func RunOnZip(archive *zip.Reader) (*Result, error) {
+	var fileNames []string
+	var quaFiles []*qua.Qua
+
+	for _, file := range archive.File {
+		if strings.Contains(file.Name, "__MACOSX") || file.FileInfo().IsDir() {
+			continue
+		}
+
+		fileNames = append(fileNames, path.Base(file.Name))
+
+		if strings.ToLower(path.Ext(file.Name)) != ".qua" {
+			continue
+		}
+
+		reader, err := file.Open()
+
+		if err != nil {
+			return nil, err
+		}
+
+		fileBytes, err := io.ReadAll(reader)
+		reader.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		quaFile, err := qua.Parse(fileBytes)
+
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %v: %v", file.Name, err)
+		}
+
+		quaFiles = append(quaFiles, quaFile)
+	}
+
+	return Run(quaFiles, fileNames), nil
+}
+
+// Run Runs every AutoMod check on a mapset's .qua files, given the names of all files in the mapset
+func Run(quaFiles []*qua.Qua, fileNames []string) *Result {
+	result := &Result{
+		Maps:         []*MapResult{},
+		MapsetIssues: checkMapset(quaFiles),
+	}
+
+	for _, quaFile := range quaFiles {
+		mapResult := &MapResult{
+			MapId:          quaFile.MapId,
+			DifficultyName: quaFile.DifficultyName,
+			Issues:         []*Issue{},
+		}
+
+		for _, check := range mapChecks {
+			mapResult.Issues = append(mapResult.Issues, check(&Map{Qua: quaFile, Files: fileNames})...)
+		}
+
+		sort.SliceStable(mapResult.Issues, func(i, j int) bool {
+			return mapResult.Issues[i].Level > mapResult.Issues[j].Level
+		})
+
+		result.Maps = append(result.Maps, mapResult)
+	}
+
+	sort.SliceStable(result.Maps, func(i, j int) bool {
+		return result.Maps[i].DifficultyName < result.Maps[j].DifficultyName
+	})
+
+	result.HasIssues = result.hasCriticalIssues()
+	return result
+}
+
+// Returns if any of the issues in the mapset are critical
+func (r *Result) hasCriticalIssues() bool {
+	issues := slices.Clone(r.MapsetIssues)
+
+	for _, mapResult := range r.Maps {
+		issues = append(issues, mapResult.Issues...)
+	}
+
+	for _, issue := range issues {
+		if issue.Level == IssueLevelCritical {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newIssue(issueType IssueType, level IssueLevel, timestamp *int, format string, args ...any) *Issue {
+	return &Issue{
+		Type:      issueType,
+		Level:     level,
+		Timestamp: timestamp,
+		Text:      fmt.Sprintf(format, args...),
+	}
+}
+
+func timestamp(ms int) *int {
+	return &ms
+}
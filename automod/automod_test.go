@@ -0,0 +1,132 @@
+package automod
+
+import (
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"slices"
+	"testing"
+)
+
+// Returns a 4K map that passes every check
+func testMap(difficultyName string) *qua.Qua {
+	q := &qua.Qua{
+		AudioFile:       "audio.mp3",
+		BackgroundFile:  "bg.jpg",
+		SongPreviewTime: 5000,
+		Mode:            enums.GameModeKeys4,
+		Title:           "Title",
+		Artist:          "Artist",
+		Creator:         "Creator",
+		DifficultyName:  difficultyName,
+		TimingPoints:    []qua.TimingPoint{{StartTime: 0, BPM: 120}},
+	}
+
+	for time := 1000; time <= 41000; time += 250 {
+		q.HitObjects = append(q.HitObjects, qua.HitObject{StartTime: time, Lane: (time/250)%4 + 1})
+	}
+
+	return q
+}
+
+var testFiles = []string{"audio.mp3", "bg.jpg", "map.qua"}
+
+// Returns the types of every issue found in the map result
+func issueTypes(issues []*Issue) []IssueType {
+	types := []IssueType{}
+
+	for _, issue := range issues {
+		types = append(types, issue.Type)
+	}
+
+	return types
+}
+
+func TestRunNoIssues(t *testing.T) {
+	result := Run([]*qua.Qua{testMap("Easy"), testMap("Hard")}, testFiles)
+
+	if result.HasIssues {
+		t.Fatalf("expected no issues, got %+v %+v", result.MapsetIssues, result.Maps[0].Issues)
+	}
+
+	if len(result.Maps) != 2 || len(result.Maps[0].Issues) != 0 || len(result.MapsetIssues) != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestRunMapIssues(t *testing.T) {
+	q := testMap("Hard")
+	q.SongPreviewTime = 100_000
+	q.HitObjects = append(q.HitObjects,
+		qua.HitObject{StartTime: 1005, Lane: 1},
+		qua.HitObject{StartTime: 2000, Lane: 5},
+		qua.HitObject{StartTime: 3100, Lane: 2, EndTime: 3120},
+	)
+
+	result := Run([]*qua.Qua{q}, []string{"audio.mp3"})
+
+	if !result.HasIssues {
+		t.Fatal("expected critical issues")
+	}
+
+	types := issueTypes(result.Maps[0].Issues)
+	expected := []IssueType{
+		IssueOverlappingObjects, IssueInvalidLane, IssueShortLongNote,
+		IssuePreviewTimePastEnd, IssueMissingBackgroundFile,
+	}
+
+	for _, issueType := range expected {
+		if !slices.Contains(types, issueType) {
+			t.Errorf("expected issue %v, got %v", issueType, types)
+		}
+	}
+
+	for _, issue := range result.Maps[0].Issues {
+		if issue.Type == IssueOverlappingObjects && (issue.Timestamp == nil || *issue.Timestamp != 1005) {
+			t.Errorf("expected overlapping object at 1005 ms, got %v", issue.Timestamp)
+		}
+	}
+}
+
+func TestRunObjectBeforeTimingPoint(t *testing.T) {
+	q := testMap("Hard")
+	q.TimingPoints[0].StartTime = 1200
+
+	result := Run([]*qua.Qua{q}, testFiles)
+	types := issueTypes(result.Maps[0].Issues)
+
+	if !result.HasIssues || !slices.Contains(types, IssueObjectBeforeTimingPoint) {
+		t.Fatalf("expected objects before the first timing point, got %v", types)
+	}
+}
+
+func TestRunMapsetIssues(t *testing.T) {
+	other := testMap("hard")
+	other.Artist = "Someone Else"
+
+	result := Run([]*qua.Qua{testMap("Hard"), other}, testFiles)
+	types := issueTypes(result.MapsetIssues)
+
+	if !slices.Contains(types, IssueDifficultyNameConflict) || !slices.Contains(types, IssueMismatchingMetadata) {
+		t.Fatalf("expected difficulty name conflict and mismatching metadata, got %v", types)
+	}
+}
+
+func TestWarningsAreNotCritical(t *testing.T) {
+	q := testMap("Hard")
+
+	for i := range q.HitObjects {
+		if q.HitObjects[i].Lane == 4 {
+			q.HitObjects[i].Lane = 3
+		}
+	}
+
+	result := Run([]*qua.Qua{q}, testFiles)
+
+	if result.HasIssues {
+		t.Fatalf("expected only warnings, got %+v", result.Maps[0].Issues)
+	}
+
+	if types := issueTypes(result.Maps[0].Issues); !slices.Equal(types, []IssueType{IssueEmptyLane}) {
+		t.Fatalf("expected an empty lane warning, got %v", types)
+	}
+}
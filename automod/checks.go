@@ -0,0 +1,323 @@
+package automod
+
+import (
+	"github.com/Quaver/api2/qua"
+	"math"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	shortLongNoteMs     int = 36
+	maxBreakTimeMs      int = 30_000
+	minMapLengthMs      int = 30_000
+	overlapToleranceMs  int = 10
+	maxScrollVelocity       = 10_000
+	timingPointBufferMs     = 1_000
+)
+
+type mapCheck func(m *Map) []*Issue
+
+// The checks that are run on each individual .qua file
+var mapChecks = []mapCheck{
+	checkTimingPoints,
+	checkObjectsBeforeTimingPoint,
+	checkInvalidLanes,
+	checkOverlappingObjects,
+	checkShortLongNotes,
+	checkEmptyLanes,
+	checkBreakTime,
+	checkMapLength,
+	checkPreviewTime,
+	checkAudioFile,
+	checkBackgroundFile,
+	checkScrollVelocities,
+}
+
+// The map must have at least one timing point and none of them should come after the last object
+func checkTimingPoints(m *Map) []*Issue {
+	if len(m.Qua.TimingPoints) == 0 {
+		return []*Issue{newIssue(IssueNoTimingPoints, IssueLevelCritical, nil, "The map has no timing points.")}
+	}
+
+	issues := []*Issue{}
+	length := m.Qua.MapLength()
+
+	for _, tp := range m.Qua.TimingPoints {
+		if int(tp.StartTime) > length+timingPointBufferMs && len(m.Qua.HitObjects) > 0 {
+			issues = append(issues, newIssue(IssueTimingPointAfterLastNote, IssueLevelWarning, timestamp(int(tp.StartTime)),
+				"This timing point is placed after the last object in the map."))
+		}
+
+		if tp.BPM <= 0 || math.IsInf(float64(tp.BPM), 0) || math.IsNaN(float64(tp.BPM)) {
+			issues = append(issues, newIssue(IssueInvalidTimingPoint, IssueLevelCritical, timestamp(int(tp.StartTime)),
+				"This timing point has an invalid BPM (%v).", tp.BPM))
+		}
+	}
+
+	return issues
+}
+
+// Objects placed before the first timing point have no BPM and can't be snapped
+func checkObjectsBeforeTimingPoint(m *Map) []*Issue {
+	if len(m.Qua.TimingPoints) == 0 {
+		return nil
+	}
+
+	firstTimingPoint := m.Qua.TimingPoints[0].StartTime
+
+	for _, tp := range m.Qua.TimingPoints {
+		firstTimingPoint = min(firstTimingPoint, tp.StartTime)
+	}
+
+	issues := []*Issue{}
+
+	for _, hitObject := range m.Qua.HitObjects {
+		if float32(hitObject.StartTime) < firstTimingPoint {
+			issues = append(issues, newIssue(IssueObjectBeforeTimingPoint, IssueLevelCritical, timestamp(hitObject.StartTime),
+				"The object in lane %v is placed before the first timing point.", hitObject.Lane))
+		}
+	}
+
+	return issues
+}
+
+// Objects must be placed in a lane that exists in the game mode
+func checkInvalidLanes(m *Map) []*Issue {
+	issues := []*Issue{}
+	keyCount := m.Qua.KeyCount(true)
+
+	for _, hitObject := range m.Qua.HitObjects {
+		if hitObject.Lane < 1 || hitObject.Lane > keyCount {
+			issues = append(issues, newIssue(IssueInvalidLane, IssueLevelCritical, timestamp(hitObject.StartTime),
+				"The object is placed in lane %v, which does not exist in this game mode.", hitObject.Lane))
+		}
+	}
+
+	return issues
+}
+
+// Objects in the same lane must not be stacked on top of each other or placed inside a long note
+func checkOverlappingObjects(m *Map) []*Issue {
+	issues := []*Issue{}
+
+	for lane, objects := range getObjectsByLane(m.Qua) {
+		for i := 1; i < len(objects); i++ {
+			previous := objects[i-1]
+			current := objects[i]
+
+			previousEnd := previous.StartTime
+
+			if previous.IsLongNote() {
+				previousEnd = previous.EndTime
+			}
+
+			if current.StartTime-previousEnd <= overlapToleranceMs {
+				issues = append(issues, newIssue(IssueOverlappingObjects, IssueLevelCritical, timestamp(current.StartTime),
+					"The object in lane %v overlaps with the object at %v ms.", lane, previous.StartTime))
+			}
+		}
+	}
+
+	sortIssues(issues)
+	return issues
+}
+
+// Long notes that are too short are indistinguishable from regular notes
+func checkShortLongNotes(m *Map) []*Issue {
+	issues := []*Issue{}
+
+	for _, hitObject := range m.Qua.HitObjects {
+		if !hitObject.IsLongNote() {
+			continue
+		}
+
+		length := hitObject.EndTime - hitObject.StartTime
+
+		if length < shortLongNoteMs {
+			issues = append(issues, newIssue(IssueShortLongNote, IssueLevelCritical, timestamp(hitObject.StartTime),
+				"The long note in lane %v is %v ms long (minimum %v ms).", hitObject.Lane, length, shortLongNoteMs))
+		}
+	}
+
+	return issues
+}
+
+// Every lane in the game mode should have at least one object in it
+func checkEmptyLanes(m *Map) []*Issue {
+	issues := []*Issue{}
+	objectsByLane := getObjectsByLane(m.Qua)
+
+	for lane := 1; lane <= m.Qua.KeyCount(false); lane++ {
+		if len(objectsByLane[lane]) == 0 {
+			issues = append(issues, newIssue(IssueEmptyLane, IssueLevelWarning, nil,
+				"Lane %v does not have any objects in it.", lane))
+		}
+	}
+
+	return issues
+}
+
+// Breaks that are too long make up a large part of the map without any gameplay
+func checkBreakTime(m *Map) []*Issue {
+	issues := []*Issue{}
+
+	objects := slices.Clone(m.Qua.HitObjects)
+	sort.SliceStable(objects, func(i, j int) bool { return objects[i].StartTime < objects[j].StartTime })
+
+	var latestEnd int
+
+	for i, hitObject := range objects {
+		if i > 0 && hitObject.StartTime-latestEnd > maxBreakTimeMs {
+			issues = append(issues, newIssue(IssueExcessiveBreakTime, IssueLevelCritical, timestamp(latestEnd),
+				"There is a break of %v seconds (maximum %v seconds).",
+				(hitObject.StartTime-latestEnd)/1000, maxBreakTimeMs/1000))
+		}
+
+		latestEnd = max(latestEnd, hitObject.StartTime, hitObject.EndTime)
+	}
+
+	return issues
+}
+
+// The map must be long enough to be ranked
+func checkMapLength(m *Map) []*Issue {
+	if len(m.Qua.HitObjects) == 0 {
+		return []*Issue{newIssue(IssueShortMapLength, IssueLevelCritical, nil, "The map does not have any objects.")}
+	}
+
+	start := m.Qua.HitObjects[0].StartTime
+
+	for _, hitObject := range m.Qua.HitObjects {
+		start = min(start, hitObject.StartTime)
+	}
+
+	length := m.Qua.MapLength() - start
+
+	if length < minMapLengthMs {
+		return []*Issue{newIssue(IssueShortMapLength, IssueLevelCritical, nil,
+			"The map is %v seconds long (minimum %v seconds).", length/1000, minMapLengthMs/1000)}
+	}
+
+	return nil
+}
+
+// The song preview must start before the map ends
+func checkPreviewTime(m *Map) []*Issue {
+	if m.Qua.SongPreviewTime < 0 || m.Qua.SongPreviewTime > m.Qua.MapLength() {
+		return []*Issue{newIssue(IssuePreviewTimePastEnd, IssueLevelCritical, timestamp(m.Qua.SongPreviewTime),
+			"The song preview time is not within the length of the map.")}
+	}
+
+	return nil
+}
+
+// The audio file that the map references must be in the mapset
+func checkAudioFile(m *Map) []*Issue {
+	if m.Qua.AudioFile == "" || !containsFile(m.Files, m.Qua.AudioFile) {
+		return []*Issue{newIssue(IssueMissingAudioFile, IssueLevelCritical, nil,
+			"The audio file \"%v\" does not exist in the mapset.", m.Qua.AudioFile)}
+	}
+
+	return nil
+}
+
+// The background file that the map references must be in the mapset
+func checkBackgroundFile(m *Map) []*Issue {
+	if m.Qua.BackgroundFile == "" || !containsFile(m.Files, m.Qua.BackgroundFile) {
+		return []*Issue{newIssue(IssueMissingBackgroundFile, IssueLevelCritical, nil,
+			"The background file \"%v\" does not exist in the mapset.", m.Qua.BackgroundFile)}
+	}
+
+	return nil
+}
+
+// Scroll velocities must be finite and within a sensible range
+func checkScrollVelocities(m *Map) []*Issue {
+	issues := []*Issue{}
+
+	for _, sv := range m.Qua.ScrollVelocities {
+		multiplier := float64(sv.Multiplier)
+
+		if math.IsNaN(multiplier) || math.IsInf(multiplier, 0) || math.Abs(multiplier) > maxScrollVelocity {
+			issues = append(issues, newIssue(IssueInvalidScrollVelocity, IssueLevelCritical, timestamp(int(sv.StartTime)),
+				"This scroll velocity has an invalid multiplier (%v).", sv.Multiplier))
+		}
+	}
+
+	return issues
+}
+
+// Checks for issues across all difficulties of the mapset
+func checkMapset(quaFiles []*qua.Qua) []*Issue {
+	issues := []*Issue{}
+
+	difficultyNames := map[string]int{}
+
+	for _, quaFile := range quaFiles {
+		difficultyNames[strings.ToLower(strings.TrimSpace(quaFile.DifficultyName))]++
+	}
+
+	for name, count := range difficultyNames {
+		if count > 1 {
+			issues = append(issues, newIssue(IssueDifficultyNameConflict, IssueLevelCritical, nil,
+				"%v difficulties share the difficulty name \"%v\".", count, name))
+		}
+	}
+
+	if len(quaFiles) < 2 {
+		return issues
+	}
+
+	reference := quaFiles[0]
+
+	for _, quaFile := range quaFiles[1:] {
+		if quaFile.Artist != reference.Artist || quaFile.Title != reference.Title ||
+			quaFile.Source != reference.Source || quaFile.Tags != reference.Tags || quaFile.Creator != reference.Creator {
+			issues = append(issues, newIssue(IssueMismatchingMetadata, IssueLevelCritical, nil,
+				"The metadata of \"%v\" does not match \"%v\".", quaFile.DifficultyName, reference.DifficultyName))
+		}
+
+		if quaFile.AudioFile != reference.AudioFile {
+			issues = append(issues, newIssue(IssueMismatchingAudioFile, IssueLevelWarning, nil,
+				"\"%v\" uses a different audio file than \"%v\".", quaFile.DifficultyName, reference.DifficultyName))
+		}
+	}
+
+	return issues
+}
+
+// Returns the map's objects grouped by lane and sorted by start time
+func getObjectsByLane(q *qua.Qua) map[int][]qua.HitObject {
+	objectsByLane := map[int][]qua.HitObject{}
+
+	for _, hitObject := range q.HitObjects {
+		objectsByLane[hitObject.Lane] = append(objectsByLane[hitObject.Lane], hitObject)
+	}
+
+	for _, objects := range objectsByLane {
+		sort.SliceStable(objects, func(i, j int) bool { return objects[i].StartTime < objects[j].StartTime })
+	}
+
+	return objectsByLane
+}
+
+// Checks if a file exists in a list of file names (case-insensitive)
+func containsFile(fileNames []string, name string) bool {
+	return slices.ContainsFunc(fileNames, func(fileName string) bool {
+		return strings.EqualFold(fileName, path.Base(name))
+	})
+}
+
+// Sorts issues by their timestamp
+func sortIssues(issues []*Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Timestamp == nil || issues[j].Timestamp == nil {
+			return issues[i].Timestamp == nil && issues[j].Timestamp != nil
+		}
+
+		return *issues[i].Timestamp < *issues[j].Timestamp
+	})
+}
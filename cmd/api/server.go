@@ -5,6 +5,7 @@ import (
 	"fmt"
 	ratelimit "github.com/JGLTechnologies/gin-rate-limit"
 	"github.com/Quaver/api2/config"
+	"github.com/Quaver/api2/db"
 	"github.com/Quaver/api2/handlers"
 	"github.com/Quaver/api2/middleware"
 	"github.com/gin-contrib/cors"
@@ -90,31 +91,31 @@ func initializeRateLimiter(engine *gin.Engine) {
 // Initializes all the routes for the server.
 func initializeRoutes(engine *gin.Engine) {
 	// Clan Invites
-	engine.POST("/v2/clan/invite", middleware.RequireAuth, handlers.CreateHandler(handlers.InviteUserToClan))
-	engine.GET("/v2/clan/invite/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.GetClanInvite))
-	engine.GET("/v2/clan/invites", middleware.RequireAuth, handlers.CreateHandler(handlers.GetClanPendingInvites))
-	engine.GET("/v2/clan/user/invites", middleware.RequireAuth, handlers.CreateHandler(handlers.GetUserPendingClanInvites))
-	engine.POST("/v2/clan/invite/:id/accept", middleware.RequireAuth, handlers.CreateHandler(handlers.AcceptClanInvite))
-	engine.POST("/v2/clan/invite/:id/decline", middleware.RequireAuth, handlers.CreateHandler(handlers.DeclineClanInvite))
+	engine.POST("/v2/clan/invite", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.InviteUserToClan))
+	engine.GET("/v2/clan/invite/:id", middleware.RequireScope(db.OAuthScopeReadClans), handlers.CreateHandler(handlers.GetClanInvite))
+	engine.GET("/v2/clan/invites", middleware.RequireScope(db.OAuthScopeReadClans), handlers.CreateHandler(handlers.GetClanPendingInvites))
+	engine.GET("/v2/clan/user/invites", middleware.RequireScope(db.OAuthScopeReadClans), handlers.CreateHandler(handlers.GetUserPendingClanInvites))
+	engine.POST("/v2/clan/invite/:id/accept", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.AcceptClanInvite))
+	engine.POST("/v2/clan/invite/:id/decline", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.DeclineClanInvite))
 
 	// Clans
-	engine.POST("/v2/clan", middleware.RequireAuth, handlers.CreateHandler(handlers.CreateClan))
+	engine.POST("/v2/clan", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.CreateClan))
 	engine.GET("/v2/clan/:id", handlers.CreateHandler(handlers.GetClan))
-	engine.POST("/v2/clan/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateClan))
-	engine.DELETE("/v2/clan/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.DeleteClan))
+	engine.POST("/v2/clan/:id", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.UpdateClan))
+	engine.DELETE("/v2/clan/:id", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.DeleteClan))
 
 	// Clan Members
 	engine.GET("/v2/clan/:id/members", handlers.CreateHandler(handlers.GetClanMembers))
-	engine.POST("/v2/clan/leave", middleware.RequireAuth, handlers.CreateHandler(handlers.LeaveClan))
-	engine.POST("/v2/clan/transfer/:user_id", middleware.RequireAuth, handlers.CreateHandler(handlers.TransferClanOwnership))
-	engine.POST("/v2/clan/kick/:user_id", middleware.RequireAuth, handlers.CreateHandler(handlers.KickClanMember))
+	engine.POST("/v2/clan/leave", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.LeaveClan))
+	engine.POST("/v2/clan/transfer/:user_id", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.TransferClanOwnership))
+	engine.POST("/v2/clan/kick/:user_id", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.KickClanMember))
 
 	// Clan Activity
 	engine.GET("/v2/clan/:id/activity", handlers.CreateHandler(handlers.GetClanActivity))
 
 	// Clan Images
-	engine.POST("/v2/clan/avatar", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadClanAvatar))
-	engine.POST("/v2/clan/banner", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadClanBanner))
+	engine.POST("/v2/clan/avatar", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.UploadClanAvatar))
+	engine.POST("/v2/clan/banner", middleware.RequireScope(db.OAuthScopeWriteClans), handlers.CreateHandler(handlers.UploadClanBanner))
 
 	// Clan Scores
 	engine.GET("/v2/clan/:id/scores/:mode", handlers.CreateHandler(handlers.GetClanScoresForMode))
@@ -136,49 +137,57 @@ func initializeRoutes(engine *gin.Engine) {
 	engine.GET("/v2/user/:id/scores/:mode/grades/:grade", middleware.AllowAuth, handlers.CreateHandler(handlers.GetUserGradesForMode))
 	engine.GET("/v2/user/:id/scores/:mode/pinned", middleware.AllowAuth, handlers.CreateHandler(handlers.GetPinnedScoresForMode))
 	engine.GET("/v2/user/:id/statistics/:mode/rank", middleware.AllowAuth, handlers.CreateHandler(handlers.GetUserRankStatisticsForMode))
-	engine.POST("/v2/user/:id/ban", middleware.RequireAuth, handlers.CreateHandler(handlers.BanUser))
-	engine.POST("/v2/user/:id/unban", middleware.RequireAuth, handlers.CreateHandler(handlers.UnbanUser))
-	engine.POST("/v2/user/:id/discord", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateUserDiscordId))
-	engine.POST("/v2/user/:id/accent", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateUserAccentColor))
+	engine.GET("/v2/user/:id/statistics/:mode/history", middleware.AllowAuth, handlers.CreateHandler(handlers.GetUserRankHistoryForMode))
+	engine.GET("/v2/user/:id/compare/:other_id/:mode", middleware.AllowAuth, handlers.CreateHandler(handlers.CompareUsers))
+	engine.GET("/v2/user/:id/multiplayer", middleware.AllowAuth, handlers.CreateHandler(handlers.GetUserMultiplayerGames))
+	engine.GET("/v2/user/:id/multiplayer/:mode/rating", middleware.AllowAuth, handlers.CreateHandler(handlers.GetUserMultiplayerRating))
+	engine.POST("/v2/user/:id/ban", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.BanUser))
+	engine.POST("/v2/user/:id/unban", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UnbanUser))
+	engine.POST("/v2/user/:id/discord", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UpdateUserDiscordId))
+	engine.POST("/v2/user/:id/accent", middleware.RequireScope(db.OAuthScopeWriteProfile), handlers.CreateHandler(handlers.UpdateUserAccentColor))
 	engine.GET("/v2/user/search/:name", handlers.CreateHandler(handlers.SearchUsers))
 	engine.GET("/v2/user/team/members", handlers.CreateHandler(handlers.GetTeamMembers))
 
 	// User Profile
-	engine.POST("/v2/user/profile/aboutme", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateUserAboutMe))
-	engine.POST("/v2/user/profile/cover", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadUserProfileCover))
-	engine.GET("/v2/user/profile/username/eligible", middleware.RequireAuth, handlers.CreateHandler(handlers.GetCanUserChangeUsername))
-	engine.GET("/v2/user/profile/username/available", middleware.RequireAuth, handlers.CreateHandler(handlers.IsUsernameAvailable))
-	engine.POST("/v2/user/profile/username/", middleware.RequireAuth, handlers.CreateHandler(handlers.ChangeUserUsername))
+	engine.POST("/v2/user/profile/aboutme", middleware.RequireScope(db.OAuthScopeWriteProfile), handlers.CreateHandler(handlers.UpdateUserAboutMe))
+	engine.POST("/v2/user/profile/cover", middleware.RequireScope(db.OAuthScopeWriteProfile), handlers.CreateHandler(handlers.UploadUserProfileCover))
+	engine.GET("/v2/user/profile/username/eligible", middleware.RequireScope(db.OAuthScopeWriteProfile), handlers.CreateHandler(handlers.GetCanUserChangeUsername))
+	engine.GET("/v2/user/profile/username/available", middleware.RequireScope(db.OAuthScopeWriteProfile), handlers.CreateHandler(handlers.IsUsernameAvailable))
+	engine.POST("/v2/user/profile/username/", middleware.RequireScope(db.OAuthScopeWriteProfile), handlers.CreateHandler(handlers.ChangeUserUsername))
 
 	// User Relationships
-	engine.GET("/v2/user/relationship/friends", middleware.RequireAuth, handlers.CreateHandler(handlers.GetFriendsList))
-	engine.POST("/v2/user/:id/relationship/add", middleware.RequireAuth, handlers.CreateHandler(handlers.AddFriend))
-	engine.POST("/v2/user/:id/relationship/remove", middleware.RequireAuth, handlers.CreateHandler(handlers.RemoveFriend))
+	engine.GET("/v2/user/relationship/friends", middleware.RequireScope(db.OAuthScopeReadFriends), handlers.CreateHandler(handlers.GetFriendsList))
+	engine.POST("/v2/user/:id/relationship/add", middleware.RequireScope(db.OAuthScopeWriteFriends), handlers.CreateHandler(handlers.AddFriend))
+	engine.POST("/v2/user/:id/relationship/remove", middleware.RequireScope(db.OAuthScopeWriteFriends), handlers.CreateHandler(handlers.RemoveFriend))
 
 	// Maps
 	engine.GET("/v2/map/:id", handlers.CreateHandler(handlers.GetMap))
-	engine.POST("/v2/map", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadUnsubmittedMap))
+	engine.GET("/v2/map/:id/statistics", handlers.CreateHandler(handlers.GetMapStatistics))
+	engine.POST("/v2/map", middleware.RequireScope(db.OAuthScopeWriteMapsets), handlers.CreateHandler(handlers.UploadUnsubmittedMap))
 
 	// Map Mods
+	engine.GET("/v2/map/:id/revisions", handlers.CreateHandler(handlers.GetMapRevisions))
+	engine.GET("/v2/map/:id/diff", handlers.CreateHandler(handlers.GetMapDiff))
 	engine.GET("/v2/map/:id/mods", handlers.CreateHandler(handlers.GetMapMods))
-	engine.POST("/v2/map/:id/mods", middleware.RequireAuth, handlers.CreateHandler(handlers.SubmitMapMod))
-	engine.POST("/v2/map/:id/mods/:mod_id/status", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateMapModStatus))
-	engine.POST("/v2/map/:id/mods/:mod_id/comment", middleware.RequireAuth, handlers.CreateHandler(handlers.SubmitMapModComment))
+	engine.POST("/v2/map/:id/mods", middleware.RequireScope(db.OAuthScopeWriteComments), handlers.CreateHandler(handlers.SubmitMapMod))
+	engine.POST("/v2/map/:id/mods/:mod_id/status", middleware.RequireScope(db.OAuthScopeWriteComments), handlers.CreateHandler(handlers.UpdateMapModStatus))
+	engine.POST("/v2/map/:id/mods/:mod_id/comment", middleware.RequireScope(db.OAuthScopeWriteComments), handlers.CreateHandler(handlers.SubmitMapModComment))
 
 	// Mapsets
 	engine.GET("/v2/mapset/search", handlers.CreateHandler(handlers.GetMapsetsSearch))
-	engine.POST("/v2/mapset", middleware.RequireAuth, handlers.CreateHandler(handlers.HandleMapsetSubmission))
+	engine.POST("/v2/mapset", middleware.RequireScope(db.OAuthScopeWriteMapsets), handlers.CreateHandler(handlers.HandleMapsetSubmission))
 	engine.GET("/v2/mapset/:id", handlers.CreateHandler(handlers.GetMapsetById))
-	engine.POST("/v2/mapset/:id/delete", middleware.RequireAuth, handlers.CreateHandler(handlers.DeleteMapset))
+	engine.POST("/v2/mapset/:id/delete", middleware.RequireScope(db.OAuthScopeWriteMapsets), handlers.CreateHandler(handlers.DeleteMapset))
 	engine.GET("/v2/mapset/ranked", handlers.CreateHandler(handlers.GetRankedMapsetIds))
 	engine.GET("/v2/mapset/offsets", handlers.CreateHandler(handlers.GetMapsetOnlineOffsets))
-	engine.GET("/v2/mapset/:id/elastic", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateElasticSearchMapset))
-	engine.POST("/v2/mapset/:id/description", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateMapsetDescription))
-	engine.POST("/v2/mapset/:id/explicit", middleware.RequireAuth, handlers.CreateHandler(handlers.MarkMapsetAsExplicit))
-	engine.POST("/v2/mapset/:id/unexplicit", middleware.RequireAuth, handlers.CreateHandler(handlers.MarkMapsetAsNotExplicit))
+	engine.GET("/v2/mapset/:id/automod", handlers.CreateHandler(handlers.GetMapsetAutoMod))
+	engine.GET("/v2/mapset/:id/elastic", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UpdateElasticSearchMapset))
+	engine.POST("/v2/mapset/:id/description", middleware.RequireScope(db.OAuthScopeWriteMapsets), handlers.CreateHandler(handlers.UpdateMapsetDescription))
+	engine.POST("/v2/mapset/:id/explicit", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.MarkMapsetAsExplicit))
+	engine.POST("/v2/mapset/:id/unexplicit", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.MarkMapsetAsNotExplicit))
 
 	// Chat
-	engine.GET("/v2/chat/:channel/history", middleware.RequireAuth, handlers.CreateHandler(handlers.GetChatHistory))
+	engine.GET("/v2/chat/:channel/history", middleware.RequireScope(db.OAuthScopeReadChat), handlers.CreateHandler(handlers.GetChatHistory))
 
 	// Server
 	engine.GET("/v2/server/stats", handlers.CreateHandler(handlers.GetServerStats))
@@ -188,75 +197,109 @@ func initializeRoutes(engine *gin.Engine) {
 	// Download
 	engine.GET("/v2/download/map/:id", middleware.RequireAuthOrClientSecret, handlers.CreateHandler(handlers.DownloadQua))
 	engine.GET("/v2/download/replay/:id", middleware.RequireAuthOrClientSecret, handlers.CreateHandler(handlers.DownloadReplay))
-	engine.Match([]string{"GET", "HEAD"}, "/v2/download/mapset/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.DownloadMapset))
-	engine.POST("/v2/download/multiplayer/:id/upload", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadMultiplayerMapset))
-	engine.Match([]string{"GET", "HEAD"}, "/v2/download/multiplayer/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.DownloadMultiplayerMapset))
+	engine.Match([]string{"GET", "HEAD"}, "/v2/download/mapset/:id", middleware.RequireScope(db.OAuthScopeDownload), handlers.CreateHandler(handlers.DownloadMapset))
+	engine.POST("/v2/download/multiplayer/:id/upload", middleware.RequireScope(db.OAuthScopeDownload), handlers.CreateHandler(handlers.UploadMultiplayerMapset))
+	engine.Match([]string{"GET", "HEAD"}, "/v2/download/multiplayer/:id", middleware.RequireScope(db.OAuthScopeDownload), handlers.CreateHandler(handlers.DownloadMultiplayerMapset))
+
+	// Anti-Cheat
+	engine.GET("/v2/anticheat/reviews", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.GetScoreReviews))
+	engine.POST("/v2/anticheat/reviews/:id/status", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UpdateScoreReviewStatus))
 
 	// Logs
-	engine.POST("/v2/logs/crash", middleware.RequireAuth, handlers.CreateHandler(handlers.AddCrashLog))
+	engine.POST("/v2/logs/crash", middleware.RequireScope(db.OAuthScopePublic), handlers.CreateHandler(handlers.AddCrashLog))
 
 	// Leaderboards
 	engine.GET("/v2/leaderboard/global", handlers.CreateHandler(handlers.GetGlobalLeaderboardForMode))
 	engine.GET("/v2/leaderboard/country", handlers.CreateHandler(handlers.GetCountryLeaderboard))
 	engine.GET("/v2/leaderboard/hits", handlers.CreateHandler(handlers.GetTotalHitsLeaderboard))
 	engine.GET("/v2/leaderboard/clans", handlers.CreateHandler(handlers.GetClanLeaderboard))
+	engine.GET("/v2/leaderboard/mods", handlers.CreateHandler(handlers.GetModLeaderboard))
+	engine.GET("/v2/leaderboard/multiplayer", handlers.CreateHandler(handlers.GetMultiplayerLeaderboard))
+	engine.GET("/v2/leaderboard/season/:id", handlers.CreateHandler(handlers.GetSeasonLeaderboard))
 
-	// Scores
-	engine.GET("/v2/score/:id", middleware.AllowAuth, handlers.CreateHandler(handlers.GetScoreById))
-
-	engine.GET("/v2/scores/:md5/stats", middleware.RequireAuth, handlers.CreateHandler(handlers.GetVirtualReplayPlayerOutput))
+	// Seasons
+	engine.GET("/v2/seasons", handlers.CreateHandler(handlers.GetSeasons))
+	engine.POST("/v2/seasons", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.CreateSeason))
 
-	engine.GET("/v2/scores/:md5/global", middleware.AllowAuth, handlers.CreateHandler(handlers.GetGlobalScoresForMap))
-	engine.GET("/v2/scores/:md5/country/:country", middleware.RequireAuth, handlers.CreateHandler(handlers.GetCountryScoresForMap))
-	engine.GET("/v2/scores/:md5/mods/:mods", middleware.AllowAuth, handlers.CreateHandler(handlers.GetModifierScoresForMap))
-	engine.GET("/v2/scores/:md5/rate/:mods", middleware.AllowAuth, handlers.CreateHandler(handlers.GetRateScoresForMap))
-	engine.GET("/v2/scores/:md5/all", middleware.RequireAuth, handlers.CreateHandler(handlers.GetAllScoresForMap))
-	engine.GET("/v2/scores/:md5/friends", middleware.RequireAuth, handlers.CreateHandler(handlers.GetFriendScoresForMap))
-	engine.GET("/v2/scores/:md5/clans", middleware.AllowAuth, handlers.CreateHandler(handlers.GetClanScoresForMap))
+	// Scores
+	engine.GET("/v2/score/:id", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetScoreById))
+
+	engine.GET("/v2/scores/:md5/stats", middleware.RequireScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetVirtualReplayPlayerOutput))
+	engine.GET("/v2/scores/:md5/analytics", middleware.RequireScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetScoreTimingAnalytics))
+
+	engine.GET("/v2/scores/:md5/global", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetGlobalScoresForMap))
+	engine.GET("/v2/scores/:md5/country/:country", middleware.RequireScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetCountryScoresForMap))
+	engine.GET("/v2/scores/:md5/mods/:mods", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetModifierScoresForMap))
+	engine.GET("/v2/scores/:md5/rate/:mods", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetRateScoresForMap))
+	engine.GET("/v2/scores/:md5/all", middleware.RequireScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetAllScoresForMap))
+	engine.GET("/v2/scores/:md5/friends", middleware.RequireScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetFriendScoresForMap))
+	engine.GET("/v2/scores/:md5/clans", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetClanScoresForMap))
 	// Scores (Personal Best)
-	engine.GET("/v2/scores/:md5/:user_id/global", middleware.AllowAuth, handlers.CreateHandler(handlers.GetUserPersonalBestScoreGlobal))
-	engine.GET("/v2/scores/:md5/:user_id/all", middleware.AllowAuth, handlers.CreateHandler(handlers.GetUserPersonalBestScoreAll))
-	engine.GET("/v2/scores/:md5/:user_id/mods/:mods", middleware.AllowAuth, handlers.CreateHandler(handlers.GetUserPersonalBestScoreMods))
-	engine.GET("/v2/scores/:md5/:user_id/rate/:mods", middleware.AllowAuth, handlers.CreateHandler(handlers.GetUserPersonalBestScoreRate))
-	engine.GET("/v2/scores/:md5/:user_id/clan", middleware.AllowAuth, handlers.CreateHandler(handlers.GetClanPersonalBestScore)) // user_id is clan_id
+	engine.GET("/v2/scores/:md5/:user_id/global", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetUserPersonalBestScoreGlobal))
+	engine.GET("/v2/scores/:md5/:user_id/all", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetUserPersonalBestScoreAll))
+	engine.GET("/v2/scores/:md5/:user_id/mods/:mods", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetUserPersonalBestScoreMods))
+	engine.GET("/v2/scores/:md5/:user_id/rate/:mods", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetUserPersonalBestScoreRate))
+	engine.GET("/v2/scores/:md5/:user_id/clan", middleware.AllowScope(db.OAuthScopeReadScores), handlers.CreateHandler(handlers.GetClanPersonalBestScore)) // user_id is clan_id
 
 	// Pinned Scores
-	engine.POST("/v2/scores/:id/pin", middleware.RequireAuth, handlers.CreateHandler(handlers.CreatePinnedScore))
-	engine.POST("/v2/scores/:id/unpin", middleware.RequireAuth, handlers.CreateHandler(handlers.RemovePinnedScore))
-	engine.POST("/v2/scores/pinned/:mode/sort", middleware.RequireAuth, handlers.CreateHandler(handlers.SortPinnedScores))
+	engine.POST("/v2/scores/:id/pin", middleware.RequireScope(db.OAuthScopeWriteScores), handlers.CreateHandler(handlers.CreatePinnedScore))
+	engine.POST("/v2/scores/:id/unpin", middleware.RequireScope(db.OAuthScopeWriteScores), handlers.CreateHandler(handlers.RemovePinnedScore))
+	engine.POST("/v2/scores/pinned/:mode/sort", middleware.RequireScope(db.OAuthScopeWriteScores), handlers.CreateHandler(handlers.SortPinnedScores))
+
+	// Score Moderation
+	engine.POST("/v2/scores/:id/delete", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.DeleteScore))
+	engine.POST("/v2/scores/:id/restore", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.RestoreScore))
+	engine.POST("/v2/scores/bulk/delete", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.DeleteScoresBulk))
+	engine.POST("/v2/scores/bulk/restore", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.RestoreScoresBulk))
 
 	// Ranking Queue
 	engine.GET("/v2/ranking/config", handlers.CreateHandler(handlers.GetRankingQueueConfig))
 	engine.GET("/v2/ranking/queue/mode/:mode", handlers.CreateHandler(handlers.GetRankingQueue))
-	engine.GET("/v2/ranking/queue/supervisors/actions", middleware.RequireAuth, handlers.CreateHandler(handlers.GetRankingSupervisorActions))
+	engine.GET("/v2/ranking/queue/supervisors/actions", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.GetRankingSupervisorActions))
 	engine.GET("/v2/ranking/queue/:id", handlers.CreateHandler(handlers.GetRankingQueueMapset))
-	engine.POST("/v2/ranking/queue/:id/submit", middleware.RequireAuth, handlers.CreateHandler(handlers.SubmitMapsetToRankingQueue))
-	engine.POST("/v2/ranking/queue/:id/remove", middleware.RequireAuth, handlers.CreateHandler(handlers.RemoveFromRankingQueue))
+	engine.POST("/v2/ranking/queue/:id/submit", middleware.RequireScope(db.OAuthScopeWriteMapsets), handlers.CreateHandler(handlers.SubmitMapsetToRankingQueue))
+	engine.POST("/v2/ranking/queue/:id/remove", middleware.RequireScope(db.OAuthScopeWriteMapsets), handlers.CreateHandler(handlers.RemoveFromRankingQueue))
 	engine.GET("/v2/ranking/queue/:id/comments", handlers.CreateHandler(handlers.GetRankingQueueComments))
-	engine.POST("/v2/ranking/queue/:id/comment", middleware.RequireAuth, handlers.CreateHandler(handlers.AddRankingQueueComment))
-	engine.POST("/v2/ranking/queue/comment/:id/edit", middleware.RequireAuth, handlers.CreateHandler(handlers.EditRankingQueueComment))
-	engine.POST("/v2/ranking/queue/:id/vote", middleware.RequireAuth, handlers.CreateHandler(handlers.VoteForRankingQueueMapset))
-	engine.POST("/v2/ranking/queue/:id/deny", middleware.RequireAuth, handlers.CreateHandler(handlers.DenyRankingQueueMapset))
-	engine.POST("/v2/ranking/queue/:id/blacklist", middleware.RequireAuth, handlers.CreateHandler(handlers.BlacklistRankingQueueMapset))
-	engine.POST("/v2/ranking/queue/:id/hold", middleware.RequireAuth, handlers.CreateHandler(handlers.OnHoldRankingQueueMapset))
+	engine.POST("/v2/ranking/queue/:id/comment", middleware.RequireScope(db.OAuthScopeWriteComments), handlers.CreateHandler(handlers.AddRankingQueueComment))
+	engine.POST("/v2/ranking/queue/comment/:id/edit", middleware.RequireScope(db.OAuthScopeWriteComments), handlers.CreateHandler(handlers.EditRankingQueueComment))
+	engine.POST("/v2/ranking/queue/:id/vote", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.VoteForRankingQueueMapset))
+	engine.POST("/v2/ranking/queue/:id/deny", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.DenyRankingQueueMapset))
+	engine.POST("/v2/ranking/queue/:id/blacklist", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.BlacklistRankingQueueMapset))
+	engine.POST("/v2/ranking/queue/:id/hold", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.OnHoldRankingQueueMapset))
 
 	// Game Builds
-	engine.POST("/v2/builds", middleware.RequireAuth, handlers.CreateHandler(handlers.AddNewGameBuild))
+	engine.POST("/v2/builds", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.AddNewGameBuild))
 
 	// Multiplayer
 	engine.GET("/v2/multiplayer/games", handlers.CreateHandler(handlers.GetRecentMultiplayerGames))
 	engine.GET("/v2/multiplayer/game/:id", handlers.CreateHandler(handlers.GetMultiplayerGame))
 
+	// Tournaments
+	engine.GET("/v2/tournaments", handlers.CreateHandler(handlers.GetTournaments))
+	engine.POST("/v2/tournaments", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.CreateTournament))
+	engine.GET("/v2/tournaments/:id", handlers.CreateHandler(handlers.GetTournament))
+	engine.POST("/v2/tournaments/:id", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.UpdateTournament))
+	engine.POST("/v2/tournaments/:id/register", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.RegisterTournamentTeam))
+	engine.POST("/v2/tournaments/:id/withdraw", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.WithdrawTournamentTeam))
+	engine.GET("/v2/tournaments/:id/mappool", handlers.CreateHandler(handlers.GetTournamentMappool))
+	engine.POST("/v2/tournaments/:id/mappool", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.AddTournamentMappoolMap))
+	engine.DELETE("/v2/tournaments/:id/mappool/:pool_map_id", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.RemoveTournamentMappoolMap))
+	engine.POST("/v2/tournaments/:id/qualifiers", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.AddTournamentQualifierLobby))
+	engine.POST("/v2/tournaments/:id/seeding", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.CalculateTournamentSeeding))
+	engine.POST("/v2/tournaments/:id/bracket", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.GenerateTournamentBracket))
+	engine.GET("/v2/tournaments/:id/matches", handlers.CreateHandler(handlers.GetTournamentMatches))
+	engine.POST("/v2/tournaments/:id/matches/:match_id", middleware.RequireScope(db.OAuthScopeWriteTournaments), handlers.CreateHandler(handlers.ReportTournamentMatchResult))
+
 	// Playlists
-	engine.POST("/v2/playlists", middleware.RequireAuth, handlers.CreateHandler(handlers.CreatePlaylist))
+	engine.POST("/v2/playlists", middleware.RequireScope(db.OAuthScopeWritePlaylists), handlers.CreateHandler(handlers.CreatePlaylist))
 	engine.GET("/v2/playlists/search", handlers.CreateHandler(handlers.SearchPlaylists))
 	engine.GET("/v2/playlists/:id", handlers.CreateHandler(handlers.GetPlaylist))
-	engine.POST("/v2/playlists/:id/update", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdatePlaylist))
-	engine.DELETE("/v2/playlists/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.DeletePlaylist))
+	engine.POST("/v2/playlists/:id/update", middleware.RequireScope(db.OAuthScopeWritePlaylists), handlers.CreateHandler(handlers.UpdatePlaylist))
+	engine.DELETE("/v2/playlists/:id", middleware.RequireScope(db.OAuthScopeWritePlaylists), handlers.CreateHandler(handlers.DeletePlaylist))
 	engine.GET("/v2/playlists/:id/contains/:map_id", handlers.CreateHandler(handlers.GetPlaylistContainsMap))
-	engine.POST("/v2/playlists/:id/add/:map_id", middleware.RequireAuth, handlers.CreateHandler(handlers.AddMapToPlaylist))
-	engine.POST("/v2/playlists/:id/remove/:map_id", middleware.RequireAuth, handlers.CreateHandler(handlers.RemoveMapFromPlaylist))
-	engine.POST("/v2/playlists/:id/cover", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadPlaylistCover))
+	engine.POST("/v2/playlists/:id/add/:map_id", middleware.RequireScope(db.OAuthScopeWritePlaylists), handlers.CreateHandler(handlers.AddMapToPlaylist))
+	engine.POST("/v2/playlists/:id/remove/:map_id", middleware.RequireScope(db.OAuthScopeWritePlaylists), handlers.CreateHandler(handlers.RemoveMapFromPlaylist))
+	engine.POST("/v2/playlists/:id/cover", middleware.RequireScope(db.OAuthScopeWritePlaylists), handlers.CreateHandler(handlers.UploadPlaylistCover))
 
 	// Orders
 	engine.GET("/v2/orders", middleware.RequireAuth, handlers.CreateHandler(handlers.GetUserOrders))
@@ -285,36 +328,57 @@ func initializeRoutes(engine *gin.Engine) {
 	engine.DELETE("/v2/developers/applications/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.DeleteUserApplication))
 	engine.POST("/v2/developers/applications/:id/secret", middleware.RequireAuth, handlers.CreateHandler(handlers.ResetApplicationSecret))
 
+	// Personal Access Tokens
+	engine.GET("/v2/developers/tokens", middleware.RequireAuth, handlers.CreateHandler(handlers.GetPersonalAccessTokens))
+	engine.POST("/v2/developers/tokens", middleware.RequireAuth, handlers.CreateHandler(handlers.CreatePersonalAccessToken))
+	engine.DELETE("/v2/developers/tokens/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.RevokePersonalAccessToken))
+
+	// Sessions
+	engine.GET("/v2/sessions", middleware.RequireAuth, handlers.CreateHandler(handlers.GetSessions))
+	engine.POST("/v2/sessions", handlers.CreateHandler(handlers.CreateSession))
+	engine.POST("/v2/sessions/refresh", handlers.CreateHandler(handlers.RefreshSession))
+	engine.DELETE("/v2/sessions", middleware.RequireAuth, handlers.CreateHandler(handlers.RevokeAllSessions))
+	engine.DELETE("/v2/sessions/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.RevokeSession))
+
+	// OAuth
+	engine.GET("/v2/oauth/authorize", middleware.RequireAuth, handlers.CreateHandler(handlers.GetOAuthAuthorization))
+	engine.POST("/v2/oauth/authorize", middleware.RequireAuth, handlers.CreateHandler(handlers.AuthorizeOAuthApplication))
+	engine.POST("/v2/oauth/token", handlers.CreateHandler(handlers.CreateOAuthToken))
+	engine.POST("/v2/oauth/revoke", handlers.CreateHandler(handlers.RevokeOAuthToken))
+	engine.GET("/v2/oauth/me", middleware.RequireScope(db.OAuthScopePublic), handlers.CreateHandler(handlers.GetOAuthUser))
+	engine.GET("/v2/oauth/consents", middleware.RequireAuth, handlers.CreateHandler(handlers.GetOAuthConsents))
+	engine.DELETE("/v2/oauth/consents/:application_id", middleware.RequireAuth, handlers.CreateHandler(handlers.DeleteOAuthConsent))
+
 	// Notifications
-	engine.GET("/v2/notifications", middleware.RequireAuth, handlers.CreateHandler(handlers.GetUserNotifications))
-	engine.POST("/v2/notifications", middleware.RequireAuth, handlers.CreateHandler(handlers.CreateUserNotification))
-	engine.POST("/v2/notifications/all/read", middleware.RequireAuth, handlers.CreateHandler(handlers.MarkAllNotificationsAsRead))
-	engine.POST("/v2/notifications/:id/read", middleware.RequireAuth, handlers.CreateHandler(handlers.MarkUserNotificationAsRead))
-	engine.POST("/v2/notifications/:id/unread", middleware.RequireAuth, handlers.CreateHandler(handlers.MarkUserNotificationAsUnread))
-	engine.DELETE("/v2/notifications/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.DeleteNotification))
+	engine.GET("/v2/notifications", middleware.RequireScope(db.OAuthScopeReadNotifications), handlers.CreateHandler(handlers.GetUserNotifications))
+	engine.POST("/v2/notifications", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.CreateUserNotification))
+	engine.POST("/v2/notifications/all/read", middleware.RequireScope(db.OAuthScopeWriteNotifications), handlers.CreateHandler(handlers.MarkAllNotificationsAsRead))
+	engine.POST("/v2/notifications/:id/read", middleware.RequireScope(db.OAuthScopeWriteNotifications), handlers.CreateHandler(handlers.MarkUserNotificationAsRead))
+	engine.POST("/v2/notifications/:id/unread", middleware.RequireScope(db.OAuthScopeWriteNotifications), handlers.CreateHandler(handlers.MarkUserNotificationAsUnread))
+	engine.DELETE("/v2/notifications/:id", middleware.RequireScope(db.OAuthScopeWriteNotifications), handlers.CreateHandler(handlers.DeleteNotification))
 
 	// Artists
-	engine.POST("/v2/artists", middleware.RequireAuth, handlers.CreateHandler(handlers.InsertMusicArtist))
-	engine.POST("/v2/artists/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateMusicArtist))
-	engine.DELETE("/v2/artists/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.DeleteMusicArtist))
+	engine.POST("/v2/artists", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.InsertMusicArtist))
+	engine.POST("/v2/artists/:id", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UpdateMusicArtist))
+	engine.DELETE("/v2/artists/:id", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.DeleteMusicArtist))
 	engine.GET("/v2/artists", handlers.CreateHandler(handlers.GetMusicArtists))
 	engine.GET("/v2/artists/:id", handlers.CreateHandler(handlers.GetSingleMusicArtist))
-	engine.POST("/v2/artists/:id/avatar", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadMusicArtistAvatar))
-	engine.POST("/v2/artists/:id/banner", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadMusicArtistBanner))
-	engine.POST("/v2/artists/sort", middleware.RequireAuth, handlers.CreateHandler(handlers.SortMusicArtists))
+	engine.POST("/v2/artists/:id/avatar", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UploadMusicArtistAvatar))
+	engine.POST("/v2/artists/:id/banner", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UploadMusicArtistBanner))
+	engine.POST("/v2/artists/sort", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.SortMusicArtists))
 
 	// Albums
-	engine.POST("/v2/artists/:id/album", middleware.RequireAuth, handlers.CreateHandler(handlers.CreateMusicArtistAlbum))
-	engine.POST("/v2/artists/:id/album/sort", middleware.RequireAuth, handlers.CreateHandler(handlers.SortMusicArtistAlbums))
-	engine.POST("/v2/artists/album/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateMusicArtistAlbum))
-	engine.DELETE("/v2/artists/album/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.DeleteMusicArtistAlbum))
-	engine.POST("/v2/artists/album/:id/cover", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadMusicArtistAlbumCover))
+	engine.POST("/v2/artists/:id/album", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.CreateMusicArtistAlbum))
+	engine.POST("/v2/artists/:id/album/sort", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.SortMusicArtistAlbums))
+	engine.POST("/v2/artists/album/:id", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UpdateMusicArtistAlbum))
+	engine.DELETE("/v2/artists/album/:id", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.DeleteMusicArtistAlbum))
+	engine.POST("/v2/artists/album/:id/cover", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UploadMusicArtistAlbumCover))
 
 	// Songs
-	engine.POST("/v2/artists/album/:id/song", middleware.RequireAuth, handlers.CreateHandler(handlers.UploadMusicArtistSong))
-	engine.POST("/v2/artists/song/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.UpdateMusicArtistSong))
-	engine.DELETE("/v2/artists/song/:id", middleware.RequireAuth, handlers.CreateHandler(handlers.DeleteMusicArtistSong))
-	engine.POST("/v2/artists/album/:id/song/sort", middleware.RequireAuth, handlers.CreateHandler(handlers.SortMusicArtistSongs))
+	engine.POST("/v2/artists/album/:id/song", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UploadMusicArtistSong))
+	engine.POST("/v2/artists/song/:id", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.UpdateMusicArtistSong))
+	engine.DELETE("/v2/artists/song/:id", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.DeleteMusicArtistSong))
+	engine.POST("/v2/artists/album/:id/song/sort", middleware.RequireScope(db.OAuthScopeModeration), handlers.CreateHandler(handlers.SortMusicArtistSongs))
 
 	engine.NoRoute(func(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
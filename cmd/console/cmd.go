@@ -40,6 +40,7 @@ func init() {
 	RootCmd.AddCommand(commands.CacheClearCmd)
 	RootCmd.AddCommand(commands.CacheLeaderboardCmd)
 	RootCmd.AddCommand(commands.CacheClanLeaderboard)
+	RootCmd.AddCommand(commands.CacheModLeaderboardsCmd)
 	RootCmd.AddCommand(commands.ElasticIndexMapsets)
 	RootCmd.AddCommand(commands.PlayerDonatorCheckCmd)
 	RootCmd.AddCommand(commands.WeeklyMostPlayedMapsetsCmd)
@@ -57,6 +58,12 @@ func init() {
 	RootCmd.AddCommand(commands.ClanRecalculateCommand)
 	RootCmd.AddCommand(commands.RemoveUnrankedClanScores)
 	RootCmd.AddCommand(commands.BadgePlayerGiveCmd)
+	RootCmd.AddCommand(commands.AchievementsBackfillCmd)
+	RootCmd.AddCommand(commands.RatingsReprocessCmd)
+	RootCmd.AddCommand(commands.RatingsPromoteCmd)
+	RootCmd.AddCommand(commands.SeasonsFinalizeCmd)
+	RootCmd.AddCommand(commands.MapStatisticsCmd)
+	RootCmd.AddCommand(commands.MultiplayerRatingsCmd)
 
 	// Migrations
 	RootCmd.AddCommand(migrations.MigrationPlaylistMapsetCmd)
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"github.com/Quaver/api2/achievements"
+	"github.com/Quaver/api2/db"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+var AchievementsBackfillCmd = &cobra.Command{
+	Use:   "achievements:backfill [user id]",
+	Short: "Unlocks achievements from the previous scores of users that haven't been checked yet",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			id, err := strconv.Atoi(args[0])
+
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+
+			user, err := db.GetUserById(id)
+
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+
+			if user.CheckedPreviousAchievements {
+				logrus.Info("User has already been checked for previous achievements: ", user.Id)
+				return
+			}
+
+			if err := backfillAchievements(user); err != nil {
+				logrus.Error(err)
+			}
+
+			return
+		}
+
+		total := 0
+
+		for {
+			users, err := db.GetUsersWithUncheckedAchievements(1000)
+
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+
+			if len(users) == 0 {
+				break
+			}
+
+			for _, user := range users {
+				if err := backfillAchievements(user); err != nil {
+					logrus.Errorf("Error backfilling achievements for user #%v: %v", user.Id, err)
+					return
+				}
+			}
+
+			total += len(users)
+			logrus.Infof("Checked previous achievements for %v users", total)
+		}
+	},
+}
+
+func backfillAchievements(user *db.User) error {
+	unlocked, err := achievements.Backfill(user)
+
+	if err != nil {
+		return err
+	}
+
+	if len(unlocked) > 0 {
+		logrus.Infof("Unlocked %v achievements for user #%v", len(unlocked), user.Id)
+	}
+
+	return nil
+}
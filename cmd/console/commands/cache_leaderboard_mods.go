@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var CacheModLeaderboardsCmd = &cobra.Command{
+	Use:   "cache:leaderboard:mods [name]",
+	Short: "Rebuilds the enabled mod leaderboards in cache",
+	Run: func(cmd *cobra.Command, args []string) {
+		leaderboards := db.GetEnabledModLeaderboards()
+
+		if len(args) > 0 {
+			leaderboard := db.GetEnabledModLeaderboardByName(args[0])
+
+			if leaderboard == nil {
+				logrus.Error("Mod leaderboard is not enabled: ", args[0])
+				return
+			}
+
+			leaderboards = []*db.ModLeaderboard{leaderboard}
+		}
+
+		for _, leaderboard := range leaderboards {
+			logrus.Infof("Rebuilding mod leaderboard: %v", leaderboard.Name)
+
+			if err := leaderboard.Rebuild(); err != nil {
+				logrus.Error(err)
+				return
+			}
+		}
+
+		logrus.Info("Mod leaderboards rebuilt.")
+	},
+}
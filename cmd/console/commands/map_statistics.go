@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+var MapStatisticsCmd = &cobra.Command{
+	Use:   "maps:statistics [map id]",
+	Short: "Refreshes the statistics of maps that have new scores",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			id, err := strconv.Atoi(args[0])
+
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+
+			if err := refreshMapStatistics(id); err != nil {
+				logrus.Error(err)
+			}
+
+			return
+		}
+
+		total := 0
+
+		for {
+			ids, err := db.PopDirtyMapStatistics(100)
+
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+
+			if len(ids) == 0 {
+				break
+			}
+
+			for i, id := range ids {
+				if err := refreshMapStatistics(id); err != nil {
+					logrus.Errorf("Error refreshing statistics for map #%v: %v", id, err)
+
+					// Put the maps that weren't refreshed back, so they're picked up next time
+					for _, remaining := range ids[i:] {
+						_ = db.MarkMapStatisticsDirty(remaining)
+					}
+
+					return
+				}
+			}
+
+			total += len(ids)
+		}
+
+		logrus.Infof("Refreshed the statistics of %v maps", total)
+	},
+}
+
+func refreshMapStatistics(id int) error {
+	mapQua, err := db.GetMapById(id)
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	_, err = db.RefreshMapStatistics(mapQua)
+	return err
+}
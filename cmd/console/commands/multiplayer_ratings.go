@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"github.com/Quaver/api2/multiplayer"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var MultiplayerRatingsCmd = &cobra.Command{
+	Use:   "multiplayer:ratings [backfill]",
+	Short: "Updates multiplayer ratings with newly played matches, or recalculates them from every match",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 && strings.ToLower(args[0]) == "backfill" {
+			rated, err := multiplayer.Backfill()
+
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+
+			logrus.Infof("Recalculated multiplayer ratings from %v matches", rated)
+			return
+		}
+
+		rated, err := multiplayer.RateNewMatches()
+
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		logrus.Infof("Updated multiplayer ratings with %v new matches", rated)
+	},
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/files"
+	"github.com/Quaver/api2/ratings"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+var RatingsPromoteCmd = &cobra.Command{
+	Use:   "ratings:promote <job-id>",
+	Short: "Promotes the ratings of a reprocess job once its rank movement report has been reviewed",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			logrus.Error("You must provide the id of the job to promote")
+			return
+		}
+
+		files.CreateDirectories()
+
+		id, err := strconv.Atoi(args[0])
+
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		job, err := db.GetRatingReprocessJobById(id)
+
+		if err != nil && err != gorm.ErrRecordNotFound {
+			logrus.Error(err)
+			return
+		}
+
+		if job == nil {
+			logrus.Error("Rating reprocess job not found")
+			return
+		}
+
+		if err := ratings.Promote(job); err != nil {
+			logrus.Error(err)
+		}
+	},
+}
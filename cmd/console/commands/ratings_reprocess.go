@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"github.com/Quaver/api2/files"
+	"github.com/Quaver/api2/ratings"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var RatingsReprocessCmd = &cobra.Command{
+	Use:   "ratings:reprocess",
+	Short: "Re-rates scores that were rated with an outdated processor version into the shadow tables",
+	Run: func(cmd *cobra.Command, args []string) {
+		files.CreateDirectories()
+
+		job, err := ratings.Reprocess()
+
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		if job == nil {
+			logrus.Info("Every score is already rated with the current processor versions.")
+			return
+		}
+
+		logrus.Infof("Rank movement report for job #%v: %v", job.Id, string(job.Report))
+		logrus.Infof("Review the report, then run `ratings:promote %v` to promote the new ratings.", job.Id)
+	},
+}
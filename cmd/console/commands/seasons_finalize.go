@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/seasons"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var SeasonsFinalizeCmd = &cobra.Command{
+	Use:   "seasons:finalize",
+	Short: "Archives the standings of seasons that have ended and awards their badges",
+	Run: func(cmd *cobra.Command, args []string) {
+		ended, err := db.GetEndedUnfinalizedSeasons()
+
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		for _, season := range ended {
+			if err := seasons.Finalize(season); err != nil {
+				logrus.Errorf("Error finalizing season #%v: %v", season.Id, err)
+				return
+			}
+		}
+	},
+}
@@ -1,15 +1,18 @@
 package commands
 
 import (
-	"fmt"
 	"github.com/Quaver/api2/db"
 	"github.com/Quaver/api2/enums"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"strconv"
 	"time"
 )
 
+// The amount of time daily rank snapshots are kept for before they are compacted into weekly ones
+const userRankDailyRetention = time.Hour * 24 * 90
+
 var UserRankCmd = &cobra.Command{
 	Use:   "stats:rank",
 	Short: "Inserts the rank stats for all users ",
@@ -21,7 +24,10 @@ var UserRankCmd = &cobra.Command{
 			var users = make([]*db.User, 0)
 
 			result := db.SQL.
+				Joins("StatsKeys4").
+				Joins("StatsKeys7").
 				Where("allowed = 1").
+				Order("id ASC").
 				Limit(batchSize).
 				Offset(offset).
 				Find(&users)
@@ -35,69 +41,116 @@ var UserRankCmd = &cobra.Command{
 			}
 
 			for _, user := range users {
-				for i := 1; i <= 2; i++ {
-					key := fmt.Sprintf("quaver:leaderboard:%v", i)
-					userStr := fmt.Sprintf("%v", user.Id)
-
-					data, err := db.Redis.ZRevRankWithScore(db.RedisCtx, key, userStr).Result()
-
-					if err != nil && err != redis.Nil {
-						logrus.Error(err)
-						return
-					}
-
-					if err == redis.Nil {
-						logrus.Info("Skipping user: ", user.Id, " (no rank found)")
-						continue
-					}
-
-					switch enums.GameMode(i) {
-					case enums.GameModeKeys4:
-						rank := &db.UserRankKeys4{
-							UserId:                   user.Id,
-							Rank:                     int(data.Rank + 1),
-							OverallPerformanceRating: data.Score,
-							Timestamp:                time.Now(),
-						}
-
-						if err := db.SQL.Create(&rank).Error; err != nil {
-							logrus.Error(err)
-							return
-						}
-
-					case enums.GameModeKeys7:
-						rank := &db.UserRankKeys7{
-							UserId:                   user.Id,
-							Rank:                     int(data.Rank + 1),
-							OverallPerformanceRating: data.Score,
-							Timestamp:                time.Now(),
-						}
-
-						if err := db.SQL.Create(&rank).Error; err != nil {
-							logrus.Error(err)
-							return
-						}
-					}
-
-					logrus.Info("Inserted rank for user: ", user.Id)
+				if err := insertUserRanks(user); err != nil {
+					logrus.Error(err)
+					return
 				}
 			}
 
 			offset += batchSize
 		}
 
-		for i := 1; i <= 2; i++ {
-			table := fmt.Sprintf("user_rank_%v", enums.GetGameModeString(enums.GameMode(i)))
-			query := fmt.Sprintf("DELETE FROM %v WHERE timestamp <= (CURDATE() - INTERVAL 90 DAY)", table)
-
-			result := db.SQL.Exec(query)
+		cutoff := db.GetUserRankCompactionCutoff(time.Now(), userRankDailyRetention)
 
-			if result.Error != nil {
-				logrus.Error(result.Error)
+		for i := 1; i <= 2; i++ {
+			if err := db.CompactUserRanks(enums.GameMode(i), cutoff); err != nil {
+				logrus.Error(err)
 				return
 			}
 		}
 
+		if err := db.CompactUserRankTotalHits(cutoff); err != nil {
+			logrus.Error(err)
+			return
+		}
+
 		logrus.Info("Complete!")
 	},
 }
+
+// Inserts a snapshot of a user's ranks & stats for each game mode that they're ranked in,
+// along with their total hits rank
+func insertUserRanks(user *db.User) error {
+	userStr := strconv.Itoa(user.Id)
+
+	totalHitsRank, err := getLeaderboardRank(db.TotalHitsLeaderboardRedisKey(), userStr)
+
+	if err != nil {
+		return err
+	}
+
+	if totalHitsRank != nil {
+		if err := db.InsertUserRankTotalHits(user.Id, *totalHitsRank); err != nil {
+			return err
+		}
+	}
+
+	for i := 1; i <= 2; i++ {
+		mode := enums.GameMode(i)
+
+		data, err := db.Redis.ZRevRankWithScore(db.RedisCtx, db.GlobalLeaderboardRedisKey(mode), userStr).Result()
+
+		if err != nil && err != redis.Nil {
+			return err
+		}
+
+		if err == redis.Nil {
+			logrus.Info("Skipping user: ", user.Id, " (no rank found)")
+			continue
+		}
+
+		rank := &db.UserRank{
+			UserId:                   user.Id,
+			Rank:                     int(data.Rank + 1),
+			OverallPerformanceRating: data.Score,
+			Timestamp:                time.Now(),
+		}
+
+		if user.Country != "XX" {
+			rank.CountryRank, err = getLeaderboardRank(db.CountryLeaderboardRedisKey(user.Country, mode), userStr)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		var stats *db.UserStats
+
+		switch mode {
+		case enums.GameModeKeys4:
+			stats = (*db.UserStats)(user.StatsKeys4)
+		case enums.GameModeKeys7:
+			stats = (*db.UserStats)(user.StatsKeys7)
+		}
+
+		if stats != nil {
+			rank.OverallAccuracy = stats.OverallAccuracy
+			rank.PlayCount = stats.PlayCount
+			rank.RankedScore = stats.RankedScore
+		}
+
+		if err := db.InsertUserRank(mode, rank); err != nil {
+			return err
+		}
+
+		logrus.Info("Inserted rank for user: ", user.Id)
+	}
+
+	return nil
+}
+
+// Returns the 1-based rank of a user on a leaderboard, or nil if they aren't on it
+func getLeaderboardRank(key string, userStr string) (*int, error) {
+	rank, err := db.Redis.ZRevRank(db.RedisCtx, key, userStr).Result()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	value := int(rank + 1)
+	return &value, nil
+}
@@ -46,6 +46,10 @@ func main() {
 	registerCronJob(c, jobs.RankClanMap.Job, func() { commands.ClanRankMapCmd.Run(nil, nil) })
 	registerCronJob(c, jobs.DenyOnHoldOneMonth.Job, func() { commands.DenyOnHoldCmd.Run(nil, nil) })
 	registerCronJob(c, jobs.ClanRecalculate.Job, func() { commands.ClanRecalculateCommand.Run(nil, nil) })
+	registerCronJob(c, jobs.RatingsReprocess.Job, func() { commands.RatingsReprocessCmd.Run(nil, nil) })
+	registerCronJob(c, jobs.SeasonsFinalize.Job, func() { commands.SeasonsFinalizeCmd.Run(nil, nil) })
+	registerCronJob(c, jobs.MapStatistics.Job, func() { commands.MapStatisticsCmd.Run(nil, nil) })
+	registerCronJob(c, jobs.MultiplayerRatings.Job, func() { commands.MultiplayerRatingsCmd.Run(nil, nil) })
 
 	c.Start()
 
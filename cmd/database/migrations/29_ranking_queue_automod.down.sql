@@ -0,0 +1,2 @@
+ALTER TABLE mapset_ranking_queue
+    DROP COLUMN automod_issues;
@@ -0,0 +1,2 @@
+ALTER TABLE mapset_ranking_queue
+    ADD COLUMN automod_issues JSON NULL;
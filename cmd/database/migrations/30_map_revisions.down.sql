@@ -0,0 +1 @@
+DROP TABLE map_revisions;
\ No newline at end of file
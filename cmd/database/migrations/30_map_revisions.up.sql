@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS map_revisions
+(
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    map_id     INT         NOT NULL,
+    mapset_id  INT         NOT NULL,
+    md5        VARCHAR(32) NOT NULL,
+    timestamp  BIGINT      NOT NULL
+);
+
+CREATE INDEX map_revisions_map_id_index
+    ON map_revisions (map_id, timestamp);
+
+COMMIT;
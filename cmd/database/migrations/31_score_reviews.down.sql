@@ -0,0 +1 @@
+DROP TABLE score_reviews;
\ No newline at end of file
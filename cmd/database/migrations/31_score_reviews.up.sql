@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS score_reviews
+(
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    score_id    INT                                        NOT NULL,
+    user_id     INT                                        NOT NULL,
+    map_id      INT                                        NOT NULL,
+    analysis    JSON                                       NOT NULL,
+    status      ENUM ('Pending', 'Cleared', 'Confirmed')   NOT NULL DEFAULT 'Pending',
+    reviewer_id INT                                        NULL,
+    timestamp   BIGINT                                     NOT NULL
+);
+
+CREATE INDEX score_reviews_status_index
+    ON score_reviews (status, id);
+
+CREATE INDEX score_reviews_user_id_index
+    ON score_reviews (user_id);
+
+COMMIT;
@@ -0,0 +1,4 @@
+DROP TABLE user_stats_shadow_ratings;
+DROP TABLE scores_shadow_ratings;
+DROP TABLE maps_shadow_ratings;
+DROP TABLE rating_reprocess_jobs;
@@ -0,0 +1,43 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS rating_reprocess_jobs
+(
+    id                            INT AUTO_INCREMENT PRIMARY KEY,
+    status                        ENUM ('Processing', 'Ready', 'Promoted') NOT NULL DEFAULT 'Processing',
+    phase                         ENUM ('Maps', 'Users', 'Report')        NOT NULL DEFAULT 'Maps',
+    cursor_id                     INT                                     NOT NULL DEFAULT 0,
+    difficulty_processor_version  VARCHAR(16)                             NOT NULL,
+    performance_processor_version VARCHAR(16)                             NOT NULL,
+    report                        JSON                                    NULL,
+    started_at                    BIGINT                                  NOT NULL,
+    promoted_at                   BIGINT                                  NULL
+);
+
+CREATE TABLE IF NOT EXISTS maps_shadow_ratings
+(
+    map_id            INT         NOT NULL PRIMARY KEY,
+    md5               VARCHAR(32) NOT NULL,
+    difficulty_rating DOUBLE      NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS scores_shadow_ratings
+(
+    score_id           INT         NOT NULL PRIMARY KEY,
+    user_id            INT         NOT NULL,
+    map_md5            VARCHAR(32) NOT NULL,
+    mode               TINYINT     NOT NULL,
+    performance_rating DOUBLE      NOT NULL
+);
+
+CREATE INDEX scores_shadow_ratings_user_id_mode_index
+    ON scores_shadow_ratings (user_id, mode);
+
+CREATE TABLE IF NOT EXISTS user_stats_shadow_ratings
+(
+    user_id                    INT     NOT NULL,
+    mode                       TINYINT NOT NULL,
+    overall_performance_rating DOUBLE  NOT NULL,
+    PRIMARY KEY (user_id, mode)
+);
+
+COMMIT;
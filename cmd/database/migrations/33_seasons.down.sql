@@ -0,0 +1,2 @@
+DROP TABLE season_user_stats;
+DROP TABLE seasons;
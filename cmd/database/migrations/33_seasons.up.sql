@@ -0,0 +1,47 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS seasons
+(
+    id                   INT AUTO_INCREMENT PRIMARY KEY,
+    name                 VARCHAR(100) NOT NULL,
+    starts_at            BIGINT       NOT NULL,
+    ends_at              BIGINT       NOT NULL,
+    badge_id             INT          NULL,
+    badge_rank_threshold INT          NOT NULL DEFAULT 0,
+    finalized            TINYINT(1)   NOT NULL DEFAULT 0
+);
+
+CREATE INDEX seasons_dates_index
+    ON seasons (starts_at, ends_at);
+
+CREATE TABLE IF NOT EXISTS season_user_stats
+(
+    season_id                  INT        NOT NULL,
+    user_id                    INT        NOT NULL,
+    mode                       TINYINT    NOT NULL,
+    total_score                BIGINT     NOT NULL DEFAULT 0,
+    ranked_score               BIGINT     NOT NULL DEFAULT 0,
+    overall_accuracy           DOUBLE     NOT NULL DEFAULT 0,
+    overall_performance_rating DOUBLE     NOT NULL DEFAULT 0,
+    play_count                 INT        NOT NULL DEFAULT 0,
+    fail_count                 INT        NOT NULL DEFAULT 0,
+    max_combo                  INT        NOT NULL DEFAULT 0,
+    total_marv                 INT        NOT NULL DEFAULT 0,
+    total_perf                 INT        NOT NULL DEFAULT 0,
+    total_great                INT        NOT NULL DEFAULT 0,
+    total_good                 INT        NOT NULL DEFAULT 0,
+    total_okay                 INT        NOT NULL DEFAULT 0,
+    total_miss                 INT        NOT NULL DEFAULT 0,
+    country                    VARCHAR(2) NOT NULL,
+    final_rank                 INT        NULL,
+    final_country_rank         INT        NULL,
+    PRIMARY KEY (season_id, user_id, mode)
+);
+
+CREATE INDEX season_user_stats_final_rank_index
+    ON season_user_stats (season_id, mode, final_rank);
+
+CREATE INDEX season_user_stats_final_country_rank_index
+    ON season_user_stats (season_id, mode, country, final_country_rank);
+
+COMMIT;
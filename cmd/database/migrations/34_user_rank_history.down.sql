@@ -0,0 +1,18 @@
+DROP TABLE user_rank_total_hits;
+
+DROP INDEX user_rank_keys4_user_id_timestamp_index ON user_rank_keys4;
+DROP INDEX user_rank_keys7_user_id_timestamp_index ON user_rank_keys7;
+
+ALTER TABLE user_rank_keys4
+    DROP COLUMN country_rank,
+    DROP COLUMN overall_accuracy,
+    DROP COLUMN play_count,
+    DROP COLUMN ranked_score,
+    DROP COLUMN granularity;
+
+ALTER TABLE user_rank_keys7
+    DROP COLUMN country_rank,
+    DROP COLUMN overall_accuracy,
+    DROP COLUMN play_count,
+    DROP COLUMN ranked_score,
+    DROP COLUMN granularity;
@@ -0,0 +1,32 @@
+BEGIN;
+
+ALTER TABLE user_rank_keys4
+    ADD country_rank     INT                        NULL AFTER overall_performance_rating,
+    ADD overall_accuracy DOUBLE                     NOT NULL DEFAULT 0 AFTER country_rank,
+    ADD play_count       INT                        NOT NULL DEFAULT 0 AFTER overall_accuracy,
+    ADD ranked_score     BIGINT                     NOT NULL DEFAULT 0 AFTER play_count,
+    ADD granularity      ENUM ('Daily', 'Weekly')   NOT NULL DEFAULT 'Daily' AFTER ranked_score;
+
+ALTER TABLE user_rank_keys7
+    ADD country_rank     INT                        NULL AFTER overall_performance_rating,
+    ADD overall_accuracy DOUBLE                     NOT NULL DEFAULT 0 AFTER country_rank,
+    ADD play_count       INT                        NOT NULL DEFAULT 0 AFTER overall_accuracy,
+    ADD ranked_score     BIGINT                     NOT NULL DEFAULT 0 AFTER play_count,
+    ADD granularity      ENUM ('Daily', 'Weekly')   NOT NULL DEFAULT 'Daily' AFTER ranked_score;
+
+CREATE INDEX user_rank_keys4_user_id_timestamp_index
+    ON user_rank_keys4 (user_id, timestamp);
+
+CREATE INDEX user_rank_keys7_user_id_timestamp_index
+    ON user_rank_keys7 (user_id, timestamp);
+
+CREATE TABLE IF NOT EXISTS user_rank_total_hits
+(
+    user_id         INT                      NOT NULL,
+    total_hits_rank INT                      NOT NULL,
+    granularity     ENUM ('Daily', 'Weekly') NOT NULL DEFAULT 'Daily',
+    timestamp       DATE                     NOT NULL,
+    INDEX user_rank_total_hits_user_id_timestamp_index (user_id, timestamp)
+);
+
+COMMIT;
@@ -0,0 +1 @@
+DROP TABLE map_statistics;
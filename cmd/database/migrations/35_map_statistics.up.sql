@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS map_statistics
+(
+    map_id     INT    NOT NULL PRIMARY KEY,
+    statistics JSON   NOT NULL,
+    updated_at BIGINT NOT NULL
+);
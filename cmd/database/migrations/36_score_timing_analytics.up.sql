@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS score_timing_analytics
+(
+    score_id   INT    NOT NULL PRIMARY KEY,
+    version    INT    NOT NULL,
+    analytics  JSON   NOT NULL,
+    created_at BIGINT NOT NULL
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS score_deletions
+(
+    id                INT AUTO_INCREMENT PRIMARY KEY,
+    score_id          INT          NOT NULL,
+    user_id           INT          NOT NULL,
+    map_md5           VARCHAR(32)  NOT NULL,
+    mode              TINYINT      NOT NULL,
+    was_personal_best TINYINT(1)   NOT NULL,
+    was_donator_score TINYINT(1)   NOT NULL,
+    clan_id           INT          NULL,
+    deleted_by        INT          NOT NULL,
+    deleted_at        BIGINT       NOT NULL,
+    restored_by       INT          NULL,
+    restored_at       BIGINT       NULL,
+    notes             VARCHAR(255) NOT NULL DEFAULT '',
+    INDEX score_deletions_score_id_restored_at_index (score_id, restored_at),
+    INDEX score_deletions_user_id_index (user_id)
+);
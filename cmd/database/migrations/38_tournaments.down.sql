@@ -0,0 +1,6 @@
+DROP TABLE tournament_matches;
+DROP TABLE tournament_qualifier_lobbies;
+DROP TABLE tournament_mappool_maps;
+DROP TABLE tournament_team_members;
+DROP TABLE tournament_teams;
+DROP TABLE tournaments;
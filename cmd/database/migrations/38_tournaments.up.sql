@@ -0,0 +1,74 @@
+CREATE TABLE IF NOT EXISTS tournaments
+(
+    id                     INT AUTO_INCREMENT PRIMARY KEY,
+    name                   VARCHAR(100)                                                      NOT NULL,
+    description            TEXT                                                              NOT NULL,
+    mode                   TINYINT                                                           NOT NULL,
+    status                 ENUM ('Upcoming', 'Registration', 'Qualifiers', 'Bracket', 'Completed') NOT NULL DEFAULT 'Upcoming',
+    bracket_type           ENUM ('SingleElimination', 'DoubleElimination')                   NOT NULL,
+    team_size              INT                                                               NOT NULL DEFAULT 1,
+    registration_starts_at BIGINT                                                            NOT NULL,
+    registration_ends_at   BIGINT                                                            NOT NULL,
+    created_by             INT                                                               NOT NULL,
+    created_at             BIGINT                                                            NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tournament_teams
+(
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    tournament_id INT          NOT NULL,
+    name          VARCHAR(50)  NOT NULL,
+    captain_id    INT          NOT NULL,
+    seed          INT          NULL,
+    created_at    BIGINT       NOT NULL,
+    UNIQUE INDEX tournament_teams_tournament_id_name_uindex (tournament_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS tournament_team_members
+(
+    tournament_id INT NOT NULL,
+    user_id       INT NOT NULL,
+    team_id       INT NOT NULL,
+    PRIMARY KEY (tournament_id, user_id),
+    INDEX tournament_team_members_team_id_index (team_id)
+);
+
+CREATE TABLE IF NOT EXISTS tournament_mappool_maps
+(
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    tournament_id INT         NOT NULL,
+    stage         VARCHAR(32) NOT NULL,
+    slot          VARCHAR(8)  NOT NULL,
+    map_id        INT         NOT NULL,
+    mods          BIGINT      NOT NULL DEFAULT 0,
+    UNIQUE INDEX tournament_mappool_maps_stage_slot_uindex (tournament_id, stage, slot)
+);
+
+CREATE TABLE IF NOT EXISTS tournament_qualifier_lobbies
+(
+    tournament_id INT NOT NULL,
+    game_id       INT NOT NULL,
+    PRIMARY KEY (tournament_id, game_id)
+);
+
+CREATE TABLE IF NOT EXISTS tournament_matches
+(
+    id                    INT AUTO_INCREMENT PRIMARY KEY,
+    tournament_id         INT                                        NOT NULL,
+    bracket               ENUM ('Winners', 'Losers', 'GrandFinals') NOT NULL,
+    round                 INT                                        NOT NULL,
+    position              INT                                        NOT NULL,
+    team1_id              INT                                        NULL,
+    team2_id              INT                                        NULL,
+    team1_score           INT                                        NOT NULL DEFAULT 0,
+    team2_score           INT                                        NOT NULL DEFAULT 0,
+    winner_id             INT                                        NULL,
+    bye                   TINYINT(1)                                 NOT NULL DEFAULT 0,
+    next_match_id         INT                                        NULL,
+    next_match_slot       TINYINT                                    NULL,
+    loser_next_match_id   INT                                        NULL,
+    loser_next_match_slot TINYINT                                    NULL,
+    game_id               INT                                        NULL,
+    completed_at          BIGINT                                     NULL,
+    INDEX tournament_matches_tournament_id_index (tournament_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE multiplayer_rating_history;
+DROP TABLE multiplayer_ratings;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS multiplayer_ratings
+(
+    user_id        INT     NOT NULL,
+    mode           TINYINT NOT NULL,
+    rating         DOUBLE  NOT NULL,
+    deviation      DOUBLE  NOT NULL,
+    volatility     DOUBLE  NOT NULL,
+    matches_played INT     NOT NULL DEFAULT 0,
+    wins           INT     NOT NULL DEFAULT 0,
+    updated_at     BIGINT  NOT NULL,
+    PRIMARY KEY (user_id, mode),
+    INDEX multiplayer_ratings_mode_rating_index (mode, rating)
+);
+
+CREATE TABLE IF NOT EXISTS multiplayer_rating_history
+(
+    id        INT AUTO_INCREMENT PRIMARY KEY,
+    user_id   INT     NOT NULL,
+    mode      TINYINT NOT NULL,
+    match_id  INT     NOT NULL,
+    rating    DOUBLE  NOT NULL,
+    deviation DOUBLE  NOT NULL,
+    `change`  DOUBLE  NOT NULL,
+    timestamp BIGINT  NOT NULL,
+    INDEX multiplayer_rating_history_user_id_mode_index (user_id, mode, timestamp),
+    INDEX multiplayer_rating_history_match_id_index (match_id)
+);
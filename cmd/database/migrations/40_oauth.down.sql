@@ -0,0 +1,3 @@
+DROP TABLE oauth_consents;
+DROP TABLE oauth_tokens;
+DROP TABLE oauth_authorization_codes;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes
+(
+    id                    INT AUTO_INCREMENT PRIMARY KEY,
+    code_hash             VARCHAR(64)  NOT NULL,
+    application_id        INT          NOT NULL,
+    user_id               INT          NOT NULL,
+    redirect_url          VARCHAR(255) NOT NULL,
+    scopes                VARCHAR(255) NOT NULL,
+    code_challenge        VARCHAR(128) NOT NULL,
+    code_challenge_method VARCHAR(10)  NOT NULL,
+    expires_at            BIGINT       NOT NULL,
+    created_at            BIGINT       NOT NULL,
+    UNIQUE INDEX oauth_authorization_codes_code_hash_uindex (code_hash)
+);
+
+CREATE TABLE IF NOT EXISTS oauth_tokens
+(
+    id                       INT AUTO_INCREMENT PRIMARY KEY,
+    application_id           INT          NOT NULL,
+    user_id                  INT          NOT NULL,
+    access_token_hash        VARCHAR(64)  NOT NULL,
+    refresh_token_hash       VARCHAR(64)  NOT NULL,
+    scopes                   VARCHAR(255) NOT NULL,
+    access_token_expires_at  BIGINT       NOT NULL,
+    refresh_token_expires_at BIGINT       NOT NULL,
+    created_at               BIGINT       NOT NULL,
+    revoked_at               BIGINT       NULL,
+    UNIQUE INDEX oauth_tokens_access_token_hash_uindex (access_token_hash),
+    UNIQUE INDEX oauth_tokens_refresh_token_hash_uindex (refresh_token_hash),
+    INDEX oauth_tokens_user_id_application_id_index (user_id, application_id)
+);
+
+CREATE TABLE IF NOT EXISTS oauth_consents
+(
+    user_id        INT          NOT NULL,
+    application_id INT          NOT NULL,
+    scopes         VARCHAR(255) NOT NULL,
+    created_at     BIGINT       NOT NULL,
+    updated_at     BIGINT       NOT NULL,
+    PRIMARY KEY (user_id, application_id)
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS personal_access_tokens
+(
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT          NOT NULL,
+    name         VARCHAR(50)  NOT NULL,
+    token_hash   VARCHAR(64)  NOT NULL,
+    token_hint   VARCHAR(16)  NOT NULL,
+    scopes       VARCHAR(255) NOT NULL,
+    expires_at   BIGINT       NULL,
+    last_used_at BIGINT       NULL,
+    created_at   BIGINT       NOT NULL,
+    revoked_at   BIGINT       NULL,
+    UNIQUE INDEX personal_access_tokens_token_hash_uindex (token_hash),
+    INDEX personal_access_tokens_user_id_index (user_id)
+);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS user_sessions
+(
+    id                          INT AUTO_INCREMENT PRIMARY KEY,
+    user_id                     INT          NOT NULL,
+    refresh_token_hash          VARCHAR(64)  NOT NULL,
+    previous_refresh_token_hash VARCHAR(64)  NULL,
+    access_token_id             VARCHAR(64)  NOT NULL,
+    user_agent                  VARCHAR(255) NOT NULL,
+    ip_address                  VARCHAR(45)  NOT NULL,
+    created_at                  BIGINT       NOT NULL,
+    last_used_at                BIGINT       NOT NULL,
+    expires_at                  BIGINT       NOT NULL,
+    revoked_at                  BIGINT       NULL,
+    UNIQUE INDEX user_sessions_refresh_token_hash_uindex (refresh_token_hash),
+    INDEX user_sessions_previous_refresh_token_hash_index (previous_refresh_token_hash),
+    INDEX user_sessions_user_id_index (user_id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE applications
+    ADD COLUMN public_client TINYINT(1) NOT NULL DEFAULT 0;
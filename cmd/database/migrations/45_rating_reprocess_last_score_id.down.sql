@@ -0,0 +1,2 @@
+ALTER TABLE rating_reprocess_jobs
+    DROP COLUMN last_score_id;
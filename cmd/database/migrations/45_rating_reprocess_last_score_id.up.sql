@@ -0,0 +1,2 @@
+ALTER TABLE rating_reprocess_jobs
+    ADD COLUMN last_score_id INT NOT NULL DEFAULT 0;
@@ -0,0 +1,48 @@
+BEGIN;
+
+ALTER TABLE user_achievements
+    DROP INDEX user_achievements_user_id_achievement_id_uindex;
+
+DELETE FROM user_achievements
+WHERE achievement_id IN (SELECT id FROM achievements WHERE steam_api_name IN (
+    'PLAY_COUNT_1',
+    'PLAY_COUNT_100',
+    'PLAY_COUNT_1000',
+    'PLAY_COUNT_10000',
+    'GRADE_S',
+    'GRADE_SS',
+    'GRADE_X',
+    'COMBO_500',
+    'COMBO_1000',
+    'COMBO_2500',
+    'DIFFICULTY_10',
+    'DIFFICULTY_20',
+    'DIFFICULTY_30',
+    'DIFFICULTY_40',
+    'FIRST_PLACE_1',
+    'FIRST_PLACE_100',
+    'CLAN_MEMBER'
+));
+
+DELETE FROM achievements
+WHERE steam_api_name IN (
+    'PLAY_COUNT_1',
+    'PLAY_COUNT_100',
+    'PLAY_COUNT_1000',
+    'PLAY_COUNT_10000',
+    'GRADE_S',
+    'GRADE_SS',
+    'GRADE_X',
+    'COMBO_500',
+    'COMBO_1000',
+    'COMBO_2500',
+    'DIFFICULTY_10',
+    'DIFFICULTY_20',
+    'DIFFICULTY_30',
+    'DIFFICULTY_40',
+    'FIRST_PLACE_1',
+    'FIRST_PLACE_100',
+    'CLAN_MEMBER'
+);
+
+COMMIT;
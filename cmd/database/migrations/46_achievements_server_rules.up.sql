@@ -0,0 +1,103 @@
+BEGIN;
+
+-- Achievements that are unlocked by the server. Existing achievements with the same Steam API name are left as they are.
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'PLAY_COUNT_1', 'First Steps', 'Play your first map.', 'Easy'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'PLAY_COUNT_1');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'PLAY_COUNT_100', 'Warming Up', 'Play 100 maps.', 'Easy'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'PLAY_COUNT_100');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'PLAY_COUNT_1000', 'Regular', 'Play 1,000 maps.', 'Medium'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'PLAY_COUNT_1000');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'PLAY_COUNT_10000', 'Dedicated', 'Play 10,000 maps.', 'Hard'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'PLAY_COUNT_10000');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'GRADE_S', 'Sharp', 'Achieve an S grade or higher on a map.', 'Medium'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'GRADE_S');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'GRADE_SS', 'Sharper', 'Achieve an SS grade or higher on a map.', 'Hard'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'GRADE_SS');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'GRADE_X', 'Flawless', 'Achieve an X grade on a map.', 'Insane'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'GRADE_X');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'COMBO_500', 'Chain Reaction', 'Reach a 500 combo.', 'Easy'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'COMBO_500');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'COMBO_1000', 'Unbroken', 'Reach a 1,000 combo.', 'Medium'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'COMBO_1000');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'COMBO_2500', 'Endurance', 'Reach a 2,500 combo.', 'Hard'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'COMBO_2500');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'DIFFICULTY_10', 'Getting Serious', 'Pass a ranked map with a difficulty rating of 10 or higher.', 'Easy'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'DIFFICULTY_10');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'DIFFICULTY_20', 'Rising Star', 'Pass a ranked map with a difficulty rating of 20 or higher.', 'Medium'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'DIFFICULTY_20');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'DIFFICULTY_30', 'Top Tier', 'Pass a ranked map with a difficulty rating of 30 or higher.', 'Hard'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'DIFFICULTY_30');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'DIFFICULTY_40', 'Beyond Limits', 'Pass a ranked map with a difficulty rating of 40 or higher.', 'Insane'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'DIFFICULTY_40');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'FIRST_PLACE_1', 'Number One', 'Hold a first place score on a ranked map.', 'Medium'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'FIRST_PLACE_1');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'FIRST_PLACE_100', 'Dominator', 'Hold 100 first place scores on ranked maps.', 'Insane'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'FIRST_PLACE_100');
+
+INSERT INTO achievements (steam_api_name, name, description, difficulty)
+SELECT 'CLAN_MEMBER', 'Better Together', 'Play a map while in a clan.', 'Easy'
+FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM achievements WHERE steam_api_name = 'CLAN_MEMBER');
+
+-- Duplicate unlocks are removed, so each achievement can only be unlocked once per user
+CREATE TABLE user_achievements_unique LIKE user_achievements;
+
+ALTER TABLE user_achievements_unique
+    ADD UNIQUE INDEX user_achievements_user_id_achievement_id_uindex (user_id, achievement_id);
+
+INSERT IGNORE INTO user_achievements_unique
+SELECT * FROM user_achievements;
+
+RENAME TABLE user_achievements TO user_achievements_duplicates,
+    user_achievements_unique TO user_achievements;
+
+DROP TABLE user_achievements_duplicates;
+
+COMMIT;
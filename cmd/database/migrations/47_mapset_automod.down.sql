@@ -0,0 +1 @@
+DROP TABLE mapset_automod;
@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS mapset_automod
+(
+    mapset_id   INT         NOT NULL PRIMARY KEY,
+    package_md5 VARCHAR(32) NOT NULL,
+    has_issues  TINYINT(1)  NOT NULL DEFAULT 0,
+    result      JSON        NOT NULL,
+    timestamp   BIGINT      NOT NULL
+);
+
+COMMIT;
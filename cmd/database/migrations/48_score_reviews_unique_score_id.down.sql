@@ -0,0 +1,2 @@
+ALTER TABLE score_reviews
+    DROP INDEX score_reviews_score_id_uindex;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DELETE duplicate
+FROM score_reviews duplicate
+         JOIN score_reviews original ON original.score_id = duplicate.score_id AND original.id < duplicate.id;
+
+ALTER TABLE score_reviews
+    ADD UNIQUE INDEX score_reviews_score_id_uindex (score_id);
+
+COMMIT;
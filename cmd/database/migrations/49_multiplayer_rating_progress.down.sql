@@ -0,0 +1 @@
+DROP TABLE multiplayer_rating_progress;
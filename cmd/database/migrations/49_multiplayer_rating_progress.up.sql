@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS multiplayer_rating_progress
+(
+    id            TINYINT NOT NULL PRIMARY KEY,
+    last_match_id INT     NOT NULL
+);
+
+INSERT INTO multiplayer_rating_progress (id, last_match_id)
+SELECT 1, COALESCE(MAX(match_id), 0)
+FROM multiplayer_rating_history;
+
+COMMIT;
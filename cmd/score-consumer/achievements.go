@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/Quaver/api2/achievements"
+	"github.com/Quaver/api2/db"
+	"github.com/sirupsen/logrus"
+)
+
+// Unlocks the achievements that a new score qualifies for
+type achievementHandler struct{}
+
+func (*achievementHandler) Name() string {
+	return "achievements"
+}
+
+func (*achievementHandler) Handle(score *db.RedisScore) error {
+	user, err := db.GetUserById(score.User.Id)
+
+	if err != nil {
+		return err
+	}
+
+	// Users that haven't been backfilled yet get their previous scores checked first
+	if !user.CheckedPreviousAchievements {
+		if _, err := achievements.Backfill(user); err != nil {
+			return err
+		}
+	}
+
+	progress, err := achievements.ProgressFromScore(user, score)
+
+	if err != nil {
+		return err
+	}
+
+	unlocked, err := achievements.Unlock(progress, true)
+
+	if err != nil {
+		return err
+	}
+
+	for _, achievement := range unlocked {
+		logrus.Infof("%v (#%v) unlocked achievement: %v", user.Username, user.Id, achievement.Name)
+	}
+
+	return nil
+}
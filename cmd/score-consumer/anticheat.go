@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/Quaver/api2/anticheat"
+	"github.com/Quaver/api2/config"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/files"
+	"github.com/Quaver/api2/qua"
+	"github.com/Quaver/api2/replay"
+	"github.com/Quaver/api2/webhooks"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+// Flags suspicious replays of ranked personal bests for review
+type antiCheatHandler struct{}
+
+func (*antiCheatHandler) Name() string {
+	return "anti_cheat"
+}
+
+func (*antiCheatHandler) Handle(score *db.RedisScore) error {
+	return analyzeScoreReplay(score)
+}
+
+// Analyzes the replay of a new ranked personal best and stores a review if anything suspicious is found
+func analyzeScoreReplay(score *db.RedisScore) error {
+	if !config.Instance.AntiCheat.Enabled {
+		return nil
+	}
+
+	if score.Score.Failed || !score.Score.PersonalBest || score.Map.RankedStatus != enums.RankedStatusRanked {
+		return nil
+	}
+
+	dbScore, err := db.GetScoreById(score.Score.Id)
+
+	if err != nil {
+		return err
+	}
+
+	mapQua, err := db.GetMapById(score.Map.Id)
+
+	if err != nil {
+		return err
+	}
+
+	quaPath, err := files.CacheQuaFile(mapQua)
+
+	if err != nil {
+		return err
+	}
+
+	quaBytes, err := os.ReadFile(quaPath)
+
+	if err != nil {
+		return err
+	}
+
+	quaFile, err := qua.Parse(quaBytes)
+
+	if err != nil {
+		return err
+	}
+
+	scoreReplay, err := loadReplay(score.Score.Id)
+
+	if err != nil {
+		return err
+	}
+
+	comparisons, err := getComparisonReplays(score)
+
+	if err != nil {
+		return err
+	}
+
+	result := anticheat.Analyze(quaFile, scoreReplay.Frames, enums.Mods(dbScore.Modifiers), comparisons,
+		config.Instance.AntiCheat)
+
+	if !result.IsSuspicious() {
+		return nil
+	}
+
+	analysis, err := json.Marshal(result)
+
+	if err != nil {
+		return err
+	}
+
+	review := &db.ScoreReview{
+		ScoreId:  score.Score.Id,
+		UserId:   score.User.Id,
+		MapId:    score.Map.Id,
+		Analysis: analysis,
+	}
+
+	inserted, err := review.Insert()
+
+	if err != nil {
+		return err
+	}
+
+	// The score was already flagged when this message was delivered before
+	if !inserted {
+		return nil
+	}
+
+	logrus.Warnf("Score #%v by %v (#%v) was flagged by the anti-cheat (review #%v)",
+		score.Score.Id, score.User.Username, score.User.Id, review.Id)
+
+	var flags []string
+
+	for _, flag := range result.Flags {
+		flags = append(flags, flag.Message)
+	}
+
+	_ = webhooks.SendAntiCheatWebhook(score, mapQua, review, flags)
+	return nil
+}
+
+// Loads the replays of the top scores on the map from other users
+func getComparisonReplays(score *db.RedisScore) ([]anticheat.ComparisonReplay, error) {
+	count := config.Instance.AntiCheat.ReplaysToCompare
+
+	if count <= 0 || config.Instance.AntiCheat.MaxReplaySimilarity <= 0 {
+		return nil, nil
+	}
+
+	scores, err := db.GetGlobalScoresForMap(score.Map.MD5, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var comparisons []anticheat.ComparisonReplay
+
+	for _, existing := range scores {
+		if len(comparisons) >= count {
+			break
+		}
+
+		if existing.UserId == score.User.Id {
+			continue
+		}
+
+		existingReplay, err := loadReplay(existing.Id)
+
+		if err != nil {
+			logrus.Warnf("Could not load replay of score #%v for comparison: %v", existing.Id, err)
+			continue
+		}
+
+		comparisons = append(comparisons, anticheat.ComparisonReplay{ScoreId: existing.Id, Frames: existingReplay.Frames})
+	}
+
+	return comparisons, nil
+}
+
+// Downloads and parses the replay of a score
+func loadReplay(scoreId int) (*replay.Replay, error) {
+	path, err := files.CacheReplay(scoreId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return replay.ParseFile(path, true)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/webhooks"
+	"gorm.io/gorm"
+)
+
+// Updates the clan scores, stats & first places of the user's clan
+type clanScoreHandler struct{}
+
+func (*clanScoreHandler) Name() string {
+	return "clan_score"
+}
+
+func (*clanScoreHandler) Handle(score *db.RedisScore) error {
+	return insertClanScore(score, nil)
+}
+
+func (*clanScoreHandler) HandleSteps(score *db.RedisScore, steps *ScoreHandlerSteps) error {
+	return insertClanScore(score, steps)
+}
+
+// The clan that held first place on a map before a score was submitted
+type clanFirstPlace struct {
+	ClanId        int     `json:"clan_id"`
+	OverallRating float64 `json:"overall_rating"`
+}
+
+// Handles the insertion of a new clan score. The clan score & leaderboards are recalculated, so they can run again
+// when the message is redelivered, but the stats, activities, notifications & webhooks are only sent once.
+func insertClanScore(score *db.RedisScore, steps *ScoreHandlerSteps) error {
+	if !score.Map.ClanRanked || score.User.ClanId <= 0 {
+		return nil
+	}
+
+	if score.Score.Failed {
+		return nil
+	}
+
+	var previousFirstPlace *clanFirstPlace
+
+	err := steps.Remember("previous_first_place", &previousFirstPlace, func() error {
+		scoreboard, err := db.GetClanScoreboardForMap(score.Map.MD5)
+
+		if err != nil {
+			return err
+		}
+
+		if len(scoreboard) > 0 {
+			previousFirstPlace = &clanFirstPlace{
+				ClanId:        scoreboard[0].ClanId,
+				OverallRating: scoreboard[0].OverallRating,
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	existingScore, err := db.GetClanScore(score.Map.MD5, score.User.ClanId)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	newScore, err := db.CalculateClanScore(score.Map.MD5, score.User.ClanId, score.Map.GameMode)
+
+	if err != nil {
+		return err
+	}
+
+	// Make sure the id is the same on the newly calculated score, so it can be upserted properly.
+	if existingScore != nil {
+		newScore.Id = existingScore.Id
+	}
+
+	if err := db.SQL.Save(&newScore).Error; err != nil {
+		return err
+	}
+
+	// The judgement totals are incremented by the new score, so they can only be added once
+	err = steps.Run("stats", func() error {
+		return db.RecalculateClanStats(score.User.ClanId, score.Map.GameMode, score)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Set values needed for the webhook
+	clan, err := db.GetClanById(score.User.ClanId)
+
+	if err != nil {
+		return err
+	}
+
+	if err := db.UpdateAllClanLeaderboards(clan); err != nil {
+		return err
+	}
+
+	mapQua, err := db.GetMapById(score.Map.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := handleClanFirstPlaces(score, clan, mapQua, newScore, previousFirstPlace, steps); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func handleClanFirstPlaces(score *db.RedisScore, clan *db.Clan, mapQua *db.MapQua, newScore *db.ClanScore,
+	previousFirstPlace *clanFirstPlace, steps *ScoreHandlerSteps) error {
+	if previousFirstPlace != nil && (previousFirstPlace.ClanId == score.User.ClanId ||
+		newScore.OverallRating <= previousFirstPlace.OverallRating) {
+		return nil
+	}
+
+	var previousScore *db.ClanScore
+
+	if previousFirstPlace != nil {
+		previousClan, err := db.GetClanById(previousFirstPlace.ClanId)
+
+		if err != nil {
+			return err
+		}
+
+		previousScore = &db.ClanScore{
+			ClanId:        previousFirstPlace.ClanId,
+			OverallRating: previousFirstPlace.OverallRating,
+			Clan:          previousClan,
+		}
+	}
+
+	err := steps.Run("first_place_webhook", func() error {
+		_ = webhooks.SendClanFirstPlaceWebhook(clan, mapQua, newScore, previousScore)
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Add activity for clan who won first place
+	err = steps.Run("first_place_activity", func() error {
+		firstPlaceActivity := db.NewClanActivity(clan.Id, db.ClanActivityAchievedFirstPlace, score.User.Id)
+		firstPlaceActivity.MapId = mapQua.Id
+		firstPlaceActivity.Message = mapQua.String()
+
+		return firstPlaceActivity.Insert()
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = steps.Run("first_place_publish", func() error {
+		return SendClanFirstPLaceToRedis(clan.Id, true, mapQua)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if previousFirstPlace == nil {
+		return nil
+	}
+
+	// Add activity for clan who lost first place
+	err = steps.Run("lost_activity", func() error {
+		lostActivity := db.NewClanActivity(previousFirstPlace.ClanId, db.ClanActivityLostFirstPlace, score.User.Id)
+		lostActivity.MapId = mapQua.Id
+		lostActivity.Message = mapQua.String()
+
+		return lostActivity.Insert()
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = steps.Run("lost_publish", func() error {
+		return SendClanFirstPLaceToRedis(previousFirstPlace.ClanId, false, mapQua)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Add lost notification for clan members
+	clanMembers, err := db.GetUsersInClan(previousFirstPlace.ClanId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, member := range clanMembers {
+		err := steps.Run(fmt.Sprintf("lost_notification:%v", member.Id), func() error {
+			return db.NewClanLostFirstPlaceNotification(mapQua, member.Id).Insert()
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func SendClanFirstPLaceToRedis(clanId int, won bool, mapQua *db.MapQua) error {
+	type payload struct {
+		ClanId int  `json:"clan_id"`
+		Won    bool `json:"won"`
+		Map    struct {
+			Id             int    `json:"id"`
+			Artist         string `json:"artist"`
+			Title          string `json:"title"`
+			DifficultyName string `json:"difficulty_name"`
+			CreatorName    string `json:"creator_name"`
+			Mode           string `json:"mode"`
+		} `json:"map"`
+	}
+
+	data := payload{}
+	data.ClanId = clanId
+	data.Won = won
+	data.Map.Id = mapQua.Id
+	data.Map.Artist = mapQua.Artist
+	data.Map.Title = mapQua.Title
+	data.Map.DifficultyName = mapQua.DifficultyName
+	data.Map.CreatorName = mapQua.CreatorUsername
+	data.Map.Mode = enums.GetShorthandGameModeString(mapQua.GameMode)
+
+	dataStr, _ := json.Marshal(data)
+
+	if err := db.Redis.Publish(db.RedisCtx, "quaver:clan_first_place", dataStr).Err(); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ScoreHandler Reacts to a new score from the score stream
+type ScoreHandler interface {
+	// Name Unique name of the handler, used to track its progress and metrics
+	Name() string
+	Handle(score *db.RedisScore) error
+}
+
+// SteppedScoreHandler A handler that isn't idempotent. The steps it finishes are recorded for each message,
+// so only the remaining steps run when the message is redelivered.
+type SteppedScoreHandler interface {
+	ScoreHandler
+	HandleSteps(score *db.RedisScore, steps *ScoreHandlerSteps) error
+}
+
+// RetryPolicy How many times a handler is attempted during a single delivery of a score, and how long to wait
+// between attempts. Handlers that still fail leave the message pending, so it's retried on a later delivery.
+type RetryPolicy struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+// ScoreHandlerMetrics Counters of a single handler since the consumer started
+type ScoreHandlerMetrics struct {
+	Handled       int64         `json:"handled"`
+	Failed        int64         `json:"failed"`
+	Retries       int64         `json:"retries"`
+	TotalDuration time.Duration `json:"total_duration"`
+}
+
+// Persists which handlers have already handled a message, so they aren't run twice when it's redelivered
+type scoreHandlerProgress interface {
+	Completed(messageId string) (map[string]bool, error)
+	MarkCompleted(messageId string, handler string) error
+	Clear(messageId string) error
+	Step(messageId string, step string) (string, bool, error)
+	CompleteStep(messageId string, step string, data string) error
+	RecordMetrics(handler string, failed bool, duration time.Duration) error
+}
+
+type registeredScoreHandler struct {
+	Handler ScoreHandler
+	Order   int
+	Retry   RetryPolicy
+}
+
+// ScoreHandlerRegistry Runs every registered handler for each score in order.
+// A failing handler doesn't stop the ones after it from running.
+type ScoreHandlerRegistry struct {
+	handlers []*registeredScoreHandler
+	progress scoreHandlerProgress
+	metrics  map[string]*ScoreHandlerMetrics
+	mutex    sync.Mutex
+}
+
+// NewScoreHandlerRegistry Creates a registry that stores handler progress & metrics in Redis
+func NewScoreHandlerRegistry() *ScoreHandlerRegistry {
+	return newScoreHandlerRegistry(&redisScoreHandlerProgress{})
+}
+
+func newScoreHandlerRegistry(progress scoreHandlerProgress) *ScoreHandlerRegistry {
+	return &ScoreHandlerRegistry{
+		progress: progress,
+		metrics:  map[string]*ScoreHandlerMetrics{},
+	}
+}
+
+// Register Adds a handler to the registry. Handlers with a lower order run first.
+func (r *ScoreHandlerRegistry) Register(order int, handler ScoreHandler, retry RetryPolicy) {
+	if retry.Attempts < 1 {
+		retry.Attempts = 1
+	}
+
+	for _, existing := range r.handlers {
+		if existing.Handler.Name() == handler.Name() {
+			logrus.Panicf("Score handler %v is already registered", handler.Name())
+		}
+	}
+
+	r.handlers = append(r.handlers, &registeredScoreHandler{Handler: handler, Order: order, Retry: retry})
+	r.metrics[handler.Name()] = &ScoreHandlerMetrics{}
+
+	sort.SliceStable(r.handlers, func(i, j int) bool { return r.handlers[i].Order < r.handlers[j].Order })
+}
+
+// Handle Runs the handlers that haven't handled the message yet.
+// Returns an error if any of them failed, in which case the message should be retried later.
+func (r *ScoreHandlerRegistry) Handle(messageId string, score *db.RedisScore) error {
+	completed, err := r.progress.Completed(messageId)
+
+	if err != nil {
+		return fmt.Errorf("error retrieving handler progress: %w", err)
+	}
+
+	var errs []error
+
+	for _, registered := range r.handlers {
+		name := registered.Handler.Name()
+
+		if completed[name] {
+			continue
+		}
+
+		if err := r.run(registered, messageId, score); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", name, err))
+			continue
+		}
+
+		if err := r.progress.MarkCompleted(messageId, name); err != nil {
+			logrus.Errorf("Error marking score handler %v as completed: %v", name, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if err := r.progress.Clear(messageId); err != nil {
+		logrus.Error("Error clearing score handler progress: ", err)
+	}
+
+	return nil
+}
+
+// Forget Removes the progress of a message that won't be handled again
+func (r *ScoreHandlerRegistry) Forget(messageId string) {
+	if err := r.progress.Clear(messageId); err != nil {
+		logrus.Error("Error clearing score handler progress: ", err)
+	}
+}
+
+// Metrics Returns a copy of the metrics of every handler
+func (r *ScoreHandlerRegistry) Metrics() map[string]ScoreHandlerMetrics {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	metrics := map[string]ScoreHandlerMetrics{}
+
+	for name, m := range r.metrics {
+		metrics[name] = *m
+	}
+
+	return metrics
+}
+
+// Runs a handler with its retry policy. Panics are recovered, so a single handler can't take down the consumer.
+func (r *ScoreHandlerRegistry) run(registered *registeredScoreHandler, messageId string, score *db.RedisScore) error {
+	steps := &ScoreHandlerSteps{
+		messageId: messageId,
+		handler:   registered.Handler.Name(),
+		progress:  r.progress,
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= registered.Retry.Attempts; attempt++ {
+		if attempt > 1 {
+			r.updateMetrics(registered.Handler.Name(), func(m *ScoreHandlerMetrics) { m.Retries++ })
+			time.Sleep(registered.Retry.Backoff * time.Duration(attempt-1))
+		}
+
+		start := time.Now()
+		err = safelyHandleScore(registered.Handler, score, steps)
+		duration := time.Since(start)
+
+		r.updateMetrics(registered.Handler.Name(), func(m *ScoreHandlerMetrics) {
+			m.TotalDuration += duration
+
+			if err == nil {
+				m.Handled++
+			} else {
+				m.Failed++
+			}
+		})
+
+		if metricsErr := r.progress.RecordMetrics(registered.Handler.Name(), err != nil, duration); metricsErr != nil {
+			logrus.Error("Error recording score handler metrics: ", metricsErr)
+		}
+
+		if err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (r *ScoreHandlerRegistry) updateMetrics(name string, update func(m *ScoreHandlerMetrics)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	update(r.metrics[name])
+}
+
+func safelyHandleScore(handler ScoreHandler, score *db.RedisScore, steps *ScoreHandlerSteps) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	if stepped, ok := handler.(SteppedScoreHandler); ok {
+		return stepped.HandleSteps(score, steps)
+	}
+
+	return handler.Handle(score)
+}
+
+// ScoreHandlerSteps Records the steps a handler has finished for a message.
+// A nil value runs every step, which is used when a handler is called outside the registry.
+type ScoreHandlerSteps struct {
+	messageId string
+	handler   string
+	progress  scoreHandlerProgress
+}
+
+// Run Runs a step, unless it already finished during an earlier delivery of the message
+func (s *ScoreHandlerSteps) Run(step string, run func() error) error {
+	return s.Remember(step, nil, run)
+}
+
+// Remember Runs a step that produces a value, unless it already finished during an earlier delivery of the message,
+// in which case the value it produced then is loaded instead.
+func (s *ScoreHandlerSteps) Remember(step string, value interface{}, run func() error) error {
+	if s == nil {
+		return run()
+	}
+
+	name := fmt.Sprintf("%v:%v", s.handler, step)
+	data, completed, err := s.progress.Step(s.messageId, name)
+
+	if err != nil {
+		return fmt.Errorf("error retrieving step %v: %w", name, err)
+	}
+
+	if completed {
+		if value == nil {
+			return nil
+		}
+
+		return json.Unmarshal([]byte(data), value)
+	}
+
+	if err := run(); err != nil {
+		return err
+	}
+
+	if value != nil {
+		encoded, err := json.Marshal(value)
+
+		if err != nil {
+			return err
+		}
+
+		data = string(encoded)
+	}
+
+	return s.progress.CompleteStep(s.messageId, name, data)
+}
+
+// Stores handler progress in a Redis set per message, and metrics in a single hash shared by all consumers
+type redisScoreHandlerProgress struct{}
+
+const (
+	scoreHandlerProgressTTL     time.Duration = time.Hour * 24
+	scoreHandlerMetricsRedisKey string        = "quaver:scores:handlers:metrics"
+)
+
+func scoreHandlerProgressKey(messageId string) string {
+	return fmt.Sprintf("quaver:scores:handlers:progress:%v", messageId)
+}
+
+func scoreHandlerStepsKey(messageId string) string {
+	return fmt.Sprintf("quaver:scores:handlers:steps:%v", messageId)
+}
+
+func (*redisScoreHandlerProgress) Completed(messageId string) (map[string]bool, error) {
+	names, err := db.Redis.SMembers(db.RedisCtx, scoreHandlerProgressKey(messageId)).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	completed := map[string]bool{}
+
+	for _, name := range names {
+		completed[name] = true
+	}
+
+	return completed, nil
+}
+
+func (*redisScoreHandlerProgress) MarkCompleted(messageId string, handler string) error {
+	key := scoreHandlerProgressKey(messageId)
+
+	if err := db.Redis.SAdd(db.RedisCtx, key, handler).Err(); err != nil {
+		return err
+	}
+
+	return db.Redis.Expire(db.RedisCtx, key, scoreHandlerProgressTTL).Err()
+}
+
+func (*redisScoreHandlerProgress) Clear(messageId string) error {
+	return db.Redis.Del(db.RedisCtx, scoreHandlerProgressKey(messageId), scoreHandlerStepsKey(messageId)).Err()
+}
+
+func (*redisScoreHandlerProgress) Step(messageId string, step string) (string, bool, error) {
+	data, err := db.Redis.HGet(db.RedisCtx, scoreHandlerStepsKey(messageId), step).Result()
+
+	if err == redis.Nil {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return data, true, nil
+}
+
+func (*redisScoreHandlerProgress) CompleteStep(messageId string, step string, data string) error {
+	key := scoreHandlerStepsKey(messageId)
+
+	if err := db.Redis.HSet(db.RedisCtx, key, step, data).Err(); err != nil {
+		return err
+	}
+
+	return db.Redis.Expire(db.RedisCtx, key, scoreHandlerProgressTTL).Err()
+}
+
+func (*redisScoreHandlerProgress) RecordMetrics(handler string, failed bool, duration time.Duration) error {
+	field := fmt.Sprintf("%v:handled", handler)
+
+	if failed {
+		field = fmt.Sprintf("%v:failed", handler)
+	}
+
+	pipeline := db.Redis.Pipeline()
+	pipeline.HIncrBy(db.RedisCtx, scoreHandlerMetricsRedisKey, field, 1)
+	pipeline.HIncrBy(db.RedisCtx, scoreHandlerMetricsRedisKey, fmt.Sprintf("%v:duration_ms", handler), duration.Milliseconds())
+
+	_, err := pipeline.Exec(db.RedisCtx)
+	return err
+}
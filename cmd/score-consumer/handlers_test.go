@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"testing"
+	"time"
+)
+
+type memoryScoreHandlerProgress struct {
+	completed map[string]map[string]bool
+	steps     map[string]map[string]string
+}
+
+func (p *memoryScoreHandlerProgress) Completed(messageId string) (map[string]bool, error) {
+	return p.completed[messageId], nil
+}
+
+func (p *memoryScoreHandlerProgress) MarkCompleted(messageId string, handler string) error {
+	if p.completed[messageId] == nil {
+		p.completed[messageId] = map[string]bool{}
+	}
+
+	p.completed[messageId][handler] = true
+	return nil
+}
+
+func (p *memoryScoreHandlerProgress) Clear(messageId string) error {
+	delete(p.completed, messageId)
+	delete(p.steps, messageId)
+	return nil
+}
+
+func (p *memoryScoreHandlerProgress) Step(messageId string, step string) (string, bool, error) {
+	data, ok := p.steps[messageId][step]
+	return data, ok, nil
+}
+
+func (p *memoryScoreHandlerProgress) CompleteStep(messageId string, step string, data string) error {
+	if p.steps == nil {
+		p.steps = map[string]map[string]string{}
+	}
+
+	if p.steps[messageId] == nil {
+		p.steps[messageId] = map[string]string{}
+	}
+
+	p.steps[messageId][step] = data
+	return nil
+}
+
+func (*memoryScoreHandlerProgress) RecordMetrics(string, bool, time.Duration) error {
+	return nil
+}
+
+type testScoreHandler struct {
+	name     string
+	calls    *[]string
+	failures int
+	panics   bool
+}
+
+func (h *testScoreHandler) Name() string {
+	return h.name
+}
+
+func (h *testScoreHandler) Handle(*db.RedisScore) error {
+	*h.calls = append(*h.calls, h.name)
+
+	if h.panics {
+		panic("handler panicked")
+	}
+
+	if h.failures > 0 {
+		h.failures--
+		return errors.New("handler failed")
+	}
+
+	return nil
+}
+
+// Runs a step that can't be repeated, remembering a value, followed by a step that fails once
+type testSteppedScoreHandler struct {
+	calls    *[]string
+	failures int
+}
+
+func (*testSteppedScoreHandler) Name() string {
+	return "stepped"
+}
+
+func (h *testSteppedScoreHandler) Handle(score *db.RedisScore) error {
+	return h.HandleSteps(score, nil)
+}
+
+func (h *testSteppedScoreHandler) HandleSteps(_ *db.RedisScore, steps *ScoreHandlerSteps) error {
+	var value int
+
+	err := steps.Remember("first", &value, func() error {
+		*h.calls = append(*h.calls, "first")
+		value = 42
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return steps.Run("second", func() error {
+		*h.calls = append(*h.calls, fmt.Sprintf("second:%v", value))
+
+		if h.failures > 0 {
+			h.failures--
+			return errors.New("step failed")
+		}
+
+		return nil
+	})
+}
+
+func TestScoreHandlerRegistrySteps(t *testing.T) {
+	var calls []string
+	progress := &memoryScoreHandlerProgress{completed: map[string]map[string]bool{}}
+	registry := newScoreHandlerRegistry(progress)
+
+	registry.Register(0, &testSteppedScoreHandler{calls: &calls, failures: 1}, RetryPolicy{})
+
+	if err := registry.Handle("1-0", &db.RedisScore{}); err == nil {
+		t.Fatal("expected an error when a step fails")
+	}
+
+	// The finished step isn't run again on the next delivery, but its value is remembered
+	if err := registry.Handle("1-0", &db.RedisScore{}); err != nil {
+		t.Fatalf("expected the handler to succeed, got %v", err)
+	}
+
+	expected := []string{"first", "second:42", "second:42"}
+
+	if fmt.Sprint(calls) != fmt.Sprint(expected) {
+		t.Fatalf("expected calls %v, got %v", expected, calls)
+	}
+
+	if _, ok := progress.steps["1-0"]; ok {
+		t.Error("expected the steps to be cleared once every handler succeeded")
+	}
+}
+
+func TestScoreHandlerRegistryOrderAndIsolation(t *testing.T) {
+	var calls []string
+	progress := &memoryScoreHandlerProgress{completed: map[string]map[string]bool{}}
+	registry := newScoreHandlerRegistry(progress)
+
+	registry.Register(20, &testScoreHandler{name: "last", calls: &calls}, RetryPolicy{})
+	registry.Register(0, &testScoreHandler{name: "panics", calls: &calls, panics: true}, RetryPolicy{})
+	registry.Register(10, &testScoreHandler{name: "fails", calls: &calls, failures: 1}, RetryPolicy{Attempts: 1})
+
+	if err := registry.Handle("1-0", &db.RedisScore{}); err == nil {
+		t.Fatal("expected an error when handlers fail")
+	}
+
+	expected := []string{"panics", "fails", "last"}
+
+	if len(calls) != len(expected) {
+		t.Fatalf("expected calls %v, got %v", expected, calls)
+	}
+
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Fatalf("expected calls %v, got %v", expected, calls)
+		}
+	}
+
+	// Only the failed handlers run again on the next delivery
+	calls = nil
+	_ = registry.Handle("1-0", &db.RedisScore{})
+
+	if len(calls) != 2 || calls[0] != "panics" || calls[1] != "fails" {
+		t.Fatalf("expected only the failed handlers to be retried, got %v", calls)
+	}
+
+	if !progress.completed["1-0"]["fails"] || progress.completed["1-0"]["panics"] {
+		t.Fatalf("unexpected progress: %v", progress.completed["1-0"])
+	}
+
+	metrics := registry.Metrics()
+
+	if metrics["fails"].Failed != 1 || metrics["fails"].Handled != 1 || metrics["last"].Handled != 1 {
+		t.Errorf("unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestScoreHandlerRegistryRetryPolicy(t *testing.T) {
+	var calls []string
+	progress := &memoryScoreHandlerProgress{completed: map[string]map[string]bool{}}
+	registry := newScoreHandlerRegistry(progress)
+
+	registry.Register(0, &testScoreHandler{name: "flaky", calls: &calls, failures: 2}, RetryPolicy{Attempts: 3})
+
+	if err := registry.Handle("1-0", &db.RedisScore{}); err != nil {
+		t.Fatalf("expected the handler to succeed within its retry policy, got %v", err)
+	}
+
+	if len(calls) != 3 || registry.Metrics()["flaky"].Retries != 2 {
+		t.Fatalf("expected 3 attempts, got %v (%+v)", calls, registry.Metrics()["flaky"])
+	}
+
+	if _, ok := progress.completed["1-0"]; ok {
+		t.Error("expected the progress to be cleared once every handler succeeded")
+	}
+}
@@ -1,23 +1,48 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"github.com/Quaver/api2/azure"
 	"github.com/Quaver/api2/config"
 	"github.com/Quaver/api2/db"
-	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/files"
 	"github.com/Quaver/api2/webhooks"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
+const (
+	scoresStream        string = "quaver:scores:stream"
+	scoresDeadStream    string = "quaver:scores:stream:dead"
+	scoresConsumerGroup string = "api-score-consumer-group"
+
+	// How many messages are read at a time
+	scoresBatchSize int64 = 10
+
+	// How long a read blocks before checking if the consumer is shutting down
+	scoresReadBlock time.Duration = time.Second * 5
+
+	// How often pending messages of other (crashed) consumers are reclaimed, and how long they have to be idle for
+	scoresClaimInterval time.Duration = time.Second * 30
+	scoresClaimMinIdle  time.Duration = time.Minute
+
+	// Messages that have been delivered this many times are moved to the dead-letter stream
+	scoresMaxDeliveries int64 = 5
+)
+
+var scoreHandlers *ScoreHandlerRegistry
+
 func main() {
 	configPath := flag.String("config", "../../config.json", "path to config file")
+	consumerName := flag.String("name", "", "unique name of this consumer in the consumer group (defaults to the hostname)")
 	flag.Parse()
 
 	if err := config.Load(*configPath); err != nil {
@@ -28,235 +53,289 @@ func main() {
 		logrus.SetLevel(logrus.DebugLevel)
 	}
 
+	if *consumerName == "" {
+		hostname, err := os.Hostname()
+
+		if err != nil {
+			logrus.Panic("Could not get hostname for consumer name: ", err)
+		}
+
+		*consumerName = fmt.Sprintf("api-score-consumer-%v", hostname)
+	}
+
 	db.ConnectMySQL()
 	db.InitializeRedis()
 	db.InitializeElasticSearch()
 	azure.InitializeClient()
 	webhooks.InitializeWebhooks()
+	files.CreateDirectories()
+
+	scoreHandlers = NewScoreHandlerRegistry()
+	registerScoreHandlers(scoreHandlers)
 
-	go consumeScores()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		consumeScores(ctx, *consumerName)
+		close(done)
+	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	logrus.Info("Finishing in-flight scores before exiting...")
+	cancel()
+	<-done
+
+	for name, metrics := range scoreHandlers.Metrics() {
+		logrus.Infof("Score handler %v | Handled: %v | Failed: %v | Retries: %v | Total Duration: %v",
+			name, metrics.Handled, metrics.Failed, metrics.Retries, metrics.TotalDuration)
+	}
+
 	logrus.Info("Exiting...")
 }
 
-func consumeScores() {
-	subject := "quaver:scores:stream"
-	consumersGroup := "api-score-consumer-group"
-
-	err := db.Redis.XGroupCreate(db.RedisCtx, subject, consumersGroup, "0").Err()
+// Reads new scores from the stream until the context is cancelled.
+// The batch that is being processed is always finished before returning.
+func consumeScores(ctx context.Context, consumer string) {
+	err := db.Redis.XGroupCreateMkStream(db.RedisCtx, scoresStream, scoresConsumerGroup, "0").Err()
 
 	if err != nil {
 		logrus.Warn(err)
 	}
 
-	for {
-		entries, err := db.Redis.XReadGroup(db.RedisCtx, &redis.XReadGroupArgs{
-			Group:    consumersGroup,
-			Consumer: "api-score-consumer",
-			Streams:  []string{subject, ">"},
-			Count:    2,
-			Block:    0,
-			NoAck:    false,
-		}).Result()
+	logrus.Infof("Consuming scores as %v", consumer)
 
-		if err != nil {
-			logrus.Fatal(err)
-		}
-
-		for i := 0; i < len(entries[0].Messages); i++ {
-			messageID := entries[0].Messages[i].ID
-			scoreStr := entries[0].Messages[i].Values["data"]
+	// Messages that were left pending by this consumer before a restart are retried first
+	processPendingScores(consumer)
 
-			var score db.RedisScore
-
-			if err := json.Unmarshal([]byte(scoreStr.(string)), &score); err != nil {
-				logrus.Error(err)
-				break
-			}
+	lastClaim := time.Now()
 
-			logrus.Infof("New Score: %v (#%v) | Map #%v | Difficulty: %v | Score #%v | Rating: %v | Acc: %v%%",
-				score.User.Username, score.User.Id, score.Map.Id, score.Map.DifficultyRating,
-				score.Score.Id, score.Score.PerformanceRating, score.Score.Accuracy)
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= scoresClaimInterval {
+			claimStaleScores(consumer)
+			lastClaim = time.Now()
+		}
 
-			if score.Score.Failed {
-				if err := db.IncrementFailedScoresMetric(); err != nil {
-					logrus.Error("Error incrementing failed score metric in db", err)
-				}
-			}
+		entries, err := db.Redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    scoresConsumerGroup,
+			Consumer: consumer,
+			Streams:  []string{scoresStream, ">"},
+			Count:    scoresBatchSize,
+			Block:    scoresReadBlock,
+			NoAck:    false,
+		}).Result()
 
-			if err := db.Redis.Incr(db.RedisCtx, "quaver:total_scores").Err(); err != nil {
-				logrus.Error("Error incrementing total score count in Redis", err)
-			}
+		switch {
+		case err == nil:
+		case errors.Is(err, redis.Nil):
+			continue
+		case ctx.Err() != nil:
+			continue
+		default:
+			logrus.Error("Error reading from score stream: ", err)
+			sleepContext(ctx, scoresReadBlock)
+			continue
+		}
 
-			if err := insertClanScore(&score); err != nil {
-				logrus.Error("Error inserting clan score: ", err)
+		for _, entry := range entries {
+			for _, message := range entry.Messages {
+				handleScoreMessage(consumer, message, 1)
 			}
-
-			db.Redis.XAck(db.RedisCtx, subject, consumersGroup, messageID)
-			db.Redis.XDel(db.RedisCtx, subject, messageID)
 		}
 	}
-}
 
-// Handles the insertion of a new clan score
-func insertClanScore(score *db.RedisScore) error {
-	if !score.Map.ClanRanked || score.User.ClanId <= 0 {
-		return nil
-	}
+	removeConsumerIfIdle(consumer)
+}
 
-	if score.Score.Failed {
-		return nil
+// Processes a single message from the stream. The message is acknowledged if every handler succeeded,
+// otherwise it stays pending, so the failed handlers can be retried later.
+func handleScoreMessage(consumer string, message redis.XMessage, deliveries int64) {
+	if deliveries > scoresMaxDeliveries {
+		deadLetterScore(consumer, message, fmt.Sprintf("exceeded %v deliveries", scoresMaxDeliveries))
+		return
 	}
 
-	existingScore, err := db.GetClanScore(score.Map.MD5, score.User.ClanId)
+	scoreStr, ok := message.Values["data"].(string)
 
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return err
+	if !ok {
+		deadLetterScore(consumer, message, "message does not have any data")
+		return
 	}
 
-	scoreboard, err := db.GetClanScoreboardForMap(score.Map.MD5)
+	var score db.RedisScore
 
-	if err != nil {
-		return err
+	if err := json.Unmarshal([]byte(scoreStr), &score); err != nil {
+		deadLetterScore(consumer, message, err.Error())
+		return
 	}
 
-	newScore, err := db.CalculateClanScore(score.Map.MD5, score.User.ClanId, score.Map.GameMode)
-
-	if err != nil {
-		return err
-	}
+	logrus.Infof("New Score: %v (#%v) | Map #%v | Difficulty: %v | Score #%v | Rating: %v | Acc: %v%%",
+		score.User.Username, score.User.Id, score.Map.Id, score.Map.DifficultyRating,
+		score.Score.Id, score.Score.PerformanceRating, score.Score.Accuracy)
 
-	// Make sure the id is the same on the newly calculated score, so it can be upserted properly.
-	if existingScore != nil {
-		newScore.Id = existingScore.Id
+	if err := scoreHandlers.Handle(message.ID, &score); err != nil {
+		logrus.Errorf("Error processing score message %v (delivery %v): %v", message.ID, deliveries, err)
+		return
 	}
 
-	if err := db.SQL.Save(&newScore).Error; err != nil {
-		return err
-	}
+	acknowledgeScore(message.ID)
+}
 
-	if err := db.RecalculateClanStats(score.User.ClanId, score.Map.GameMode, score); err != nil {
-		return err
-	}
+// Registers every handler that reacts to new scores
+func registerScoreHandlers(registry *ScoreHandlerRegistry) {
+	registry.Register(0, &totalScoresHandler{}, RetryPolicy{Attempts: 1})
+	registry.Register(10, &failedScoreMetricHandler{}, RetryPolicy{Attempts: 1})
+	registry.Register(100, &clanScoreHandler{}, RetryPolicy{Attempts: 3, Backoff: time.Second})
+	registry.Register(150, &achievementHandler{}, RetryPolicy{Attempts: 3, Backoff: time.Second})
+	registry.Register(175, &seasonHandler{}, RetryPolicy{Attempts: 3, Backoff: time.Second})
+	registry.Register(180, &modLeaderboardHandler{}, RetryPolicy{Attempts: 3, Backoff: time.Second})
+	registry.Register(190, &mapStatisticsHandler{}, RetryPolicy{Attempts: 3, Backoff: time.Second})
+	registry.Register(200, &antiCheatHandler{}, RetryPolicy{Attempts: 2, Backoff: time.Second * 5})
+}
 
-	// Set values needed for the webhook
-	clan, err := db.GetClanById(score.User.ClanId)
+// Retries the messages that are still pending for this consumer
+func processPendingScores(consumer string) {
+	pending, err := db.Redis.XPendingExt(db.RedisCtx, &redis.XPendingExtArgs{
+		Stream:   scoresStream,
+		Group:    scoresConsumerGroup,
+		Start:    "-",
+		End:      "+",
+		Count:    1000,
+		Consumer: consumer,
+	}).Result()
 
 	if err != nil {
-		return err
+		logrus.Error("Error retrieving pending scores: ", err)
+		return
 	}
 
-	if err := db.UpdateAllClanLeaderboards(clan); err != nil {
-		return err
-	}
+	for _, entry := range pending {
+		messages, err := db.Redis.XRangeN(db.RedisCtx, scoresStream, entry.ID, entry.ID, 1).Result()
 
-	mapQua, err := db.GetMapById(score.Map.Id)
+		if err != nil {
+			logrus.Error("Error retrieving pending score message: ", err)
+			continue
+		}
 
-	if err != nil {
-		return err
-	}
+		// The message was trimmed or deleted from the stream, so there is nothing to process
+		if len(messages) == 0 {
+			acknowledgeScore(entry.ID)
+			continue
+		}
 
-	if err := handleClanFirstPlaces(score, clan, mapQua, newScore, scoreboard); err != nil {
-		return err
+		// Reading the message through XRANGE doesn't count as a delivery
+		handleScoreMessage(consumer, messages[0], entry.RetryCount+1)
 	}
-
-	return nil
 }
 
-func handleClanFirstPlaces(score *db.RedisScore, clan *db.Clan, mapQua *db.MapQua, newScore *db.ClanScore, scoreboard []*db.ClanScore) error {
-	var achievedFirstPlace bool
+// Takes over messages that have been pending for too long, which happens when a consumer
+// crashes or keeps failing to process them.
+func claimStaleScores(consumer string) {
+	start := "0-0"
 
-	if len(scoreboard) == 0 {
-		achievedFirstPlace = true
-		_ = webhooks.SendClanFirstPlaceWebhook(clan, mapQua, newScore, nil)
-	} else if scoreboard[0].ClanId != score.User.ClanId && newScore.OverallRating > scoreboard[0].OverallRating {
-		achievedFirstPlace = true
-		_ = webhooks.SendClanFirstPlaceWebhook(clan, mapQua, newScore, scoreboard[0])
-	}
+	for {
+		messages, next, err := db.Redis.XAutoClaim(db.RedisCtx, &redis.XAutoClaimArgs{
+			Stream:   scoresStream,
+			Group:    scoresConsumerGroup,
+			MinIdle:  scoresClaimMinIdle,
+			Start:    start,
+			Count:    scoresBatchSize,
+			Consumer: consumer,
+		}).Result()
 
-	if !achievedFirstPlace {
-		return nil
-	}
+		if err != nil {
+			logrus.Error("Error claiming stale scores: ", err)
+			return
+		}
 
-	// Add activity for clan who won first place
-	firstPlaceActivity := db.NewClanActivity(clan.Id, db.ClanActivityAchievedFirstPlace, score.User.Id)
-	firstPlaceActivity.MapId = mapQua.Id
-	firstPlaceActivity.Message = mapQua.String()
+		for _, message := range messages {
+			handleScoreMessage(consumer, message, getScoreDeliveryCount(message.ID))
+		}
 
-	if err := firstPlaceActivity.Insert(); err != nil {
-		return err
-	}
+		if next == "0-0" || len(messages) == 0 {
+			return
+		}
 
-	if err := SendClanFirstPLaceToRedis(clan.Id, true, mapQua); err != nil {
-		return err
+		start = next
 	}
+}
 
-	if len(scoreboard) == 0 {
-		return nil
+// Returns how many times a pending message has been delivered
+func getScoreDeliveryCount(id string) int64 {
+	pending, err := db.Redis.XPendingExt(db.RedisCtx, &redis.XPendingExtArgs{
+		Stream: scoresStream,
+		Group:  scoresConsumerGroup,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+
+	if err != nil || len(pending) == 0 {
+		return 1
 	}
 
-	// Add activity for clan who lost first place
-	lostActivity := db.NewClanActivity(scoreboard[0].ClanId, db.ClanActivityLostFirstPlace, score.User.Id)
-	lostActivity.MapId = mapQua.Id
-	lostActivity.Message = mapQua.String()
+	return pending[0].RetryCount
+}
 
-	if err := lostActivity.Insert(); err != nil {
-		return err
-	}
+// Moves a message that can't be processed to the dead-letter stream, so it doesn't stay pending forever
+func deadLetterScore(consumer string, message redis.XMessage, reason string) {
+	logrus.Errorf("Moving score message %v to the dead-letter stream: %v", message.ID, reason)
 
-	if err := SendClanFirstPLaceToRedis(scoreboard[0].ClanId, false, mapQua); err != nil {
-		return err
-	}
+	data, _ := message.Values["data"].(string)
 
-	// Add lost notification for clan members
-	clanMembers, err := db.GetUsersInClan(scoreboard[0].ClanId)
+	err := db.Redis.XAdd(db.RedisCtx, &redis.XAddArgs{
+		Stream: scoresDeadStream,
+		Values: map[string]interface{}{
+			"id":        message.ID,
+			"data":      data,
+			"error":     reason,
+			"consumer":  consumer,
+			"timestamp": time.Now().UnixMilli(),
+		},
+	}).Err()
 
 	if err != nil {
-		return err
+		logrus.Error("Error adding score message to the dead-letter stream: ", err)
+		return
 	}
 
-	for _, member := range clanMembers {
-		if err := db.NewClanLostFirstPlaceNotification(mapQua, member.Id).Insert(); err != nil {
-			return err
-		}
-	}
+	scoreHandlers.Forget(message.ID)
+	acknowledgeScore(message.ID)
+}
 
-	return nil
+func acknowledgeScore(id string) {
+	db.Redis.XAck(db.RedisCtx, scoresStream, scoresConsumerGroup, id)
+	db.Redis.XDel(db.RedisCtx, scoresStream, id)
 }
 
-func SendClanFirstPLaceToRedis(clanId int, won bool, mapQua *db.MapQua) error {
-	type payload struct {
-		ClanId int  `json:"clan_id"`
-		Won    bool `json:"won"`
-		Map    struct {
-			Id             int    `json:"id"`
-			Artist         string `json:"artist"`
-			Title          string `json:"title"`
-			DifficultyName string `json:"difficulty_name"`
-			CreatorName    string `json:"creator_name"`
-			Mode           string `json:"mode"`
-		} `json:"map"`
+// Removes the consumer from the group when it shuts down without pending messages,
+// so replicas with changing names don't pile up in the group.
+func removeConsumerIfIdle(consumer string) {
+	pending, err := db.Redis.XPendingExt(db.RedisCtx, &redis.XPendingExtArgs{
+		Stream:   scoresStream,
+		Group:    scoresConsumerGroup,
+		Start:    "-",
+		End:      "+",
+		Count:    1,
+		Consumer: consumer,
+	}).Result()
+
+	if err != nil || len(pending) > 0 {
+		return
 	}
 
-	data := payload{}
-	data.ClanId = clanId
-	data.Won = won
-	data.Map.Id = mapQua.Id
-	data.Map.Artist = mapQua.Artist
-	data.Map.Title = mapQua.Title
-	data.Map.DifficultyName = mapQua.DifficultyName
-	data.Map.CreatorName = mapQua.CreatorUsername
-	data.Map.Mode = enums.GetShorthandGameModeString(mapQua.GameMode)
-
-	dataStr, _ := json.Marshal(data)
-
-	if err := db.Redis.Publish(db.RedisCtx, "quaver:clan_first_place", dataStr).Err(); err != nil {
-		return err
+	if err := db.Redis.XGroupDelConsumer(db.RedisCtx, scoresStream, scoresConsumerGroup, consumer).Err(); err != nil {
+		logrus.Error("Error removing consumer from group: ", err)
 	}
+}
 
-	return nil
+// Sleeps for the duration or until the context is cancelled
+func sleepContext(ctx context.Context, duration time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(duration):
+	}
 }
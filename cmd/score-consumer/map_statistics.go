@@ -0,0 +1,14 @@
+package main
+
+import "github.com/Quaver/api2/db"
+
+// Queues the map of a new score to have its statistics refreshed
+type mapStatisticsHandler struct{}
+
+func (*mapStatisticsHandler) Name() string {
+	return "map_statistics"
+}
+
+func (*mapStatisticsHandler) Handle(score *db.RedisScore) error {
+	return db.MarkMapStatisticsDirty(score.Map.Id)
+}
@@ -0,0 +1,29 @@
+package main
+
+import "github.com/Quaver/api2/db"
+
+// Increments the total amount of submitted scores
+type totalScoresHandler struct{}
+
+func (*totalScoresHandler) Name() string {
+	return "total_scores"
+}
+
+func (*totalScoresHandler) Handle(*db.RedisScore) error {
+	return db.Redis.Incr(db.RedisCtx, "quaver:total_scores").Err()
+}
+
+// Increments the failed scores metric
+type failedScoreMetricHandler struct{}
+
+func (*failedScoreMetricHandler) Name() string {
+	return "failed_score_metric"
+}
+
+func (*failedScoreMetricHandler) Handle(score *db.RedisScore) error {
+	if !score.Score.Failed {
+		return nil
+	}
+
+	return db.IncrementFailedScoresMetric()
+}
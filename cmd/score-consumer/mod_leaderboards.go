@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+)
+
+// Updates the mod leaderboards that a new score counts towards
+type modLeaderboardHandler struct{}
+
+func (*modLeaderboardHandler) Name() string {
+	return "mod_leaderboards"
+}
+
+func (*modLeaderboardHandler) Handle(score *db.RedisScore) error {
+	if score.Score.Failed || score.User.ShadowBanned || score.Map.RankedStatus != enums.RankedStatusRanked {
+		return nil
+	}
+
+	leaderboards := db.GetEnabledModLeaderboards()
+
+	if len(leaderboards) == 0 {
+		return nil
+	}
+
+	dbScore, err := db.GetScoreById(score.Score.Id)
+
+	if err != nil {
+		return err
+	}
+
+	for _, leaderboard := range leaderboards {
+		if !leaderboard.Matches(enums.Mods(dbScore.Modifiers)) {
+			continue
+		}
+
+		if err := leaderboard.UpdateUser(score.User.Id, dbScore.Mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/seasons"
+	"gorm.io/gorm"
+)
+
+// Adds a new score to the stats & leaderboards of the season it was set in
+type seasonHandler struct{}
+
+func (*seasonHandler) Name() string {
+	return "seasons"
+}
+
+func (*seasonHandler) Handle(score *db.RedisScore) error {
+	dbScore, err := db.GetScoreById(score.Score.Id)
+
+	if err != nil {
+		return err
+	}
+
+	season, err := db.GetSeasonAtTime(dbScore.Timestamp)
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	return seasons.AddScore(season, score, dbScore)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -17,6 +18,8 @@ type Config struct {
 
 	JWTSecret string `json:"jwt_secret"`
 
+	JWT JWT `json:"jwt"`
+
 	Server struct {
 		Port                 int      `json:"port"`
 		RateLimitIpWhitelist []string `json:"rate_limit_ip_whitelist"`
@@ -74,6 +77,11 @@ type Config struct {
 
 	BundledMapsets []int `json:"bundled_mapsets"`
 
+	AntiCheat AntiCheat `json:"anti_cheat"`
+
+	// ModLeaderboards The names of the mod leaderboards that are kept up to date, e.g. "nln", "mirror" or "rate_1.2"
+	ModLeaderboards []string `json:"mod_leaderboards"`
+
 	EventsWebhook          string `json:"events_webhook"`
 	TeamAnnounceWebhook    string `json:"team_announce_webhook"`
 	ClansFirstPlaceWebhook string `json:"clans_first_place_webhook"`
@@ -119,9 +127,50 @@ type Config struct {
 		RankClanMap          CronJob `json:"rank_clan_map"`
 		DenyOnHoldOneMonth   CronJob `json:"deny_on_hold_one_month"`
 		ClanRecalculate      CronJob `json:"clan_recalculate"`
+		RatingsReprocess     CronJob `json:"ratings_reprocess"`
+		SeasonsFinalize      CronJob `json:"seasons_finalize"`
+		MapStatistics        CronJob `json:"map_statistics"`
+		MultiplayerRatings   CronJob `json:"multiplayer_ratings"`
 	} `json:"cron"`
 }
 
+// AntiCheat Thresholds used when analyzing replays of new personal bests. A threshold of 0 disables its check.
+type AntiCheat struct {
+	Enabled                      bool    `json:"enabled"`
+	MinUnstableRate              float64 `json:"min_unstable_rate"`
+	MaxExactHitRatio             float64 `json:"max_exact_hit_ratio"`
+	MinKeyPressDuration          float64 `json:"min_key_press_duration"`
+	MinKeyPressDurationDeviation float64 `json:"min_key_press_duration_deviation"`
+	MaxReplaySimilarity          float64 `json:"max_replay_similarity"`
+	ReplaysToCompare             int     `json:"replays_to_compare"`
+}
+
+// JWT The keys that session access tokens are signed with, and how long sessions last.
+// To rotate keys, add a new key and make it the current one. The old key can be removed once its tokens have expired.
+// Legacy tokens signed with the jwt secret are accepted until the sunset time (RFC 3339), if one is set.
+type JWT struct {
+	Keys               []JWTKey `json:"keys"`
+	CurrentKeyId       string   `json:"current_key_id"`
+	AccessTokenMinutes int      `json:"access_token_minutes"`
+	RefreshTokenDays   int      `json:"refresh_token_days"`
+	LegacyTokensSunset string   `json:"legacy_tokens_sunset"`
+}
+
+// AcceptsLegacyTokens Returns if legacy tokens are still accepted. They're only refused once a configured sunset time has passed.
+func (j *JWT) AcceptsLegacyTokens() bool {
+	if j.LegacyTokensSunset == "" {
+		return true
+	}
+
+	sunset, err := time.Parse(time.RFC3339, j.LegacyTokensSunset)
+	return err == nil && time.Now().Before(sunset)
+}
+
+type JWTKey struct {
+	Id     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
 type CronJob struct {
 	Job
 }
@@ -156,6 +205,20 @@ func Load(path string) error {
 		panic("ranking_queue configuration must be set and greater than 1")
 	}
 
+	if Instance.JWT.LegacyTokensSunset != "" {
+		if _, err := time.Parse(time.RFC3339, Instance.JWT.LegacyTokensSunset); err != nil {
+			panic("jwt legacy_tokens_sunset must be an RFC 3339 time")
+		}
+	}
+
+	if Instance.JWT.AccessTokenMinutes < 1 {
+		Instance.JWT.AccessTokenMinutes = 15
+	}
+
+	if Instance.JWT.RefreshTokenDays < 1 {
+		Instance.JWT.RefreshTokenDays = 30
+	}
+
 	logrus.Info("Config file has been loaded")
 	return nil
 }
@@ -0,0 +1,203 @@
+package converters
+
+import (
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"strings"
+	"testing"
+)
+
+const testOsuFile = `osu file format v14
+
+[General]
+AudioFilename: audio.mp3
+PreviewTime: 2000
+Mode: 3
+
+[Metadata]
+Title:Song
+Artist:Artist
+Creator:Mapper
+Version:Hard
+Source:Game
+Tags:tag1 tag2
+
+[Difficulty]
+CircleSize:4
+
+[Events]
+//Background and Video events
+0,0,"bg.jpg",0,0
+
+[TimingPoints]
+1000,500,4,2,0,50,1,0
+2000,-50,4,2,0,50,0,0
+3000,400,4,2,0,50,1,0
+
+[HitObjects]
+64,192,1000,1,0,0:0:0:0:
+192,192,1500,1,2,0:0:0:0:
+320,192,2000,128,0,2500:0:0:0:0:
+448,192,3000,1,8,0:0:0:0:
+`
+
+const testStepManiaFile = `#TITLE:Song;
+#ARTIST:Artist;
+#CREDIT:Stepper;
+#MUSIC:audio.ogg;
+#BACKGROUND:bg.png;
+#OFFSET:-0.100;
+#SAMPLESTART:10.5;
+#BPMS:0.000=120.000,8.000=240.000;
+#STOPS:;
+
+//---------------dance-single - Hard----------------
+#NOTES:
+     dance-single:
+     Stepper:
+     Hard:
+     8:
+     0,0,0,0,0:
+1000
+0100
+0020
+0000
+,
+0030
+0001
+0000
+0000
+,
+1000
+0000
+0000
+0000
+;
+
+#NOTES:
+     dance-double:
+     Stepper:
+     Hard:
+     8:
+     0,0,0,0,0:
+10000000
+00000000
+00000000
+00000000
+;
+`
+
+func TestConvertOsu(t *testing.T) {
+	q, err := ConvertOsu([]byte(testOsuFile))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Mode != enums.GameModeKeys4 || q.Title != "Song" || q.DifficultyName != "Hard" || q.BackgroundFile != "bg.jpg" {
+		t.Fatalf("unexpected metadata: %+v", q)
+	}
+
+	if len(q.TimingPoints) != 2 || q.TimingPoints[0].BPM != 120 || q.TimingPoints[1].BPM != 150 {
+		t.Fatalf("unexpected timing points: %+v", q.TimingPoints)
+	}
+
+	// The inherited point doubles the scroll speed until the next uninherited point resets it.
+	expectedSVs := []qua.ScrollVelocity{{StartTime: 2000, Multiplier: 2}, {StartTime: 3000, Multiplier: 1}}
+
+	if len(q.ScrollVelocities) != len(expectedSVs) || q.ScrollVelocities[0] != expectedSVs[0] || q.ScrollVelocities[1] != expectedSVs[1] {
+		t.Fatalf("unexpected scroll velocities: %+v", q.ScrollVelocities)
+	}
+
+	expectedLanes := []int{1, 2, 3, 4}
+
+	for i, hitObject := range q.HitObjects {
+		if hitObject.Lane != expectedLanes[i] {
+			t.Errorf("expected object %v in lane %v, got %v", i, expectedLanes[i], hitObject.Lane)
+		}
+	}
+
+	if q.HitObjects[2].EndTime != 2500 || q.HitObjects[1].HitSound != "Whistle" || q.HitObjects[3].HitSound != "Clap" {
+		t.Fatalf("unexpected hit objects: %+v", q.HitObjects)
+	}
+
+	if _, err := qua.Parse(q.RawBytes); err != nil {
+		t.Fatalf("converted file could not be parsed: %v", err)
+	}
+}
+
+func TestConvertOsuNegativeBeatLength(t *testing.T) {
+	q, err := ConvertOsu([]byte(strings.Replace(testOsuFile, "3000,400,4,2,0,50,1,0", "3000,-400,4,2,0,50,1,0", 1)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(q.TimingPoints) != 1 || q.TimingPoints[0].BPM != 120 {
+		t.Fatalf("unexpected timing points: %+v", q.TimingPoints)
+	}
+}
+
+func TestConvertOsuUnsupportedKeyCount(t *testing.T) {
+	_, err := ConvertOsu([]byte(strings.Replace(testOsuFile, "CircleSize:4", "CircleSize:6", 1)))
+
+	if err == nil || !strings.Contains(err.Error(), "6K") {
+		t.Fatalf("expected an unsupported key count error, got %v", err)
+	}
+
+	_, err = ConvertOsu([]byte(strings.Replace(testOsuFile, "Mode: 3", "Mode: 0", 1)))
+
+	if err == nil {
+		t.Fatal("expected an error for a non-mania beatmap")
+	}
+}
+
+func TestConvertStepMania(t *testing.T) {
+	quaFiles, err := ConvertStepMania([]byte(testStepManiaFile))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The dance-double chart is skipped
+	if len(quaFiles) != 1 {
+		t.Fatalf("expected 1 converted chart, got %v", len(quaFiles))
+	}
+
+	q := quaFiles[0]
+
+	if q.Mode != enums.GameModeKeys4 || q.AudioFile != "audio.ogg" || q.SongPreviewTime != 10500 || q.DifficultyName != "Hard" {
+		t.Fatalf("unexpected metadata: %+v", q)
+	}
+
+	// Beat 0 starts 100 ms into the song, with 500 ms per beat until beat 8 switches to 250 ms per beat.
+	if len(q.TimingPoints) != 2 || q.TimingPoints[0].StartTime != 100 || q.TimingPoints[1].StartTime != 4100 {
+		t.Fatalf("unexpected timing points: %+v", q.TimingPoints)
+	}
+
+	expected := []qua.HitObject{
+		{StartTime: 100, Lane: 1},
+		{StartTime: 600, Lane: 2},
+		{StartTime: 1100, Lane: 3, EndTime: 2100},
+		{StartTime: 2600, Lane: 4},
+		{StartTime: 4100, Lane: 1},
+	}
+
+	if len(q.HitObjects) != len(expected) {
+		t.Fatalf("expected %v objects, got %+v", len(expected), q.HitObjects)
+	}
+
+	for i, hitObject := range q.HitObjects {
+		if hitObject.StartTime != expected[i].StartTime || hitObject.Lane != expected[i].Lane || hitObject.EndTime != expected[i].EndTime {
+			t.Errorf("expected %+v, got %+v", expected[i], hitObject)
+		}
+	}
+}
+
+func TestConvertStepManiaNoSupportedCharts(t *testing.T) {
+	file := strings.Replace(testStepManiaFile, "dance-single:", "pump-single:", 1)
+
+	if _, err := ConvertStepMania([]byte(file)); err == nil {
+		t.Fatal("expected an error when there are no dance-single charts")
+	}
+}
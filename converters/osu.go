@@ -0,0 +1,299 @@
+package converters
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	osuModeMania      int = 3
+	osuPlayfieldWidth int = 512
+	osuHoldNoteType   int = 128
+	osuMinScrollSpeed     = 0.1
+	osuMaxScrollSpeed     = 10
+)
+
+type osuTimingPoint struct {
+	Time        float32
+	BeatLength  float32
+	Uninherited bool
+}
+
+// ConvertOsu Converts an osu!mania beatmap (.osu) into a Qua
+func ConvertOsu(file []byte) (*qua.Qua, error) {
+	sections := map[string][]string{}
+	var section string
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(file, []byte("\xef\xbb\xbf"))))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+
+		sections[section] = append(sections[section], line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	general := parseOsuKeyValues(sections["General"])
+	metadata := parseOsuKeyValues(sections["Metadata"])
+	difficulty := parseOsuKeyValues(sections["Difficulty"])
+
+	if mode, _ := strconv.Atoi(general["Mode"]); mode != osuModeMania {
+		return nil, errors.New("the beatmap is not an osu!mania beatmap")
+	}
+
+	keyCount, err := strconv.ParseFloat(difficulty["CircleSize"], 64)
+
+	if err != nil {
+		return nil, errors.New("the beatmap does not have a valid key count")
+	}
+
+	gameMode, err := osuKeyCountToGameMode(int(keyCount))
+
+	if err != nil {
+		return nil, err
+	}
+
+	previewTime, _ := strconv.Atoi(general["PreviewTime"])
+
+	q := &qua.Qua{
+		AudioFile:       general["AudioFilename"],
+		SongPreviewTime: max(previewTime, 0),
+		BackgroundFile:  parseOsuBackground(sections["Events"]),
+		MapId:           -1,
+		MapSetId:        -1,
+		Mode:            gameMode,
+		Title:           metadata["Title"],
+		Artist:          metadata["Artist"],
+		Source:          metadata["Source"],
+		Tags:            metadata["Tags"],
+		Creator:         metadata["Creator"],
+		DifficultyName:  metadata["Version"],
+		Description:     fmt.Sprintf("This is a converted osu!mania map. Original map by %v.", metadata["Creator"]),
+	}
+
+	q.RawMode = fmt.Sprintf("Keys%v", q.KeyCount(false))
+
+	timingPoints, err := parseOsuTimingPoints(sections["TimingPoints"])
+
+	if err != nil {
+		return nil, err
+	}
+
+	convertOsuTimingPoints(q, timingPoints)
+
+	if err := convertOsuHitObjects(q, sections["HitObjects"]); err != nil {
+		return nil, err
+	}
+
+	if len(q.TimingPoints) == 0 {
+		return nil, errors.New("the beatmap does not have any timing points")
+	}
+
+	q.RawBytes = q.Serialize()
+	return q, nil
+}
+
+// Returns the Quaver game mode for an osu!mania key count
+func osuKeyCountToGameMode(keyCount int) (enums.GameMode, error) {
+	switch keyCount {
+	case 4:
+		return enums.GameModeKeys4, nil
+	case 7:
+		return enums.GameModeKeys7, nil
+	default:
+		return 0, fmt.Errorf("%vK osu!mania beatmaps are not supported, only 4K and 7K beatmaps can be converted", keyCount)
+	}
+}
+
+// Parses the "Key: Value" lines of a section
+func parseOsuKeyValues(lines []string) map[string]string {
+	values := map[string]string{}
+
+	for _, line := range lines {
+		key, value, found := strings.Cut(line, ":")
+
+		if found {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return values
+}
+
+// Finds the background image in the [Events] section (0,0,"bg.jpg",0,0)
+func parseOsuBackground(lines []string) string {
+	for _, line := range lines {
+		values := strings.Split(line, ",")
+
+		if len(values) >= 3 && values[0] == "0" && values[1] == "0" {
+			return strings.Trim(values[2], "\"")
+		}
+	}
+
+	return ""
+}
+
+// Parses the lines of the [TimingPoints] section
+func parseOsuTimingPoints(lines []string) ([]osuTimingPoint, error) {
+	var timingPoints []osuTimingPoint
+
+	for _, line := range lines {
+		values := strings.Split(line, ",")
+
+		if len(values) < 2 {
+			return nil, fmt.Errorf("invalid timing point: %v", line)
+		}
+
+		time, err := strconv.ParseFloat(strings.TrimSpace(values[0]), 32)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid timing point: %v", line)
+		}
+
+		beatLength, err := strconv.ParseFloat(strings.TrimSpace(values[1]), 32)
+
+		if err != nil || math.IsNaN(beatLength) || math.IsInf(beatLength, 0) || beatLength == 0 {
+			return nil, fmt.Errorf("invalid timing point: %v", line)
+		}
+
+		// Older beatmaps don't have the uninherited field, so negative beat lengths are used to tell them apart.
+		uninherited := beatLength > 0
+
+		if len(values) >= 7 {
+			uninherited = strings.TrimSpace(values[6]) == "1"
+		}
+
+		// Malformed uninherited points would have a negative BPM, so they're left out
+		if uninherited && beatLength < 0 {
+			continue
+		}
+
+		timingPoints = append(timingPoints, osuTimingPoint{
+			Time:        float32(time),
+			BeatLength:  float32(beatLength),
+			Uninherited: uninherited,
+		})
+	}
+
+	sort.SliceStable(timingPoints, func(i, j int) bool { return timingPoints[i].Time < timingPoints[j].Time })
+	return timingPoints, nil
+}
+
+// Converts osu! timing points into Quaver timing points & scroll velocities
+func convertOsuTimingPoints(q *qua.Qua, timingPoints []osuTimingPoint) {
+	currentMultiplier := float32(1)
+
+	for _, tp := range timingPoints {
+		if tp.Uninherited {
+			q.TimingPoints = append(q.TimingPoints, qua.TimingPoint{StartTime: tp.Time, BPM: 60_000 / tp.BeatLength})
+
+			// Red lines reset the scroll speed in osu!
+			if currentMultiplier != 1 {
+				addScrollVelocity(q, tp.Time, 1)
+				currentMultiplier = 1
+			}
+
+			continue
+		}
+
+		multiplier := float32(math.Max(osuMinScrollSpeed, math.Min(osuMaxScrollSpeed, float64(-100/tp.BeatLength))))
+
+		addScrollVelocity(q, tp.Time, multiplier)
+		currentMultiplier = multiplier
+	}
+}
+
+// Adds a scroll velocity, replacing the previous one if it starts at the same time
+func addScrollVelocity(q *qua.Qua, time float32, multiplier float32) {
+	if length := len(q.ScrollVelocities); length > 0 && q.ScrollVelocities[length-1].StartTime == time {
+		q.ScrollVelocities[length-1].Multiplier = multiplier
+		return
+	}
+
+	q.ScrollVelocities = append(q.ScrollVelocities, qua.ScrollVelocity{StartTime: time, Multiplier: multiplier})
+}
+
+// Converts the lines of the [HitObjects] section (x,y,time,type,hitSound,endTime:hitSample)
+func convertOsuHitObjects(q *qua.Qua, lines []string) error {
+	keyCount := q.KeyCount(false)
+
+	for _, line := range lines {
+		values := strings.Split(line, ",")
+
+		if len(values) < 5 {
+			return fmt.Errorf("invalid hit object: %v", line)
+		}
+
+		x, errX := strconv.ParseFloat(values[0], 64)
+		startTime, errTime := strconv.ParseFloat(values[2], 64)
+		objectType, errType := strconv.Atoi(values[3])
+		hitSound, errHitSound := strconv.Atoi(values[4])
+
+		if errX != nil || errTime != nil || errType != nil || errHitSound != nil {
+			return fmt.Errorf("invalid hit object: %v", line)
+		}
+
+		lane := int(math.Floor(x*float64(keyCount)/float64(osuPlayfieldWidth))) + 1
+
+		hitObject := qua.HitObject{
+			StartTime: int(startTime),
+			Lane:      min(max(lane, 1), keyCount),
+			HitSound:  convertOsuHitSound(hitSound),
+			KeySounds: []qua.KeySound{},
+		}
+
+		if objectType&osuHoldNoteType != 0 && len(values) >= 6 {
+			endTime, _, _ := strings.Cut(values[5], ":")
+			parsedEndTime, err := strconv.ParseFloat(endTime, 64)
+
+			if err != nil {
+				return fmt.Errorf("invalid hold note: %v", line)
+			}
+
+			if int(parsedEndTime) > hitObject.StartTime {
+				hitObject.EndTime = int(parsedEndTime)
+			}
+		}
+
+		q.HitObjects = append(q.HitObjects, hitObject)
+	}
+
+	return nil
+}
+
+// Converts osu! hit sound flags into Quaver's hit sounds
+func convertOsuHitSound(hitSound int) string {
+	var hitSounds []string
+
+	for _, flag := range []struct {
+		Bit  int
+		Name string
+	}{{2, "Whistle"}, {4, "Finish"}, {8, "Clap"}} {
+		if hitSound&flag.Bit != 0 {
+			hitSounds = append(hitSounds, flag.Name)
+		}
+	}
+
+	return strings.Join(hitSounds, ", ")
+}
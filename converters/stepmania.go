@@ -0,0 +1,274 @@
+package converters
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const stepManiaDanceSingle string = "dance-single"
+
+var stepManiaCommentRegex = regexp.MustCompile(`//[^\n]*`)
+
+// A change in BPM or a stop at a given beat
+type stepManiaTimingEvent struct {
+	Beat  float64
+	Value float64
+}
+
+type stepManiaChart struct {
+	Type        string
+	Description string
+	Difficulty  string
+	Notes       string
+}
+
+// ConvertStepMania Converts a StepMania file (.sm) into a Qua for every dance-single chart that it contains
+func ConvertStepMania(file []byte) ([]*qua.Qua, error) {
+	tags, charts := parseStepManiaTags(string(file))
+
+	bpms, err := parseStepManiaTimingEvents(tags["BPMS"])
+
+	if err != nil || len(bpms) == 0 {
+		return nil, errors.New("the file does not have any valid BPMs")
+	}
+
+	for _, bpm := range bpms {
+		if bpm.Value <= 0 {
+			return nil, errors.New("charts with negative or zero BPMs are not supported")
+		}
+	}
+
+	stops, err := parseStepManiaTimingEvents(tags["STOPS"])
+
+	if err != nil {
+		return nil, errors.New("the file has invalid stops")
+	}
+
+	offset, _ := strconv.ParseFloat(tags["OFFSET"], 64)
+	sampleStart, _ := strconv.ParseFloat(tags["SAMPLESTART"], 64)
+
+	timing := &stepManiaTiming{Offset: offset, BPMs: bpms, Stops: stops}
+	var quaFiles []*qua.Qua
+
+	for _, chart := range charts {
+		if chart.Type != stepManiaDanceSingle {
+			continue
+		}
+
+		q := &qua.Qua{
+			AudioFile:       tags["MUSIC"],
+			SongPreviewTime: max(int(sampleStart*1000), 0),
+			BackgroundFile:  tags["BACKGROUND"],
+			BannerFile:      tags["BANNER"],
+			MapId:           -1,
+			MapSetId:        -1,
+			RawMode:         "Keys4",
+			Mode:            enums.GameModeKeys4,
+			Title:           firstNonEmpty(tags["TITLETRANSLIT"], tags["TITLE"]),
+			Artist:          firstNonEmpty(tags["ARTISTTRANSLIT"], tags["ARTIST"]),
+			Creator:         tags["CREDIT"],
+			DifficultyName:  firstNonEmpty(chart.Difficulty, chart.Description),
+			Description:     "This is a converted StepMania chart.",
+		}
+
+		for _, bpm := range bpms {
+			q.TimingPoints = append(q.TimingPoints, qua.TimingPoint{
+				StartTime: float32(timing.TimeAt(bpm.Beat)),
+				BPM:       float32(bpm.Value),
+			})
+		}
+
+		if err := convertStepManiaNotes(q, timing, chart.Notes); err != nil {
+			return nil, fmt.Errorf("%v chart: %v", q.DifficultyName, err)
+		}
+
+		q.RawBytes = q.Serialize()
+		quaFiles = append(quaFiles, q)
+	}
+
+	if len(quaFiles) == 0 {
+		return nil, errors.New("the file does not contain any dance-single charts, only 4K charts can be converted")
+	}
+
+	return quaFiles, nil
+}
+
+// Parses the #TAG:value; pairs of the file. Each #NOTES tag is returned as a separate chart.
+func parseStepManiaTags(file string) (map[string]string, []stepManiaChart) {
+	file = stepManiaCommentRegex.ReplaceAllString(file, "")
+
+	tags := map[string]string{}
+	var charts []stepManiaChart
+
+	for {
+		start := strings.Index(file, "#")
+
+		if start == -1 {
+			break
+		}
+
+		tag, rest, _ := strings.Cut(file[start+1:], ";")
+		file = rest
+
+		key, value, found := strings.Cut(tag, ":")
+
+		if !found {
+			continue
+		}
+
+		key = strings.ToUpper(strings.TrimSpace(key))
+
+		if key != "NOTES" {
+			tags[key] = strings.TrimSpace(value)
+			continue
+		}
+
+		fields := strings.SplitN(value, ":", 6)
+
+		if len(fields) < 6 {
+			continue
+		}
+
+		charts = append(charts, stepManiaChart{
+			Type:        strings.TrimSpace(fields[0]),
+			Description: strings.TrimSpace(fields[1]),
+			Difficulty:  strings.TrimSpace(fields[2]),
+			Notes:       fields[5],
+		})
+	}
+
+	return tags, charts
+}
+
+// Parses a list of beat=value pairs (#BPMS and #STOPS)
+func parseStepManiaTimingEvents(value string) ([]stepManiaTimingEvent, error) {
+	var events []stepManiaTimingEvent
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+
+		if pair == "" {
+			continue
+		}
+
+		beatStr, valueStr, found := strings.Cut(pair, "=")
+
+		if !found {
+			return nil, fmt.Errorf("invalid timing event: %v", pair)
+		}
+
+		beat, errBeat := strconv.ParseFloat(strings.TrimSpace(beatStr), 64)
+		eventValue, errValue := strconv.ParseFloat(strings.TrimSpace(valueStr), 64)
+
+		if errBeat != nil || errValue != nil || math.IsNaN(eventValue) || math.IsInf(eventValue, 0) {
+			return nil, fmt.Errorf("invalid timing event: %v", pair)
+		}
+
+		events = append(events, stepManiaTimingEvent{Beat: beat, Value: eventValue})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Beat < events[j].Beat })
+
+	return events, nil
+}
+
+type stepManiaTiming struct {
+	Offset float64
+	BPMs   []stepManiaTimingEvent
+	Stops  []stepManiaTimingEvent
+}
+
+// TimeAt Returns the time in milliseconds of a beat, taking BPM changes and stops into account
+func (t *stepManiaTiming) TimeAt(beat float64) float64 {
+	seconds := -t.Offset
+	previousBeat := 0.0
+	bpm := t.BPMs[0].Value
+
+	for _, change := range t.BPMs[1:] {
+		if change.Beat >= beat {
+			break
+		}
+
+		seconds += (change.Beat - previousBeat) * 60 / bpm
+		previousBeat = change.Beat
+		bpm = change.Value
+	}
+
+	seconds += (beat - previousBeat) * 60 / bpm
+
+	for _, stop := range t.Stops {
+		if stop.Beat < beat {
+			seconds += stop.Value
+		}
+	}
+
+	return seconds * 1000
+}
+
+// Converts the measures of a chart into hit objects
+func convertStepManiaNotes(q *qua.Qua, timing *stepManiaTiming, notes string) error {
+	keyCount := q.KeyCount(false)
+	heldNotes := map[int]*qua.HitObject{}
+
+	for measureIndex, measure := range strings.Split(notes, ",") {
+		rows := strings.Fields(measure)
+
+		for rowIndex, row := range rows {
+			if len(row) != keyCount {
+				return fmt.Errorf("measure %v has a row with %v columns instead of %v", measureIndex+1, len(row), keyCount)
+			}
+
+			beat := float64(measureIndex)*4 + 4*float64(rowIndex)/float64(len(rows))
+			time := int(math.Round(timing.TimeAt(beat)))
+
+			for column, note := range row {
+				lane := column + 1
+
+				switch note {
+				case '1', 'L':
+					q.HitObjects = append(q.HitObjects, qua.HitObject{StartTime: time, Lane: lane, KeySounds: []qua.KeySound{}})
+				case '2', '4':
+					heldNotes[lane] = &qua.HitObject{StartTime: time, Lane: lane, KeySounds: []qua.KeySound{}}
+				case '3':
+					head, ok := heldNotes[lane]
+
+					if !ok {
+						return fmt.Errorf("measure %v has a hold end without a hold head in column %v", measureIndex+1, lane)
+					}
+
+					if time > head.StartTime {
+						head.EndTime = time
+					}
+
+					q.HitObjects = append(q.HitObjects, *head)
+					delete(heldNotes, lane)
+				}
+			}
+		}
+	}
+
+	if len(heldNotes) > 0 {
+		return errors.New("the chart has a hold note that never ends")
+	}
+
+	sort.SliceStable(q.HitObjects, func(i, j int) bool { return q.HitObjects[i].StartTime < q.HitObjects[j].StartTime })
+	return nil
+}
+
+// Returns the first string that isn't empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
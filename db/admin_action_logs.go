@@ -18,6 +18,9 @@ const (
 	AdminActionKicked  AdminActionLogType = "Kicked"
 	AdminActionUnmuted AdminActionLogType = "Unmuted"
 	AdminActionUpdated AdminActionLogType = "Updated"
+
+	AdminActionScoresDeleted  AdminActionLogType = "ScoresDeleted"
+	AdminActionScoresRestored AdminActionLogType = "ScoresRestored"
 )
 
 func (*AdminActionLog) TableName() string {
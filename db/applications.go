@@ -15,6 +15,7 @@ type Application struct {
 	Timestamp     int64     `gorm:"column:timestamp" json:"-"`
 	TimestampJSON time.Time `gorm:"-:all" json:"timestamp"`
 	Active        bool      `gorm:"column:active" json:"active"`
+	PublicClient  bool      `gorm:"column:public_client" json:"public_client"`
 }
 
 func (*Application) TableName() string {
@@ -120,3 +121,18 @@ func (app *Application) SetRedirectURL(url string) error {
 
 	return result.Error
 }
+
+// GetActiveApplicationByClientId Retrieves an active application by client id
+func GetActiveApplicationByClientId(clientId string) (*Application, error) {
+	var application *Application
+
+	result := SQL.
+		Where("client_id = ? AND active = 1", clientId).
+		First(&application)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return application, nil
+}
@@ -143,3 +143,39 @@ func CalculateClanScore(md5 string, clanId int, mode enums.GameMode) (*ClanScore
 
 	return score, nil
 }
+
+// RecalculateClanScore Recalculates a clan's score on a map from its members' scores.
+// The clan score is removed if none of its members have a score on the map anymore.
+func RecalculateClanScore(md5 string, clanId int, mode enums.GameMode) error {
+	existing, err := GetClanScore(md5, clanId)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	scores, err := GetClanPlayerScoresOnMap(md5, clanId, false)
+
+	if err != nil {
+		return err
+	}
+
+	if len(scores) == 0 {
+		if existing == nil {
+			return nil
+		}
+
+		return SQL.Delete(&ClanScore{}, existing.Id).Error
+	}
+
+	newScore, err := CalculateClanScore(md5, clanId, mode)
+
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		newScore.Id = existing.Id
+	}
+
+	return SQL.Save(&newScore).Error
+}
@@ -186,7 +186,13 @@ func GetClanTagAndAccentColor(clanId int) (string, *string, error) {
 
 // DeleteClan Fully deletes a clan with a given id
 func DeleteClan(id int) error {
-	err := SQL.Transaction(func(tx *gorm.DB) error {
+	members, err := GetUsersInClan(id)
+
+	if err != nil {
+		return err
+	}
+
+	err = SQL.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Delete(&Clan{}, "id = ?", id).Error; err != nil {
 			return err
 		}
@@ -222,6 +228,10 @@ func DeleteClan(id int) error {
 		return err
 	}
 
+	for _, member := range members {
+		logScoreboardInvalidationError(InvalidateUserScoreboards(member.Id, ScoreboardInvalidationClan))
+	}
+
 	return nil
 }
 
@@ -286,7 +296,12 @@ func (clan *Clan) UpdateTag(tag string) error {
 		Where("id = ?", clan.Id).
 		Update("tag", tag)
 
-	return result.Error
+	if result.Error != nil {
+		return result.Error
+	}
+
+	logScoreboardInvalidationError(InvalidateClanScoreboards(clan.Id))
+	return nil
 }
 
 // UpdateFavoriteMode Updates the favorite mode of a clan
@@ -322,7 +337,12 @@ func (clan *Clan) UpdateAccentColor(hex string) error {
 		Where("id = ?", clan.Id).
 		Update("accent_color", hex)
 
-	return result.Error
+	if result.Error != nil {
+		return result.Error
+	}
+
+	logScoreboardInvalidationError(InvalidateClanScoreboards(clan.Id))
+	return nil
 }
 
 func (clan *Clan) UpdateLastUpdated() error {
@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm"
 	"sort"
 	"strconv"
 	"strings"
@@ -20,6 +21,14 @@ func TotalHitsLeaderboardRedisKey() string {
 	return "quaver:leaderboard:total_hits_global"
 }
 
+func SeasonLeaderboardRedisKey(seasonId int, mode enums.GameMode) string {
+	return fmt.Sprintf("quaver:season:%v:leaderboard:%v", seasonId, mode)
+}
+
+func SeasonCountryLeaderboardRedisKey(seasonId int, country string, mode enums.GameMode) string {
+	return fmt.Sprintf("quaver:season:%v:country_leaderboard:%v:%v", seasonId, strings.ToLower(country), mode)
+}
+
 // GetGlobalLeaderboard Retrieves the global leaderboard for a specific game mode
 func GetGlobalLeaderboard(mode enums.GameMode, page int, limit int) ([]*User, error) {
 	users, err := getLeaderboardUsers(GlobalLeaderboardRedisKey(mode), page, limit)
@@ -126,5 +135,42 @@ func RemoveUserFromLeaderboards(user *User) error {
 		return err
 	}
 
+	for _, leaderboard := range ModLeaderboards {
+		for i := 1; i <= 2; i++ {
+			if err := Redis.ZRem(RedisCtx, leaderboard.RedisKey(enums.GameMode(i)), strconv.Itoa(user.Id)).Err(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return removeUserFromSeasonLeaderboards(user)
+}
+
+// Removes a user from the leaderboards of the active season
+func removeUserFromSeasonLeaderboards(user *User) error {
+	season, err := GetActiveSeason()
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	for i := 1; i <= 2; i++ {
+		mode := enums.GameMode(i)
+		global := SeasonLeaderboardRedisKey(season.Id, mode)
+		country := SeasonCountryLeaderboardRedisKey(season.Id, user.Country, mode)
+
+		if err := Redis.ZRem(RedisCtx, global, strconv.Itoa(user.Id)).Err(); err != nil {
+			return err
+		}
+
+		if err := Redis.ZRem(RedisCtx, country, strconv.Itoa(user.Id)).Err(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,55 @@
+package db
+
+type MapRevision struct {
+	Id        int    `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	MapId     int    `gorm:"column:map_id" json:"map_id"`
+	MapsetId  int    `gorm:"column:mapset_id" json:"mapset_id"`
+	MD5       string `gorm:"column:md5" json:"md5"`
+	Timestamp int64  `gorm:"column:timestamp" json:"timestamp"`
+}
+
+func (*MapRevision) TableName() string {
+	return "map_revisions"
+}
+
+func (r *MapRevision) Insert() error {
+	return SQL.Create(&r).Error
+}
+
+// Delete Deletes a revision
+func (r *MapRevision) Delete() error {
+	return SQL.
+		Delete(&MapRevision{}, "id = ?", r.Id).
+		Error
+}
+
+// GetMapRevisions Retrieves every stored revision of a map, newest first
+func GetMapRevisions(mapId int) ([]*MapRevision, error) {
+	revisions := make([]*MapRevision, 0)
+
+	result := SQL.
+		Where("map_id = ?", mapId).
+		Order("id DESC").
+		Find(&revisions)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return revisions, nil
+}
+
+// GetMapRevisionById Retrieves a revision of a map by its id
+func GetMapRevisionById(mapId int, id int) (*MapRevision, error) {
+	var revision *MapRevision
+
+	result := SQL.
+		Where("map_id = ? AND id = ?", mapId, id).
+		First(&revision)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return revision, nil
+}
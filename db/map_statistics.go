@@ -0,0 +1,354 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm/clause"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	mapStatisticsCacheDuration = time.Hour
+
+	// The redis set of map ids that have new scores and need their statistics refreshed
+	mapStatisticsDirtyRedisKey = "quaver:map_statistics:dirty"
+)
+
+// The lower bounds of the accuracy histogram buckets
+var mapStatisticsAccuracyBuckets = []float64{0, 70, 80, 85, 90, 92, 94, 95, 96, 97, 98, 99}
+
+// MapStatistics Aggregates over every score that has been set on a map
+type MapStatistics struct {
+	MapId             int                     `json:"map_id"`
+	TotalPlays        int                     `json:"total_plays"`
+	TotalPasses       int                     `json:"total_passes"`
+	Grades            map[string]int          `json:"grades"`
+	AccuracyHistogram []*MapAccuracyBucket    `json:"accuracy_histogram"`
+	AccuracyByRate    []*MapRateStatistics    `json:"accuracy_by_rate"`
+	PassRateByMods    []*MapModsStatistics    `json:"pass_rate_by_mods"`
+	PlayerRatings     *MapPlayerRatingsSpread `json:"player_ratings"`
+	UpdatedAt         time.Time               `json:"updated_at"`
+}
+
+// MapAccuracyBucket The amount of passed scores with an accuracy of at least Min and below Max
+type MapAccuracyBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// MapRateStatistics The average accuracy of passed scores played at a rate
+type MapRateStatistics struct {
+	Rate            float32 `json:"rate"`
+	Passes          int     `json:"passes"`
+	AverageAccuracy float64 `json:"average_accuracy"`
+}
+
+// MapModsStatistics How often a combination of mods was passed
+type MapModsStatistics struct {
+	Mods        enums.Mods `json:"mods"`
+	ModsString  string     `json:"mods_string"`
+	Plays       int        `json:"plays"`
+	Passes      int        `json:"passes"`
+	PassRate    float64    `json:"pass_rate"`
+	AvgAccuracy float64    `json:"average_accuracy"`
+}
+
+// MapPlayerRatingsSpread The spread of overall ratings of the players that have played a map
+type MapPlayerRatingsSpread struct {
+	Players int     `json:"players"`
+	Min     float64 `json:"min"`
+	P25     float64 `json:"p25"`
+	Median  float64 `json:"median"`
+	P75     float64 `json:"p75"`
+	Max     float64 `json:"max"`
+}
+
+// MapStatisticsRow The materialized statistics of a map
+type MapStatisticsRow struct {
+	MapId      int             `gorm:"column:map_id; PRIMARY_KEY"`
+	Statistics json.RawMessage `gorm:"column:statistics"`
+	UpdatedAt  int64           `gorm:"column:updated_at"`
+}
+
+func (*MapStatisticsRow) TableName() string {
+	return "map_statistics"
+}
+
+type mapStatisticsModsRow struct {
+	Mods        enums.Mods
+	Plays       int
+	Passes      int
+	AvgAccuracy float64
+}
+
+type mapStatisticsAccuracyRow struct {
+	Accuracy int
+	Count    int
+}
+
+func mapStatisticsRedisKey(mapId int) string {
+	return fmt.Sprintf("quaver:map_statistics:%v", mapId)
+}
+
+// GetMapStatistics Retrieves the statistics of a map from cache, or the materialized statistics in the database.
+// Statistics are calculated if the map doesn't have any yet.
+func GetMapStatistics(mapQua *MapQua) (*MapStatistics, error) {
+	var stats *MapStatistics
+
+	err := CacheJsonInRedis(mapStatisticsRedisKey(mapQua.Id), &stats, mapStatisticsCacheDuration, false, func() error {
+		var row *MapStatisticsRow
+
+		result := SQL.
+			Where("map_id = ?", mapQua.Id).
+			Limit(1).
+			Find(&row)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected > 0 {
+			return json.Unmarshal(row.Statistics, &stats)
+		}
+
+		var err error
+		stats, err = RefreshMapStatistics(mapQua)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// RefreshMapStatistics Calculates the statistics of a map and materializes them in the database
+func RefreshMapStatistics(mapQua *MapQua) (*MapStatistics, error) {
+	stats, err := calculateMapStatistics(mapQua)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(stats)
+
+	if err != nil {
+		return nil, err
+	}
+
+	row := &MapStatisticsRow{MapId: mapQua.Id, Statistics: data, UpdatedAt: stats.UpdatedAt.UnixMilli()}
+
+	if err := SQL.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+		return nil, err
+	}
+
+	if err := Redis.Del(RedisCtx, mapStatisticsRedisKey(mapQua.Id)).Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// MarkMapStatisticsDirty Queues a map to have its statistics refreshed
+func MarkMapStatisticsDirty(mapId int) error {
+	return Redis.SAdd(RedisCtx, mapStatisticsDirtyRedisKey, mapId).Err()
+}
+
+// PopDirtyMapStatistics Removes and returns a batch of maps that need their statistics refreshed
+func PopDirtyMapStatistics(count int) ([]int, error) {
+	members, err := Redis.SPopN(RedisCtx, mapStatisticsDirtyRedisKey, int64(count)).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(members))
+
+	for _, member := range members {
+		id, err := strconv.Atoi(member)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func calculateMapStatistics(mapQua *MapQua) (*MapStatistics, error) {
+	stats := &MapStatistics{
+		MapId:     mapQua.Id,
+		Grades:    map[string]int{},
+		UpdatedAt: time.Now(),
+	}
+
+	var grades []struct {
+		Grade string
+		Count int
+	}
+
+	result := SQL.
+		Model(&Score{}).
+		Select("grade, COUNT(*) AS count").
+		Where("map_md5 = ? AND failed = 0", mapQua.MD5).
+		Group("grade").
+		Scan(&grades)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, grade := range grades {
+		stats.Grades[grade.Grade] = grade.Count
+	}
+
+	var accuracies []*mapStatisticsAccuracyRow
+
+	result = SQL.
+		Model(&Score{}).
+		Select("FLOOR(accuracy) AS accuracy, COUNT(*) AS count").
+		Where("map_md5 = ? AND failed = 0", mapQua.MD5).
+		Group("FLOOR(accuracy)").
+		Scan(&accuracies)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	stats.AccuracyHistogram = buildMapAccuracyHistogram(accuracies)
+
+	var mods []*mapStatisticsModsRow
+
+	result = SQL.
+		Model(&Score{}).
+		Select("mods, COUNT(*) AS plays, SUM(failed = 0) AS passes, "+
+			"COALESCE(AVG(CASE WHEN failed = 0 THEN accuracy END), 0) AS avg_accuracy").
+		Where("map_md5 = ?", mapQua.MD5).
+		Group("mods").
+		Scan(&mods)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	stats.PassRateByMods, stats.AccuracyByRate = buildMapModsStatistics(mods)
+
+	for _, m := range mods {
+		stats.TotalPlays += m.Plays
+		stats.TotalPasses += m.Passes
+	}
+
+	var ratings []float64
+
+	result = SQL.
+		Table(fmt.Sprintf("user_stats_%v", enums.GetGameModeString(mapQua.GameMode))).
+		Where("user_id IN (?)", SQL.Model(&Score{}).Distinct("user_id").Where("map_md5 = ?", mapQua.MD5)).
+		Order("overall_performance_rating ASC").
+		Pluck("overall_performance_rating", &ratings)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	stats.PlayerRatings = buildMapPlayerRatingsSpread(ratings)
+	return stats, nil
+}
+
+// Groups the amount of scores per whole accuracy percentage into the histogram buckets
+func buildMapAccuracyHistogram(rows []*mapStatisticsAccuracyRow) []*MapAccuracyBucket {
+	buckets := make([]*MapAccuracyBucket, 0, len(mapStatisticsAccuracyBuckets))
+
+	for i, min := range mapStatisticsAccuracyBuckets {
+		max := 100.0
+
+		if i < len(mapStatisticsAccuracyBuckets)-1 {
+			max = mapStatisticsAccuracyBuckets[i+1]
+		}
+
+		buckets = append(buckets, &MapAccuracyBucket{Min: min, Max: max})
+	}
+
+	for _, row := range rows {
+		for i := len(buckets) - 1; i >= 0; i-- {
+			if float64(row.Accuracy) >= buckets[i].Min {
+				buckets[i].Count += row.Count
+				break
+			}
+		}
+	}
+
+	return buckets
+}
+
+// Returns the pass rate of each mod combination and the average accuracy of each rate
+func buildMapModsStatistics(rows []*mapStatisticsModsRow) ([]*MapModsStatistics, []*MapRateStatistics) {
+	mods := make([]*MapModsStatistics, 0, len(rows))
+	rates := make([]*MapRateStatistics, 0)
+
+	for _, row := range rows {
+		stats := &MapModsStatistics{
+			Mods:        row.Mods,
+			ModsString:  enums.GetModsString(row.Mods),
+			Plays:       row.Plays,
+			Passes:      row.Passes,
+			AvgAccuracy: row.AvgAccuracy,
+		}
+
+		if row.Plays > 0 {
+			stats.PassRate = float64(row.Passes) / float64(row.Plays) * 100
+		}
+
+		mods = append(mods, stats)
+
+		if row.Passes == 0 {
+			continue
+		}
+
+		rate := enums.GetRateFromMods(row.Mods)
+		index := slices.IndexFunc(rates, func(r *MapRateStatistics) bool { return r.Rate == rate })
+
+		if index == -1 {
+			rates = append(rates, &MapRateStatistics{Rate: rate})
+			index = len(rates) - 1
+		}
+
+		// Weighted by the amount of passes of each combination
+		total := rates[index].AverageAccuracy*float64(rates[index].Passes) + row.AvgAccuracy*float64(row.Passes)
+		rates[index].Passes += row.Passes
+		rates[index].AverageAccuracy = total / float64(rates[index].Passes)
+	}
+
+	slices.SortFunc(mods, func(a, b *MapModsStatistics) int { return b.Plays - a.Plays })
+	slices.SortFunc(rates, func(a, b *MapRateStatistics) int { return int(a.Rate*100) - int(b.Rate*100) })
+
+	return mods, rates
+}
+
+// Returns the spread of a list of ratings, which must be sorted in ascending order
+func buildMapPlayerRatingsSpread(ratings []float64) *MapPlayerRatingsSpread {
+	spread := &MapPlayerRatingsSpread{Players: len(ratings)}
+
+	if len(ratings) == 0 {
+		return spread
+	}
+
+	percentile := func(p float64) float64 {
+		return ratings[int(math.Round(p*float64(len(ratings)-1)))]
+	}
+
+	spread.Min = ratings[0]
+	spread.P25 = percentile(0.25)
+	spread.Median = percentile(0.5)
+	spread.P75 = percentile(0.75)
+	spread.Max = ratings[len(ratings)-1]
+
+	return spread
+}
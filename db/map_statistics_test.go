@@ -0,0 +1,62 @@
+package db
+
+import (
+	"github.com/Quaver/api2/enums"
+	"math"
+	"testing"
+)
+
+func TestBuildMapAccuracyHistogram(t *testing.T) {
+	buckets := buildMapAccuracyHistogram([]*mapStatisticsAccuracyRow{
+		{Accuracy: 50, Count: 1},
+		{Accuracy: 92, Count: 2},
+		{Accuracy: 93, Count: 3},
+		{Accuracy: 99, Count: 4},
+		{Accuracy: 100, Count: 5},
+	})
+
+	expected := map[float64]int{0: 1, 92: 5, 99: 9}
+
+	for _, bucket := range buckets {
+		if bucket.Count != expected[bucket.Min] {
+			t.Errorf("expected %v scores in bucket %v-%v, got %v", expected[bucket.Min], bucket.Min, bucket.Max, bucket.Count)
+		}
+	}
+
+	if last := buckets[len(buckets)-1]; last.Max != 100 {
+		t.Errorf("expected the last bucket to end at 100, got %v", last.Max)
+	}
+}
+
+func TestBuildMapModsStatistics(t *testing.T) {
+	mods, rates := buildMapModsStatistics([]*mapStatisticsModsRow{
+		{Mods: 0, Plays: 10, Passes: 5, AvgAccuracy: 96},
+		{Mods: enums.ModMirror, Plays: 4, Passes: 1, AvgAccuracy: 90},
+		{Mods: enums.ModSpeed12X, Plays: 20, Passes: 0},
+	})
+
+	if len(mods) != 3 || mods[0].Mods != enums.ModSpeed12X || mods[0].PassRate != 0 {
+		t.Fatalf("expected mod combinations to be sorted by plays, got %+v", mods)
+	}
+
+	if mods[1].PassRate != 50 || mods[2].PassRate != 25 {
+		t.Errorf("unexpected pass rates: %v, %v", mods[1].PassRate, mods[2].PassRate)
+	}
+
+	// Rates without any passes are left out, and 1.0x combines no mods & mirror
+	if len(rates) != 1 || rates[0].Rate != 1 || rates[0].Passes != 6 || math.Abs(rates[0].AverageAccuracy-95) > 1e-9 {
+		t.Errorf("unexpected rate statistics: %+v", rates)
+	}
+}
+
+func TestBuildMapPlayerRatingsSpread(t *testing.T) {
+	spread := buildMapPlayerRatingsSpread([]float64{1, 2, 3, 4, 5})
+
+	if spread.Players != 5 || spread.Min != 1 || spread.P25 != 2 || spread.Median != 3 || spread.P75 != 4 || spread.Max != 5 {
+		t.Errorf("unexpected spread: %+v", spread)
+	}
+
+	if empty := buildMapPlayerRatingsSpread(nil); empty.Players != 0 {
+		t.Errorf("unexpected spread: %+v", empty)
+	}
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"encoding/json"
+	"gorm.io/gorm/clause"
+)
+
+// MapsetAutoMod The result of the last time AutoMod ran on a mapset, whether or not it was accepted into the ranking queue
+type MapsetAutoMod struct {
+	MapsetId   int             `gorm:"column:mapset_id; PRIMARY_KEY" json:"mapset_id"`
+	PackageMD5 string          `gorm:"column:package_md5" json:"package_md5"`
+	HasIssues  bool            `gorm:"column:has_issues" json:"has_issues"`
+	Result     json.RawMessage `gorm:"column:result" json:"result"`
+	Timestamp  int64           `gorm:"column:timestamp" json:"timestamp"`
+}
+
+func (*MapsetAutoMod) TableName() string {
+	return "mapset_automod"
+}
+
+// Save Inserts or replaces the AutoMod result of a mapset
+func (m *MapsetAutoMod) Save() error {
+	return SQL.Clauses(clause.OnConflict{UpdateAll: true}).Create(&m).Error
+}
+
+// GetMapsetAutoMod Retrieves the last AutoMod result of a mapset
+func GetMapsetAutoMod(mapsetId int) (*MapsetAutoMod, error) {
+	var automod *MapsetAutoMod
+
+	result := SQL.
+		Where("mapset_id = ?", mapsetId).
+		First(&automod)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return automod, nil
+}
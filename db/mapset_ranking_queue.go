@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/Quaver/api2/enums"
 	"gorm.io/gorm"
@@ -27,6 +28,7 @@ type RankingQueueMapset struct {
 	LastUpdatedJSON time.Time                    `gorm:"-:all" json:"last_updated"` // Same value as DateLastUpdated
 	Status          RankingQueueStatus           `gorm:"column:status" json:"status"`
 	NeedsAttention  bool                         `gorm:"column:needs_attention" json:"-"`
+	AutoModIssues   json.RawMessage              `gorm:"column:automod_issues" json:"automod_issues,omitempty"`
 	VoteCount       int                          `gorm:"-:all" json:"-"`
 	Mapset          *Mapset                      `gorm:"foreignKey:MapsetId; references:Id" json:"mapset"`
 	Votes           []*MapsetRankingQueueComment `gorm:"-:all" json:"votes,omitempty"`
@@ -66,6 +68,17 @@ func (mapset *RankingQueueMapset) UpdateStatus(status RankingQueueStatus) error
 	return result.Error
 }
 
+// UpdateAutoModIssues Updates the stored AutoMod result of a ranking queue mapset
+func (mapset *RankingQueueMapset) UpdateAutoModIssues(issues json.RawMessage) error {
+	mapset.AutoModIssues = issues
+
+	result := SQL.Model(&RankingQueueMapset{}).
+		Where("id = ?", mapset.Id).
+		Update("automod_issues", issues)
+
+	return result.Error
+}
+
 // UpdateVoteCount Updates the vote count of a ranking queue mapset
 func (mapset *RankingQueueMapset) UpdateVoteCount(votes int) error {
 	mapset.VoteCount = votes
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"fmt"
+	"github.com/Quaver/api2/config"
+	"github.com/Quaver/api2/enums"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The amount of best scores that make up the overall rating of a user on a mod leaderboard
+const modLeaderboardScoreCount = 500
+
+// ModLeaderboard A global player leaderboard that only counts scores played with a modifier
+type ModLeaderboard struct {
+	Name string     `json:"name"`
+	Mod  enums.Mods `json:"mods"`
+}
+
+// ModLeaderboardUser A user's position on a mod leaderboard
+type ModLeaderboardUser struct {
+	Rank                     int     `json:"rank"`
+	OverallPerformanceRating float64 `json:"overall_performance_rating"`
+	User                     *User   `json:"user"`
+}
+
+// ModLeaderboards Every mod leaderboard that can be enabled in the config.
+// Fixed rate leaderboards are added for each speed modifier, e.g. "rate_1.2".
+var ModLeaderboards = getModLeaderboards()
+
+func getModLeaderboards() []*ModLeaderboard {
+	leaderboards := []*ModLeaderboard{
+		{Name: "nln", Mod: enums.ModNoLongNotes},
+		{Name: "fln", Mod: enums.ModFullLN},
+		{Name: "inverse", Mod: enums.ModInverse},
+		{Name: "mirror", Mod: enums.ModMirror},
+	}
+
+	rates := make([]*ModLeaderboard, 0, len(enums.ModRates))
+
+	for _, rate := range enums.ModRates {
+		name := fmt.Sprintf("rate_%v", strconv.FormatFloat(float64(rate.Rate), 'f', -1, 32))
+		rates = append(rates, &ModLeaderboard{Name: name, Mod: rate.Mod})
+	}
+
+	return append(leaderboards, rates...)
+}
+
+// GetEnabledModLeaderboards Returns the mod leaderboards that are enabled in the config
+func GetEnabledModLeaderboards() []*ModLeaderboard {
+	enabled := make([]*ModLeaderboard, 0)
+
+	for _, leaderboard := range ModLeaderboards {
+		if slices.Contains(config.Instance.ModLeaderboards, leaderboard.Name) {
+			enabled = append(enabled, leaderboard)
+		}
+	}
+
+	return enabled
+}
+
+// GetEnabledModLeaderboardByName Returns an enabled mod leaderboard by its name, or nil if it isn't enabled
+func GetEnabledModLeaderboardByName(name string) *ModLeaderboard {
+	for _, leaderboard := range GetEnabledModLeaderboards() {
+		if leaderboard.Name == strings.ToLower(name) {
+			return leaderboard
+		}
+	}
+
+	return nil
+}
+
+// Matches Returns if a score played with a combination of mods counts towards the leaderboard
+func (leaderboard *ModLeaderboard) Matches(mods enums.Mods) bool {
+	return enums.IsModActivated(mods, leaderboard.Mod)
+}
+
+// RedisKey Returns the redis key of the leaderboard for a game mode
+func (leaderboard *ModLeaderboard) RedisKey(mode enums.GameMode) string {
+	return fmt.Sprintf("quaver:mod_leaderboard:%v:%v", leaderboard.Name, mode)
+}
+
+// UpdateUser Recalculates the overall rating of a user from their scores with the leaderboard's mod
+func (leaderboard *ModLeaderboard) UpdateUser(userId int, mode enums.GameMode) error {
+	var scores = make([]*Score, 0)
+
+	result := SQL.
+		Joins("JOIN maps ON maps.md5 = scores.map_md5").
+		Where("scores.user_id = ? AND scores.mode = ? AND scores.failed = 0 AND maps.ranked_status = ? "+
+			"AND scores.mods & ? != 0", userId, mode, enums.RankedStatusRanked, leaderboard.Mod).
+		Where(notDeletedScoreQuery).
+		Order("scores.performance_rating DESC").
+		Find(&scores)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	best := GetBestScorePerMap(scores, modLeaderboardScoreCount)
+	key := leaderboard.RedisKey(mode)
+
+	if len(best) == 0 {
+		return Redis.ZRem(RedisCtx, key, strconv.Itoa(userId)).Err()
+	}
+
+	return Redis.ZAdd(RedisCtx, key, redis.Z{
+		Score:  CalculateOverallRating(best),
+		Member: strconv.Itoa(userId),
+	}).Err()
+}
+
+// Rebuild Recalculates the leaderboard for every unbanned user that has a passed score with the leaderboard's mod
+func (leaderboard *ModLeaderboard) Rebuild() error {
+	var players []struct {
+		UserId int
+		Mode   enums.GameMode
+	}
+
+	result := SQL.
+		Model(&Score{}).
+		Distinct("scores.user_id", "scores.mode").
+		Joins("JOIN users ON users.id = scores.user_id").
+		Where("scores.failed = 0 AND scores.mods & ? != 0 AND users.allowed = 1", leaderboard.Mod).
+		Scan(&players)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, player := range players {
+		if err := leaderboard.UpdateUser(player.UserId, player.Mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUsers Retrieves a page of the leaderboard for a game mode
+func (leaderboard *ModLeaderboard) GetUsers(mode enums.GameMode, page int, limit int) ([]*ModLeaderboardUser, error) {
+	entries, err := Redis.ZRevRangeWithScores(RedisCtx, leaderboard.RedisKey(mode),
+		int64(page*limit), int64(page*limit+limit-1)).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*ModLeaderboardUser, 0, len(entries))
+
+	if len(entries) == 0 {
+		return users, nil
+	}
+
+	userIds := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		userIds = append(userIds, entry.Member.(string))
+	}
+
+	var dbUsers = make([]*User, 0)
+
+	result := SQL.
+		Where("users.id IN ? AND allowed = 1", userIds).
+		Find(&dbUsers)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for i, entry := range entries {
+		index := slices.IndexFunc(dbUsers, func(u *User) bool { return strconv.Itoa(u.Id) == entry.Member })
+
+		if index == -1 {
+			continue
+		}
+
+		users = append(users, &ModLeaderboardUser{
+			Rank:                     page*limit + i + 1,
+			OverallPerformanceRating: entry.Score,
+			User:                     dbUsers[index],
+		})
+	}
+
+	return users, nil
+}
+
+// GetCount Retrieves the amount of users on the leaderboard for a game mode
+func (leaderboard *ModLeaderboard) GetCount(mode enums.GameMode) (int, error) {
+	count, err := Redis.ZCard(RedisCtx, leaderboard.RedisKey(mode)).Result()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"github.com/Quaver/api2/enums"
+	"testing"
+)
+
+func TestModLeaderboards(t *testing.T) {
+	expected := map[string]enums.Mods{
+		"nln":       enums.ModNoLongNotes,
+		"mirror":    enums.ModMirror,
+		"rate_0.5":  enums.ModSpeed05X,
+		"rate_1.2":  enums.ModSpeed12X,
+		"rate_1.55": enums.ModSpeed155X,
+		"rate_2":    enums.ModSpeed20X,
+	}
+
+	for name, mod := range expected {
+		found := false
+
+		for _, leaderboard := range ModLeaderboards {
+			if leaderboard.Name == name {
+				found = leaderboard.Mod == mod
+				break
+			}
+		}
+
+		if !found {
+			t.Errorf("expected mod leaderboard %v for mod %v", name, mod)
+		}
+	}
+
+	mirror := &ModLeaderboard{Name: "mirror", Mod: enums.ModMirror}
+
+	if !mirror.Matches(enums.ModMirror|enums.ModSpeed12X) || mirror.Matches(enums.ModSpeed12X) {
+		t.Error("unexpected mirror leaderboard matching")
+	}
+}
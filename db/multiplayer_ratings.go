@@ -0,0 +1,228 @@
+package db
+
+import (
+	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// MultiplayerLeaderboardMaxDeviation Players with a less certain rating than this are still provisional,
+// and aren't shown on the leaderboard
+const MultiplayerLeaderboardMaxDeviation = 150
+
+// MultiplayerRating A user's current multiplayer skill rating for a game mode
+type MultiplayerRating struct {
+	UserId        int            `gorm:"column:user_id; PRIMARY_KEY" json:"user_id"`
+	Mode          enums.GameMode `gorm:"column:mode; PRIMARY_KEY" json:"mode"`
+	Rating        float64        `gorm:"column:rating" json:"rating"`
+	Deviation     float64        `gorm:"column:deviation" json:"deviation"`
+	Volatility    float64        `gorm:"column:volatility" json:"volatility"`
+	MatchesPlayed int            `gorm:"column:matches_played" json:"matches_played"`
+	Wins          int            `gorm:"column:wins" json:"wins"`
+	UpdatedAt     int64          `gorm:"column:updated_at" json:"-"`
+	UpdatedAtJSON time.Time      `gorm:"-:all" json:"updated_at"`
+	Rank          int            `gorm:"-:all" json:"rank,omitempty"`
+	User          *User          `gorm:"foreignKey:UserId; references:Id" json:"user,omitempty"`
+}
+
+func (*MultiplayerRating) TableName() string {
+	return "multiplayer_ratings"
+}
+
+func (r *MultiplayerRating) AfterFind(*gorm.DB) (err error) {
+	r.UpdatedAtJSON = time.UnixMilli(r.UpdatedAt)
+	return nil
+}
+
+// MultiplayerRatingHistory A user's multiplayer rating after playing a match
+type MultiplayerRatingHistory struct {
+	Id            int            `gorm:"column:id; PRIMARY_KEY" json:"-"`
+	UserId        int            `gorm:"column:user_id" json:"-"`
+	Mode          enums.GameMode `gorm:"column:mode" json:"-"`
+	MatchId       int            `gorm:"column:match_id" json:"match_id"`
+	Rating        float64        `gorm:"column:rating" json:"rating"`
+	Deviation     float64        `gorm:"column:deviation" json:"deviation"`
+	Change        float64        `gorm:"column:change" json:"change"`
+	Timestamp     int64          `gorm:"column:timestamp" json:"-"`
+	TimestampJSON time.Time      `gorm:"-:all" json:"timestamp"`
+}
+
+func (*MultiplayerRatingHistory) TableName() string {
+	return "multiplayer_rating_history"
+}
+
+func (h *MultiplayerRatingHistory) AfterFind(*gorm.DB) (err error) {
+	h.TimestampJSON = time.UnixMilli(h.Timestamp)
+	return nil
+}
+
+// GetMultiplayerRatings Retrieves the ratings of users for a game mode, keyed by user id.
+// Users that haven't played a rated match yet are left out.
+func GetMultiplayerRatings(userIds []int, mode enums.GameMode) (map[int]*MultiplayerRating, error) {
+	var list = make([]*MultiplayerRating, 0)
+
+	result := SQL.
+		Where("user_id IN ? AND mode = ?", userIds, mode).
+		Find(&list)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	ratings := make(map[int]*MultiplayerRating, len(list))
+
+	for _, rating := range list {
+		ratings[rating.UserId] = rating
+	}
+
+	return ratings, nil
+}
+
+// GetUserMultiplayerRating Retrieves a user's multiplayer rating for a game mode
+func GetUserMultiplayerRating(userId int, mode enums.GameMode) (*MultiplayerRating, error) {
+	var rating *MultiplayerRating
+
+	result := SQL.
+		Where("user_id = ? AND mode = ?", userId, mode).
+		First(&rating)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return rating, nil
+}
+
+// GetUserMultiplayerRatingHistory Retrieves a user's rating after each match they've played since a given time
+func GetUserMultiplayerRatingHistory(userId int, mode enums.GameMode, since time.Time) ([]*MultiplayerRatingHistory, error) {
+	var history = make([]*MultiplayerRatingHistory, 0)
+
+	result := SQL.
+		Where("user_id = ? AND mode = ? AND timestamp >= ?", userId, mode, since.UnixMilli()).
+		Order("timestamp ASC, id ASC").
+		Find(&history)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return history, nil
+}
+
+// GetMultiplayerLeaderboard Retrieves a page of the players with the highest multiplayer rating in a game mode
+func GetMultiplayerLeaderboard(mode enums.GameMode, page int, limit int) ([]*MultiplayerRating, error) {
+	var ratings = make([]*MultiplayerRating, 0)
+
+	result := SQL.
+		Joins("User").
+		Where("multiplayer_ratings.mode = ? AND multiplayer_ratings.deviation <= ? AND `User`.allowed = 1",
+			mode, MultiplayerLeaderboardMaxDeviation).
+		Order("multiplayer_ratings.rating DESC").
+		Limit(limit).
+		Offset(page * limit).
+		Find(&ratings)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for i, rating := range ratings {
+		rating.Rank = page*limit + i + 1
+	}
+
+	return ratings, nil
+}
+
+// GetMultiplayerLeaderboardCount Retrieves the amount of players on the multiplayer leaderboard of a game mode
+func GetMultiplayerLeaderboardCount(mode enums.GameMode) (int, error) {
+	var count int
+
+	result := SQL.Raw("SELECT COUNT(*) FROM multiplayer_ratings mr "+
+		"INNER JOIN users u ON u.id = mr.user_id "+
+		"WHERE mr.mode = ? AND mr.deviation <= ? AND u.allowed = 1", mode, MultiplayerLeaderboardMaxDeviation).
+		Scan(&count)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+// GetLastProcessedMultiplayerMatchId Retrieves the id of the latest match that has been processed for ratings,
+// including matches that couldn't be rated
+func GetLastProcessedMultiplayerMatchId() (int, error) {
+	var id int
+
+	result := SQL.Raw("SELECT COALESCE(MAX(last_match_id), 0) FROM multiplayer_rating_progress").Scan(&id)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return id, nil
+}
+
+// SetLastProcessedMultiplayerMatchId Updates the id of the latest match that has been processed for ratings
+func SetLastProcessedMultiplayerMatchId(id int) error {
+	return setLastProcessedMultiplayerMatchId(SQL, id)
+}
+
+func setLastProcessedMultiplayerMatchId(tx *gorm.DB, id int) error {
+	return tx.Exec("INSERT INTO multiplayer_rating_progress (id, last_match_id) VALUES (1, ?) "+
+		"ON DUPLICATE KEY UPDATE last_match_id = VALUES(last_match_id)", id).Error
+}
+
+// GetMultiplayerMatchesForRating Retrieves completed matches after a given match id, along with their scores
+func GetMultiplayerMatchesForRating(afterId int, limit int) ([]*MultiplayerGameMatches, error) {
+	var matches = make([]*MultiplayerGameMatches, 0)
+
+	result := SQL.
+		Preload("Scores").
+		Where("id > ? AND aborted = 0", afterId).
+		Order("id ASC").
+		Limit(limit).
+		Find(&matches)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return matches, nil
+}
+
+// SaveMultiplayerMatchRatings Saves the ratings of the players of a match along with their history,
+// and marks the match as processed
+func SaveMultiplayerMatchRatings(matchId int, ratings []*MultiplayerRating, history []*MultiplayerRatingHistory) error {
+	if len(ratings) == 0 {
+		return SetLastProcessedMultiplayerMatchId(matchId)
+	}
+
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Clauses(clause.OnConflict{UpdateAll: true}).Create(&ratings).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+
+		return setLastProcessedMultiplayerMatchId(tx, matchId)
+	})
+}
+
+// ResetMultiplayerRatings Deletes every multiplayer rating along with its history
+func ResetMultiplayerRatings() error {
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM multiplayer_rating_history").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM multiplayer_ratings").Error; err != nil {
+			return err
+		}
+
+		return setLastProcessedMultiplayerMatchId(tx, 0)
+	})
+}
@@ -0,0 +1,441 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"strings"
+	"time"
+)
+
+type OAuthScope string
+
+const (
+	OAuthScopePublic             OAuthScope = "public"
+	OAuthScopeReadScores         OAuthScope = "read:scores"
+	OAuthScopeWriteScores        OAuthScope = "write:scores"
+	OAuthScopeWritePlaylists     OAuthScope = "write:playlists"
+	OAuthScopeReadNotifications  OAuthScope = "read:notifications"
+	OAuthScopeWriteNotifications OAuthScope = "write:notifications"
+	OAuthScopeWriteProfile       OAuthScope = "write:profile"
+	OAuthScopeReadFriends        OAuthScope = "read:friends"
+	OAuthScopeWriteFriends       OAuthScope = "write:friends"
+	OAuthScopeReadClans          OAuthScope = "read:clans"
+	OAuthScopeWriteClans         OAuthScope = "write:clans"
+	OAuthScopeWriteMapsets       OAuthScope = "write:mapsets"
+	OAuthScopeWriteComments      OAuthScope = "write:comments"
+	OAuthScopeWriteTournaments   OAuthScope = "write:tournaments"
+	OAuthScopeReadChat           OAuthScope = "read:chat"
+	OAuthScopeDownload           OAuthScope = "download"
+
+	// OAuthScopeModeration Allows using the user's staff privileges. Only personal access tokens can be granted it.
+	OAuthScopeModeration OAuthScope = "moderation"
+)
+
+// OAuthScopes Every scope that an application can request
+var OAuthScopes = []OAuthScope{
+	OAuthScopePublic,
+	OAuthScopeReadScores,
+	OAuthScopeWriteScores,
+	OAuthScopeWritePlaylists,
+	OAuthScopeReadNotifications,
+	OAuthScopeWriteNotifications,
+	OAuthScopeWriteProfile,
+	OAuthScopeReadFriends,
+	OAuthScopeWriteFriends,
+	OAuthScopeReadClans,
+	OAuthScopeWriteClans,
+	OAuthScopeWriteMapsets,
+	OAuthScopeWriteComments,
+	OAuthScopeWriteTournaments,
+	OAuthScopeReadChat,
+	OAuthScopeDownload,
+}
+
+// PersonalAccessTokenScopes Every scope that a personal access token can be granted
+var PersonalAccessTokenScopes = append(slices.Clone(OAuthScopes), OAuthScopeModeration)
+
+// ParseOAuthScopes Parses a space separated list of scopes an application requested. The public scope is always included.
+func ParseOAuthScopes(value string) ([]OAuthScope, error) {
+	return parseScopes(value, OAuthScopes)
+}
+
+// ParsePersonalAccessTokenScopes Parses a space separated list of scopes for a personal access token.
+// The public scope is always included.
+func ParsePersonalAccessTokenScopes(value string) ([]OAuthScope, error) {
+	return parseScopes(value, PersonalAccessTokenScopes)
+}
+
+func parseScopes(value string, allowed []OAuthScope) ([]OAuthScope, error) {
+	scopes := []OAuthScope{OAuthScopePublic}
+
+	for _, field := range strings.Fields(value) {
+		scope := OAuthScope(field)
+
+		if !slices.Contains(allowed, scope) {
+			return nil, fmt.Errorf("invalid scope: %v", field)
+		}
+
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes, nil
+}
+
+// FormatOAuthScopes Returns scopes as a space separated list
+func FormatOAuthScopes(scopes []OAuthScope) string {
+	values := make([]string, 0, len(scopes))
+
+	for _, scope := range scopes {
+		values = append(values, string(scope))
+	}
+
+	return strings.Join(values, " ")
+}
+
+// Parses scopes that have already been validated before being stored
+func parseStoredOAuthScopes(value string) []OAuthScope {
+	scopes, err := ParsePersonalAccessTokenScopes(value)
+
+	if err != nil {
+		return []OAuthScope{OAuthScopePublic}
+	}
+
+	return scopes
+}
+
+// OAuthAuthorizationCode A short-lived code that an application exchanges for tokens once a user has authorized it
+type OAuthAuthorizationCode struct {
+	Id                  int          `gorm:"column:id; PRIMARY_KEY"`
+	CodeHash            string       `gorm:"column:code_hash"`
+	ApplicationId       int          `gorm:"column:application_id"`
+	UserId              int          `gorm:"column:user_id"`
+	RedirectURL         string       `gorm:"column:redirect_url"`
+	Scopes              string       `gorm:"column:scopes"`
+	ScopeList           []OAuthScope `gorm:"-:all"`
+	CodeChallenge       string       `gorm:"column:code_challenge"`
+	CodeChallengeMethod string       `gorm:"column:code_challenge_method"`
+	ExpiresAt           int64        `gorm:"column:expires_at"`
+	CreatedAt           int64        `gorm:"column:created_at"`
+}
+
+func (*OAuthAuthorizationCode) TableName() string {
+	return "oauth_authorization_codes"
+}
+
+func (code *OAuthAuthorizationCode) AfterFind(*gorm.DB) (err error) {
+	code.ScopeList = parseStoredOAuthScopes(code.Scopes)
+	return nil
+}
+
+// Insert Inserts an authorization code into the database
+func (code *OAuthAuthorizationCode) Insert() error {
+	code.Scopes = FormatOAuthScopes(code.ScopeList)
+	code.CreatedAt = time.Now().UnixMilli()
+
+	return SQL.Create(&code).Error
+}
+
+// ConsumeOAuthAuthorizationCode Retrieves an authorization code by its hash and deletes it, so it can only be used once
+func ConsumeOAuthAuthorizationCode(hash string) (*OAuthAuthorizationCode, error) {
+	var code *OAuthAuthorizationCode
+
+	err := SQL.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("code_hash = ?", hash).
+			First(&code)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Delete(&OAuthAuthorizationCode{}, code.Id).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return code, nil
+}
+
+// DeleteExpiredOAuthAuthorizationCodes Deletes authorization codes that were never exchanged
+func DeleteExpiredOAuthAuthorizationCodes() error {
+	return SQL.Where("expires_at < ?", time.Now().UnixMilli()).Delete(&OAuthAuthorizationCode{}).Error
+}
+
+// OAuthToken An access token that lets an application act on behalf of a user, along with the refresh token used to renew it
+type OAuthToken struct {
+	Id                    int          `gorm:"column:id; PRIMARY_KEY"`
+	ApplicationId         int          `gorm:"column:application_id"`
+	UserId                int          `gorm:"column:user_id"`
+	AccessTokenHash       string       `gorm:"column:access_token_hash"`
+	RefreshTokenHash      string       `gorm:"column:refresh_token_hash"`
+	Scopes                string       `gorm:"column:scopes"`
+	ScopeList             []OAuthScope `gorm:"-:all"`
+	AccessTokenExpiresAt  int64        `gorm:"column:access_token_expires_at"`
+	RefreshTokenExpiresAt int64        `gorm:"column:refresh_token_expires_at"`
+	CreatedAt             int64        `gorm:"column:created_at"`
+	RevokedAt             *int64       `gorm:"column:revoked_at"`
+}
+
+func (*OAuthToken) TableName() string {
+	return "oauth_tokens"
+}
+
+func (token *OAuthToken) AfterFind(*gorm.DB) (err error) {
+	token.ScopeList = parseStoredOAuthScopes(token.Scopes)
+	return nil
+}
+
+// HasScope Returns if the token was granted a scope
+func (token *OAuthToken) HasScope(scope OAuthScope) bool {
+	return slices.Contains(token.ScopeList, scope)
+}
+
+// Insert Inserts a token into the database
+func (token *OAuthToken) Insert() error {
+	token.Scopes = FormatOAuthScopes(token.ScopeList)
+	token.CreatedAt = time.Now().UnixMilli()
+
+	return SQL.Create(&token).Error
+}
+
+// Revoke Revokes the token, so neither its access nor its refresh token can be used anymore
+func (token *OAuthToken) Revoke() error {
+	now := time.Now().UnixMilli()
+	token.RevokedAt = &now
+
+	return SQL.Model(&OAuthToken{}).
+		Where("id = ? AND revoked_at IS NULL", token.Id).
+		Update("revoked_at", now).Error
+}
+
+// GetOAuthTokenByAccessToken Retrieves an unexpired, unrevoked token of an active application by the hash of its access token
+func GetOAuthTokenByAccessToken(hash string) (*OAuthToken, error) {
+	var token *OAuthToken
+
+	result := SQL.
+		Joins("JOIN applications ON applications.id = oauth_tokens.application_id AND applications.active = 1").
+		Where("oauth_tokens.access_token_hash = ? AND oauth_tokens.revoked_at IS NULL "+
+			"AND oauth_tokens.access_token_expires_at > ?", hash, time.Now().UnixMilli()).
+		First(&token)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return token, nil
+}
+
+// GetOAuthTokenByRefreshToken Retrieves a token by the hash of its refresh token, including if it has been revoked
+func GetOAuthTokenByRefreshToken(hash string) (*OAuthToken, error) {
+	var token *OAuthToken
+
+	result := SQL.
+		Where("refresh_token_hash = ?", hash).
+		First(&token)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return token, nil
+}
+
+// GetOAuthTokenByAnyToken Retrieves a token by the hash of either its access or refresh token
+func GetOAuthTokenByAnyToken(hash string) (*OAuthToken, error) {
+	var token *OAuthToken
+
+	result := SQL.
+		Where("access_token_hash = ? OR refresh_token_hash = ?", hash, hash).
+		First(&token)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return token, nil
+}
+
+// RefreshOAuthToken Revokes a token and inserts the token that replaces it. Returns false if the token was already revoked.
+func RefreshOAuthToken(previous *OAuthToken, token *OAuthToken) (bool, error) {
+	errAlreadyRevoked := errors.New("token already revoked")
+	now := time.Now().UnixMilli()
+
+	token.Scopes = FormatOAuthScopes(token.ScopeList)
+	token.CreatedAt = now
+
+	err := SQL.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&OAuthToken{}).
+			Where("id = ? AND revoked_at IS NULL", previous.Id).
+			Update("revoked_at", now)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// Another request refreshed the token at the same time
+		if result.RowsAffected == 0 {
+			return errAlreadyRevoked
+		}
+
+		return tx.Create(&token).Error
+	})
+
+	if err == errAlreadyRevoked {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	previous.RevokedAt = &now
+	return true, nil
+}
+
+// RevokeUserOAuthTokens Revokes every token a user has granted an application
+func RevokeUserOAuthTokens(userId int, applicationId int) error {
+	return SQL.Model(&OAuthToken{}).
+		Where("user_id = ? AND application_id = ? AND revoked_at IS NULL", userId, applicationId).
+		Update("revoked_at", time.Now().UnixMilli()).Error
+}
+
+// OAuthConsent The scopes a user has allowed an application to access
+type OAuthConsent struct {
+	UserId        int          `gorm:"column:user_id; PRIMARY_KEY" json:"-"`
+	ApplicationId int          `gorm:"column:application_id; PRIMARY_KEY" json:"-"`
+	Scopes        string       `gorm:"column:scopes" json:"-"`
+	ScopeList     []OAuthScope `gorm:"-:all" json:"scopes"`
+	CreatedAt     int64        `gorm:"column:created_at" json:"-"`
+	CreatedAtJSON time.Time    `gorm:"-:all" json:"created_at"`
+	UpdatedAt     int64        `gorm:"column:updated_at" json:"-"`
+	UpdatedAtJSON time.Time    `gorm:"-:all" json:"updated_at"`
+	Application   *Application `gorm:"foreignKey:ApplicationId; references:Id" json:"application,omitempty"`
+}
+
+func (*OAuthConsent) TableName() string {
+	return "oauth_consents"
+}
+
+func (consent *OAuthConsent) AfterFind(*gorm.DB) (err error) {
+	consent.ScopeList = parseStoredOAuthScopes(consent.Scopes)
+	consent.CreatedAtJSON = time.UnixMilli(consent.CreatedAt)
+	consent.UpdatedAtJSON = time.UnixMilli(consent.UpdatedAt)
+	return nil
+}
+
+// HasScopes Returns if the user has consented to every one of the scopes
+func (consent *OAuthConsent) HasScopes(scopes []OAuthScope) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(consent.ScopeList, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetOAuthConsent Retrieves the consent a user has given to an application
+func GetOAuthConsent(userId int, applicationId int) (*OAuthConsent, error) {
+	var consent *OAuthConsent
+
+	result := SQL.
+		Where("user_id = ? AND application_id = ?", userId, applicationId).
+		First(&consent)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return consent, nil
+}
+
+// GetUserOAuthConsents Retrieves every active application a user has authorized
+func GetUserOAuthConsents(userId int) ([]*OAuthConsent, error) {
+	var consents = make([]*OAuthConsent, 0)
+
+	result := SQL.
+		Joins("Application").
+		Where("oauth_consents.user_id = ? AND Application.active = 1", userId).
+		Order("oauth_consents.updated_at DESC").
+		Find(&consents)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, consent := range consents {
+		consent.Application.ClientSecret = ""
+	}
+
+	return consents, nil
+}
+
+// SaveOAuthConsent Records that a user has consented to an application accessing scopes,
+// in addition to any scopes they've previously consented to
+func SaveOAuthConsent(userId int, applicationId int, scopes []OAuthScope) (*OAuthConsent, error) {
+	existing, err := GetOAuthConsent(userId, applicationId)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+
+	consent := &OAuthConsent{
+		UserId:        userId,
+		ApplicationId: applicationId,
+		ScopeList:     scopes,
+		CreatedAt:     now,
+	}
+
+	if existing != nil {
+		consent.CreatedAt = existing.CreatedAt
+
+		for _, scope := range existing.ScopeList {
+			if !slices.Contains(consent.ScopeList, scope) {
+				consent.ScopeList = append(consent.ScopeList, scope)
+			}
+		}
+	}
+
+	consent.Scopes = FormatOAuthScopes(consent.ScopeList)
+	consent.UpdatedAt = now
+
+	if err := SQL.Omit("Application").Clauses(clause.OnConflict{UpdateAll: true}).Create(&consent).Error; err != nil {
+		return nil, err
+	}
+
+	return consent, nil
+}
+
+// DeleteOAuthConsent Removes an application's access to a user's account, revoking every token it was granted.
+// Returns false if the user hadn't authorized the application.
+func DeleteOAuthConsent(userId int, applicationId int) (bool, error) {
+	deleted := false
+
+	err := SQL.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Where("user_id = ? AND application_id = ?", userId, applicationId).
+			Delete(&OAuthConsent{})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		deleted = result.RowsAffected > 0
+
+		return tx.Model(&OAuthToken{}).
+			Where("user_id = ? AND application_id = ? AND revoked_at IS NULL", userId, applicationId).
+			Update("revoked_at", time.Now().UnixMilli()).Error
+	})
+
+	return deleted, err
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseOAuthScopes(t *testing.T) {
+	scopes, err := ParseOAuthScopes("read:scores  write:playlists read:scores")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []OAuthScope{OAuthScopePublic, OAuthScopeReadScores, OAuthScopeWritePlaylists}
+
+	if !slices.Equal(scopes, expected) {
+		t.Fatalf("expected %v, got %v", expected, scopes)
+	}
+
+	if FormatOAuthScopes(scopes) != "public read:scores write:playlists" {
+		t.Fatalf("unexpected formatted scopes: %v", FormatOAuthScopes(scopes))
+	}
+
+	if _, err := ParseOAuthScopes("public admin"); err == nil {
+		t.Fatalf("expected an unknown scope to be rejected")
+	}
+
+	// Only personal access tokens can be granted the moderation scope
+	if _, err := ParseOAuthScopes("moderation"); err == nil {
+		t.Fatalf("expected the moderation scope to be rejected for applications")
+	}
+
+	if scopes, err := ParsePersonalAccessTokenScopes("moderation"); err != nil || !slices.Contains(scopes, OAuthScopeModeration) {
+		t.Fatalf("expected the moderation scope to be accepted for personal access tokens")
+	}
+}
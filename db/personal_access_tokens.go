@@ -0,0 +1,153 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	PersonalAccessTokenPrefix = "qpat_"
+
+	// How often the last time a token was used is updated
+	personalAccessTokenUsageInterval = time.Minute
+)
+
+// PersonalAccessToken A token a user has created to use the API from their own scripts, limited to a set of scopes
+type PersonalAccessToken struct {
+	Id             int          `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	UserId         int          `gorm:"column:user_id" json:"-"`
+	Name           string       `gorm:"column:name" json:"name"`
+	TokenHash      string       `gorm:"column:token_hash" json:"-"`
+	TokenHint      string       `gorm:"column:token_hint" json:"token_hint"`
+	Scopes         string       `gorm:"column:scopes" json:"-"`
+	ScopeList      []OAuthScope `gorm:"-:all" json:"scopes"`
+	ExpiresAt      *int64       `gorm:"column:expires_at" json:"-"`
+	ExpiresAtJSON  *time.Time   `gorm:"-:all" json:"expires_at"`
+	LastUsedAt     *int64       `gorm:"column:last_used_at" json:"-"`
+	LastUsedAtJSON *time.Time   `gorm:"-:all" json:"last_used_at"`
+	CreatedAt      int64        `gorm:"column:created_at" json:"-"`
+	CreatedAtJSON  time.Time    `gorm:"-:all" json:"created_at"`
+	RevokedAt      *int64       `gorm:"column:revoked_at" json:"-"`
+}
+
+func (*PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+func (token *PersonalAccessToken) AfterFind(*gorm.DB) (err error) {
+	token.ScopeList = parseStoredOAuthScopes(token.Scopes)
+	token.CreatedAtJSON = time.UnixMilli(token.CreatedAt)
+
+	if token.ExpiresAt != nil {
+		t := time.UnixMilli(*token.ExpiresAt)
+		token.ExpiresAtJSON = &t
+	}
+
+	if token.LastUsedAt != nil {
+		t := time.UnixMilli(*token.LastUsedAt)
+		token.LastUsedAtJSON = &t
+	}
+
+	return nil
+}
+
+// IsPersonalAccessToken Returns if a bearer token is a personal access token, rather than a JWT
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// HasScope Returns if the token was granted a scope
+func (token *PersonalAccessToken) HasScope(scope OAuthScope) bool {
+	return slices.Contains(token.ScopeList, scope)
+}
+
+// Insert Inserts a personal access token into the database
+func (token *PersonalAccessToken) Insert() error {
+	token.Scopes = FormatOAuthScopes(token.ScopeList)
+	token.CreatedAt = time.Now().UnixMilli()
+	token.CreatedAtJSON = time.UnixMilli(token.CreatedAt)
+
+	if token.ExpiresAt != nil {
+		t := time.UnixMilli(*token.ExpiresAt)
+		token.ExpiresAtJSON = &t
+	}
+
+	return SQL.Create(&token).Error
+}
+
+// Revoke Revokes the token, so it can't be used anymore
+func (token *PersonalAccessToken) Revoke() error {
+	now := time.Now().UnixMilli()
+	token.RevokedAt = &now
+
+	return SQL.Model(&PersonalAccessToken{}).
+		Where("id = ?", token.Id).
+		Update("revoked_at", now).Error
+}
+
+// MarkUsed Updates the last time the token was used. To avoid writing on every request,
+// it's only updated if it hasn't been used within the last minute.
+func (token *PersonalAccessToken) MarkUsed() error {
+	now := time.Now()
+
+	if token.LastUsedAt != nil && now.Sub(time.UnixMilli(*token.LastUsedAt)) < personalAccessTokenUsageInterval {
+		return nil
+	}
+
+	lastUsed := now.UnixMilli()
+	token.LastUsedAt = &lastUsed
+
+	return SQL.Model(&PersonalAccessToken{}).
+		Where("id = ?", token.Id).
+		Update("last_used_at", lastUsed).Error
+}
+
+// GetPersonalAccessToken Retrieves an unexpired, unrevoked personal access token by its hash
+func GetPersonalAccessToken(hash string) (*PersonalAccessToken, error) {
+	var token *PersonalAccessToken
+
+	result := SQL.
+		Where("token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+			hash, time.Now().UnixMilli()).
+		First(&token)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return token, nil
+}
+
+// GetUserPersonalAccessTokens Retrieves a user's personal access tokens that haven't been revoked or expired
+func GetUserPersonalAccessTokens(userId int) ([]*PersonalAccessToken, error) {
+	var tokens = make([]*PersonalAccessToken, 0)
+
+	result := SQL.
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+			userId, time.Now().UnixMilli()).
+		Order("id DESC").
+		Find(&tokens)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return tokens, nil
+}
+
+// GetUserPersonalAccessTokenById Retrieves one of a user's personal access tokens that hasn't been revoked
+func GetUserPersonalAccessTokenById(userId int, id int) (*PersonalAccessToken, error) {
+	var token *PersonalAccessToken
+
+	result := SQL.
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		First(&token)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return token, nil
+}
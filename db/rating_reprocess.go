@@ -0,0 +1,467 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var ErrRatingReprocessBehind = errors.New("scores were submitted or maps were ranked after the rating reprocess job caught up")
+
+type RatingReprocessStatus string
+type RatingReprocessPhase string
+
+const (
+	RatingReprocessProcessing RatingReprocessStatus = "Processing"
+	RatingReprocessReady      RatingReprocessStatus = "Ready"
+	RatingReprocessPromoted   RatingReprocessStatus = "Promoted"
+
+	RatingReprocessPhaseMaps   RatingReprocessPhase = "Maps"
+	RatingReprocessPhaseUsers  RatingReprocessPhase = "Users"
+	RatingReprocessPhaseReport RatingReprocessPhase = "Report"
+)
+
+// RatingReprocessJob A run that re-rates every ranked score into the shadow tables.
+// The cursor is the id of the last map or user that was processed in the current phase, so the job can be resumed.
+// Scores newer than the last score id have been submitted since the job started, and must be caught up before promoting.
+type RatingReprocessJob struct {
+	Id                          int                   `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	Status                      RatingReprocessStatus `gorm:"column:status" json:"status"`
+	Phase                       RatingReprocessPhase  `gorm:"column:phase" json:"phase"`
+	CursorId                    int                   `gorm:"column:cursor_id" json:"cursor_id"`
+	LastScoreId                 int                   `gorm:"column:last_score_id" json:"last_score_id"`
+	DifficultyProcessorVersion  string                `gorm:"column:difficulty_processor_version" json:"difficulty_processor_version"`
+	PerformanceProcessorVersion string                `gorm:"column:performance_processor_version" json:"performance_processor_version"`
+	Report                      json.RawMessage       `gorm:"column:report" json:"report"`
+	StartedAt                   int64                 `gorm:"column:started_at" json:"started_at"`
+	PromotedAt                  *int64                `gorm:"column:promoted_at" json:"promoted_at"`
+}
+
+func (*RatingReprocessJob) TableName() string {
+	return "rating_reprocess_jobs"
+}
+
+type MapShadowRating struct {
+	MapId            int     `gorm:"column:map_id; PRIMARY_KEY"`
+	MD5              string  `gorm:"column:md5"`
+	DifficultyRating float64 `gorm:"column:difficulty_rating"`
+}
+
+func (*MapShadowRating) TableName() string {
+	return "maps_shadow_ratings"
+}
+
+type ScoreShadowRating struct {
+	ScoreId           int            `gorm:"column:score_id; PRIMARY_KEY"`
+	UserId            int            `gorm:"column:user_id"`
+	MapMD5            string         `gorm:"column:map_md5"`
+	Mode              enums.GameMode `gorm:"column:mode"`
+	PerformanceRating float64        `gorm:"column:performance_rating"`
+}
+
+func (*ScoreShadowRating) TableName() string {
+	return "scores_shadow_ratings"
+}
+
+type UserStatsShadowRating struct {
+	UserId                   int            `gorm:"column:user_id; PRIMARY_KEY"`
+	Mode                     enums.GameMode `gorm:"column:mode; PRIMARY_KEY"`
+	OverallPerformanceRating float64        `gorm:"column:overall_performance_rating"`
+}
+
+func (*UserStatsShadowRating) TableName() string {
+	return "user_stats_shadow_ratings"
+}
+
+// GetActiveRatingReprocessJob Retrieves the job that hasn't been promoted yet, if there is one
+func GetActiveRatingReprocessJob() (*RatingReprocessJob, error) {
+	var job *RatingReprocessJob
+
+	result := SQL.
+		Where("status != ?", RatingReprocessPromoted).
+		Order("id DESC").
+		First(&job)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return job, nil
+}
+
+// GetRatingReprocessJobById Retrieves a job by its id
+func GetRatingReprocessJobById(id int) (*RatingReprocessJob, error) {
+	var job *RatingReprocessJob
+
+	result := SQL.
+		Where("id = ?", id).
+		First(&job)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return job, nil
+}
+
+// StartRatingReprocessJob Clears the shadow tables and starts a new job
+func StartRatingReprocessJob(difficultyVersion string, performanceVersion string) (*RatingReprocessJob, error) {
+	job := &RatingReprocessJob{
+		Status:                      RatingReprocessProcessing,
+		Phase:                       RatingReprocessPhaseMaps,
+		DifficultyProcessorVersion:  difficultyVersion,
+		PerformanceProcessorVersion: performanceVersion,
+		StartedAt:                   time.Now().UnixMilli(),
+	}
+
+	err := SQL.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"maps_shadow_ratings", "scores_shadow_ratings", "user_stats_shadow_ratings"} {
+			if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+				return err
+			}
+		}
+
+		lastScoreId, err := getLatestScoreId(tx)
+
+		if err != nil {
+			return err
+		}
+
+		job.LastScoreId = lastScoreId
+		return tx.Create(&job).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// UpdateProgress Saves the phase & cursor of the job
+func (job *RatingReprocessJob) UpdateProgress(phase RatingReprocessPhase, cursorId int) error {
+	job.Phase = phase
+	job.CursorId = cursorId
+
+	return SQL.Model(&RatingReprocessJob{}).
+		Where("id = ?", job.Id).
+		Updates(map[string]interface{}{
+			"phase":     phase,
+			"cursor_id": cursorId,
+		}).Error
+}
+
+// UpdateLastScoreId Saves the id of the newest score that the job has rated
+func (job *RatingReprocessJob) UpdateLastScoreId(scoreId int) error {
+	job.LastScoreId = scoreId
+
+	return SQL.Model(&RatingReprocessJob{}).
+		Where("id = ?", job.Id).
+		Update("last_score_id", scoreId).Error
+}
+
+// MarkReady Stores the rank movement report and marks the job as ready to be promoted
+func (job *RatingReprocessJob) MarkReady(report json.RawMessage) error {
+	job.Status = RatingReprocessReady
+	job.Report = report
+
+	return SQL.Model(&RatingReprocessJob{}).
+		Where("id = ?", job.Id).
+		Updates(map[string]interface{}{
+			"status": RatingReprocessReady,
+			"report": report,
+		}).Error
+}
+
+// HasOutdatedScoreRatings Returns if any passed score was rated with a different version of the processors
+func HasOutdatedScoreRatings(difficultyVersion string, performanceVersion string) (bool, error) {
+	var count int64
+
+	result := SQL.
+		Model(&Score{}).
+		Joins("JOIN maps ON maps.md5 = scores.map_md5").
+		Where("scores.failed = 0 AND maps.ranked_status = ? AND "+
+			"(scores.difficulty_processor_version != ? OR scores.performance_processor_version != ?)",
+			enums.RankedStatusRanked, difficultyVersion, performanceVersion).
+		Limit(1).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// GetRankedMapsAfterId Retrieves a batch of ranked maps, ordered by id
+func GetRankedMapsAfterId(id int, limit int) ([]*MapQua, error) {
+	var maps = make([]*MapQua, 0)
+
+	result := SQL.
+		Where("id > ? AND ranked_status = ?", id, enums.RankedStatusRanked).
+		Order("id ASC").
+		Limit(limit).
+		Find(&maps)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return maps, nil
+}
+
+// GetLatestScoreId Returns the id of the newest score
+func GetLatestScoreId() (int, error) {
+	return getLatestScoreId(SQL)
+}
+
+func getLatestScoreId(tx *gorm.DB) (int, error) {
+	var id int
+
+	result := tx.
+		Model(&Score{}).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return id, nil
+}
+
+// GetRankedMapsToCatchUp Retrieves the ranked maps that don't have a shadow rating yet,
+// or that have passed scores in a range of ids that were submitted after the job rated them.
+func GetRankedMapsToCatchUp(afterScoreId int, untilScoreId int) ([]*MapQua, error) {
+	var maps = make([]*MapQua, 0)
+
+	result := SQL.
+		Where("ranked_status = ? AND (id NOT IN (SELECT map_id FROM maps_shadow_ratings) OR "+
+			"md5 IN (SELECT map_md5 FROM scores WHERE id > ? AND id <= ? AND failed = 0))",
+			enums.RankedStatusRanked, afterScoreId, untilScoreId).
+		Order("id ASC").
+		Find(&maps)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return maps, nil
+}
+
+// GetUserIdsWithScoresBetween Retrieves the ids of the users who passed a score in a range of ids
+func GetUserIdsWithScoresBetween(afterScoreId int, untilScoreId int) ([]int, error) {
+	var ids = make([]int, 0)
+
+	result := SQL.
+		Model(&Score{}).
+		Distinct("user_id").
+		Where("id > ? AND id <= ? AND failed = 0", afterScoreId, untilScoreId).
+		Pluck("user_id", &ids)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return ids, nil
+}
+
+// GetPassedScoresForMap Retrieves every passed score on a map
+func GetPassedScoresForMap(md5 string) ([]*Score, error) {
+	var scores = make([]*Score, 0)
+
+	result := SQL.
+		Where("map_md5 = ? AND failed = 0", md5).
+		Find(&scores)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return scores, nil
+}
+
+// GetUsersAfterId Retrieves a batch of users with their stats, ordered by id
+func GetUsersAfterId(id int, limit int) ([]*User, error) {
+	var users = make([]*User, 0)
+
+	result := SQL.
+		Where("id > ?", id).
+		Order("id ASC").
+		Limit(limit).
+		Find(&users)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return users, nil
+}
+
+// InsertMapShadowRatings Inserts or replaces shadow map ratings
+func InsertMapShadowRatings(ratings []*MapShadowRating) error {
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	return SQL.Clauses(clause.OnConflict{UpdateAll: true}).Create(&ratings).Error
+}
+
+// InsertScoreShadowRatings Inserts or replaces shadow score ratings
+func InsertScoreShadowRatings(ratings []*ScoreShadowRating) error {
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	return SQL.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(&ratings, 1000).Error
+}
+
+// InsertUserStatsShadowRatings Inserts or replaces shadow overall ratings
+func InsertUserStatsShadowRatings(ratings []*UserStatsShadowRating) error {
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	return SQL.Clauses(clause.OnConflict{UpdateAll: true}).Create(&ratings).Error
+}
+
+// GetUserBestShadowScores Retrieves the best shadow score of a user on each map of a game mode, best first
+func GetUserBestShadowScores(userId int, mode enums.GameMode, limit int) ([]*Score, error) {
+	var scores = make([]*Score, 0)
+
+	result := SQL.
+		Model(&ScoreShadowRating{}).
+		Select("MAX(performance_rating) AS performance_rating").
+		Where("user_id = ? AND mode = ?", userId, mode).
+		Group("map_md5").
+		Order("performance_rating DESC").
+		Limit(limit).
+		Scan(&scores)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return scores, nil
+}
+
+// GetShadowRatedMapMD5s Retrieves the md5 of every map in the shadow tables
+func GetShadowRatedMapMD5s() ([]string, error) {
+	var md5s = make([]string, 0)
+
+	result := SQL.
+		Model(&MapShadowRating{}).
+		Pluck("md5", &md5s)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return md5s, nil
+}
+
+// PromoteRatingReprocessJob Copies the shadow ratings to the live tables in a single transaction.
+// Personal bests of the reprocessed scores are reselected, as the best score on a map can change with new ratings.
+// The newest score is locked for the duration of the transaction, so no scores can be submitted while the ratings are swapped.
+// Returns ErrRatingReprocessBehind if the job hasn't caught up with every score & ranked map, so nothing stale is promoted.
+// promoteLeaderboards runs last, so the transaction is rolled back if the leaderboards couldn't be swapped.
+func PromoteRatingReprocessJob(job *RatingReprocessJob, promoteLeaderboards func() error) error {
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		var lastScoreId int
+
+		// Locks the newest score & the gap after it, which blocks new scores from being inserted until the transaction ends
+		result := tx.
+			Model(&Score{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Order("id DESC").
+			Limit(1).
+			Scan(&lastScoreId)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if lastScoreId > job.LastScoreId {
+			return ErrRatingReprocessBehind
+		}
+
+		var unratedMaps int64
+
+		result = tx.
+			Model(&MapQua{}).
+			Where("ranked_status = ? AND id NOT IN (SELECT map_id FROM maps_shadow_ratings)", enums.RankedStatusRanked).
+			Count(&unratedMaps)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if unratedMaps > 0 {
+			return ErrRatingReprocessBehind
+		}
+
+		queries := []struct {
+			Query string
+			Args  []interface{}
+		}{
+			{
+				Query: "UPDATE maps m JOIN maps_shadow_ratings s ON s.map_id = m.id " +
+					"SET m.difficulty_rating = s.difficulty_rating",
+			},
+			{
+				Query: "UPDATE scores sc JOIN scores_shadow_ratings s ON s.score_id = sc.id " +
+					"SET sc.performance_rating = s.performance_rating, " +
+					"sc.difficulty_processor_version = ?, sc.performance_processor_version = ?",
+				Args: []interface{}{job.DifficultyProcessorVersion, job.PerformanceProcessorVersion},
+			},
+			{
+				Query: "UPDATE scores sc JOIN (" +
+					"SELECT score_id, ROW_NUMBER() OVER " +
+					"(PARTITION BY user_id, map_md5 ORDER BY performance_rating DESC, score_id DESC) AS rnk " +
+					"FROM scores_shadow_ratings) r ON r.score_id = sc.id " +
+					"SET sc.personal_best = (r.rnk = 1)",
+			},
+			{
+				Query: "UPDATE user_stats_keys4 us JOIN user_stats_shadow_ratings s ON s.user_id = us.user_id AND s.mode = ? " +
+					"SET us.overall_performance_rating = s.overall_performance_rating",
+				Args: []interface{}{enums.GameModeKeys4},
+			},
+			{
+				Query: "UPDATE user_stats_keys7 us JOIN user_stats_shadow_ratings s ON s.user_id = us.user_id AND s.mode = ? " +
+					"SET us.overall_performance_rating = s.overall_performance_rating",
+				Args: []interface{}{enums.GameModeKeys7},
+			},
+		}
+
+		for _, q := range queries {
+			if err := tx.Exec(q.Query, q.Args...).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UnixMilli()
+
+		err := tx.Model(&RatingReprocessJob{}).
+			Where("id = ?", job.Id).
+			Updates(map[string]interface{}{
+				"status":      RatingReprocessPromoted,
+				"promoted_at": now,
+			}).Error
+
+		if err != nil {
+			return err
+		}
+
+		if err := promoteLeaderboards(); err != nil {
+			return err
+		}
+
+		job.Status = RatingReprocessPromoted
+		job.PromotedAt = &now
+		return nil
+	})
+}
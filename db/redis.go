@@ -83,3 +83,20 @@ func CacheJsonInRedis(key string, data interface{}, duration time.Duration, igno
 
 	return nil
 }
+
+// ScanRedisKeys Returns every key that matches a pattern. SCAN is used rather than KEYS,
+// so Redis isn't blocked while a large keyspace is searched.
+func ScanRedisKeys(pattern string) ([]string, error) {
+	keys := make([]string, 0)
+	iter := Redis.Scan(RedisCtx, 0, pattern, 1000).Iterator()
+
+	for iter.Next(RedisCtx) {
+		keys = append(keys, iter.Val())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
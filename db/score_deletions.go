@@ -0,0 +1,252 @@
+package db
+
+import (
+	"errors"
+	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Excludes scores that have been deleted by a moderator and haven't been restored
+const notDeletedScoreQuery = "scores.id NOT IN (SELECT score_id FROM score_deletions WHERE restored_at IS NULL)"
+
+// ScoreDeletion A record of a score that was deleted by a moderator, along with the state needed to restore it
+type ScoreDeletion struct {
+	Id              int            `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	ScoreId         int            `gorm:"column:score_id" json:"score_id"`
+	UserId          int            `gorm:"column:user_id" json:"user_id"`
+	MapMD5          string         `gorm:"column:map_md5" json:"map_md5"`
+	Mode            enums.GameMode `gorm:"column:mode" json:"mode"`
+	WasPersonalBest bool           `gorm:"column:was_personal_best" json:"was_personal_best"`
+	WasDonatorScore bool           `gorm:"column:was_donator_score" json:"was_donator_score"`
+	ClanId          *int           `gorm:"column:clan_id" json:"clan_id"`
+	DeletedBy       int            `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt       int64          `gorm:"column:deleted_at" json:"deleted_at"`
+	RestoredBy      *int           `gorm:"column:restored_by" json:"restored_by"`
+	RestoredAt      *int64         `gorm:"column:restored_at" json:"restored_at"`
+	Notes           string         `gorm:"column:notes" json:"notes"`
+}
+
+func (*ScoreDeletion) TableName() string {
+	return "score_deletions"
+}
+
+// ScoreModerationFilter Selects the scores a moderation action applies to.
+// Either score ids or a user must be given, so a whole map or time range can't be selected by accident.
+type ScoreModerationFilter struct {
+	ScoreIds []int  `json:"score_ids"`
+	UserId   int    `json:"user_id"`
+	MapMD5   string `json:"map_md5"`
+	After    int64  `json:"after"`
+	Before   int64  `json:"before"`
+}
+
+// IsValid Returns if the filter selects a bounded set of scores
+func (f *ScoreModerationFilter) IsValid() bool {
+	return len(f.ScoreIds) > 0 || f.UserId > 0
+}
+
+// Applies the filter to a query, using the columns of the given table
+func (f *ScoreModerationFilter) apply(query *gorm.DB, table string) *gorm.DB {
+	if len(f.ScoreIds) > 0 {
+		column := "id"
+
+		if table == "score_deletions" {
+			column = "score_id"
+		}
+
+		query = query.Where(table+"."+column+" IN ?", f.ScoreIds)
+	}
+
+	if f.UserId > 0 {
+		query = query.Where(table+".user_id = ?", f.UserId)
+	}
+
+	if f.MapMD5 != "" {
+		query = query.Where(table+".map_md5 = ?", f.MapMD5)
+	}
+
+	return query
+}
+
+// GetScoresForDeletion Retrieves the passed scores that match a filter and haven't already been deleted
+func GetScoresForDeletion(filter *ScoreModerationFilter, limit int) ([]*Score, error) {
+	var scores = make([]*Score, 0)
+
+	query := filter.apply(SQL.Model(&Score{}), "scores").
+		Where("scores.failed = 0").
+		Where(notDeletedScoreQuery)
+
+	if filter.After > 0 {
+		query = query.Where("scores.timestamp >= ?", filter.After)
+	}
+
+	if filter.Before > 0 {
+		query = query.Where("scores.timestamp < ?", filter.Before)
+	}
+
+	result := query.
+		Order("scores.id ASC").
+		Limit(limit).
+		Find(&scores)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return scores, nil
+}
+
+// GetScoreDeletionsForRestore Retrieves the deletions that match a filter and haven't been restored yet.
+// The time range applies to when the scores were deleted.
+func GetScoreDeletionsForRestore(filter *ScoreModerationFilter, limit int) ([]*ScoreDeletion, error) {
+	var deletions = make([]*ScoreDeletion, 0)
+
+	query := filter.apply(SQL.Model(&ScoreDeletion{}), "score_deletions").
+		Where("score_deletions.restored_at IS NULL")
+
+	if filter.After > 0 {
+		query = query.Where("score_deletions.deleted_at >= ?", filter.After)
+	}
+
+	if filter.Before > 0 {
+		query = query.Where("score_deletions.deleted_at < ?", filter.Before)
+	}
+
+	result := query.
+		Order("score_deletions.id ASC").
+		Limit(limit).
+		Find(&deletions)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return deletions, nil
+}
+
+// DeleteScores Deletes scores by removing them from personal bests & clan scores, and records who deleted them
+func DeleteScores(scores []*Score, authorId int, notes string) ([]*ScoreDeletion, error) {
+	if len(scores) == 0 {
+		return nil, errors.New("no scores to delete")
+	}
+
+	now := time.Now().UnixMilli()
+	deletions := make([]*ScoreDeletion, 0, len(scores))
+	ids := make([]int, 0, len(scores))
+
+	for _, score := range scores {
+		deletions = append(deletions, &ScoreDeletion{
+			ScoreId:         score.Id,
+			UserId:          score.UserId,
+			MapMD5:          score.MapMD5,
+			Mode:            score.Mode,
+			WasPersonalBest: score.IsPersonalBest,
+			WasDonatorScore: score.IsDonatorScore,
+			ClanId:          score.ClanId,
+			DeletedBy:       authorId,
+			DeletedAt:       now,
+			Notes:           notes,
+		})
+
+		ids = append(ids, score.Id)
+	}
+
+	err := SQL.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&deletions).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&Score{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"personal_best":    0,
+				"is_donator_score": 0,
+				"clan_id":          nil,
+			}).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+// RestoreScoreDeletions Restores deleted scores to the state they were in before they were deleted.
+// Personal bests that are no longer correct should be recalculated afterwards.
+func RestoreScoreDeletions(deletions []*ScoreDeletion, authorId int) error {
+	if len(deletions) == 0 {
+		return errors.New("no scores to restore")
+	}
+
+	now := time.Now().UnixMilli()
+
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		for _, deletion := range deletions {
+			err := tx.Model(&Score{}).
+				Where("id = ?", deletion.ScoreId).
+				Updates(map[string]interface{}{
+					"personal_best":    deletion.WasPersonalBest,
+					"is_donator_score": deletion.WasDonatorScore,
+					"clan_id":          deletion.ClanId,
+				}).Error
+
+			if err != nil {
+				return err
+			}
+
+			deletion.RestoredBy = &authorId
+			deletion.RestoredAt = &now
+
+			err = tx.Model(&ScoreDeletion{}).
+				Where("id = ?", deletion.Id).
+				Updates(map[string]interface{}{
+					"restored_by": authorId,
+					"restored_at": now,
+				}).Error
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RecalculatePersonalBest Marks a user's best passed score on a map as their personal best.
+// Donator scores are left alone, as they are personal bests on maps that aren't ranked.
+func RecalculatePersonalBest(userId int, md5 string) error {
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Score{}).
+			Where("user_id = ? AND map_md5 = ? AND is_donator_score = 0 AND personal_best = 1", userId, md5).
+			Update("personal_best", 0).Error
+
+		if err != nil {
+			return err
+		}
+
+		var best *Score
+
+		result := tx.
+			Where("scores.user_id = ? AND scores.map_md5 = ? AND scores.failed = 0 "+
+				"AND scores.is_donator_score = 0", userId, md5).
+			Where(notDeletedScoreQuery).
+			Order("scores.performance_rating DESC, scores.id DESC").
+			Limit(1).
+			Find(&best)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return tx.Model(&Score{}).
+			Where("id = ?", best.Id).
+			Update("personal_best", 1).Error
+	})
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"encoding/json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ScoreReviewStatus string
+
+const (
+	ScoreReviewPending   ScoreReviewStatus = "Pending"
+	ScoreReviewCleared   ScoreReviewStatus = "Cleared"
+	ScoreReviewConfirmed ScoreReviewStatus = "Confirmed"
+)
+
+// ScoreReview A score that was flagged by the anti-cheat and needs to be looked at by an admin
+type ScoreReview struct {
+	Id            int               `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	ScoreId       int               `gorm:"column:score_id" json:"score_id"`
+	UserId        int               `gorm:"column:user_id" json:"user_id"`
+	MapId         int               `gorm:"column:map_id" json:"map_id"`
+	Analysis      json.RawMessage   `gorm:"column:analysis" json:"analysis"`
+	Status        ScoreReviewStatus `gorm:"column:status" json:"status"`
+	ReviewerId    *int              `gorm:"column:reviewer_id" json:"reviewer_id"`
+	Timestamp     int64             `gorm:"column:timestamp" json:"-"`
+	TimestampJSON time.Time         `gorm:"-:all" json:"timestamp"`
+	User          *User             `gorm:"foreignKey:UserId; references:Id" json:"user,omitempty"`
+}
+
+func (*ScoreReview) TableName() string {
+	return "score_reviews"
+}
+
+func (review *ScoreReview) AfterFind(*gorm.DB) (err error) {
+	review.TimestampJSON = time.UnixMilli(review.Timestamp)
+	return nil
+}
+
+// Insert Inserts a new score review into the database. Each score is only reviewed once,
+// so returns false if the score already has a review.
+func (review *ScoreReview) Insert() (bool, error) {
+	review.Status = ScoreReviewPending
+	review.Timestamp = time.Now().UnixMilli()
+
+	result := SQL.
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "score_id"}}, DoNothing: true}).
+		Create(&review)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateStatus Updates the status of a score review and who reviewed it
+func (review *ScoreReview) UpdateStatus(status ScoreReviewStatus, reviewerId int) error {
+	review.Status = status
+	review.ReviewerId = &reviewerId
+
+	return SQL.Model(&ScoreReview{}).
+		Where("id = ?", review.Id).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewer_id": reviewerId,
+		}).Error
+}
+
+// GetScoreReviews Retrieves score reviews with a given status, newest first
+func GetScoreReviews(status ScoreReviewStatus, limit int, page int) ([]*ScoreReview, error) {
+	reviews := make([]*ScoreReview, 0)
+
+	result := SQL.
+		Joins("User").
+		Where("score_reviews.status = ?", status).
+		Order("score_reviews.id DESC").
+		Limit(limit).
+		Offset(page * limit).
+		Find(&reviews)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return reviews, nil
+}
+
+// GetScoreReviewCount Retrieves the amount of score reviews with a given status
+func GetScoreReviewCount(status ScoreReviewStatus) (int, error) {
+	var count int64
+
+	result := SQL.
+		Model(&ScoreReview{}).
+		Where("status = ?", status).
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return int(count), nil
+}
+
+// GetScoreReviewById Retrieves a score review by its id
+func GetScoreReviewById(id int) (*ScoreReview, error) {
+	var review *ScoreReview
+
+	result := SQL.
+		Joins("User").
+		Where("score_reviews.id = ?", id).
+		First(&review)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return review, nil
+}
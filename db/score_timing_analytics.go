@@ -0,0 +1,40 @@
+package db
+
+import (
+	"encoding/json"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// ScoreTimingAnalytics The timing analytics of a score's replay, stored so the replay doesn't have to be played again
+type ScoreTimingAnalytics struct {
+	ScoreId   int             `gorm:"column:score_id; PRIMARY_KEY"`
+	Version   int             `gorm:"column:version"`
+	Analytics json.RawMessage `gorm:"column:analytics"`
+	CreatedAt int64           `gorm:"column:created_at"`
+}
+
+func (*ScoreTimingAnalytics) TableName() string {
+	return "score_timing_analytics"
+}
+
+// GetScoreTimingAnalytics Retrieves the stored timing analytics of a score
+func GetScoreTimingAnalytics(scoreId int) (*ScoreTimingAnalytics, error) {
+	var analytics *ScoreTimingAnalytics
+
+	result := SQL.
+		Where("score_id = ?", scoreId).
+		First(&analytics)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return analytics, nil
+}
+
+// Save Inserts or replaces the stored timing analytics of a score
+func (analytics *ScoreTimingAnalytics) Save() error {
+	analytics.CreatedAt = time.Now().UnixMilli()
+	return SQL.Clauses(clause.OnConflict{UpdateAll: true}).Create(&analytics).Error
+}
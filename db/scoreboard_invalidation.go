@@ -0,0 +1,116 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	scoreboardCacheDuration = time.Hour * 24 * 3
+
+	// ScoreboardInvalidationChannel Redis channel that invalidated scoreboards are published to,
+	// so other services that cache scoreboards can drop them as well.
+	ScoreboardInvalidationChannel = "quaver:scoreboard:invalidate"
+)
+
+type ScoreboardInvalidationReason string
+
+const (
+	ScoreboardInvalidationUsername      ScoreboardInvalidationReason = "username"
+	ScoreboardInvalidationBan           ScoreboardInvalidationReason = "ban"
+	ScoreboardInvalidationClan          ScoreboardInvalidationReason = "clan"
+	ScoreboardInvalidationScoreDeleted  ScoreboardInvalidationReason = "score_deleted"
+	ScoreboardInvalidationScoreRestored ScoreboardInvalidationReason = "score_restored"
+	ScoreboardInvalidationRatings       ScoreboardInvalidationReason = "ratings"
+)
+
+// ScoreboardInvalidation The message that is published when scoreboards are invalidated
+type ScoreboardInvalidation struct {
+	MD5s   []string                     `json:"md5s"`
+	Reason ScoreboardInvalidationReason `json:"reason"`
+}
+
+// Returns the redis key of the set of every cached scoreboard key of a map
+func scoreboardMapKeysRedisKey(md5 string) string {
+	return fmt.Sprintf("quaver:scoreboard:%v:keys", md5)
+}
+
+// Returns the redis key of the set of map md5s that a user appears on in cached scoreboards
+func scoreboardUserMapsRedisKey(userId int) string {
+	return fmt.Sprintf("quaver:scoreboard:user:%v", userId)
+}
+
+// InvalidateScoreboards Deletes every cached scoreboard of the given maps and publishes the invalidation
+func InvalidateScoreboards(reason ScoreboardInvalidationReason, md5s ...string) error {
+	if len(md5s) == 0 {
+		return nil
+	}
+
+	var keys []string
+
+	for _, md5 := range md5s {
+		mapKeys, err := Redis.SMembers(RedisCtx, scoreboardMapKeysRedisKey(md5)).Result()
+
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, mapKeys...)
+		keys = append(keys, scoreboardMapKeysRedisKey(md5))
+	}
+
+	if err := Redis.Del(RedisCtx, keys...).Err(); err != nil {
+		return err
+	}
+
+	message, err := json.Marshal(ScoreboardInvalidation{MD5s: md5s, Reason: reason})
+
+	if err != nil {
+		return err
+	}
+
+	return Redis.Publish(RedisCtx, ScoreboardInvalidationChannel, message).Err()
+}
+
+// InvalidateUserScoreboards Invalidates every cached scoreboard that a user appears on
+func InvalidateUserScoreboards(userId int, reason ScoreboardInvalidationReason) error {
+	key := scoreboardUserMapsRedisKey(userId)
+	md5s, err := Redis.SMembers(RedisCtx, key).Result()
+
+	if err != nil {
+		return err
+	}
+
+	if err := InvalidateScoreboards(reason, md5s...); err != nil {
+		return err
+	}
+
+	return Redis.Del(RedisCtx, key).Err()
+}
+
+// InvalidateClanScoreboards Invalidates every cached scoreboard that a member of a clan appears on
+func InvalidateClanScoreboards(clanId int) error {
+	members, err := GetUsersInClan(clanId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if err := InvalidateUserScoreboards(member.Id, ScoreboardInvalidationClan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Logs an error from invalidating scoreboards. Invalidation happens after the change was already made
+// in the database, so it shouldn't fail the change itself.
+func logScoreboardInvalidationError(err error) {
+	if err != nil {
+		logrus.Error("Error invalidating scoreboards: ", err)
+	}
+}
@@ -239,7 +239,7 @@ func GetGlobalScoresForMap(md5 string, useCache bool) ([]*Score, error) {
 
 // GetCountryScoresForMap Retrieves the country scores for a map
 func GetCountryScoresForMap(md5 string, country string) ([]*Score, error) {
-	cached, err := getCachedScoreboard(scoreboardCountry, md5, 0)
+	cached, err := getCachedScoreboard(scoreboardCountry, md5, country)
 
 	if err != nil {
 		return nil, err
@@ -271,7 +271,7 @@ func GetCountryScoresForMap(md5 string, country string) ([]*Score, error) {
 		}
 	}
 
-	if err := cacheScoreboard(scoreboardCountry, md5, scores, 0); err != nil {
+	if err := cacheScoreboard(scoreboardCountry, md5, scores, country); err != nil {
 		return nil, err
 	}
 
@@ -343,10 +343,11 @@ func GetRateScoresForMap(md5 string, mods int64) ([]*Score, error) {
 	var scores = make([]*Score, 0)
 
 	modsQuery := ""
+	queryMods := mods
 
 	if mods == 0 {
 		modsQuery = "AND (s.mods = 0 OR s.mods = ?) "
-		mods = 2147483648 // TODO: USE ENUM
+		queryMods = 2147483648 // TODO: USE ENUM
 	} else {
 		modsQuery = "AND (s.mods & ?) != 0 "
 	}
@@ -363,7 +364,7 @@ func GetRateScoresForMap(md5 string, mods int64) ([]*Score, error) {
 				AND s.failed = 0
 				%v
 		)
-		%v`, modsQuery, getSelectUserScoreboardQuery(100)), md5, mods).
+		%v`, modsQuery, getSelectUserScoreboardQuery(100)), md5, queryMods).
 		Scan(&scores)
 
 	if result.Error != nil {
@@ -621,10 +622,17 @@ func RemoveUserClanScores(clanId int, userId int) error {
 }
 
 func (s *Score) SoftDelete() error {
-	return SQL.Model(&Score{}).
+	err := SQL.Model(&Score{}).
 		Where("id = ?", s.Id).
 		Update("personal_best", 0).
 		Update("is_donator_score", 0).Error
+
+	if err != nil {
+		return err
+	}
+
+	logScoreboardInvalidationError(InvalidateScoreboards(ScoreboardInvalidationScoreDeleted, s.MapMD5))
+	return nil
 }
 
 // CalculateOverallRating Calculates overall rating from a list of scores
@@ -664,6 +672,27 @@ func CalculateOverallAccuracy(scores []*Score) float64 {
 	return total / divideTotal
 }
 
+// GetBestScorePerMap Returns the best score on each map from a list of scores ordered by performance rating
+func GetBestScorePerMap(scores []*Score, limit int) []*Score {
+	best := make([]*Score, 0, min(len(scores), limit))
+	seen := map[string]bool{}
+
+	for _, score := range scores {
+		if len(best) == limit {
+			break
+		}
+
+		if seen[score.MapMD5] {
+			continue
+		}
+
+		seen[score.MapMD5] = true
+		best = append(best, score)
+	}
+
+	return best
+}
+
 type scoreboardType string
 
 const (
@@ -675,18 +704,19 @@ const (
 	scoreboardAll     scoreboardType = "all"
 )
 
-// Returns the redis key for a scoreboard
-func scoreboardRedisKey(md5 string, scoreboard scoreboardType, mods int64) string {
+// Returns the redis key for a scoreboard. Mods & rate scoreboards are cached per mod combo, and country scoreboards per country.
+func scoreboardRedisKey(md5 string, scoreboard scoreboardType, variant any) string {
 	switch scoreboard {
-	case scoreboardMods, scoreboardRate:
-		return fmt.Sprintf("quaver:scoreboard:%v:%v:%v", md5, scoreboard, mods)
+	case scoreboardMods, scoreboardRate, scoreboardCountry:
+		return fmt.Sprintf("quaver:scoreboard:%v:%v:%v", md5, scoreboard, variant)
 	default:
 		return fmt.Sprintf("quaver:scoreboard:%v:%v", md5, scoreboard)
 	}
 }
 
-// Caches a scoreboard to Redis
-func cacheScoreboard(scoreboard scoreboardType, md5 string, scores []*Score, mods int64) error {
+// Caches a scoreboard to Redis, and keeps track of which maps each user appears on,
+// so the scoreboards can be invalidated when one of them changes.
+func cacheScoreboard(scoreboard scoreboardType, md5 string, scores []*Score, variant any) error {
 	if len(scores) == 0 {
 		return nil
 	}
@@ -697,12 +727,26 @@ func cacheScoreboard(scoreboard scoreboardType, md5 string, scores []*Score, mod
 		return err
 	}
 
-	return Redis.Set(RedisCtx, scoreboardRedisKey(md5, scoreboard, mods), scoresJson, time.Hour*24*3).Err()
+	key := scoreboardRedisKey(md5, scoreboard, variant)
+	pipeline := Redis.Pipeline()
+
+	pipeline.Set(RedisCtx, key, scoresJson, scoreboardCacheDuration)
+	pipeline.SAdd(RedisCtx, scoreboardMapKeysRedisKey(md5), key)
+	pipeline.Expire(RedisCtx, scoreboardMapKeysRedisKey(md5), scoreboardCacheDuration)
+
+	for _, score := range scores {
+		pipeline.SAdd(RedisCtx, scoreboardUserMapsRedisKey(score.UserId), md5)
+		pipeline.Expire(RedisCtx, scoreboardUserMapsRedisKey(score.UserId), scoreboardCacheDuration)
+	}
+
+	_, err = pipeline.Exec(RedisCtx)
+	return err
 }
 
-// Retrieves a cached scoreboard from redis
-func getCachedScoreboard(scoreboard scoreboardType, md5 string, mods int64) ([]*Score, error) {
-	result, err := Redis.Get(RedisCtx, scoreboardRedisKey(md5, scoreboard, mods)).Result()
+// Retrieves a cached scoreboard from redis.
+// Changes to the users on it are handled by invalidating the scoreboard (see InvalidateUserScoreboards).
+func getCachedScoreboard(scoreboard scoreboardType, md5 string, variant any) ([]*Score, error) {
+	result, err := Redis.Get(RedisCtx, scoreboardRedisKey(md5, scoreboard, variant)).Result()
 
 	if err != nil {
 		if err == redis.Nil {
@@ -722,34 +766,6 @@ func getCachedScoreboard(scoreboard scoreboardType, md5 string, mods int64) ([]*
 		return nil, err
 	}
 
-	// Cache invalidation by checking for updated values
-	for _, score := range scores {
-		var user *User
-
-		result := SQL.
-			Select("allowed", "username", "country", "clan_id").
-			Where("id = ?", score.UserId).
-			First(&user)
-
-		if result.Error != nil {
-			return nil, result.Error
-		}
-
-		if err := user.SetClanTagAndColor(); err != nil {
-			return nil, result.Error
-		}
-
-		if !user.Allowed ||
-			user.Username != score.User.Username ||
-			user.Country != score.User.Country ||
-			!comparePointers(user.ClanId, score.User.ClanId) ||
-			!comparePointers(user.ClanTag, score.User.ClanTag) ||
-			!comparePointers(user.ClanAccentColor, score.User.ClanAccentColor) {
-			return nil, nil
-		}
-
-	}
-
 	return scores, nil
 }
 
@@ -796,14 +812,41 @@ func getSelectUserScoreboardQuery(limit int, donatorOnly ...bool) string {
 	return query
 }
 
-func comparePointers[T comparable](a, b *T) bool {
-	if a == nil && b == nil {
-		return true
+// GetUserHighestPassedDifficulty Retrieves the highest difficulty rating of a ranked map that a user has passed
+func GetUserHighestPassedDifficulty(userId int) (float64, error) {
+	var difficulty *float64
+
+	result := SQL.
+		Model(&Score{}).
+		Select("MAX(maps.difficulty_rating)").
+		Joins("JOIN maps ON maps.md5 = scores.map_md5").
+		Where("scores.user_id = ? AND scores.failed = 0 AND maps.ranked_status = ?", userId, enums.RankedStatusRanked).
+		Scan(&difficulty)
+
+	if result.Error != nil {
+		return 0, result.Error
 	}
 
-	if a == nil || b == nil {
-		return false
+	if difficulty == nil {
+		return 0, nil
+	}
+
+	return *difficulty, nil
+}
+
+// GetUserAchievedGrades Retrieves every grade that a user has achieved on a passed score
+func GetUserAchievedGrades(userId int) ([]string, error) {
+	var grades = make([]string, 0)
+
+	result := SQL.
+		Model(&Score{}).
+		Distinct("grade").
+		Where("user_id = ? AND failed = 0", userId).
+		Pluck("grade", &grades)
+
+	if result.Error != nil {
+		return nil, result.Error
 	}
 
-	return *a == *b
+	return grades, nil
 }
@@ -97,3 +97,34 @@ func getFirstPlaceScoreOnMap(md5 string) (*Score, error) {
 
 	return score, nil
 }
+
+// RecalculateFirstPlace Sets the first place on a map to its current best score,
+// or removes it if there are no scores left
+func RecalculateFirstPlace(md5 string) error {
+	score, err := getFirstPlaceScoreOnMap(md5)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	if score == nil {
+		return SQL.Where("md5 = ?", md5).Delete(&ScoreFirstPlace{}).Error
+	}
+
+	var count int64
+
+	if err := SQL.Model(&ScoreFirstPlace{}).Where("md5 = ?", md5).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return UpdateFirstPlace(md5, score)
+	}
+
+	return SQL.Create(&ScoreFirstPlace{
+		MD5:               md5,
+		UserId:            score.UserId,
+		ScoreId:           score.Id,
+		PerformanceRating: score.PerformanceRating,
+	}).Error
+}
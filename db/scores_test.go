@@ -0,0 +1,25 @@
+package db
+
+import "testing"
+
+func TestGetBestScorePerMap(t *testing.T) {
+	scores := []*Score{
+		{Id: 1, MapMD5: "a", PerformanceRating: 30},
+		{Id: 2, MapMD5: "b", PerformanceRating: 25},
+		{Id: 3, MapMD5: "a", PerformanceRating: 20},
+		{Id: 4, MapMD5: "c", PerformanceRating: 15},
+		{Id: 5, MapMD5: "d", PerformanceRating: 10},
+	}
+
+	best := GetBestScorePerMap(scores, 3)
+
+	if len(best) != 3 {
+		t.Fatalf("expected 3 scores, got %v", len(best))
+	}
+
+	for i, id := range []int{1, 2, 4} {
+		if best[i].Id != id {
+			t.Errorf("expected score #%v at index %v, got #%v", id, i, best[i].Id)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package db
+
+import (
+	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Season A period of time with its own stats & leaderboards, which are archived once it ends
+type Season struct {
+	Id                 int       `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	Name               string    `gorm:"column:name" json:"name"`
+	StartsAt           int64     `gorm:"column:starts_at" json:"-"`
+	StartsAtJSON       time.Time `gorm:"-:all" json:"starts_at"`
+	EndsAt             int64     `gorm:"column:ends_at" json:"-"`
+	EndsAtJSON         time.Time `gorm:"-:all" json:"ends_at"`
+	BadgeId            *int      `gorm:"column:badge_id" json:"badge_id"`
+	BadgeRankThreshold int       `gorm:"column:badge_rank_threshold" json:"badge_rank_threshold"`
+	Finalized          bool      `gorm:"column:finalized" json:"finalized"`
+}
+
+func (*Season) TableName() string {
+	return "seasons"
+}
+
+func (season *Season) AfterFind(*gorm.DB) (err error) {
+	season.StartsAtJSON = time.UnixMilli(season.StartsAt)
+	season.EndsAtJSON = time.UnixMilli(season.EndsAt)
+	return nil
+}
+
+// Insert Inserts a new season into the database
+func (season *Season) Insert() error {
+	season.StartsAtJSON = time.UnixMilli(season.StartsAt)
+	season.EndsAtJSON = time.UnixMilli(season.EndsAt)
+
+	return SQL.Create(&season).Error
+}
+
+// SeasonUserStats The stats of a user in a single season and game mode.
+// The final ranks are set once the season has ended and its leaderboards are archived.
+type SeasonUserStats struct {
+	SeasonId                 int            `gorm:"column:season_id; PRIMARY_KEY" json:"season_id"`
+	UserId                   int            `gorm:"column:user_id; PRIMARY_KEY" json:"user_id"`
+	Mode                     enums.GameMode `gorm:"column:mode; PRIMARY_KEY" json:"mode"`
+	TotalScore               int64          `gorm:"column:total_score" json:"total_score"`
+	RankedScore              int64          `gorm:"column:ranked_score" json:"ranked_score"`
+	OverallAccuracy          float64        `gorm:"column:overall_accuracy" json:"overall_accuracy"`
+	OverallPerformanceRating float64        `gorm:"column:overall_performance_rating" json:"overall_performance_rating"`
+	PlayCount                int            `gorm:"column:play_count" json:"play_count"`
+	FailCount                int            `gorm:"column:fail_count" json:"fail_count"`
+	MaxCombo                 int            `gorm:"column:max_combo" json:"max_combo"`
+	TotalMarvelous           int            `gorm:"column:total_marv" json:"total_marvelous"`
+	TotalPerfect             int            `gorm:"column:total_perf" json:"total_perfect"`
+	TotalGreat               int            `gorm:"column:total_great" json:"total_great"`
+	TotalGood                int            `gorm:"column:total_good" json:"total_good"`
+	TotalOkay                int            `gorm:"column:total_okay" json:"total_okay"`
+	TotalMiss                int            `gorm:"column:total_miss" json:"total_miss"`
+	Country                  string         `gorm:"column:country" json:"country"`
+	FinalRank                *int           `gorm:"column:final_rank" json:"final_rank"`
+	FinalCountryRank         *int           `gorm:"column:final_country_rank" json:"final_country_rank"`
+	User                     *User          `gorm:"foreignKey:UserId; references:Id" json:"user,omitempty"`
+}
+
+func (*SeasonUserStats) TableName() string {
+	return "season_user_stats"
+}
+
+// Save Inserts or updates the season stats of a user
+func (stats *SeasonUserStats) Save() error {
+	return SQL.Omit("User").Clauses(clause.OnConflict{UpdateAll: true}).Create(&stats).Error
+}
+
+// GetSeasons Retrieves every season, newest first
+func GetSeasons() ([]*Season, error) {
+	var seasons = make([]*Season, 0)
+
+	result := SQL.
+		Order("starts_at DESC").
+		Find(&seasons)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return seasons, nil
+}
+
+// GetSeasonById Retrieves a season by its id
+func GetSeasonById(id int) (*Season, error) {
+	var season *Season
+
+	result := SQL.
+		Where("id = ?", id).
+		First(&season)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return season, nil
+}
+
+// GetActiveSeason Retrieves the season that is currently running
+func GetActiveSeason() (*Season, error) {
+	return GetSeasonAtTime(time.Now().UnixMilli())
+}
+
+// GetSeasonAtTime Retrieves the unfinalized season that a timestamp (in milliseconds) falls within
+func GetSeasonAtTime(timestamp int64) (*Season, error) {
+	var season *Season
+
+	result := SQL.
+		Where("starts_at <= ? AND ends_at > ? AND finalized = 0", timestamp, timestamp).
+		Order("starts_at DESC").
+		First(&season)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return season, nil
+}
+
+// GetEndedUnfinalizedSeasons Retrieves the seasons that have ended but haven't been archived yet
+func GetEndedUnfinalizedSeasons() ([]*Season, error) {
+	var seasons = make([]*Season, 0)
+
+	result := SQL.
+		Where("ends_at <= ? AND finalized = 0", time.Now().UnixMilli()).
+		Order("ends_at ASC").
+		Find(&seasons)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return seasons, nil
+}
+
+// IsSeasonOverlapping Returns if a period of time overlaps with an existing season
+func IsSeasonOverlapping(startsAt int64, endsAt int64) (bool, error) {
+	var count int64
+
+	result := SQL.
+		Model(&Season{}).
+		Where("starts_at < ? AND ends_at > ?", endsAt, startsAt).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// GetSeasonUserStats Retrieves the stats of a user in a season. Returns empty stats if the user hasn't played yet.
+func GetSeasonUserStats(seasonId int, userId int, mode enums.GameMode) (*SeasonUserStats, error) {
+	var stats *SeasonUserStats
+
+	result := SQL.
+		Where("season_id = ? AND user_id = ? AND mode = ?", seasonId, userId, mode).
+		First(&stats)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return &SeasonUserStats{SeasonId: seasonId, UserId: userId, Mode: mode}, nil
+		}
+
+		return nil, result.Error
+	}
+
+	return stats, nil
+}
+
+// GetSeasonUserStatsForUsers Retrieves the season stats of a list of users
+func GetSeasonUserStatsForUsers(seasonId int, mode enums.GameMode, userIds []int) ([]*SeasonUserStats, error) {
+	var stats = make([]*SeasonUserStats, 0)
+
+	if len(userIds) == 0 {
+		return stats, nil
+	}
+
+	result := SQL.
+		Preload("User").
+		Where("season_id = ? AND mode = ? AND user_id IN ?", seasonId, mode, userIds).
+		Find(&stats)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return stats, nil
+}
+
+// GetSeasonLeaderboard Retrieves the live leaderboard of a season that hasn't ended yet
+func GetSeasonLeaderboard(seasonId int, mode enums.GameMode, country string, page int, limit int) ([]*SeasonUserStats, error) {
+	key := SeasonLeaderboardRedisKey(seasonId, mode)
+
+	if country != "" {
+		key = SeasonCountryLeaderboardRedisKey(seasonId, country, mode)
+	}
+
+	members, err := Redis.ZRevRange(RedisCtx, key, int64(page*limit), int64(page*limit+limit-1)).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	userIds := make([]int, 0, len(members))
+
+	for _, member := range members {
+		id, err := strconv.Atoi(member)
+
+		if err != nil {
+			return nil, err
+		}
+
+		userIds = append(userIds, id)
+	}
+
+	stats, err := GetSeasonUserStatsForUsers(seasonId, mode, userIds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return slices.Index(userIds, stats[i].UserId) < slices.Index(userIds, stats[j].UserId)
+	})
+
+	return stats, nil
+}
+
+// GetSeasonLeaderboardCount Retrieves the amount of users on the live leaderboard of a season
+func GetSeasonLeaderboardCount(seasonId int, mode enums.GameMode, country string) (int, error) {
+	key := SeasonLeaderboardRedisKey(seasonId, mode)
+
+	if country != "" {
+		key = SeasonCountryLeaderboardRedisKey(seasonId, country, mode)
+	}
+
+	count, err := Redis.ZCard(RedisCtx, key).Result()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// GetSeasonFinalStandings Retrieves the archived standings of a season that has ended.
+// If a country is given, the standings are ordered by the country rank instead.
+func GetSeasonFinalStandings(seasonId int, mode enums.GameMode, country string, page int, limit int) ([]*SeasonUserStats, error) {
+	var stats = make([]*SeasonUserStats, 0)
+
+	query := SQL.
+		Preload("User").
+		Where("season_id = ? AND mode = ? AND final_rank IS NOT NULL", seasonId, mode)
+
+	if country != "" {
+		query = query.
+			Where("country = ? AND final_country_rank IS NOT NULL", strings.ToUpper(country)).
+			Order("final_country_rank ASC")
+	} else {
+		query = query.Order("final_rank ASC")
+	}
+
+	result := query.
+		Limit(limit).
+		Offset(page * limit).
+		Find(&stats)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return stats, nil
+}
+
+// GetSeasonFinalStandingsCount Retrieves the amount of users in the archived standings of a season
+func GetSeasonFinalStandingsCount(seasonId int, mode enums.GameMode, country string) (int, error) {
+	var count int64
+
+	query := SQL.
+		Model(&SeasonUserStats{}).
+		Where("season_id = ? AND mode = ? AND final_rank IS NOT NULL", seasonId, mode)
+
+	if country != "" {
+		query = query.Where("country = ? AND final_country_rank IS NOT NULL", strings.ToUpper(country))
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// UpdateSeasonUserFinalRank Archives the final rank of a user in a season
+func UpdateSeasonUserFinalRank(seasonId int, userId int, mode enums.GameMode, rank int, countryRank int) error {
+	updates := map[string]interface{}{"final_rank": rank}
+
+	if countryRank > 0 {
+		updates["final_country_rank"] = countryRank
+	}
+
+	return SQL.Model(&SeasonUserStats{}).
+		Where("season_id = ? AND user_id = ? AND mode = ?", seasonId, userId, mode).
+		Updates(updates).Error
+}
+
+// UpdateSeasonFinalized Marks a season as finalized
+func UpdateSeasonFinalized(id int) error {
+	return SQL.Model(&Season{}).
+		Where("id = ?", id).
+		Update("finalized", true).Error
+}
+
+// SeasonUserTotals The totals of every score a user has submitted during a season
+type SeasonUserTotals struct {
+	PlayCount      int   `gorm:"column:play_count"`
+	FailCount      int   `gorm:"column:fail_count"`
+	MaxCombo       int   `gorm:"column:max_combo"`
+	TotalScore     int64 `gorm:"column:total_score"`
+	TotalMarvelous int   `gorm:"column:total_marv"`
+	TotalPerfect   int   `gorm:"column:total_perf"`
+	TotalGreat     int   `gorm:"column:total_great"`
+	TotalGood      int   `gorm:"column:total_good"`
+	TotalOkay      int   `gorm:"column:total_okay"`
+	TotalMiss      int   `gorm:"column:total_miss"`
+}
+
+// GetUserSeasonTotals Sums up every score that a user has submitted during a season
+func GetUserSeasonTotals(season *Season, userId int, mode enums.GameMode) (*SeasonUserTotals, error) {
+	var totals *SeasonUserTotals
+
+	result := SQL.
+		Model(&Score{}).
+		Select("COUNT(*) AS play_count, "+
+			"COALESCE(SUM(failed), 0) AS fail_count, "+
+			"COALESCE(MAX(CASE WHEN failed = 0 THEN max_combo END), 0) AS max_combo, "+
+			"COALESCE(SUM(total_score), 0) AS total_score, "+
+			"COALESCE(SUM(count_marv), 0) AS total_marv, "+
+			"COALESCE(SUM(count_perf), 0) AS total_perf, "+
+			"COALESCE(SUM(count_great), 0) AS total_great, "+
+			"COALESCE(SUM(count_good), 0) AS total_good, "+
+			"COALESCE(SUM(count_okay), 0) AS total_okay, "+
+			"COALESCE(SUM(count_miss), 0) AS total_miss").
+		Where("user_id = ? AND mode = ? AND timestamp >= ? AND timestamp < ?",
+			userId, mode, season.StartsAt, season.EndsAt).
+		Scan(&totals)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return totals, nil
+}
+
+// GetUserSeasonScores Retrieves the passed scores of a user on ranked maps that were set during a season
+func GetUserSeasonScores(season *Season, userId int, mode enums.GameMode) ([]*Score, error) {
+	var scores = make([]*Score, 0)
+
+	result := SQL.
+		Joins("JOIN maps ON maps.md5 = scores.map_md5").
+		Where("scores.user_id = ? AND scores.mode = ? AND scores.failed = 0 AND maps.ranked_status = ? "+
+			"AND scores.timestamp >= ? AND scores.timestamp < ?",
+			userId, mode, enums.RankedStatusRanked, season.StartsAt, season.EndsAt).
+		Where(notDeletedScoreQuery).
+		Order("scores.performance_rating DESC").
+		Find(&scores)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return scores, nil
+}
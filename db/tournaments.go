@@ -0,0 +1,495 @@
+package db
+
+import (
+	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type TournamentStatus string
+
+const (
+	TournamentStatusUpcoming     TournamentStatus = "Upcoming"
+	TournamentStatusRegistration TournamentStatus = "Registration"
+	TournamentStatusQualifiers   TournamentStatus = "Qualifiers"
+	TournamentStatusBracket      TournamentStatus = "Bracket"
+	TournamentStatusCompleted    TournamentStatus = "Completed"
+)
+
+type TournamentBracketType string
+
+const (
+	TournamentSingleElimination TournamentBracketType = "SingleElimination"
+	TournamentDoubleElimination TournamentBracketType = "DoubleElimination"
+)
+
+type TournamentBracket string
+
+const (
+	TournamentBracketWinners     TournamentBracket = "Winners"
+	TournamentBracketLosers      TournamentBracket = "Losers"
+	TournamentBracketGrandFinals TournamentBracket = "GrandFinals"
+)
+
+// TournamentStageQualifiers The mappool stage that is played in qualifier lobbies and used for seeding
+const TournamentStageQualifiers = "Qualifiers"
+
+type Tournament struct {
+	Id                       int                   `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	Name                     string                `gorm:"column:name" json:"name"`
+	Description              string                `gorm:"column:description" json:"description"`
+	Mode                     enums.GameMode        `gorm:"column:mode" json:"mode"`
+	Status                   TournamentStatus      `gorm:"column:status" json:"status"`
+	BracketType              TournamentBracketType `gorm:"column:bracket_type" json:"bracket_type"`
+	TeamSize                 int                   `gorm:"column:team_size" json:"team_size"`
+	RegistrationStartsAt     int64                 `gorm:"column:registration_starts_at" json:"-"`
+	RegistrationStartsAtJSON time.Time             `gorm:"-:all" json:"registration_starts_at"`
+	RegistrationEndsAt       int64                 `gorm:"column:registration_ends_at" json:"-"`
+	RegistrationEndsAtJSON   time.Time             `gorm:"-:all" json:"registration_ends_at"`
+	CreatedBy                int                   `gorm:"column:created_by" json:"created_by"`
+	CreatedAt                int64                 `gorm:"column:created_at" json:"-"`
+	CreatedAtJSON            time.Time             `gorm:"-:all" json:"created_at"`
+}
+
+func (*Tournament) TableName() string {
+	return "tournaments"
+}
+
+func (t *Tournament) AfterFind(*gorm.DB) (err error) {
+	t.RegistrationStartsAtJSON = time.UnixMilli(t.RegistrationStartsAt)
+	t.RegistrationEndsAtJSON = time.UnixMilli(t.RegistrationEndsAt)
+	t.CreatedAtJSON = time.UnixMilli(t.CreatedAt)
+	return nil
+}
+
+// Insert Inserts a new tournament into the database
+func (t *Tournament) Insert() error {
+	t.CreatedAt = time.Now().UnixMilli()
+
+	if err := SQL.Create(&t).Error; err != nil {
+		return err
+	}
+
+	return t.AfterFind(nil)
+}
+
+// Save Updates an existing tournament
+func (t *Tournament) Save() error {
+	if err := SQL.Save(&t).Error; err != nil {
+		return err
+	}
+
+	return t.AfterFind(nil)
+}
+
+// IsRegistrationOpen Returns if teams can currently register for the tournament
+func (t *Tournament) IsRegistrationOpen(now time.Time) bool {
+	return t.Status == TournamentStatusRegistration &&
+		now.UnixMilli() >= t.RegistrationStartsAt && now.UnixMilli() < t.RegistrationEndsAt
+}
+
+type TournamentTeam struct {
+	Id            int                     `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	TournamentId  int                     `gorm:"column:tournament_id" json:"tournament_id"`
+	Name          string                  `gorm:"column:name" json:"name"`
+	CaptainId     int                     `gorm:"column:captain_id" json:"captain_id"`
+	Seed          *int                    `gorm:"column:seed" json:"seed"`
+	CreatedAt     int64                   `gorm:"column:created_at" json:"-"`
+	CreatedAtJSON time.Time               `gorm:"-:all" json:"created_at"`
+	Members       []*TournamentTeamMember `gorm:"foreignKey:TeamId" json:"members,omitempty"`
+}
+
+func (*TournamentTeam) TableName() string {
+	return "tournament_teams"
+}
+
+func (team *TournamentTeam) AfterFind(*gorm.DB) (err error) {
+	team.CreatedAtJSON = time.UnixMilli(team.CreatedAt)
+	return nil
+}
+
+type TournamentTeamMember struct {
+	TournamentId int   `gorm:"column:tournament_id; PRIMARY_KEY" json:"-"`
+	UserId       int   `gorm:"column:user_id; PRIMARY_KEY" json:"user_id"`
+	TeamId       int   `gorm:"column:team_id" json:"-"`
+	User         *User `gorm:"foreignKey:UserId; references:Id" json:"user,omitempty"`
+}
+
+func (*TournamentTeamMember) TableName() string {
+	return "tournament_team_members"
+}
+
+type TournamentMappoolMap struct {
+	Id           int     `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	TournamentId int     `gorm:"column:tournament_id" json:"-"`
+	Stage        string  `gorm:"column:stage" json:"stage"`
+	Slot         string  `gorm:"column:slot" json:"slot"`
+	MapId        int     `gorm:"column:map_id" json:"map_id"`
+	Mods         int64   `gorm:"column:mods" json:"mods"`
+	Map          *MapQua `gorm:"foreignKey:MapId; references:Id" json:"map,omitempty"`
+}
+
+func (*TournamentMappoolMap) TableName() string {
+	return "tournament_mappool_maps"
+}
+
+type TournamentQualifierLobby struct {
+	TournamentId int `gorm:"column:tournament_id; PRIMARY_KEY" json:"tournament_id"`
+	GameId       int `gorm:"column:game_id; PRIMARY_KEY" json:"game_id"`
+}
+
+func (*TournamentQualifierLobby) TableName() string {
+	return "tournament_qualifier_lobbies"
+}
+
+// TournamentQualifierScore A player's best score on a qualifier map
+type TournamentQualifierScore struct {
+	UserId int    `gorm:"column:user_id"`
+	MapMD5 string `gorm:"column:map_md5"`
+	Score  int    `gorm:"column:score"`
+}
+
+// TournamentMatch A match in a tournament bracket. Winners (and losers in double elimination)
+// advance to the given slot (1 or 2) of the next match.
+type TournamentMatch struct {
+	Id                 int               `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	TournamentId       int               `gorm:"column:tournament_id" json:"tournament_id"`
+	Bracket            TournamentBracket `gorm:"column:bracket" json:"bracket"`
+	Round              int               `gorm:"column:round" json:"round"`
+	Position           int               `gorm:"column:position" json:"position"`
+	Team1Id            *int              `gorm:"column:team1_id" json:"team1_id"`
+	Team2Id            *int              `gorm:"column:team2_id" json:"team2_id"`
+	Team1Score         int               `gorm:"column:team1_score" json:"team1_score"`
+	Team2Score         int               `gorm:"column:team2_score" json:"team2_score"`
+	WinnerId           *int              `gorm:"column:winner_id" json:"winner_id"`
+	Bye                bool              `gorm:"column:bye" json:"bye"`
+	NextMatchId        *int              `gorm:"column:next_match_id" json:"next_match_id"`
+	NextMatchSlot      *int              `gorm:"column:next_match_slot" json:"next_match_slot"`
+	LoserNextMatchId   *int              `gorm:"column:loser_next_match_id" json:"loser_next_match_id"`
+	LoserNextMatchSlot *int              `gorm:"column:loser_next_match_slot" json:"loser_next_match_slot"`
+	GameId             *int              `gorm:"column:game_id" json:"game_id"`
+	CompletedAt        *int64            `gorm:"column:completed_at" json:"completed_at"`
+}
+
+func (*TournamentMatch) TableName() string {
+	return "tournament_matches"
+}
+
+// TournamentMatchLink The matches that the winner & loser of a match advance to,
+// as indexes in the list of matches being inserted. -1 is used when there is no next match.
+type TournamentMatchLink struct {
+	NextMatch      int
+	NextSlot       int
+	LoserNextMatch int
+	LoserNextSlot  int
+}
+
+// GetTournaments Retrieves a page of tournaments, newest first
+func GetTournaments(limit int, page int) ([]*Tournament, error) {
+	var tournaments = make([]*Tournament, 0)
+
+	result := SQL.
+		Order("id DESC").
+		Limit(limit).
+		Offset(page * limit).
+		Find(&tournaments)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return tournaments, nil
+}
+
+// GetTournamentCount Retrieves the total amount of tournaments
+func GetTournamentCount() (int, error) {
+	var count int64
+
+	if err := SQL.Model(&Tournament{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// GetTournamentById Retrieves a tournament by its id
+func GetTournamentById(id int) (*Tournament, error) {
+	var tournament *Tournament
+
+	result := SQL.
+		Where("id = ?", id).
+		First(&tournament)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return tournament, nil
+}
+
+// GetTournamentTeams Retrieves the teams of a tournament along with their members, ordered by seed
+func GetTournamentTeams(tournamentId int) ([]*TournamentTeam, error) {
+	var teams = make([]*TournamentTeam, 0)
+
+	result := SQL.
+		Preload("Members").
+		Preload("Members.User").
+		Where("tournament_id = ?", tournamentId).
+		Order("seed IS NULL, seed ASC, id ASC").
+		Find(&teams)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return teams, nil
+}
+
+// GetTournamentTeamByCaptain Retrieves the team a user is the captain of in a tournament
+func GetTournamentTeamByCaptain(tournamentId int, captainId int) (*TournamentTeam, error) {
+	var team *TournamentTeam
+
+	result := SQL.
+		Where("tournament_id = ? AND captain_id = ?", tournamentId, captainId).
+		First(&team)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return team, nil
+}
+
+// GetRegisteredTournamentUsers Returns which of the given users are already on a team in a tournament
+func GetRegisteredTournamentUsers(tournamentId int, userIds []int) ([]int, error) {
+	var registered = make([]int, 0)
+
+	result := SQL.
+		Model(&TournamentTeamMember{}).
+		Where("tournament_id = ? AND user_id IN ?", tournamentId, userIds).
+		Pluck("user_id", &registered)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return registered, nil
+}
+
+// DoesTournamentTeamExistByName Returns if a team with the given name is registered in a tournament
+func DoesTournamentTeamExistByName(tournamentId int, name string) (bool, error) {
+	var count int64
+
+	result := SQL.
+		Model(&TournamentTeam{}).
+		Where("tournament_id = ? AND name = ?", tournamentId, name).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// InsertTournamentTeam Registers a team along with its members
+func InsertTournamentTeam(team *TournamentTeam, memberIds []int) error {
+	team.CreatedAt = time.Now().UnixMilli()
+	team.CreatedAtJSON = time.UnixMilli(team.CreatedAt)
+
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Create(&team).Error; err != nil {
+			return err
+		}
+
+		team.Members = make([]*TournamentTeamMember, 0, len(memberIds))
+
+		for _, userId := range memberIds {
+			team.Members = append(team.Members, &TournamentTeamMember{
+				TournamentId: team.TournamentId,
+				UserId:       userId,
+				TeamId:       team.Id,
+			})
+		}
+
+		return tx.Create(&team.Members).Error
+	})
+}
+
+// DeleteTournamentTeam Removes a team and its members from a tournament
+func DeleteTournamentTeam(team *TournamentTeam) error {
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", team.Id).Delete(&TournamentTeamMember{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&TournamentTeam{}, team.Id).Error
+	})
+}
+
+// UpdateTournamentTeamSeeds Sets the seed of each team in a tournament
+func UpdateTournamentTeamSeeds(tournamentId int, seeds map[int]int) error {
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		for teamId, seed := range seeds {
+			err := tx.Model(&TournamentTeam{}).
+				Where("id = ? AND tournament_id = ?", teamId, tournamentId).
+				Update("seed", seed).Error
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetTournamentMappool Retrieves every map in a tournament's mappools
+func GetTournamentMappool(tournamentId int) ([]*TournamentMappoolMap, error) {
+	var maps = make([]*TournamentMappoolMap, 0)
+
+	result := SQL.
+		Preload("Map").
+		Where("tournament_id = ?", tournamentId).
+		Order("stage ASC, slot ASC").
+		Find(&maps)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return maps, nil
+}
+
+// Save Inserts a map into a mappool, replacing the map that was in the same stage & slot
+func (m *TournamentMappoolMap) Save() error {
+	return SQL.Clauses(clause.OnConflict{UpdateAll: true}).Omit("Map").Create(&m).Error
+}
+
+// DeleteTournamentMappoolMap Removes a map from a tournament's mappools
+func DeleteTournamentMappoolMap(tournamentId int, id int) (bool, error) {
+	result := SQL.
+		Where("id = ? AND tournament_id = ?", id, tournamentId).
+		Delete(&TournamentMappoolMap{})
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// InsertTournamentQualifierLobby Links a multiplayer game to a tournament as a qualifier lobby
+func InsertTournamentQualifierLobby(tournamentId int, gameId int) error {
+	lobby := &TournamentQualifierLobby{TournamentId: tournamentId, GameId: gameId}
+	return SQL.Clauses(clause.OnConflict{DoNothing: true}).Create(&lobby).Error
+}
+
+// GetTournamentQualifierScores Retrieves each player's best score on each qualifier map,
+// from the multiplayer games that were linked as qualifier lobbies
+func GetTournamentQualifierScores(tournamentId int) ([]*TournamentQualifierScore, error) {
+	var scores = make([]*TournamentQualifierScore, 0)
+
+	result := SQL.Raw(
+		"SELECT s.user_id, m.map_md5, MAX(s.score) AS score "+
+			"FROM multiplayer_match_scores s "+
+			"JOIN multiplayer_game_matches m ON m.id = s.match_id AND m.aborted = 0 "+
+			"JOIN tournament_qualifier_lobbies q ON q.game_id = m.game_id AND q.tournament_id = ? "+
+			"JOIN maps ON maps.md5 = m.map_md5 "+
+			"JOIN tournament_mappool_maps p ON p.map_id = maps.id AND p.tournament_id = q.tournament_id AND p.stage = ? "+
+			"GROUP BY s.user_id, m.map_md5", tournamentId, TournamentStageQualifiers).
+		Scan(&scores)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return scores, nil
+}
+
+// GetTournamentMatches Retrieves every match in a tournament's bracket
+func GetTournamentMatches(tournamentId int) ([]*TournamentMatch, error) {
+	var matches = make([]*TournamentMatch, 0)
+
+	result := SQL.
+		Where("tournament_id = ?", tournamentId).
+		Order("FIELD(bracket, 'Winners', 'Losers', 'GrandFinals'), round ASC, position ASC").
+		Find(&matches)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return matches, nil
+}
+
+// HasCompletedTournamentMatches Returns if any match in a tournament was played (byes aren't counted)
+func HasCompletedTournamentMatches(tournamentId int) (bool, error) {
+	var count int64
+
+	result := SQL.
+		Model(&TournamentMatch{}).
+		Where("tournament_id = ? AND bye = 0 AND completed_at IS NOT NULL", tournamentId).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// ReplaceTournamentMatches Replaces a tournament's bracket with a newly generated one
+func ReplaceTournamentMatches(tournamentId int, matches []*TournamentMatch, links []*TournamentMatchLink) error {
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tournament_id = ?", tournamentId).Delete(&TournamentMatch{}).Error; err != nil {
+			return err
+		}
+
+		if len(matches) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&matches).Error; err != nil {
+			return err
+		}
+
+		for i, link := range links {
+			match := matches[i]
+
+			if link.NextMatch != -1 {
+				match.NextMatchId = &matches[link.NextMatch].Id
+				match.NextMatchSlot = &link.NextSlot
+			}
+
+			if link.LoserNextMatch != -1 {
+				match.LoserNextMatchId = &matches[link.LoserNextMatch].Id
+				match.LoserNextMatchSlot = &link.LoserNextSlot
+			}
+
+			if match.NextMatchId == nil && match.LoserNextMatchId == nil {
+				continue
+			}
+
+			if err := tx.Save(&match).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// SaveTournamentMatches Saves the results of matches and the teams that advanced from them
+func SaveTournamentMatches(matches []*TournamentMatch) error {
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		for _, match := range matches {
+			if err := tx.Save(&match).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
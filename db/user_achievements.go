@@ -1,6 +1,9 @@
 package db
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 type Achievement struct {
 	Id           int    `gorm:"column:id; PRIMARY_KEY" json:"id"`
@@ -17,13 +20,54 @@ func (*Achievement) TableName() string {
 
 type UserAchievement struct {
 	UserId        int `gorm:"column:user_id" json:"user_id"`
-	AchievementId int `gorm:"column_achievement_id" json:"achievement_id"`
+	AchievementId int `gorm:"column:achievement_id" json:"achievement_id"`
 }
 
 func (*UserAchievement) TableName() string {
 	return "user_achievements"
 }
 
+// Insert Unlocks an achievement for a user. Returns false if the user has already unlocked it.
+func (ua *UserAchievement) Insert() (bool, error) {
+	result := SQL.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&ua)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// GetAchievements Retrieves every achievement
+func GetAchievements() ([]*Achievement, error) {
+	var achievements = make([]*Achievement, 0)
+	result := SQL.Order("id ASC").Find(&achievements)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return achievements, nil
+}
+
+// GetUserUnlockedAchievementIds Retrieves the ids of the achievements that a user has unlocked
+func GetUserUnlockedAchievementIds(userId int) (map[int]bool, error) {
+	var userAchievements = make([]*UserAchievement, 0)
+	result := SQL.Where("user_id = ?", userId).Find(&userAchievements)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	unlocked := map[int]bool{}
+
+	for _, userAchievement := range userAchievements {
+		unlocked[userAchievement.AchievementId] = true
+	}
+
+	return unlocked, nil
+}
+
 // GetUserAchievements Gets a user's unlocked achievements
 func GetUserAchievements(id int) ([]*Achievement, error) {
 	var achievements = make([]*Achievement, 0)
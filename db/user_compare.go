@@ -0,0 +1,145 @@
+package db
+
+import (
+	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm"
+)
+
+// UserComparisonSummary The overall result of comparing the personal bests of two players on the maps they've both played.
+// Maps are won by having the personal best with the higher performance rating.
+type UserComparisonSummary struct {
+	SharedMaps           int     `gorm:"column:shared_maps" json:"shared_maps"`
+	Wins                 int     `gorm:"column:wins" json:"wins"`
+	Losses               int     `gorm:"column:losses" json:"losses"`
+	Draws                int     `gorm:"column:draws" json:"draws"`
+	AverageAccuracyDelta float64 `gorm:"column:average_accuracy_delta" json:"average_accuracy_delta"`
+	AverageRatingDelta   float64 `gorm:"column:average_rating_delta" json:"average_rating_delta"`
+}
+
+// UserComparisonMap The personal bests of two players on a map. Deltas are from the perspective of the first player.
+type UserComparisonMap struct {
+	Map           *MapQua `json:"map"`
+	Score         *Score  `json:"score"`
+	OtherScore    *Score  `json:"other_score"`
+	AccuracyDelta float64 `json:"accuracy_delta"`
+	RatingDelta   float64 `json:"rating_delta"`
+}
+
+type userComparisonPair struct {
+	ScoreId      int `gorm:"column:score_id"`
+	OtherScoreId int `gorm:"column:other_score_id"`
+}
+
+// Returns a query of the personal bests of two players joined on the maps they've both played
+func userComparisonQuery(userId int, otherId int, mode enums.GameMode) *gorm.DB {
+	return SQL.
+		Table("scores a").
+		Joins("JOIN scores b ON b.map_md5 = a.map_md5 AND b.user_id = ? AND b.personal_best = 1 "+
+			"AND b.is_donator_score = 0", otherId).
+		Where("a.user_id = ? AND a.mode = ? AND a.personal_best = 1 AND a.is_donator_score = 0", userId, mode)
+}
+
+// GetUserComparisonSummary Retrieves the win/loss record and average deltas between two players
+func GetUserComparisonSummary(userId int, otherId int, mode enums.GameMode) (*UserComparisonSummary, error) {
+	var summary *UserComparisonSummary
+
+	result := userComparisonQuery(userId, otherId, mode).
+		Select("COUNT(*) AS shared_maps, " +
+			"COALESCE(SUM(a.performance_rating > b.performance_rating), 0) AS wins, " +
+			"COALESCE(SUM(a.performance_rating < b.performance_rating), 0) AS losses, " +
+			"COALESCE(SUM(a.performance_rating = b.performance_rating), 0) AS draws, " +
+			"COALESCE(AVG(a.accuracy - b.accuracy), 0) AS average_accuracy_delta, " +
+			"COALESCE(AVG(a.performance_rating - b.performance_rating), 0) AS average_rating_delta").
+		Scan(&summary)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return summary, nil
+}
+
+// GetUserComparisonMaps Retrieves the maps that two players both have personal bests on,
+// ordered by the first player's performance rating
+func GetUserComparisonMaps(userId int, otherId int, mode enums.GameMode, limit int, page int) ([]*UserComparisonMap, error) {
+	return getUserComparisonMaps(userId, otherId, mode, "a.performance_rating DESC", limit, page)
+}
+
+// GetUserComparisonBestRelativeMaps Retrieves the maps where the first player's personal best
+// is furthest ahead of the other player's
+func GetUserComparisonBestRelativeMaps(userId int, otherId int, mode enums.GameMode, limit int) ([]*UserComparisonMap, error) {
+	return getUserComparisonMaps(userId, otherId, mode, "(a.performance_rating - b.performance_rating) DESC", limit, 0)
+}
+
+func getUserComparisonMaps(userId int, otherId int, mode enums.GameMode, order string, limit int, page int) ([]*UserComparisonMap, error) {
+	var pairs []*userComparisonPair
+
+	result := userComparisonQuery(userId, otherId, mode).
+		Select("a.id AS score_id, b.id AS other_score_id").
+		Order(order).
+		Order("a.id ASC").
+		Limit(limit).
+		Offset(page * limit).
+		Scan(&pairs)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	maps := make([]*UserComparisonMap, 0, len(pairs))
+
+	if len(pairs) == 0 {
+		return maps, nil
+	}
+
+	ids := make([]int, 0, len(pairs)*2)
+
+	for _, pair := range pairs {
+		ids = append(ids, pair.ScoreId, pair.OtherScoreId)
+	}
+
+	var scores = make([]*Score, 0)
+
+	result = SQL.
+		Preload("Map").
+		Where("scores.id IN ?", ids).
+		Find(&scores)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	scoresById := make(map[int]*Score, len(scores))
+
+	for _, score := range scores {
+		scoresById[score.Id] = score
+	}
+
+	for _, pair := range pairs {
+		score, other := scoresById[pair.ScoreId], scoresById[pair.OtherScoreId]
+
+		if score == nil || other == nil {
+			continue
+		}
+
+		maps = append(maps, newUserComparisonMap(score, other))
+	}
+
+	return maps, nil
+}
+
+func newUserComparisonMap(score *Score, other *Score) *UserComparisonMap {
+	comparison := &UserComparisonMap{
+		Map:           score.Map,
+		Score:         score,
+		OtherScore:    other,
+		AccuracyDelta: score.Accuracy - other.Accuracy,
+		RatingDelta:   score.PerformanceRating - other.PerformanceRating,
+	}
+
+	// The map is already included at the top level of the comparison
+	score.Map = nil
+	other.Map = nil
+
+	return comparison
+}
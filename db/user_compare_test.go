@@ -0,0 +1,28 @@
+package db
+
+import "testing"
+
+func TestNewUserComparisonMap(t *testing.T) {
+	mapQua := &MapQua{Id: 1, MD5: "a"}
+
+	score := &Score{Id: 1, MapMD5: "a", Accuracy: 98.5, PerformanceRating: 30, Map: mapQua}
+	other := &Score{Id: 2, MapMD5: "a", Accuracy: 97, PerformanceRating: 32, Map: mapQua}
+
+	comparison := newUserComparisonMap(score, other)
+
+	if comparison.Map != mapQua {
+		t.Errorf("expected the map to be moved to the comparison")
+	}
+
+	if comparison.Score.Map != nil || comparison.OtherScore.Map != nil {
+		t.Errorf("expected the map to be removed from both scores")
+	}
+
+	if comparison.AccuracyDelta != 1.5 {
+		t.Errorf("expected accuracy delta 1.5, got %v", comparison.AccuracyDelta)
+	}
+
+	if comparison.RatingDelta != -2 {
+		t.Errorf("expected rating delta -2, got %v", comparison.RatingDelta)
+	}
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm"
+)
+
+// UserMultiplayerFilter Narrows down the multiplayer matches of a user. Zero values aren't filtered on.
+type UserMultiplayerFilter struct {
+	Ruleset *enums.MultiplayerRuleset `form:"ruleset" json:"ruleset"`
+	Mode    enums.GameMode            `form:"mode" json:"mode"`
+	Start   int64                     `form:"start" json:"start"`
+	End     int64                     `form:"end" json:"end"`
+}
+
+// UserMultiplayerStats A user's aggregated results in the multiplayer matches that match a filter
+type UserMultiplayerStats struct {
+	GamesPlayed     int                            `gorm:"column:games_played" json:"games_played"`
+	MatchesPlayed   int                            `gorm:"column:matches_played" json:"matches_played"`
+	Wins            int                            `gorm:"column:wins" json:"wins"`
+	WinRate         float64                        `gorm:"-:all" json:"win_rate"`
+	AverageAccuracy float64                        `gorm:"column:average_accuracy" json:"average_accuracy"`
+	Opponents       []*UserMultiplayerOpponent     `gorm:"-:all" json:"most_played_opponents"`
+	FavouriteMaps   []*UserMultiplayerFavouriteMap `gorm:"-:all" json:"favourite_maps"`
+}
+
+// UserMultiplayerOpponent A player that a user has played multiplayer matches with
+type UserMultiplayerOpponent struct {
+	UserId        int   `gorm:"column:user_id" json:"-"`
+	MatchesPlayed int   `gorm:"column:matches_played" json:"matches_played"`
+	User          *User `gorm:"-:all" json:"user"`
+}
+
+// UserMultiplayerFavouriteMap A map that a user has played in multiplayer.
+// Map is nil if the map isn't uploaded, in which case MapString is the name it was played under.
+type UserMultiplayerFavouriteMap struct {
+	MapMD5        string  `gorm:"column:map_md5" json:"map_md5"`
+	MapString     string  `gorm:"column:map_string" json:"map_string"`
+	MatchesPlayed int     `gorm:"column:matches_played" json:"matches_played"`
+	Map           *MapQua `gorm:"-:all" json:"map"`
+}
+
+// Returns a query of a user's scores in multiplayer matches that match a filter, joined with their match
+func userMultiplayerQuery(userId int, filter *UserMultiplayerFilter) *gorm.DB {
+	query := SQL.
+		Table("multiplayer_match_scores s").
+		Joins("JOIN multiplayer_game_matches m ON m.id = s.match_id").
+		Where("s.user_id = ? AND m.aborted = 0", userId)
+
+	if filter.Ruleset != nil {
+		query = query.Where("m.ruleset = ?", *filter.Ruleset)
+	}
+
+	if filter.Mode != 0 {
+		query = query.Where("m.game_mode = ?", filter.Mode)
+	}
+
+	if filter.Start > 0 {
+		query = query.Where("m.time_played >= ?", filter.Start)
+	}
+
+	if filter.End > 0 {
+		query = query.Where("m.time_played < ?", filter.End)
+	}
+
+	return query
+}
+
+// GetUserMultiplayerGames Retrieves the games a user played in, most recent first.
+// Only the matches that match the filter are included, along with the user's score in each.
+func GetUserMultiplayerGames(userId int, filter *UserMultiplayerFilter, limit int, page int) ([]*MultiplayerGame, error) {
+	var gameIds []int
+
+	result := userMultiplayerQuery(userId, filter).
+		Select("m.game_id").
+		Group("m.game_id").
+		Order("MAX(m.id) DESC").
+		Limit(limit).
+		Offset(page * limit).
+		Scan(&gameIds)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var games = make([]*MultiplayerGame, 0)
+
+	if len(gameIds) == 0 {
+		return games, nil
+	}
+
+	matchIds := userMultiplayerQuery(userId, filter).
+		Select("m.id").
+		Where("m.game_id IN ?", gameIds)
+
+	result = SQL.
+		Preload("Matches", "id IN (?)", matchIds).
+		Preload("Matches.Map").
+		Preload("Matches.Scores", "user_id = ?", userId).
+		Where("id IN ?", gameIds).
+		Order("id DESC").
+		Find(&games)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return games, nil
+}
+
+// GetUserMultiplayerGameCount Retrieves the amount of games a user played in that match a filter
+func GetUserMultiplayerGameCount(userId int, filter *UserMultiplayerFilter) (int, error) {
+	var count int
+
+	result := userMultiplayerQuery(userId, filter).
+		Select("COUNT(DISTINCT m.game_id)").
+		Scan(&count)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+// GetUserMultiplayerStats Retrieves a user's win rate, average accuracy, most played opponents and
+// favourite maps in the multiplayer matches that match a filter
+func GetUserMultiplayerStats(userId int, filter *UserMultiplayerFilter, limit int) (*UserMultiplayerStats, error) {
+	var stats *UserMultiplayerStats
+
+	result := userMultiplayerQuery(userId, filter).
+		Select("COUNT(DISTINCT m.game_id) AS games_played, " +
+			"COUNT(*) AS matches_played, " +
+			"COALESCE(SUM(s.won), 0) AS wins, " +
+			"COALESCE(AVG(s.accuracy), 0) AS average_accuracy").
+		Scan(&stats)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if stats.MatchesPlayed > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.MatchesPlayed) * 100
+	}
+
+	opponents, err := getUserMultiplayerOpponents(userId, filter, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	maps, err := getUserMultiplayerFavouriteMaps(userId, filter, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Opponents = opponents
+	stats.FavouriteMaps = maps
+	return stats, nil
+}
+
+// Retrieves the players a user has played the most multiplayer matches with
+func getUserMultiplayerOpponents(userId int, filter *UserMultiplayerFilter, limit int) ([]*UserMultiplayerOpponent, error) {
+	var opponents = make([]*UserMultiplayerOpponent, 0)
+
+	result := userMultiplayerQuery(userId, filter).
+		Select("o.user_id, COUNT(*) AS matches_played").
+		Joins("JOIN multiplayer_match_scores o ON o.match_id = s.match_id AND o.user_id != s.user_id").
+		Group("o.user_id").
+		Order("matches_played DESC, o.user_id ASC").
+		Limit(limit).
+		Scan(&opponents)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if len(opponents) == 0 {
+		return opponents, nil
+	}
+
+	userIds := make([]int, 0, len(opponents))
+
+	for _, opponent := range opponents {
+		userIds = append(userIds, opponent.UserId)
+	}
+
+	var users = make([]*User, 0)
+
+	if err := SQL.Where("users.id IN ?", userIds).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	usersById := make(map[int]*User, len(users))
+
+	for _, user := range users {
+		usersById[user.Id] = user
+	}
+
+	for _, opponent := range opponents {
+		opponent.User = usersById[opponent.UserId]
+	}
+
+	return opponents, nil
+}
+
+// Retrieves the maps a user has played the most in multiplayer
+func getUserMultiplayerFavouriteMaps(userId int, filter *UserMultiplayerFilter, limit int) ([]*UserMultiplayerFavouriteMap, error) {
+	var maps = make([]*UserMultiplayerFavouriteMap, 0)
+
+	result := userMultiplayerQuery(userId, filter).
+		Select("m.map_md5, MAX(m.map) AS map_string, COUNT(*) AS matches_played").
+		Group("m.map_md5").
+		Order("matches_played DESC, m.map_md5 ASC").
+		Limit(limit).
+		Scan(&maps)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if len(maps) == 0 {
+		return maps, nil
+	}
+
+	md5s := make([]string, 0, len(maps))
+
+	for _, m := range maps {
+		md5s = append(md5s, m.MapMD5)
+	}
+
+	var quas = make([]*MapQua, 0)
+
+	if err := SQL.Where("md5 IN ?", md5s).Find(&quas).Error; err != nil {
+		return nil, err
+	}
+
+	quasByMD5 := make(map[string]*MapQua, len(quas))
+
+	for _, qua := range quas {
+		quasByMD5[qua.MD5] = qua
+	}
+
+	for _, m := range maps {
+		m.Map = quasByMD5[m.MapMD5]
+	}
+
+	return maps, nil
+}
@@ -20,6 +20,7 @@ const (
 	NotificationClanKicked
 	NotificationClanMapRanked
 	NotificationClanLostFirstPlace
+	NotificationAchievementUnlocked
 )
 
 type UserNotificationCategory int
@@ -401,3 +402,22 @@ func NewClanLostFirstPlaceNotification(mapQua *MapQua, userId int) *UserNotifica
 	notif.RawData = string(marshaled)
 	return notif
 }
+
+// NewAchievementUnlockedNotification Returns a new notification for when a user unlocks an achievement
+func NewAchievementUnlockedNotification(userId int, achievement *Achievement) *UserNotification {
+	notif := &UserNotification{
+		SenderId:   QuaverBotId,
+		ReceiverId: userId,
+		Type:       NotificationAchievementUnlocked,
+		Category:   NotificationCategoryProfile,
+	}
+
+	data := map[string]interface{}{
+		"achievement_id":   achievement.Id,
+		"achievement_name": achievement.Name,
+	}
+
+	marshaled, _ := json.Marshal(data)
+	notif.RawData = string(marshaled)
+	return notif
+}
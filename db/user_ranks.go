@@ -3,14 +3,27 @@ package db
 import (
 	"fmt"
 	"github.com/Quaver/api2/enums"
+	"gorm.io/gorm"
 	"time"
 )
 
+type UserRankGranularity string
+
+const (
+	UserRankDaily  UserRankGranularity = "Daily"
+	UserRankWeekly UserRankGranularity = "Weekly"
+)
+
 type UserRank struct {
-	UserId                   int       `gorm:"column:user_id" json:"-"`
-	Rank                     int       `gorm:"column:rank" json:"rank"`
-	OverallPerformanceRating float64   `gorm:"column:overall_performance_rating" json:"overall_performance_rating"`
-	Timestamp                time.Time `gorm:"type:date; column:timestamp" json:"timestamp"`
+	UserId                   int                 `gorm:"column:user_id" json:"-"`
+	Rank                     int                 `gorm:"column:rank" json:"rank"`
+	OverallPerformanceRating float64             `gorm:"column:overall_performance_rating" json:"overall_performance_rating"`
+	CountryRank              *int                `gorm:"column:country_rank" json:"country_rank"`
+	OverallAccuracy          float64             `gorm:"column:overall_accuracy" json:"overall_accuracy"`
+	PlayCount                int                 `gorm:"column:play_count" json:"play_count"`
+	RankedScore              int64               `gorm:"column:ranked_score" json:"ranked_score"`
+	Granularity              UserRankGranularity `gorm:"column:granularity" json:"granularity"`
+	Timestamp                time.Time           `gorm:"type:date; column:timestamp" json:"timestamp"`
 }
 
 type UserRankKeys4 UserRank
@@ -25,6 +38,41 @@ func (ur *UserRankKeys7) TableName() string {
 	return "user_rank_keys7"
 }
 
+// UserRankTotalHits A snapshot of a user's total hits rank. The total hits leaderboard spans every game mode,
+// so it's stored separately from the per-mode ranks.
+type UserRankTotalHits struct {
+	UserId        int                 `gorm:"column:user_id" json:"-"`
+	TotalHitsRank int                 `gorm:"column:total_hits_rank" json:"total_hits_rank"`
+	Granularity   UserRankGranularity `gorm:"column:granularity" json:"granularity"`
+	Timestamp     time.Time           `gorm:"type:date; column:timestamp" json:"timestamp"`
+}
+
+func (*UserRankTotalHits) TableName() string {
+	return "user_rank_total_hits"
+}
+
+// UserRankHistoryPoint A single value of a rank history metric
+type UserRankHistoryPoint struct {
+	Value     float64   `gorm:"column:value" json:"value"`
+	Timestamp time.Time `gorm:"column:timestamp" json:"timestamp"`
+}
+
+// UserRankHistoryMetrics The metrics that can be retrieved as a time series, mapped to their column.
+// The total hits rank isn't per mode, so it's the same for every mode.
+var UserRankHistoryMetrics = map[string]string{
+	"rank":                       "rank",
+	"country_rank":               "country_rank",
+	"total_hits_rank":            "total_hits_rank",
+	"overall_performance_rating": "overall_performance_rating",
+	"overall_accuracy":           "overall_accuracy",
+	"play_count":                 "play_count",
+	"ranked_score":               "ranked_score",
+}
+
+func getUserRankTable(mode enums.GameMode) string {
+	return fmt.Sprintf("user_rank_%v", enums.GetGameModeString(mode))
+}
+
 // GetUserRankStatisticsForMode Retrieves a users rank statistics for a given game mode
 func GetUserRankStatisticsForMode(id int, mode enums.GameMode) ([]*UserRank, error) {
 	var ranks = make([]*UserRank, 0)
@@ -34,11 +82,10 @@ func GetUserRankStatisticsForMode(id int, mode enums.GameMode) ([]*UserRank, err
 		return ranks, nil
 	}
 
-	modeStr := fmt.Sprintf("user_rank_%v", enums.GetGameModeString(mode))
-
 	result := SQL.
 		Where("user_id = ?", id).
-		Table(modeStr).
+		Table(getUserRankTable(mode)).
+		Order("timestamp ASC").
 		Find(&ranks)
 
 	if result.Error != nil {
@@ -47,3 +94,102 @@ func GetUserRankStatisticsForMode(id int, mode enums.GameMode) ([]*UserRank, err
 
 	return ranks, nil
 }
+
+// GetUserRankHistory Retrieves a single metric of a user's rank statistics as a time series.
+// Snapshots where the metric wasn't recorded are left out.
+func GetUserRankHistory(id int, mode enums.GameMode, metric string, since time.Time) ([]*UserRankHistoryPoint, error) {
+	var points = make([]*UserRankHistoryPoint, 0)
+
+	column, ok := UserRankHistoryMetrics[metric]
+
+	if !ok || mode < enums.GameModeKeys4 || mode > enums.GameModeKeys7 {
+		return points, nil
+	}
+
+	table := getUserRankTable(mode)
+
+	if metric == "total_hits_rank" {
+		table = (&UserRankTotalHits{}).TableName()
+	}
+
+	result := SQL.
+		Table(table).
+		Select(fmt.Sprintf("`%v` AS value, timestamp", column)).
+		Where(fmt.Sprintf("user_id = ? AND timestamp >= ? AND `%v` IS NOT NULL", column), id, since).
+		Order("timestamp ASC").
+		Scan(&points)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return points, nil
+}
+
+// InsertUserRank Inserts a daily snapshot of a user's ranks for a given game mode
+func InsertUserRank(mode enums.GameMode, rank *UserRank) error {
+	rank.Granularity = UserRankDaily
+
+	switch mode {
+	case enums.GameModeKeys4:
+		keys4 := UserRankKeys4(*rank)
+		return SQL.Create(&keys4).Error
+	case enums.GameModeKeys7:
+		keys7 := UserRankKeys7(*rank)
+		return SQL.Create(&keys7).Error
+	default:
+		return fmt.Errorf("invalid game mode: %v", mode)
+	}
+}
+
+// InsertUserRankTotalHits Inserts a daily snapshot of a user's total hits rank
+func InsertUserRankTotalHits(userId int, totalHitsRank int) error {
+	rank := &UserRankTotalHits{
+		UserId:        userId,
+		TotalHitsRank: totalHitsRank,
+		Granularity:   UserRankDaily,
+		Timestamp:     time.Now(),
+	}
+
+	return SQL.Create(rank).Error
+}
+
+// CompactUserRanks Compacts the daily snapshots before the cutoff into a single weekly snapshot,
+// keeping the last snapshot of each week. The cutoff should be the start of a week, so weeks aren't split.
+func CompactUserRanks(mode enums.GameMode, cutoff time.Time) error {
+	return compactUserRankTable(getUserRankTable(mode), cutoff)
+}
+
+// CompactUserRankTotalHits Compacts the daily total hits rank snapshots before the cutoff, like CompactUserRanks
+func CompactUserRankTotalHits(cutoff time.Time) error {
+	return compactUserRankTable((&UserRankTotalHits{}).TableName(), cutoff)
+}
+
+func compactUserRankTable(table string, cutoff time.Time) error {
+	return SQL.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(fmt.Sprintf("UPDATE %[1]v r JOIN ("+
+			"SELECT user_id, MAX(timestamp) AS timestamp FROM %[1]v "+
+			"WHERE granularity = ? AND timestamp < ? "+
+			"GROUP BY user_id, YEARWEEK(timestamp, 3)) w "+
+			"ON w.user_id = r.user_id AND w.timestamp = r.timestamp "+
+			"SET r.granularity = ?", table), UserRankDaily, cutoff, UserRankWeekly)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Exec(fmt.Sprintf("DELETE FROM %v WHERE granularity = ? AND timestamp < ?", table),
+			UserRankDaily, cutoff).Error
+	})
+}
+
+// GetUserRankCompactionCutoff Returns the start of the week that daily snapshots older than the retention
+// period fall in. Snapshots before it are compacted into weekly ones.
+func GetUserRankCompactionCutoff(now time.Time, retention time.Duration) time.Time {
+	cutoff := now.Add(-retention)
+	cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, cutoff.Location())
+
+	// Weeks start on Monday, to match YEARWEEK(timestamp, 3)
+	daysSinceMonday := (int(cutoff.Weekday()) + 6) % 7
+	return cutoff.AddDate(0, 0, -daysSinceMonday)
+}
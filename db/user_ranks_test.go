@@ -0,0 +1,42 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetUserRankCompactionCutoff(t *testing.T) {
+	retention := time.Hour * 24 * 90
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "retention ends mid-week",
+			now:      time.Date(2024, time.June, 13, 15, 30, 0, 0, time.UTC),
+			expected: time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "retention ends on a monday",
+			now:      time.Date(2024, time.June, 9, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "retention ends on a sunday",
+			now:      time.Date(2024, time.June, 8, 12, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cutoff := GetUserRankCompactionCutoff(test.now, retention)
+
+			if !cutoff.Equal(test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, cutoff)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// UserSession A device a user is logged in on. Each session has one valid access token at a time,
+// which is replaced along with the refresh token whenever the session is refreshed.
+type UserSession struct {
+	Id                       int       `gorm:"column:id; PRIMARY_KEY" json:"id"`
+	UserId                   int       `gorm:"column:user_id" json:"-"`
+	RefreshTokenHash         string    `gorm:"column:refresh_token_hash" json:"-"`
+	PreviousRefreshTokenHash *string   `gorm:"column:previous_refresh_token_hash" json:"-"`
+	AccessTokenId            string    `gorm:"column:access_token_id" json:"-"`
+	UserAgent                string    `gorm:"column:user_agent" json:"user_agent"`
+	IPAddress                string    `gorm:"column:ip_address" json:"ip_address"`
+	CreatedAt                int64     `gorm:"column:created_at" json:"-"`
+	CreatedAtJSON            time.Time `gorm:"-:all" json:"created_at"`
+	LastUsedAt               int64     `gorm:"column:last_used_at" json:"-"`
+	LastUsedAtJSON           time.Time `gorm:"-:all" json:"last_used_at"`
+	ExpiresAt                int64     `gorm:"column:expires_at" json:"-"`
+	ExpiresAtJSON            time.Time `gorm:"-:all" json:"expires_at"`
+	RevokedAt                *int64    `gorm:"column:revoked_at" json:"-"`
+	Current                  bool      `gorm:"-:all" json:"current"`
+}
+
+func (*UserSession) TableName() string {
+	return "user_sessions"
+}
+
+func (session *UserSession) AfterFind(*gorm.DB) (err error) {
+	session.CreatedAtJSON = time.UnixMilli(session.CreatedAt)
+	session.LastUsedAtJSON = time.UnixMilli(session.LastUsedAt)
+	session.ExpiresAtJSON = time.UnixMilli(session.ExpiresAt)
+	return nil
+}
+
+// Insert Inserts a session into the database
+func (session *UserSession) Insert() error {
+	session.CreatedAt = time.Now().UnixMilli()
+	session.LastUsedAt = session.CreatedAt
+	session.CreatedAtJSON = time.UnixMilli(session.CreatedAt)
+	session.LastUsedAtJSON = time.UnixMilli(session.LastUsedAt)
+	session.ExpiresAtJSON = time.UnixMilli(session.ExpiresAt)
+
+	return SQL.Create(&session).Error
+}
+
+// Rotate Replaces the session's refresh token and access token id, and extends its expiry.
+// The previous refresh token is kept, so it can be detected if it is used again.
+func (session *UserSession) Rotate(refreshTokenHash string, accessTokenId string, ip string, expiresAt int64) error {
+	previous := session.RefreshTokenHash
+
+	session.PreviousRefreshTokenHash = &previous
+	session.RefreshTokenHash = refreshTokenHash
+	session.AccessTokenId = accessTokenId
+	session.IPAddress = ip
+	session.LastUsedAt = time.Now().UnixMilli()
+	session.LastUsedAtJSON = time.UnixMilli(session.LastUsedAt)
+	session.ExpiresAt = expiresAt
+	session.ExpiresAtJSON = time.UnixMilli(expiresAt)
+
+	return SQL.Model(&UserSession{}).
+		Where("id = ?", session.Id).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":          session.RefreshTokenHash,
+			"previous_refresh_token_hash": previous,
+			"access_token_id":             accessTokenId,
+			"ip_address":                  ip,
+			"last_used_at":                session.LastUsedAt,
+			"expires_at":                  expiresAt,
+		}).Error
+}
+
+// Revoke Revokes the session, so it can't be refreshed anymore
+func (session *UserSession) Revoke() error {
+	now := time.Now().UnixMilli()
+	session.RevokedAt = &now
+
+	return SQL.Model(&UserSession{}).
+		Where("id = ?", session.Id).
+		Update("revoked_at", now).Error
+}
+
+// GetUserSessionByRefreshToken Retrieves an unexpired, unrevoked session by the hash of its refresh token
+func GetUserSessionByRefreshToken(hash string) (*UserSession, error) {
+	var session *UserSession
+
+	result := SQL.
+		Where("refresh_token_hash = ? AND revoked_at IS NULL AND expires_at > ?", hash, time.Now().UnixMilli()).
+		First(&session)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return session, nil
+}
+
+// GetUserSessionByPreviousRefreshToken Retrieves an unrevoked session whose refresh token has been replaced by a newer one
+func GetUserSessionByPreviousRefreshToken(hash string) (*UserSession, error) {
+	var session *UserSession
+
+	result := SQL.
+		Where("previous_refresh_token_hash = ? AND revoked_at IS NULL", hash).
+		First(&session)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return session, nil
+}
+
+// GetUserSessions Retrieves a user's sessions that haven't been revoked or expired
+func GetUserSessions(userId int) ([]*UserSession, error) {
+	var sessions = make([]*UserSession, 0)
+
+	result := SQL.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now().UnixMilli()).
+		Order("last_used_at DESC").
+		Find(&sessions)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return sessions, nil
+}
+
+// GetUserSessionById Retrieves one of a user's sessions that hasn't been revoked or expired
+func GetUserSessionById(userId int, id int) (*UserSession, error) {
+	var session *UserSession
+
+	result := SQL.
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", id, userId, time.Now().UnixMilli()).
+		First(&session)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return session, nil
+}
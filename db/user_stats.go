@@ -1,5 +1,15 @@
 package db
 
+import (
+	"fmt"
+	"github.com/Quaver/api2/enums"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+)
+
+// The amount of best scores that make up the overall rating of a user
+const userStatsRatingScoreCount = 500
+
 type UserStats struct {
 	UserId                   int        `gorm:"column:user_id" json:"-"`
 	Ranks                    *UserRanks `gorm:"-:all" json:"ranks"`
@@ -41,3 +51,58 @@ func (*UserStatsKeys4) TableName() string {
 func (*UserStatsKeys7) TableName() string {
 	return "user_stats_keys7"
 }
+
+// RecalculateUserStatsRatings Recalculates a user's overall rating, accuracy & ranked score from their
+// personal bests on ranked maps, and updates their position on the global & country leaderboards.
+func RecalculateUserStatsRatings(user *User, mode enums.GameMode) error {
+	var scores = make([]*Score, 0)
+
+	result := SQL.
+		Joins("JOIN maps ON maps.md5 = scores.map_md5").
+		Where("scores.user_id = ? AND scores.mode = ? AND scores.personal_best = 1 "+
+			"AND scores.is_donator_score = 0 AND maps.ranked_status = ?", user.Id, mode, enums.RankedStatusRanked).
+		Order("scores.performance_rating DESC").
+		Find(&scores)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var rankedScore int64
+
+	for _, score := range scores {
+		rankedScore += int64(score.TotalScore)
+	}
+
+	best := scores[:min(len(scores), userStatsRatingScoreCount)]
+	rating := CalculateOverallRating(best)
+
+	result = SQL.
+		Table(fmt.Sprintf("user_stats_%v", enums.GetGameModeString(mode))).
+		Where("user_id = ?", user.Id).
+		Updates(map[string]interface{}{
+			"overall_performance_rating": rating,
+			"overall_accuracy":           CalculateOverallAccuracy(best),
+			"ranked_score":               rankedScore,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if !user.Allowed || user.ShadowBanned {
+		return nil
+	}
+
+	member := redis.Z{Score: rating, Member: strconv.Itoa(user.Id)}
+
+	if err := Redis.ZAdd(RedisCtx, GlobalLeaderboardRedisKey(mode), member).Err(); err != nil {
+		return err
+	}
+
+	if user.Country == "XX" {
+		return nil
+	}
+
+	return Redis.ZAdd(RedisCtx, CountryLeaderboardRedisKey(user.Country, mode), member).Err()
+}
@@ -47,6 +47,7 @@ type User struct {
 	ClanLeaveTimeJSON           time.Time         `gorm:"-:all" json:"clan_leave_time"`
 	ShadowBanned                bool              `gorm:"column:shadow_banned" json:"-"`
 	RememberToken               *string           `gorm:"column:remember_token" json:"-"`
+	TokensRevokedAt             *int64            `gorm:"column:tokens_revoked_at" json:"-"`
 	AccentColorCustomizable     bool              `gorm:"column:accent_color_customizable" json:"accent_color_customizable"`
 	AccentColor                 *string           `gorm:"column:accent_color" json:"accent_color"`
 	ClientStatus                *UserClientStatus `gorm:"-:all" json:"client_status"`
@@ -363,6 +364,7 @@ func UpdateUserClan(userId int, clanId ...int) error {
 		return result.Error
 	}
 
+	logScoreboardInvalidationError(InvalidateUserScoreboards(userId, ScoreboardInvalidationClan))
 	return nil
 }
 
@@ -385,9 +387,15 @@ func UpdateUserUsername(userId int, username string) error {
 		return result.Error
 	}
 
+	logScoreboardInvalidationError(InvalidateUserScoreboards(userId, ScoreboardInvalidationUsername))
 	return nil
 }
 
+// UpdateUserTokensRevokedAt Updates the time before which every JWT issued to the user is rejected
+func UpdateUserTokensRevokedAt(userId int, revokedAt int64) error {
+	return SQL.Model(&User{}).Where("id = ?", userId).Update("tokens_revoked_at", revokedAt).Error
+}
+
 // UpdateUserAllowed Updates whether the user is allowed to play (banned)
 func UpdateUserAllowed(userId int, isAllowed bool) error {
 	result := SQL.Model(&User{}).Where("id = ?", userId).Update("allowed", isAllowed)
@@ -396,9 +404,40 @@ func UpdateUserAllowed(userId int, isAllowed bool) error {
 		return result.Error
 	}
 
+	logScoreboardInvalidationError(InvalidateUserScoreboards(userId, ScoreboardInvalidationBan))
+	return nil
+}
+
+// UpdateUserCheckedPreviousAchievements Marks that a user's previous scores have been checked for achievements
+func UpdateUserCheckedPreviousAchievements(userId int) error {
+	result := SQL.Model(&User{}).Where("id = ?", userId).Update("checked_previous_achievements", true)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
 	return nil
 }
 
+// GetUsersWithUncheckedAchievements Retrieves users whose previous scores haven't been checked for achievements yet
+func GetUsersWithUncheckedAchievements(limit int) ([]*User, error) {
+	var users = make([]*User, 0)
+
+	result := SQL.
+		Joins("StatsKeys4").
+		Joins("StatsKeys7").
+		Where("users.checked_previous_achievements = 0 AND users.allowed = 1").
+		Order("users.id ASC").
+		Limit(limit).
+		Find(&users)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return users, nil
+}
+
 // UpdateUserDiscordId Updates a user's discord id
 func UpdateUserDiscordId(userId int, discordId *string) error {
 	result := SQL.Model(&User{}).Where("id = ?", userId).Update("discord_id", discordId)
@@ -0,0 +1,87 @@
+package difficulty
+
+// StrainConstants Constants used by the difficulty processor to weigh the strain of each finger action
+type StrainConstants struct {
+	SJackLowerBoundaryMs  float32
+	SJackUpperBoundaryMs  float32
+	SJackMaxStrainValue   float32
+	SJackCurveExponential float32
+
+	TJackLowerBoundaryMs  float32
+	TJackUpperBoundaryMs  float32
+	TJackMaxStrainValue   float32
+	TJackCurveExponential float32
+
+	RollLowerBoundaryMs  float32
+	RollUpperBoundaryMs  float32
+	RollMaxStrainValue   float32
+	RollCurveExponential float32
+
+	BracketLowerBoundaryMs  float32
+	BracketUpperBoundaryMs  float32
+	BracketMaxStrainValue   float32
+	BracketCurveExponential float32
+
+	RollRatioToleranceMs float32
+	RollRatioMultiplier  float32
+	RollLengthMultiplier float32
+	RollMaxLength        float32
+
+	VibroActionDurationMs  float32
+	VibroActionToleranceMs float32
+	VibroMultiplier        float32
+	VibroLengthMultiplier  float32
+	VibroMaxLength         float32
+
+	LnBaseMultiplier          float32
+	LnLayerToleranceMs        float32
+	LnLayerThresholdMs        float32
+	LnReleaseAfterMultiplier  float32
+	LnReleaseBeforeMultiplier float32
+	LnTapMultiplier           float32
+	LnEndThresholdMs          float32
+}
+
+// DefaultStrainConstants Returns the strain constants that are used for ranked difficulty ratings
+func DefaultStrainConstants() StrainConstants {
+	return StrainConstants{
+		SJackLowerBoundaryMs:  40,
+		SJackUpperBoundaryMs:  320,
+		SJackMaxStrainValue:   68,
+		SJackCurveExponential: 1.17,
+
+		TJackLowerBoundaryMs:  40,
+		TJackUpperBoundaryMs:  330,
+		TJackMaxStrainValue:   70,
+		TJackCurveExponential: 1.14,
+
+		RollLowerBoundaryMs:  30,
+		RollUpperBoundaryMs:  230,
+		RollMaxStrainValue:   55,
+		RollCurveExponential: 1.13,
+
+		BracketLowerBoundaryMs:  30,
+		BracketUpperBoundaryMs:  230,
+		BracketMaxStrainValue:   56,
+		BracketCurveExponential: 1.13,
+
+		RollRatioToleranceMs: 2,
+		RollRatioMultiplier:  0.25,
+		RollLengthMultiplier: 0.6,
+		RollMaxLength:        14,
+
+		VibroActionDurationMs:  88.2,
+		VibroActionToleranceMs: 22,
+		VibroMultiplier:        0.75,
+		VibroLengthMultiplier:  0.3,
+		VibroMaxLength:         6,
+
+		LnBaseMultiplier:          0.6,
+		LnLayerToleranceMs:        60,
+		LnLayerThresholdMs:        93.7,
+		LnReleaseAfterMultiplier:  1.0,
+		LnReleaseBeforeMultiplier: 1.3,
+		LnTapMultiplier:           1.05,
+		LnEndThresholdMs:          42,
+	}
+}
@@ -0,0 +1,459 @@
+package difficulty
+
+import (
+	"fmt"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"math"
+	"os"
+	"slices"
+	"sort"
+)
+
+// Version The version of the difficulty processor. This must be bumped whenever the algorithm changes.
+const Version string = "0.0.5"
+
+const (
+	chordClumpToleranceMs float32 = 8
+	binSizeMs             float32 = 1000
+)
+
+type Result struct {
+	OverallDifficulty float64 `json:"OverallDifficulty"`
+	Version           string  `json:"Version"`
+}
+
+type processor struct {
+	Map             *qua.Qua
+	Constants       StrainConstants
+	StrainSolveData []*strainSolverData
+}
+
+// Calculate Calculates the difficulty rating of a map with a given combination of mods
+func Calculate(q *qua.Qua, mods enums.Mods) *Result {
+	return CalculateWithConstants(q, mods, DefaultStrainConstants())
+}
+
+// CalculateWithConstants Calculates the difficulty rating of a map using custom strain constants
+func CalculateWithConstants(q *qua.Qua, mods enums.Mods, constants StrainConstants) *Result {
+	result := &Result{Version: Version}
+
+	if len(q.HitObjects) < 2 {
+		return result
+	}
+
+	p := &processor{
+		Map:       applyMods(q, mods),
+		Constants: constants,
+	}
+
+	rate := enums.GetRateFromMods(mods)
+
+	switch q.Mode {
+	case enums.GameModeKeys4:
+		result.OverallDifficulty = float64(p.computeForOverallDifficulty(rate, handRight))
+	case enums.GameModeKeys7:
+		left := p.computeForOverallDifficulty(rate, handLeft)
+		right := p.computeForOverallDifficulty(rate, handRight)
+		result.OverallDifficulty = float64((left + right) / 2)
+	}
+
+	return result
+}
+
+// CalculateFromFile Parses a .qua file on disk and calculates its difficulty rating
+func CalculateFromFile(path string, mods enums.Mods) (*Result, error) {
+	file, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := qua.Parse(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %v: %v", path, err)
+	}
+
+	return Calculate(q, mods), nil
+}
+
+// Returns a copy of the map with the gameplay-affecting mods applied to its hit objects
+func applyMods(q *qua.Qua, mods enums.Mods) *qua.Qua {
+	copied := *q
+	copied.HitObjects = slices.Clone(q.HitObjects)
+
+	keyCount := q.KeyCount(false)
+
+	for i := range copied.HitObjects {
+		hitObject := &copied.HitObjects[i]
+
+		if enums.IsModActivated(mods, enums.ModNoLongNotes) {
+			hitObject.EndTime = 0
+		}
+
+		if enums.IsModActivated(mods, enums.ModMirror) && hitObject.Lane <= keyCount {
+			hitObject.Lane = keyCount - hitObject.Lane + 1
+		}
+	}
+
+	sort.SliceStable(copied.HitObjects, func(i, j int) bool {
+		return copied.HitObjects[i].StartTime < copied.HitObjects[j].StartTime
+	})
+
+	return &copied
+}
+
+// Runs every step of the difficulty calculation for one assumption of the ambiguous (7K thumb) hand
+func (p *processor) computeForOverallDifficulty(rate float32, assumeHand hand) float32 {
+	p.computeBaseStrainStates(rate, assumeHand)
+	p.computeForChords()
+	p.computeForFingerActions()
+	p.computeForRollManipulation()
+	p.computeForJackManipulation()
+	p.computeForLnMultiplier()
+
+	return p.calculateOverallDifficulty()
+}
+
+// Creates a strain data point for every hit object in the map and assigns its finger & hand
+func (p *processor) computeBaseStrainStates(rate float32, assumeHand hand) {
+	p.StrainSolveData = []*strainSolverData{}
+
+	scratchLane := p.Map.KeyCount(true)
+
+	for _, hitObject := range p.Map.HitObjects {
+		if p.Map.HasScratchKey && hitObject.Lane == scratchLane {
+			continue
+		}
+
+		solverHitObject := &strainSolverHitObject{HitObject: hitObject, LnStrainMultiplier: 1}
+		data := newStrainSolverData(solverHitObject, rate)
+
+		switch p.Map.Mode {
+		case enums.GameModeKeys4:
+			solverHitObject.FingerState = laneToFinger4K[hitObject.Lane]
+			data.Hand = laneToHand4K[hitObject.Lane]
+		case enums.GameModeKeys7:
+			solverHitObject.FingerState = laneToFinger7K[hitObject.Lane]
+			data.Hand = laneToHand7K[hitObject.Lane]
+
+			if data.Hand == handAmbiguous {
+				data.Hand = assumeHand
+			}
+		}
+
+		p.StrainSolveData = append(p.StrainSolveData, data)
+	}
+}
+
+// Merges hit objects that are pressed on the same hand at (roughly) the same time into one data point
+func (p *processor) computeForChords() {
+	for i := 0; i < len(p.StrainSolveData)-1; i++ {
+		for j := i + 1; j < len(p.StrainSolveData); j++ {
+			current := p.StrainSolveData[i]
+			next := p.StrainSolveData[j]
+
+			msDiff := next.StartTime - current.StartTime
+
+			if msDiff > chordClumpToleranceMs {
+				break
+			}
+
+			if float32(math.Abs(float64(msDiff))) > chordClumpToleranceMs || current.Hand != next.Hand {
+				continue
+			}
+
+			for _, nextHitObject := range next.HitObjects {
+				sameStateFound := slices.ContainsFunc(current.HitObjects, func(h *strainSolverHitObject) bool {
+					return h.FingerState == nextHitObject.FingerState
+				})
+
+				if !sameStateFound {
+					current.HitObjects = append(current.HitObjects, nextHitObject)
+				}
+			}
+
+			// The reference processor doesn't step back after removing, so the following point is skipped.
+			p.StrainSolveData = slices.Delete(p.StrainSolveData, j, j+1)
+		}
+	}
+
+	for _, data := range p.StrainSolveData {
+		data.solveFingerState()
+	}
+}
+
+// Determines the finger action between each data point and the next one on the same hand
+func (p *processor) computeForFingerActions() {
+	for i := 0; i < len(p.StrainSolveData)-1; i++ {
+		current := p.StrainSolveData[i]
+
+		for j := i + 1; j < len(p.StrainSolveData); j++ {
+			next := p.StrainSolveData[j]
+
+			if current.Hand != next.Hand || next.StartTime <= current.StartTime {
+				continue
+			}
+
+			actionJackFound := int(next.FingerState)&(1<<(int(current.FingerState)-1)) != 0
+			actionChordFound := current.handChord() || next.handChord()
+			actionSameState := current.FingerState == next.FingerState
+			actionDuration := next.StartTime - current.StartTime
+
+			current.NextOnCurrentHand = next
+			current.FingerActionDurationMs = actionDuration
+
+			c := p.Constants
+
+			switch {
+			case !actionChordFound && !actionSameState:
+				current.FingerAction = fingerActionRoll
+				current.ActionStrainCoefficient = getCoefficientValue(actionDuration, c.RollLowerBoundaryMs,
+					c.RollUpperBoundaryMs, c.RollMaxStrainValue, c.RollCurveExponential)
+			case actionSameState:
+				current.FingerAction = fingerActionSimpleJack
+				current.ActionStrainCoefficient = getCoefficientValue(actionDuration, c.SJackLowerBoundaryMs,
+					c.SJackUpperBoundaryMs, c.SJackMaxStrainValue, c.SJackCurveExponential)
+			case actionJackFound:
+				current.FingerAction = fingerActionTechnicalJack
+				current.ActionStrainCoefficient = getCoefficientValue(actionDuration, c.TJackLowerBoundaryMs,
+					c.TJackUpperBoundaryMs, c.TJackMaxStrainValue, c.TJackCurveExponential)
+			default:
+				current.FingerAction = fingerActionBracket
+				current.ActionStrainCoefficient = getCoefficientValue(actionDuration, c.BracketLowerBoundaryMs,
+					c.BracketUpperBoundaryMs, c.BracketMaxStrainValue, c.BracketCurveExponential)
+			}
+
+			break
+		}
+	}
+}
+
+// Reduces the strain of rolls/trills that can be manipulated by playing them as jumps
+func (p *processor) computeForRollManipulation() {
+	c := p.Constants
+	manipulationIndex := 0
+
+	for _, data := range p.StrainSolveData {
+		manipulationFound := false
+
+		if data.NextOnCurrentHand != nil && data.NextOnCurrentHand.NextOnCurrentHand != nil {
+			middle := data.NextOnCurrentHand
+			last := middle.NextOnCurrentHand
+
+			if data.FingerAction == fingerActionRoll && middle.FingerAction == fingerActionRoll &&
+				data.FingerState == last.FingerState {
+				durationRatio := max(data.FingerActionDurationMs/middle.FingerActionDurationMs,
+					middle.FingerActionDurationMs/data.FingerActionDurationMs)
+
+				if durationRatio >= c.RollRatioToleranceMs {
+					durationMultiplier := 1 / (1 + ((durationRatio - 1) * c.RollRatioMultiplier))
+					manipulationFoundRatio := 1 - ((float32(manipulationIndex) / c.RollMaxLength) * (1 - c.RollLengthMultiplier))
+					data.RollManipulationStrainMultiplier = durationMultiplier * manipulationFoundRatio
+
+					manipulationFound = true
+
+					if float32(manipulationIndex) < c.RollMaxLength {
+						manipulationIndex++
+					}
+				}
+			}
+		}
+
+		if !manipulationFound && manipulationIndex > 0 {
+			manipulationIndex--
+		}
+	}
+}
+
+// Reduces the strain of long jacks that can be vibro'd
+func (p *processor) computeForJackManipulation() {
+	c := p.Constants
+	longJackSize := 0
+
+	for _, data := range p.StrainSolveData {
+		manipulationFound := false
+		next := data.NextOnCurrentHand
+
+		if next != nil && data.FingerAction == fingerActionSimpleJack && next.FingerAction == fingerActionSimpleJack {
+			durationValue := min(1, max(0, ((c.VibroActionDurationMs+c.VibroActionToleranceMs)-data.FingerActionDurationMs)/c.VibroActionToleranceMs))
+			durationMultiplier := 1 - (durationValue * (1 - c.VibroMultiplier))
+			manipulationFoundRatio := 1 - ((float32(longJackSize) / c.VibroMaxLength) * (1 - c.VibroLengthMultiplier))
+
+			// The reference processor applies the vibro multiplier to the roll manipulation multiplier.
+			data.RollManipulationStrainMultiplier = durationMultiplier * manipulationFoundRatio
+
+			manipulationFound = true
+
+			if float32(longJackSize) < c.VibroMaxLength {
+				longJackSize++
+			}
+		}
+
+		if !manipulationFound {
+			longJackSize = 0
+		}
+	}
+}
+
+// Applies the long note multipliers, including layering with the next object on the same hand
+func (p *processor) computeForLnMultiplier() {
+	c := p.Constants
+
+	for _, data := range p.StrainSolveData {
+		if data.EndTime <= data.StartTime {
+			continue
+		}
+
+		durationValue := 1 - min(1, max(0, ((c.LnLayerThresholdMs+c.LnLayerToleranceMs)-(data.EndTime-data.StartTime))/c.LnLayerToleranceMs))
+		baseMultiplier := 1 + durationValue*c.LnBaseMultiplier
+
+		for _, hitObject := range data.HitObjects {
+			hitObject.LnStrainMultiplier = baseMultiplier
+		}
+
+		next := data.NextOnCurrentHand
+
+		if next == nil || next.StartTime >= data.EndTime-c.LnEndThresholdMs ||
+			next.StartTime < data.StartTime+c.LnEndThresholdMs {
+			continue
+		}
+
+		layerType := lnLayerInsideTap
+		multiplier := c.LnTapMultiplier
+
+		if next.EndTime > data.EndTime+c.LnEndThresholdMs {
+			layerType = lnLayerOutsideRelease
+			multiplier = c.LnReleaseAfterMultiplier
+		} else if next.EndTime > 0 {
+			layerType = lnLayerInsideRelease
+			multiplier = c.LnReleaseBeforeMultiplier
+		}
+
+		for _, hitObject := range data.HitObjects {
+			hitObject.LnLayerType = layerType
+			hitObject.LnStrainMultiplier *= multiplier
+		}
+	}
+}
+
+// Averages the strain of the hardest sections of the map and adjusts it for continuity & length
+func (p *processor) calculateOverallDifficulty() float32 {
+	if len(p.StrainSolveData) == 0 {
+		return 0
+	}
+
+	for _, data := range p.StrainSolveData {
+		data.calculateStrainValue()
+	}
+
+	mapStart := p.StrainSolveData[0].StartTime
+	mapEnd := float32(0)
+
+	for _, data := range p.StrainSolveData {
+		mapStart = min(mapStart, data.StartTime)
+		mapEnd = max(mapEnd, data.StartTime, data.EndTime)
+	}
+
+	var bins []float32
+
+	for i := mapStart; i < mapEnd; i += binSizeMs {
+		var total float32
+		var count int
+
+		for _, data := range p.StrainSolveData {
+			if data.StartTime >= i && data.StartTime < i+binSizeMs {
+				total += data.TotalStrainValue
+				count++
+			}
+		}
+
+		if count == 0 {
+			bins = append(bins, 0)
+		} else {
+			bins = append(bins, total/float32(count))
+		}
+	}
+
+	if !slices.ContainsFunc(bins, func(strain float32) bool { return strain > 0 }) {
+		return 0
+	}
+
+	// Average of the hardest 40% of the map
+	sorted := slices.Clone(bins)
+	slices.SortFunc(sorted, func(a, b float32) int {
+		switch {
+		case a > b:
+			return -1
+		case a < b:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	cutoffPos := int(math.Floor(float64(len(sorted)) * 0.4))
+	var easyRatingCutoff float32
+
+	if cutoffPos > 0 {
+		var total float32
+
+		for _, strain := range sorted[:cutoffPos] {
+			total += strain
+		}
+
+		easyRatingCutoff = total / float32(cutoffPos)
+	}
+
+	var continuityTotal float64
+	var continuityCount int
+
+	for _, strain := range bins {
+		if strain <= 0 {
+			continue
+		}
+
+		continuityTotal += math.Sqrt(float64(strain / easyRatingCutoff))
+		continuityCount++
+	}
+
+	continuity := continuityTotal / float64(continuityCount)
+
+	const (
+		maxContinuity = 1.00
+		avgContinuity = 0.85
+		minContinuity = 0.60
+
+		maxAdjustment = 1.05
+		avgAdjustment = 1.00
+		minAdjustment = 0.90
+	)
+
+	var continuityAdjustment float64
+
+	if continuity > avgContinuity {
+		continuityFactor := 1 - (continuity-avgContinuity)/(maxContinuity-avgContinuity)
+		continuityAdjustment = math.Min(avgAdjustment, math.Max(minAdjustment, continuityFactor*(avgAdjustment-minAdjustment)+minAdjustment))
+	} else {
+		continuityFactor := 1 - (continuity-minContinuity)/(avgContinuity-minContinuity)
+		continuityAdjustment = math.Min(maxAdjustment, math.Max(avgAdjustment, continuityFactor*(maxAdjustment-avgAdjustment)+avgAdjustment))
+	}
+
+	calculatedDiff := easyRatingCutoff * float32(continuityAdjustment)
+
+	trueDrainTime := float64(len(bins)) * continuity * float64(binSizeMs)
+	shortMapAdjustment := math.Max(0.75, math.Min(1, 0.25*math.Sqrt(trueDrainTime/1000)+0.25))
+
+	return calculatedDiff * float32(shortMapAdjustment)
+}
+
+// Returns the strain coefficient of a finger action depending on how long it is
+func getCoefficientValue(duration float32, xMin float32, xMax float32, strainMax float32, exp float32) float32 {
+	const lowestDifficulty float32 = 1
+
+	ratio := max(0, (duration-xMin)/(xMax-xMin))
+	ratio = 1 - min(1, ratio)
+
+	return lowestDifficulty + (strainMax-lowestDifficulty)*float32(math.Pow(float64(ratio), float64(exp)))
+}
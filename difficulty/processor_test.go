@@ -0,0 +1,161 @@
+package difficulty
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Quaver/api2/config"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/tools"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// Golden values are the output of `Quaver.Tools -calcdiff <file> <mods>` for each file in testdata,
+// so the calculator is checked against the C# implementation rather than against itself.
+// Running `go test ./difficulty -update -quaver-tools <path>` regenerates them with Quaver.Tools.
+var (
+	update      = flag.Bool("update", false, "update the golden difficulty file with Quaver.Tools")
+	quaverTools = flag.String("quaver-tools", "", "the path to the Quaver.Tools executable used by -update")
+)
+
+const goldenPath = "testdata/golden.json"
+
+// The golden difficulties of each file in testdata, for each combination of mods
+type goldenFile map[string]map[string]*Result
+
+// Returns every combination of mods the difficulty is checked against
+func goldenMods() []enums.Mods {
+	mods := []enums.Mods{0, enums.ModNoLongNotes, enums.ModMirror}
+
+	for _, rate := range enums.ModRates {
+		mods = append(mods, rate.Mod)
+	}
+
+	return mods
+}
+
+// Regenerates the golden file from the output of Quaver.Tools
+func updateGoldenFile(t *testing.T, paths []string) {
+	if *quaverTools == "" {
+		t.Fatal("-update requires the path to Quaver.Tools with -quaver-tools")
+	}
+
+	config.Instance = &config.Config{QuaverToolsPath: *quaverTools}
+	golden := goldenFile{}
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		golden[name] = map[string]*Result{}
+
+		for _, mods := range goldenMods() {
+			calc, err := tools.RunDifficultyCalculator(path, int64(mods))
+
+			if err != nil {
+				t.Fatalf("%v (mods %v): %v", name, mods, err)
+			}
+
+			golden[name][fmt.Sprintf("%v", int64(mods))] = &Result{
+				OverallDifficulty: calc.Difficulty.OverallDifficulty,
+				Version:           calc.Difficulty.Version,
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(golden, "", "  ")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(goldenPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCalculateGolden(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.qua")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) == 0 {
+		t.Fatal("no .qua files in testdata")
+	}
+
+	if *update {
+		updateGoldenFile(t, paths)
+		return
+	}
+
+	data, err := os.ReadFile(goldenPath)
+
+	if os.IsNotExist(err) {
+		t.Fatal("no golden file, generate it with `go test ./difficulty -update -quaver-tools <path>`")
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var golden goldenFile
+
+	if err := json.Unmarshal(data, &golden); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+
+		if _, ok := golden[name]; !ok {
+			t.Errorf("%v: missing from the golden file", name)
+		}
+	}
+
+	for name, modResults := range golden {
+		for mods, expected := range modResults {
+			modCombo, err := strconv.ParseInt(mods, 10, 64)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := CalculateFromFile(filepath.Join("testdata", name), enums.Mods(modCombo))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual.Version != expected.Version {
+				t.Errorf("%v (mods %v): expected version %v, got %v", name, mods, expected.Version, actual.Version)
+			}
+
+			if math.Abs(actual.OverallDifficulty-expected.OverallDifficulty) > 0.0001 {
+				t.Errorf("%v (mods %v): expected difficulty %v, got %v", name, mods,
+					expected.OverallDifficulty, actual.OverallDifficulty)
+			}
+		}
+	}
+}
+
+func TestCalculateRateIncreasesDifficulty(t *testing.T) {
+	result, err := CalculateFromFile("testdata/keys4_stream.qua", 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	faster, err := CalculateFromFile("testdata/keys4_stream.qua", enums.ModSpeed15X)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if faster.OverallDifficulty <= result.OverallDifficulty {
+		t.Fatalf("expected 1.5x (%v) to be harder than 1.0x (%v)", faster.OverallDifficulty, result.OverallDifficulty)
+	}
+}
@@ -0,0 +1,137 @@
+package difficulty
+
+import "github.com/Quaver/api2/qua"
+
+type fingerState int
+
+const (
+	fingerNone   fingerState = 0
+	fingerIndex  fingerState = 1 << 0
+	fingerMiddle fingerState = 1 << 1
+	fingerRing   fingerState = 1 << 2
+	fingerPinkie fingerState = 1 << 3
+	fingerThumb  fingerState = 1 << 4
+)
+
+type hand int
+
+const (
+	handLeft hand = iota
+	handRight
+	handAmbiguous
+)
+
+type fingerAction int
+
+const (
+	fingerActionNone fingerAction = iota
+	fingerActionSimpleJack
+	fingerActionTechnicalJack
+	fingerActionRoll
+	fingerActionBracket
+)
+
+type lnLayerType int
+
+const (
+	lnLayerNone lnLayerType = iota
+	lnLayerInsideRelease
+	lnLayerOutsideRelease
+	lnLayerInsideTap
+)
+
+var laneToFinger4K = map[int]fingerState{
+	1: fingerMiddle,
+	2: fingerIndex,
+	3: fingerIndex,
+	4: fingerMiddle,
+}
+
+var laneToFinger7K = map[int]fingerState{
+	1: fingerRing,
+	2: fingerMiddle,
+	3: fingerIndex,
+	4: fingerThumb,
+	5: fingerIndex,
+	6: fingerMiddle,
+	7: fingerRing,
+}
+
+var laneToHand4K = map[int]hand{
+	1: handLeft,
+	2: handLeft,
+	3: handRight,
+	4: handRight,
+}
+
+var laneToHand7K = map[int]hand{
+	1: handLeft,
+	2: handLeft,
+	3: handLeft,
+	4: handAmbiguous,
+	5: handRight,
+	6: handRight,
+	7: handRight,
+}
+
+// A single hit object and the strain applied to it
+type strainSolverHitObject struct {
+	HitObject          qua.HitObject
+	FingerState        fingerState
+	LnLayerType        lnLayerType
+	LnStrainMultiplier float32
+	StrainValue        float32
+}
+
+// A group of hit objects (single note or hand chord) that are pressed at the same time by one hand
+type strainSolverData struct {
+	HitObjects                       []*strainSolverHitObject
+	StartTime                        float32
+	EndTime                          float32
+	Hand                             hand
+	FingerState                      fingerState
+	FingerAction                     fingerAction
+	FingerActionDurationMs           float32
+	NextOnCurrentHand                *strainSolverData
+	ActionStrainCoefficient          float32
+	PatternStrainMultiplier          float32
+	RollManipulationStrainMultiplier float32
+	JackManipulationStrainMultiplier float32
+	TotalStrainValue                 float32
+}
+
+func newStrainSolverData(hitObject *strainSolverHitObject, rate float32) *strainSolverData {
+	return &strainSolverData{
+		HitObjects:                       []*strainSolverHitObject{hitObject},
+		StartTime:                        float32(hitObject.HitObject.StartTime) / rate,
+		EndTime:                          float32(hitObject.HitObject.EndTime) / rate,
+		ActionStrainCoefficient:          1,
+		PatternStrainMultiplier:          1,
+		RollManipulationStrainMultiplier: 1,
+		JackManipulationStrainMultiplier: 1,
+	}
+}
+
+// Returns if the data point is a chord on a single hand
+func (d *strainSolverData) handChord() bool {
+	return len(d.HitObjects) > 1
+}
+
+// Combines the finger states of all hit objects in the data point
+func (d *strainSolverData) solveFingerState() {
+	for _, hitObject := range d.HitObjects {
+		d.FingerState |= hitObject.FingerState
+	}
+}
+
+// Calculates the average strain value of the hit objects in the data point
+func (d *strainSolverData) calculateStrainValue() {
+	for _, hitObject := range d.HitObjects {
+		hitObject.StrainValue = d.ActionStrainCoefficient * d.PatternStrainMultiplier *
+			d.RollManipulationStrainMultiplier * d.JackManipulationStrainMultiplier * hitObject.LnStrainMultiplier
+
+		d.TotalStrainValue += hitObject.StrainValue
+	}
+
+	d.TotalStrainValue /= float32(len(d.HitObjects))
+}
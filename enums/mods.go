@@ -141,6 +141,47 @@ var ModStrings = map[Mods]string{
 	ModEnumMaxValue:       "INVALID!",
 }
 
+// ModRate A speed modifier and the audio rate it plays the map at
+type ModRate struct {
+	Mod  Mods
+	Rate float32
+}
+
+// ModRates Every speed modifier, from slowest to fastest. This is a slice rather than a map,
+// so mods are always checked in the same order.
+var ModRates = []ModRate{
+	{ModSpeed05X, 0.5},
+	{ModSpeed055X, 0.55},
+	{ModSpeed06X, 0.6},
+	{ModSpeed065X, 0.65},
+	{ModSpeed07X, 0.7},
+	{ModSpeed075X, 0.75},
+	{ModSpeed08X, 0.8},
+	{ModSpeed085X, 0.85},
+	{ModSpeed09X, 0.9},
+	{ModSpeed095X, 0.95},
+	{ModSpeed105X, 1.05},
+	{ModSpeed11X, 1.1},
+	{ModSpeed115X, 1.15},
+	{ModSpeed12X, 1.2},
+	{ModSpeed125X, 1.25},
+	{ModSpeed13X, 1.3},
+	{ModSpeed135X, 1.35},
+	{ModSpeed14X, 1.4},
+	{ModSpeed145X, 1.45},
+	{ModSpeed15X, 1.5},
+	{ModSpeed155X, 1.55},
+	{ModSpeed16X, 1.6},
+	{ModSpeed165X, 1.65},
+	{ModSpeed17X, 1.7},
+	{ModSpeed175X, 1.75},
+	{ModSpeed18X, 1.8},
+	{ModSpeed185X, 1.85},
+	{ModSpeed19X, 1.9},
+	{ModSpeed195X, 1.95},
+	{ModSpeed20X, 2.0},
+}
+
 // IsModActivated Returns if a given mod is activated in a mod combo
 func IsModActivated(modCombo Mods, mod Mods) bool {
 	return modCombo&mod != 0
@@ -281,3 +322,14 @@ func isSpeedModifier(mod Mods) bool {
 func isLongNoteModifier(mod Mods) bool {
 	return mod == ModFullLN || mod == ModInverse || mod == ModNoLongNotes
 }
+
+// GetRateFromMods Returns the audio rate of a combination of mods
+func GetRateFromMods(modCombo Mods) float32 {
+	for _, rate := range ModRates {
+		if IsModActivated(modCombo, rate.Mod) {
+			return rate.Rate
+		}
+	}
+
+	return 1.0
+}
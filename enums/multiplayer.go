@@ -0,0 +1,16 @@
+package enums
+
+type MultiplayerRuleset int8
+
+const (
+	MultiplayerRulesetFreeForAll MultiplayerRuleset = iota
+	MultiplayerRulesetTeam
+	MultiplayerRulesetBattleRoyale
+)
+
+type MultiplayerTeam int8
+
+const (
+	MultiplayerTeamRed MultiplayerTeam = iota
+	MultiplayerTeamBlue
+)
@@ -24,6 +24,7 @@ const (
 	PrivilegeEditAvatar
 	PrivilegeViewCrashes
 	PrivilegeEditDonate
+	PrivilegeManageTournaments
 )
 
 // HasPrivilege Returns if a combination of user groups contains a single group
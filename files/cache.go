@@ -21,6 +21,7 @@ func CreateDirectories() {
 	directories := []string{
 		config.Instance.Cache.DataDirectory,
 		getMapsDirectory(),
+		getMapRevisionsDirectory(),
 		getMapsetDirectory(),
 		getReplayDirectory(),
 		GetBackupsDirectory(),
@@ -83,6 +84,23 @@ func CacheQuaFile(mapQua *db.MapQua) (string, error) {
 	return path, nil
 }
 
+// CacheMapRevision Caches a stored revision of a .qua file. Returns the path of the file
+func CacheMapRevision(revision *db.MapRevision) (string, error) {
+	fileName := fmt.Sprintf("%v.qua", revision.Id)
+	path, _ := filepath.Abs(fmt.Sprintf("%v/%v", getMapRevisionsDirectory(), fileName))
+
+	// Revisions never change, so an existing file can always be used.
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if _, err := azure.Client.DownloadFile("map-revisions", fileName, path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
 // CacheMapset Caches a mapset file and returns the path to it
 func CacheMapset(mapset *db.Mapset) (string, error) {
 	fileName := fmt.Sprintf("%v.qp", mapset.Id)
@@ -129,6 +147,10 @@ func getMapsDirectory() string {
 	return fmt.Sprintf("%v/maps", config.Instance.Cache.DataDirectory)
 }
 
+func getMapRevisionsDirectory() string {
+	return fmt.Sprintf("%v/maps/revisions", config.Instance.Cache.DataDirectory)
+}
+
 func getMapsetDirectory() string {
 	return fmt.Sprintf("%v/mapsets", config.Instance.Cache.DataDirectory)
 }
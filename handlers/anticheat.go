@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// GetScoreReviews Returns the scores flagged by the anti-cheat with a given status
+// Endpoint: GET /v2/anticheat/reviews?status=&page=
+func GetScoreReviews(c *gin.Context) *APIError {
+	if !canUserAccessAdminRoute(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	status := db.ScoreReviewStatus(c.Query("status"))
+
+	if status == "" {
+		status = db.ScoreReviewPending
+	}
+
+	if !isValidScoreReviewStatus(status) {
+		return APIErrorBadRequest("Invalid status")
+	}
+
+	page, err := strconv.Atoi(c.Query("page"))
+
+	if err != nil {
+		page = 0
+	}
+
+	reviews, err := db.GetScoreReviews(status, 50, page)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving score reviews from db", err)
+	}
+
+	count, err := db.GetScoreReviewCount(status)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving score review count from db", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_count": count,
+		"reviews":     reviews,
+	})
+
+	return nil
+}
+
+// UpdateScoreReviewStatus Clears or confirms a score flagged by the anti-cheat
+// Endpoint: POST /v2/anticheat/reviews/:id/status
+func UpdateScoreReviewStatus(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	if !canUserAccessAdminRoute(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid id")
+	}
+
+	body := struct {
+		Status db.ScoreReviewStatus `form:"status" json:"status" binding:"required"`
+	}{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	if !isValidScoreReviewStatus(body.Status) {
+		return APIErrorBadRequest("Invalid status")
+	}
+
+	review, err := db.GetScoreReviewById(id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving score review from db", err)
+	}
+
+	if review == nil {
+		return APIErrorNotFound("Score review")
+	}
+
+	if err := review.UpdateStatus(body.Status, user.Id); err != nil {
+		return APIErrorServerError("Error updating score review status", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The score review status has been successfully updated."})
+	return nil
+}
+
+func isValidScoreReviewStatus(status db.ScoreReviewStatus) bool {
+	return slices.Contains([]db.ScoreReviewStatus{db.ScoreReviewPending, db.ScoreReviewCleared, db.ScoreReviewConfirmed}, status)
+}
@@ -65,8 +65,9 @@ func CreateNewApplication(c *gin.Context) *APIError {
 	}
 
 	body := struct {
-		Name        string `form:"name" json:"name" binding:"required"`
-		RedirectURL string `form:"redirect_url" json:"redirect_url" binding:"required"`
+		Name         string `form:"name" json:"name" binding:"required"`
+		RedirectURL  string `form:"redirect_url" json:"redirect_url" binding:"required"`
+		PublicClient bool   `form:"public_client" json:"public_client"`
 	}{}
 
 	if err := c.ShouldBind(&body); err != nil {
@@ -111,6 +112,7 @@ func CreateNewApplication(c *gin.Context) *APIError {
 		ClientSecret: clientSecret,
 		Timestamp:    time.Now().UnixMilli(),
 		Active:       true,
+		PublicClient: body.PublicClient,
 	}
 
 	if err := db.SQL.Create(&newApp).Error; err != nil {
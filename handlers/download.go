@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"github.com/Quaver/api2/db"
 	"github.com/Quaver/api2/files"
-	"github.com/Quaver/api2/tools"
+	"github.com/Quaver/api2/replay"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -124,7 +124,7 @@ func DownloadReplay(c *gin.Context) *APIError {
 
 	tempPath := fmt.Sprintf("%v/replay-%v.qr", files.GetTempDirectory(), time.Now().UnixMilli())
 
-	if err := tools.BuildReplay(user, score, path, tempPath); err != nil {
+	if err := replay.BuildFullFile(user, score, path, tempPath); err != nil {
 		return APIErrorServerError("Error building replay", err)
 	}
 
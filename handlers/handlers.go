@@ -42,6 +42,11 @@ func getAuthedUser(c *gin.Context) *db.User {
 	return user.(*db.User)
 }
 
+// Gets the id of the session that the request's JWT belongs to, or 0 if it wasn't authenticated with a session
+func getAuthedSessionId(c *gin.Context) int {
+	return c.GetInt("session_id")
+}
+
 // Gets the ip address from the request
 func getIpFromRequest(c *gin.Context) string {
 	// Running under NGINX
@@ -102,6 +107,17 @@ func canUserAccessAdminRoute(c *gin.Context) bool {
 		enums.HasUserGroup(user.UserGroups, enums.UserGroupBot)
 }
 
+// Returns if a user can manage tournaments
+func canUserManageTournaments(c *gin.Context) bool {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return false
+	}
+
+	return enums.HasPrivilege(user.Privileges, enums.PrivilegeManageTournaments)
+}
+
 // Returns if a user can access private ranking supervisor endpoints
 func canUserAccessSupervisorRoute(c *gin.Context) bool {
 	user := getAuthedUser(c)
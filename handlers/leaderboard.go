@@ -145,3 +145,80 @@ func GetClanLeaderboard(c *gin.Context) *APIError {
 	})
 	return nil
 }
+
+// GetModLeaderboard Retrieves a global leaderboard that only counts scores played with a modifier
+// Endpoint: GET /v2/leaderboard/mods?name=&mode=&page=
+func GetModLeaderboard(c *gin.Context) *APIError {
+	mode, err := strconv.Atoi(c.Query("mode"))
+
+	if err != nil {
+		return APIErrorBadRequest("You must supply a valid `mode` query parameter.")
+	}
+
+	page, err := strconv.Atoi(c.Query("page"))
+
+	if err != nil {
+		page = 0
+	}
+
+	leaderboard := db.GetEnabledModLeaderboardByName(c.Query("name"))
+
+	if leaderboard == nil {
+		return APIErrorBadRequest("You must supply a valid `name` query parameter.")
+	}
+
+	users, err := leaderboard.GetUsers(enums.GameMode(mode), page, 50)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving users for mod leaderboard", err)
+	}
+
+	count, err := leaderboard.GetCount(enums.GameMode(mode))
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving mod leaderboard user count", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"leaderboard": leaderboard,
+		"total_users": count,
+		"users":       users,
+	})
+
+	return nil
+}
+
+// GetMultiplayerLeaderboard Retrieves the players with the highest multiplayer rating for a given game mode
+// Endpoint: GET /v2/leaderboard/multiplayer?mode=&page=
+func GetMultiplayerLeaderboard(c *gin.Context) *APIError {
+	mode, err := strconv.Atoi(c.Query("mode"))
+
+	if err != nil {
+		return APIErrorBadRequest("You must supply a valid `mode` query parameter.")
+	}
+
+	page, err := strconv.Atoi(c.Query("page"))
+
+	if err != nil {
+		page = 0
+	}
+
+	users, err := db.GetMultiplayerLeaderboard(enums.GameMode(mode), page, 50)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving users for multiplayer leaderboard", err)
+	}
+
+	count, err := db.GetMultiplayerLeaderboardCount(enums.GameMode(mode))
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving multiplayer leaderboard user count", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_users": count,
+		"users":       users,
+	})
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/files"
+	"github.com/Quaver/api2/qua"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetMapRevisions Returns every uploaded revision of a map
+// Endpoint: GET /v2/map/:id/revisions
+func GetMapRevisions(c *gin.Context) *APIError {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid id")
+	}
+
+	revisions, err := db.GetMapRevisions(id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving map revisions from db", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+	return nil
+}
+
+// GetMapDiff Compares two revisions of a map.
+// If the revisions aren't provided, the latest revision is compared to the one before it.
+// Endpoint: GET /v2/map/:id/diff?from=&to=
+func GetMapDiff(c *gin.Context) *APIError {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid id")
+	}
+
+	revisions, err := db.GetMapRevisions(id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving map revisions from db", err)
+	}
+
+	if len(revisions) < 2 && (c.Query("from") == "" || c.Query("to") == "") {
+		return APIErrorBadRequest("This map does not have a previous revision to compare to.")
+	}
+
+	from, apiErr := getMapRevisionFromQuery(c, id, "from", revisions, 1)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	to, apiErr := getMapRevisionFromQuery(c, id, "to", revisions, 0)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var diff *qua.Diff
+	key := fmt.Sprintf("quaver:map:%v:diff:%v:%v", id, from.Id, to.Id)
+
+	// Revisions never change, so the diff between them can be cached for a long time.
+	err = db.CacheJsonInRedis(key, &diff, time.Hour*24*7, false, func() error {
+		fromQua, err := parseMapRevision(from)
+
+		if err != nil {
+			return err
+		}
+
+		toQua, err := parseMapRevision(to)
+
+		if err != nil {
+			return err
+		}
+
+		diff = fromQua.Diff(toQua)
+		return nil
+	})
+
+	if err != nil {
+		return APIErrorServerError("Error creating map diff", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": from,
+		"to":   to,
+		"diff": diff,
+	})
+
+	return nil
+}
+
+// Gets a map revision by the id in a query parameter. If it isn't provided, the revision at the fallback index is used.
+func getMapRevisionFromQuery(c *gin.Context, mapId int, param string, revisions []*db.MapRevision,
+	fallbackIndex int) (*db.MapRevision, *APIError) {
+	if c.Query(param) == "" {
+		return revisions[fallbackIndex], nil
+	}
+
+	revisionId, err := strconv.Atoi(c.Query(param))
+
+	if err != nil {
+		return nil, APIErrorBadRequest(fmt.Sprintf("Invalid `%v` revision id", param))
+	}
+
+	revision, err := db.GetMapRevisionById(mapId, revisionId)
+
+	switch err {
+	case nil:
+		return revision, nil
+	case gorm.ErrRecordNotFound:
+		return nil, APIErrorNotFound("Map revision")
+	default:
+		return nil, APIErrorServerError("Error retrieving map revision from db", err)
+	}
+}
+
+// Downloads and parses the .qua file of a map revision
+func parseMapRevision(revision *db.MapRevision) (*qua.Qua, error) {
+	path, err := files.CacheMapRevision(revision)
+
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return qua.Parse(file)
+}
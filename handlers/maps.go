@@ -60,3 +60,33 @@ func GetMap(c *gin.Context) *APIError {
 	c.JSON(http.StatusOK, gin.H{"map": resp})
 	return nil
 }
+
+// GetMapStatistics Retrieves the aggregate statistics of every score set on a map
+// Endpoint: GET /v2/map/:id/statistics
+func GetMapStatistics(c *gin.Context) *APIError {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid id")
+	}
+
+	qua, err := db.GetMapById(id)
+
+	switch err {
+	case nil:
+		break
+	case gorm.ErrRecordNotFound:
+		return APIErrorNotFound("Map")
+	default:
+		return APIErrorServerError("Error retrieving map from database", err)
+	}
+
+	stats, err := db.GetMapStatistics(qua)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving map statistics", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statistics": stats})
+	return nil
+}
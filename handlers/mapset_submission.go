@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/Quaver/api2/azure"
 	"github.com/Quaver/api2/cache"
+	"github.com/Quaver/api2/converters"
 	"github.com/Quaver/api2/db"
 	"github.com/Quaver/api2/enums"
 	"github.com/Quaver/api2/files"
@@ -25,6 +26,7 @@ import (
 	_ "image/jpeg"
 	_ "image/png"
 	"io"
+	"maps"
 	"math"
 	"net/http"
 	"os"
@@ -37,7 +39,10 @@ import (
 )
 
 var (
-	acceptedFileExtensions = []string{".mp3", ".qua", ".ogg", ".png", ".jpg", ".jpeg", ".wav", ".lua"}
+	acceptedFileExtensions = []string{".mp3", ".qua", ".ogg", ".png", ".jpg", ".jpeg", ".wav", ".lua", ".osu", ".sm"}
+
+	// Map files from other games that are converted to .qua when they are uploaded
+	convertedFileExtensions = []string{".osu", ".sm"}
 
 	acceptedMimeTypes = []string{
 		"audio/mpeg", "audio/ogg", "audio/wav", "audio/wave", "audio/x-pn-wav",
@@ -72,7 +77,7 @@ func HandleMapsetSubmission(c *gin.Context) *APIError {
 		return apiErr
 	}
 
-	quaFiles, apiErr := readQuaFilesFromZip(zipReader)
+	quaFiles, apiErr := readQuaFilesFromZip(zipReader, user)
 
 	if apiErr != nil {
 		return apiErr
@@ -215,13 +220,13 @@ func validateMapsetZipFiles(zip *zip.Reader) *APIError {
 			return invalidErr
 		}
 
-		if extension == ".qua" {
+		if extension == ".qua" || slices.Contains(convertedFileExtensions, extension) {
 			hasAtleastOneQua = true
 		}
 	}
 
 	if !hasAtleastOneQua {
-		return APIErrorBadRequest("Your mapset archive must contain at least one .qua file.")
+		return APIErrorBadRequest("Your mapset archive must contain at least one .qua, .osu or .sm file.")
 	}
 
 	return nil
@@ -261,12 +266,14 @@ func validateMimetype(file *zip.File) error {
 	return nil
 }
 
-// Reads all .qua files from a mapset archive
-func readQuaFilesFromZip(archive *zip.Reader) (map[*zip.File]*qua.Qua, *APIError) {
+// Reads all .qua files from a mapset archive. .osu and .sm files are converted to .qua.
+func readQuaFilesFromZip(archive *zip.Reader, user *db.User) (map[*zip.File]*qua.Qua, *APIError) {
 	quaFiles := map[*zip.File]*qua.Qua{}
 
 	for _, file := range archive.File {
-		if strings.Contains(file.Name, __MACOSX) || strings.ToLower(path.Ext(file.Name)) != ".qua" {
+		extension := strings.ToLower(path.Ext(file.Name))
+
+		if strings.Contains(file.Name, __MACOSX) || (extension != ".qua" && !slices.Contains(convertedFileExtensions, extension)) {
 			continue
 		}
 
@@ -285,6 +292,17 @@ func readQuaFilesFromZip(archive *zip.Reader) (map[*zip.File]*qua.Qua, *APIError
 			return nil, APIErrorBadRequest(fmt.Sprintf("Error reading file: %v", file.Name))
 		}
 
+		if extension != ".qua" {
+			convertedFiles, apiErr := convertMapFile(file, fileBytes, user)
+
+			if apiErr != nil {
+				return nil, apiErr
+			}
+
+			maps.Copy(quaFiles, convertedFiles)
+			continue
+		}
+
 		quaFile, err := qua.Parse(fileBytes)
 
 		if err != nil {
@@ -298,6 +316,43 @@ func readQuaFilesFromZip(archive *zip.Reader) (map[*zip.File]*qua.Qua, *APIError
 	return quaFiles, nil
 }
 
+// Converts a .osu or .sm file into .qua files. The uploader is set as the creator of the converted maps.
+func convertMapFile(file *zip.File, fileBytes []byte, user *db.User) (map[*zip.File]*qua.Qua, *APIError) {
+	var convertedFiles []*qua.Qua
+	var err error
+
+	switch strings.ToLower(path.Ext(file.Name)) {
+	case ".osu":
+		var quaFile *qua.Qua
+		quaFile, err = converters.ConvertOsu(fileBytes)
+		convertedFiles = []*qua.Qua{quaFile}
+	case ".sm":
+		convertedFiles, err = converters.ConvertStepMania(fileBytes)
+	}
+
+	if err != nil {
+		return nil, APIErrorBadRequest(fmt.Sprintf("Error converting %v: %v", file.Name, err))
+	}
+
+	quaFiles := map[*zip.File]*qua.Qua{}
+
+	for i, quaFile := range convertedFiles {
+		quaFile.Creator = user.Username
+		quaFile.RawBytes = quaFile.Serialize()
+
+		// A single .sm file can contain multiple charts, so each one is given its own entry.
+		key := file
+
+		if i > 0 {
+			key = &zip.File{FileHeader: zip.FileHeader{Name: fmt.Sprintf("%v:%v", file.Name, i)}}
+		}
+
+		quaFiles[key] = quaFile
+	}
+
+	return quaFiles, nil
+}
+
 // Goes through a map of qua files and makes sure they are valid
 func validateQuaFiles(user *db.User, quaFiles map[*zip.File]*qua.Qua) *APIError {
 	for _, quaFile := range quaFiles {
@@ -485,6 +540,10 @@ func InsertOrUpdateMap(user *db.User, mapset *db.Mapset, quaFile *qua.Qua) (*db.
 		return nil, APIErrorServerError("Error uploading .qua file to azure", err)
 	}
 
+	if apiErr := storeMapRevision(songMap, quaFile); apiErr != nil {
+		return nil, apiErr
+	}
+
 	go func() {
 		calcMapDifficulty(songMap, filePath)
 
@@ -496,6 +555,42 @@ func InsertOrUpdateMap(user *db.User, mapset *db.Mapset, quaFile *qua.Qua) (*db.
 	return songMap, nil
 }
 
+// Stores the uploaded .qua file as a new revision of the map, so it can be compared to later versions
+func storeMapRevision(songMap *db.MapQua, quaFile *qua.Qua) *APIError {
+	revisions, err := db.GetMapRevisions(songMap.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving map revisions from db", err)
+	}
+
+	if len(revisions) > 0 && revisions[0].MD5 == songMap.MD5 {
+		return nil
+	}
+
+	revision := &db.MapRevision{
+		MapId:     songMap.Id,
+		MapsetId:  songMap.MapsetId,
+		MD5:       songMap.MD5,
+		Timestamp: time.Now().UnixMilli(),
+	}
+
+	if err := revision.Insert(); err != nil {
+		return APIErrorServerError("Error inserting map revision into db", err)
+	}
+
+	// The revision is stored under its id, so the row is removed if the file couldn't be uploaded.
+	// Otherwise it would be listed as a revision that can't be downloaded.
+	if err := azure.Client.UploadFile("map-revisions", fmt.Sprintf("%v.qua", revision.Id), quaFile.RawBytes); err != nil {
+		if err := revision.Delete(); err != nil {
+			logrus.Error("Error deleting map revision after failing to upload it: ", err)
+		}
+
+		return APIErrorServerError("Error uploading map revision to azure", err)
+	}
+
+	return nil
+}
+
 // Checks if a user is eligible to upload an existing mapset
 func checkUserUploadEligibility(user *db.User) *APIError {
 	mapsets, err := db.GetUserMonthlyUploadMapsets(user.Id)
@@ -583,7 +678,8 @@ func createMapsetArchive(zipReader *zip.Reader, quaFiles map[*zip.File]*qua.Qua)
 	for _, zipFile := range zipReader.File {
 		if strings.Contains(zipFile.Name, __MACOSX) ||
 			strings.Contains(strings.ToLower(zipFile.Name), "thumbs.db") ||
-			path.Ext(zipFile.Name) == ".qua" {
+			path.Ext(zipFile.Name) == ".qua" ||
+			slices.Contains(convertedFileExtensions, strings.ToLower(path.Ext(zipFile.Name))) {
 			continue
 		}
 
@@ -827,7 +923,7 @@ func createAudioPreviewFromFile(filePath string, outputPath string, previewTimeS
 }
 
 // Sets a mapset's ranking queue status to resolved. This is usually done when a user updates their map
-// while on hold.
+// while on hold. AutoMod runs again while the mapset is still waiting in the queue, as supervisors see its stored issues.
 func resolveMapsetInRankingQueue(user *db.User, mapset *db.Mapset) *APIError {
 	rankingQueueMapset, err := db.GetRankingQueueMapset(mapset.Id)
 
@@ -839,17 +935,24 @@ func resolveMapsetInRankingQueue(user *db.User, mapset *db.Mapset) *APIError {
 		return nil
 	}
 
-	if rankingQueueMapset.Status != db.RankingQueueOnHold {
+	switch rankingQueueMapset.Status {
+	case db.RankingQueuePending, db.RankingQueueOnHold, db.RankingQueueResolved:
+		break
+	default:
 		return nil
 	}
 
-	ok, err := downloadAndRunAutomod(mapset)
+	automodResult, err := downloadAndRunAutomod(mapset)
 
 	if err != nil {
 		return APIErrorServerError("Error running automod", err)
 	}
 
-	if !ok {
+	if apiErr := storeRankingQueueAutoMod(rankingQueueMapset, automodResult); apiErr != nil {
+		return apiErr
+	}
+
+	if rankingQueueMapset.Status != db.RankingQueueOnHold || automodResult.HasIssues {
 		return nil
 	}
 
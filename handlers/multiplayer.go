@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // GetRecentMultiplayerGames Retrieves the most recent multiplayer games from the database
@@ -59,3 +61,97 @@ func GetMultiplayerGame(c *gin.Context) *APIError {
 	c.JSON(http.StatusOK, gin.H{"game": game})
 	return nil
 }
+
+// GetUserMultiplayerRating Gets a user's multiplayer rating for a game mode, along with its history
+// Endpoint: GET /v2/user/:id/multiplayer/:mode/rating?days=
+func GetUserMultiplayerRating(c *gin.Context) *APIError {
+	query, apiErr := parseUserScoreParams(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	rating, err := db.GetUserMultiplayerRating(query.Id, query.Mode)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving multiplayer rating", err)
+	}
+
+	since := time.Time{}
+
+	if days, err := strconv.Atoi(c.Query("days")); err == nil && days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+
+	history, err := db.GetUserMultiplayerRatingHistory(query.Id, query.Mode, since)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving multiplayer rating history", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rating":  rating,
+		"history": history,
+	})
+
+	return nil
+}
+
+// GetUserMultiplayerGames Gets the multiplayer games a user took part in, along with their aggregated stats
+// Endpoint: GET /v2/user/:id/multiplayer?ruleset=&mode=&start=&end=&page=
+func GetUserMultiplayerGames(c *gin.Context) *APIError {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid id")
+	}
+
+	user, apiErr := getUserById(id, canAuthedUserViewBannedUsers(c))
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	body := struct {
+		db.UserMultiplayerFilter
+		Page int `form:"page" json:"page"`
+	}{}
+
+	if err := c.ShouldBindQuery(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	if body.Ruleset != nil && (*body.Ruleset < enums.MultiplayerRulesetFreeForAll || *body.Ruleset > enums.MultiplayerRulesetBattleRoyale) {
+		return APIErrorBadRequest("Invalid ruleset")
+	}
+
+	if body.Start > 0 && body.End > 0 && body.End <= body.Start {
+		return APIErrorBadRequest("The end of the date range must be after its start.")
+	}
+
+	games, err := db.GetUserMultiplayerGames(user.Id, &body.UserMultiplayerFilter, 20, body.Page)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving user multiplayer games", err)
+	}
+
+	count, err := db.GetUserMultiplayerGameCount(user.Id, &body.UserMultiplayerFilter)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving user multiplayer game count", err)
+	}
+
+	stats, err := db.GetUserMultiplayerStats(user.Id, &body.UserMultiplayerFilter, 10)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving user multiplayer stats", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_game_count": count,
+		"games":            games,
+		"stats":            stats,
+	})
+
+	return nil
+}
@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/oauth"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type oauthAuthorizationBody struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientId            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	Approve             bool   `form:"approve" json:"approve"`
+}
+
+// The credentials of an application making a token request
+type oauthClientBody struct {
+	ClientId     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+}
+
+// A validated request from an application to access a user's account
+type oauthAuthorizationRequest struct {
+	Application   *db.Application
+	RedirectURL   string
+	Scopes        []db.OAuthScope
+	State         string
+	CodeChallenge string
+}
+
+// GetOAuthAuthorization Validates an authorization request, and returns the application & scopes the user is asked to consent to
+// Endpoint: GET /v2/oauth/authorize?response_type=code&client_id=&redirect_uri=&scope=&state=&code_challenge=&code_challenge_method=S256
+func GetOAuthAuthorization(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	body := oauthAuthorizationBody{}
+
+	if err := c.ShouldBindQuery(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	request, apiErr := parseOAuthAuthorizationRequest(&body)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	consent, err := db.GetOAuthConsent(user.Id, request.Application.Id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving oauth consent", err)
+	}
+
+	request.Application.ClientSecret = ""
+
+	c.JSON(http.StatusOK, gin.H{
+		"application": request.Application,
+		"scopes":      request.Scopes,
+		"consented":   consent != nil && consent.HasScopes(request.Scopes),
+	})
+
+	return nil
+}
+
+// AuthorizeOAuthApplication Approves or denies an authorization request, and returns the URL the user should be redirected to
+// Endpoint: POST /v2/oauth/authorize
+func AuthorizeOAuthApplication(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	body := oauthAuthorizationBody{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	request, apiErr := parseOAuthAuthorizationRequest(&body)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	params := url.Values{}
+
+	if body.Approve {
+		if _, err := db.SaveOAuthConsent(user.Id, request.Application.Id, request.Scopes); err != nil {
+			return APIErrorServerError("Error saving oauth consent", err)
+		}
+
+		code, err := oauth.CreateAuthorizationCode(request.Application, user.Id, request.RedirectURL, request.Scopes, request.CodeChallenge)
+
+		if err != nil {
+			return APIErrorServerError("Error creating oauth authorization code", err)
+		}
+
+		params.Set("code", code)
+	} else {
+		params.Set("error", "access_denied")
+	}
+
+	if request.State != "" {
+		params.Set("state", request.State)
+	}
+
+	redirect, _ := url.Parse(request.RedirectURL)
+	query := redirect.Query()
+
+	for key, values := range params {
+		query[key] = values
+	}
+
+	redirect.RawQuery = query.Encode()
+
+	c.JSON(http.StatusOK, gin.H{"redirect_url": redirect.String()})
+	return nil
+}
+
+// CreateOAuthToken Exchanges an authorization code or refresh token for an access token.
+// Errors are returned in the format described by RFC 6749, so OAuth client libraries can understand them.
+// Endpoint: POST /v2/oauth/token
+func CreateOAuthToken(c *gin.Context) *APIError {
+	body := struct {
+		oauthClientBody
+		GrantType    string `form:"grant_type" json:"grant_type"`
+		Code         string `form:"code" json:"code"`
+		RedirectURI  string `form:"redirect_uri" json:"redirect_uri"`
+		CodeVerifier string `form:"code_verifier" json:"code_verifier"`
+		RefreshToken string `form:"refresh_token" json:"refresh_token"`
+	}{}
+
+	c.Header("Cache-Control", "no-store")
+
+	if err := c.ShouldBind(&body); err != nil {
+		return oauthError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+	}
+
+	app, apiErr := authenticateOAuthClient(c, &body.oauthClientBody)
+
+	if app == nil {
+		return apiErr
+	}
+
+	var response *oauth.TokenResponse
+	var err error
+
+	switch body.GrantType {
+	case "authorization_code":
+		if body.Code == "" || body.RedirectURI == "" || body.CodeVerifier == "" {
+			return oauthError(c, http.StatusBadRequest, "invalid_request", "You must provide a `code`, `redirect_uri` and `code_verifier`.")
+		}
+
+		response, err = oauth.ExchangeAuthorizationCode(app, body.Code, body.RedirectURI, body.CodeVerifier)
+	case "refresh_token":
+		if body.RefreshToken == "" {
+			return oauthError(c, http.StatusBadRequest, "invalid_request", "You must provide a `refresh_token`.")
+		}
+
+		response, err = oauth.RefreshAccessToken(app, body.RefreshToken)
+	default:
+		return oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "The grant type must be `authorization_code` or `refresh_token`.")
+	}
+
+	switch err {
+	case nil:
+		break
+	case oauth.ErrInvalidGrant:
+		return oauthError(c, http.StatusBadRequest, "invalid_grant", err.Error())
+	default:
+		return APIErrorServerError("Error creating oauth token", err)
+	}
+
+	c.JSON(http.StatusOK, response)
+	return nil
+}
+
+// RevokeOAuthToken Revokes an access or refresh token that was issued to the application, as described by RFC 7009
+// Endpoint: POST /v2/oauth/revoke
+func RevokeOAuthToken(c *gin.Context) *APIError {
+	body := struct {
+		oauthClientBody
+		Token string `form:"token" json:"token"`
+	}{}
+
+	if err := c.ShouldBind(&body); err != nil || body.Token == "" {
+		return oauthError(c, http.StatusBadRequest, "invalid_request", "You must provide a `token`.")
+	}
+
+	app, apiErr := authenticateOAuthClient(c, &body.oauthClientBody)
+
+	if app == nil {
+		return apiErr
+	}
+
+	if err := oauth.RevokeToken(app, body.Token); err != nil {
+		return APIErrorServerError("Error revoking oauth token", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+	return nil
+}
+
+// GetOAuthUser Gets the user that an access token acts on behalf of
+// Endpoint: GET /v2/oauth/me
+func GetOAuthUser(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+	return nil
+}
+
+// GetOAuthConsents Gets the applications the user has authorized to access their account
+// Endpoint: GET /v2/oauth/consents
+func GetOAuthConsents(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	consents, err := db.GetUserOAuthConsents(user.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving oauth consents", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"consents": consents})
+	return nil
+}
+
+// DeleteOAuthConsent Removes an application's access to the user's account, revoking all of its tokens
+// Endpoint: DELETE /v2/oauth/consents/:application_id
+func DeleteOAuthConsent(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	id, err := strconv.Atoi(c.Param("application_id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid application_id")
+	}
+
+	deleted, err := db.DeleteOAuthConsent(user.Id, id)
+
+	if err != nil {
+		return APIErrorServerError("Error deleting oauth consent", err)
+	}
+
+	if !deleted {
+		return APIErrorNotFound("Consent")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The application no longer has access to your account."})
+	return nil
+}
+
+// Validates the application, redirect url, scopes and code challenge of an authorization request
+func parseOAuthAuthorizationRequest(body *oauthAuthorizationBody) (*oauthAuthorizationRequest, *APIError) {
+	if body.ResponseType != "code" {
+		return nil, APIErrorBadRequest("The `response_type` must be `code`.")
+	}
+
+	app, err := db.GetActiveApplicationByClientId(body.ClientId)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, APIErrorServerError("Error retrieving application", err)
+	}
+
+	if app == nil {
+		return nil, APIErrorNotFound("Application")
+	}
+
+	if body.RedirectURI == "" {
+		body.RedirectURI = app.RedirectURL
+	}
+
+	if body.RedirectURI != app.RedirectURL {
+		return nil, APIErrorBadRequest("The `redirect_uri` does not match the application's redirect URL.")
+	}
+
+	if _, err := url.ParseRequestURI(body.RedirectURI); err != nil {
+		return nil, APIErrorBadRequest("The application's redirect URL is not valid.")
+	}
+
+	scopes, err := db.ParseOAuthScopes(body.Scope)
+
+	if err != nil {
+		return nil, APIErrorBadRequest("The `scope` contains an invalid scope.")
+	}
+
+	if body.CodeChallengeMethod != oauth.CodeChallengeMethodS256 || !oauth.IsValidCodeChallenge(body.CodeChallenge) {
+		return nil, APIErrorBadRequest("You must provide a valid `code_challenge` with the `S256` `code_challenge_method`.")
+	}
+
+	return &oauthAuthorizationRequest{
+		Application:   app,
+		RedirectURL:   body.RedirectURI,
+		Scopes:        scopes,
+		State:         body.State,
+		CodeChallenge: body.CodeChallenge,
+	}, nil
+}
+
+// Authenticates the application making a token request, by its client id and secret. Public clients can leave out their secret.
+// Credentials can be sent in the body or with HTTP basic authentication.
+// Returns a nil application if the error response has already been written.
+func authenticateOAuthClient(c *gin.Context, body *oauthClientBody) (*db.Application, *APIError) {
+	clientId, clientSecret, ok := c.Request.BasicAuth()
+
+	if !ok {
+		clientId, clientSecret = body.ClientId, body.ClientSecret
+	}
+
+	if clientId == "" {
+		return nil, oauthError(c, http.StatusUnauthorized, "invalid_client", "You must provide a `client_id`.")
+	}
+
+	app, err := db.GetActiveApplicationByClientId(clientId)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, APIErrorServerError("Error retrieving application", err)
+	}
+
+	if app == nil || !oauth.AuthenticateClient(app, clientSecret) {
+		return nil, oauthError(c, http.StatusUnauthorized, "invalid_client", "The client credentials are invalid.")
+	}
+
+	return app, nil
+}
+
+// Writes an error in the format described by RFC 6749
+func oauthError(c *gin.Context, status int, code string, description string) *APIError {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+
+	return nil
+}
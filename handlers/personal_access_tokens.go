@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/stringutil"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The maximum amount of active personal access tokens a user can have
+const maxPersonalAccessTokens = 25
+
+// GetPersonalAccessTokens Gets the user's active personal access tokens
+// Endpoint: GET /v2/developers/tokens
+func GetPersonalAccessTokens(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	tokens, err := db.GetUserPersonalAccessTokens(user.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving personal access tokens", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+	return nil
+}
+
+// CreatePersonalAccessToken Creates a personal access token. The token is only ever returned in this response.
+// Endpoint: POST /v2/developers/tokens
+func CreatePersonalAccessToken(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	body := struct {
+		Name          string `form:"name" json:"name" binding:"required"`
+		Scopes        string `form:"scopes" json:"scopes"`
+		ExpiresInDays int    `form:"expires_in_days" json:"expires_in_days"`
+	}{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+
+	if body.Name == "" || len(body.Name) > 50 {
+		return APIErrorBadRequest("The name of your token must be between 1 and 50 characters.")
+	}
+
+	if body.ExpiresInDays < 0 || body.ExpiresInDays > 365 {
+		return APIErrorBadRequest("Your token must expire within 365 days, or never.")
+	}
+
+	scopes, err := db.ParsePersonalAccessTokenScopes(body.Scopes)
+
+	if err != nil {
+		return APIErrorBadRequest("Your token contains an invalid scope.")
+	}
+
+	tokens, err := db.GetUserPersonalAccessTokens(user.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving personal access tokens", err)
+	}
+
+	if len(tokens) >= maxPersonalAccessTokens {
+		return APIErrorForbidden("You have already created the maximum amount of personal access tokens.")
+	}
+
+	value, err := stringutil.GenerateToken(32)
+
+	if err != nil {
+		return APIErrorServerError("Error generating personal access token", err)
+	}
+
+	value = db.PersonalAccessTokenPrefix + value
+
+	token := &db.PersonalAccessToken{
+		UserId:    user.Id,
+		Name:      body.Name,
+		TokenHash: stringutil.HashToken(value),
+		TokenHint: value[len(value)-4:],
+		ScopeList: scopes,
+	}
+
+	if body.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, body.ExpiresInDays).UnixMilli()
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := token.Insert(); err != nil {
+		return APIErrorServerError("Error inserting personal access token", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Your personal access token has been created. Make sure to copy it now, as you won't be able to see it again.",
+		"token":        token,
+		"access_token": value,
+	})
+
+	return nil
+}
+
+// RevokePersonalAccessToken Revokes one of the user's personal access tokens
+// Endpoint: DELETE /v2/developers/tokens/:id
+func RevokePersonalAccessToken(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid id")
+	}
+
+	token, err := db.GetUserPersonalAccessTokenById(user.Id, id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving personal access token", err)
+	}
+
+	if token == nil {
+		return APIErrorNotFound("Personal access token")
+	}
+
+	if err := token.Revoke(); err != nil {
+		return APIErrorServerError("Error revoking personal access token", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Your personal access token has been revoked."})
+	return nil
+}
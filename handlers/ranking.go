@@ -1,18 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
+	"github.com/Quaver/api2/automod"
 	"github.com/Quaver/api2/config"
 	"github.com/Quaver/api2/db"
 	"github.com/Quaver/api2/enums"
 	"github.com/Quaver/api2/files"
-	"github.com/Quaver/api2/tools"
 	"github.com/Quaver/api2/webhooks"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 )
@@ -148,49 +147,101 @@ func SubmitMapsetToRankingQueue(c *gin.Context) *APIError {
 		return APIErrorForbidden("You cannot submit any more mapsets to the queue at this time.")
 	}
 
-	automodOk, err := downloadAndRunAutomod(mapset)
+	automodResult, err := downloadAndRunAutomod(mapset)
 
 	if err != nil {
 		return APIErrorServerError("Error running automod", err)
 	}
 
-	if !automodOk {
-		return APIErrorBadRequest("Your mapset has AutoMod issues. Please fix them, re-upload your mapset, then try again.")
+	if automodResult.HasIssues {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Your mapset has AutoMod issues. Please fix them, re-upload your mapset, then try again.",
+			"automod": automodResult,
+		})
+
+		return nil
 	}
 
 	if existingQueueMapset == nil {
-		return addMapsetToRankingQueue(c, mapset)
+		return addMapsetToRankingQueue(c, mapset, automodResult)
 	} else {
-		return resubmitMapsetToRankingQueue(c, existingQueueMapset)
+		return resubmitMapsetToRankingQueue(c, existingQueueMapset, automodResult)
 	}
 }
 
-// Runs the automod and returns if the mapset is ok
-func downloadAndRunAutomod(mapset *db.Mapset) (bool, error) {
+// Downloads the mapset archive and runs AutoMod on it. The result is stored by mapset id,
+// so it can be viewed later, even if the mapset was rejected from the ranking queue.
+func downloadAndRunAutomod(mapset *db.Mapset) (*automod.Result, error) {
 	archivePath, err := files.CacheMapset(mapset)
 
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	output, _ := filepath.Abs(fmt.Sprintf("%v/%v", files.GetTempDirectory(), time.Now().UnixMilli()))
+	result, err := automod.RunOnArchive(archivePath)
 
-	if err := files.UnzipArchive(archivePath, output); err != nil {
-		return false, err
+	if err != nil {
+		return nil, err
 	}
 
-	automod, err := tools.RunAutoMod(output)
+	resultJson, err := json.Marshal(result)
 
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+
+	stored := &db.MapsetAutoMod{
+		MapsetId:   mapset.Id,
+		PackageMD5: mapset.PackageMD5,
+		HasIssues:  result.HasIssues,
+		Result:     resultJson,
+		Timestamp:  time.Now().UnixMilli(),
+	}
+
+	if err := stored.Save(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Stores the result of AutoMod with the ranking queue mapset, so supervisors can see it
+func storeRankingQueueAutoMod(queueMapset *db.RankingQueueMapset, result *automod.Result) *APIError {
+	issues, err := json.Marshal(result)
+
+	if err != nil {
+		return APIErrorServerError("Error serializing automod result", err)
+	}
+
+	if err := queueMapset.UpdateAutoModIssues(issues); err != nil {
+		return APIErrorServerError("Error updating ranking queue automod issues", err)
+	}
+
+	return nil
+}
+
+// GetMapsetAutoMod Returns the result of the last time AutoMod ran on a mapset. AutoMod only runs when a mapset is
+// submitted to or updated in the ranking queue, so this never has to download the mapset.
+// Endpoint: GET /v2/mapset/:id/automod
+func GetMapsetAutoMod(c *gin.Context) *APIError {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid id")
 	}
 
-	if automod.HasIssues {
-		return false, nil
+	result, err := db.GetMapsetAutoMod(id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving mapset automod result", err)
 	}
 
-	_ = os.Remove(output)
-	return true, nil
+	if result == nil {
+		return APIErrorNotFound("AutoMod result")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"automod": result.Result})
+	return nil
 }
 
 // RemoveFromRankingQueue Allows a user to remove their mapset from the ranking queue (self deny)
@@ -252,7 +303,7 @@ func RemoveFromRankingQueue(c *gin.Context) *APIError {
 }
 
 // Adds a new mapset to the ranking queue
-func addMapsetToRankingQueue(c *gin.Context, mapset *db.Mapset) *APIError {
+func addMapsetToRankingQueue(c *gin.Context, mapset *db.Mapset, automodResult *automod.Result) *APIError {
 	rankingQueueMapset := &db.RankingQueueMapset{
 		MapsetId:        mapset.Id,
 		Timestamp:       time.Now().UnixMilli(),
@@ -264,6 +315,10 @@ func addMapsetToRankingQueue(c *gin.Context, mapset *db.Mapset) *APIError {
 		return APIErrorServerError("Error inserting mapset into ranking queue", err)
 	}
 
+	if apiErr := storeRankingQueueAutoMod(rankingQueueMapset, automodResult); apiErr != nil {
+		return apiErr
+	}
+
 	comment := &db.MapsetRankingQueueComment{
 		UserId:     mapset.CreatorID,
 		MapsetId:   mapset.Id,
@@ -281,7 +336,7 @@ func addMapsetToRankingQueue(c *gin.Context, mapset *db.Mapset) *APIError {
 }
 
 // Resubmits a mapset to the ranking queue
-func resubmitMapsetToRankingQueue(c *gin.Context, mapset *db.RankingQueueMapset) *APIError {
+func resubmitMapsetToRankingQueue(c *gin.Context, mapset *db.RankingQueueMapset, automodResult *automod.Result) *APIError {
 	switch mapset.Status {
 	case db.RankingQueuePending, db.RankingQueueOnHold, db.RankingQueueResolved:
 		return APIErrorForbidden("Your mapset is already pending for rank.")
@@ -308,6 +363,10 @@ func resubmitMapsetToRankingQueue(c *gin.Context, mapset *db.RankingQueueMapset)
 		return APIErrorServerError("Error updating ranking queue mapset status", err)
 	}
 
+	if apiErr := storeRankingQueueAutoMod(mapset, automodResult); apiErr != nil {
+		return apiErr
+	}
+
 	// Deactivate previous actions since they no longer count
 	if err := db.DeactivateRankingQueueActions(mapset.MapsetId); err != nil {
 		return APIErrorServerError("Error deactivating previous ranking queue actions", err)
@@ -1,10 +1,11 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/Quaver/api2/db"
 	"github.com/Quaver/api2/files"
-	"github.com/Quaver/api2/tools"
+	"github.com/Quaver/api2/replay"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
@@ -15,43 +16,17 @@ import (
 // GetVirtualReplayPlayerOutput Plays the virtual replay player & returns the output
 // Endpoint: GET /v2/scores/:id/stats
 func GetVirtualReplayPlayerOutput(c *gin.Context) *APIError {
-	id, err := strconv.Atoi(c.Param("md5")) // Have to name the id as MD5 due to gin limitation...
-
-	if err != nil {
-		return APIErrorBadRequest("Invalid id")
-	}
-
-	score, err := db.GetScoreById(id)
-
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return APIErrorServerError("Error retrieving score from db", err)
-	}
-
-	if score == nil {
-		return APIErrorNotFound("Score")
-	}
-
-	if score.Failed {
-		return APIErrorBadRequest("Failed scores do not have replay data.")
-	}
-
-	quaPath, err := files.CacheQuaFile(score.Map)
-
-	if err != nil {
-		return APIErrorServerError("Failed to cache qua file", err)
-	}
-
-	replayPath, err := files.CacheReplay(score.Id)
+	score, apiErr := getReplayPlayerScore(c)
 
-	if err != nil {
-		return APIErrorServerError("Failed to cache replay file", err)
+	if apiErr != nil {
+		return apiErr
 	}
 
 	var data interface{}
 	key := fmt.Sprintf("quaver:score:%v:stats", score.Id)
 
-	err = db.CacheJsonInRedis(key, &data, time.Hour*1, false, func() error {
-		replayStats, err := tools.PlayReplayVirtually(quaPath, replayPath, score.Modifiers)
+	err := db.CacheJsonInRedis(key, &data, time.Hour*1, false, func() error {
+		replayStats, err := playScoreReplay(score)
 
 		if err != nil {
 			return err
@@ -62,15 +37,15 @@ func GetVirtualReplayPlayerOutput(c *gin.Context) *APIError {
 				"score":           replayStats.Score,
 				"accuracy":        replayStats.Accuracy,
 				"max_combo":       replayStats.MaxCombo,
-				"count_marvelous": replayStats.CountMarv,
-				"count_perfect":   replayStats.CountPerf,
+				"count_marvelous": replayStats.CountMarvelous,
+				"count_perfect":   replayStats.CountPerfect,
 				"count_great":     replayStats.CountGreat,
 				"count_good":      replayStats.CountGood,
 				"count_okay":      replayStats.CountOkay,
 				"count_miss":      replayStats.CountMiss,
 				"count_minehit":   replayStats.CountMineHit,
 			},
-			"deviance": replayStats.Hits,
+			"deviance": replayStats.Deviance(),
 		}
 
 		return nil
@@ -83,3 +58,99 @@ func GetVirtualReplayPlayerOutput(c *gin.Context) *APIError {
 	c.JSON(http.StatusOK, data)
 	return nil
 }
+
+// GetScoreTimingAnalytics Returns the hit error & judgement analytics of a score's replay.
+// The replay is only played the first time, after which the analytics are stored.
+// Endpoint: GET /v2/scores/:id/analytics
+func GetScoreTimingAnalytics(c *gin.Context) *APIError {
+	score, apiErr := getReplayPlayerScore(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var analytics *replay.TimingAnalytics
+	key := fmt.Sprintf("quaver:score:%v:analytics", score.Id)
+
+	err := db.CacheJsonInRedis(key, &analytics, time.Hour*1, false, func() error {
+		stored, err := db.GetScoreTimingAnalytics(score.Id)
+
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		if stored != nil && stored.Version == replay.TimingAnalyticsVersion {
+			return json.Unmarshal(stored.Analytics, &analytics)
+		}
+
+		player, err := playScoreReplay(score)
+
+		if err != nil {
+			return err
+		}
+
+		analytics = player.TimingAnalytics()
+		data, err := json.Marshal(analytics)
+
+		if err != nil {
+			return err
+		}
+
+		stored = &db.ScoreTimingAnalytics{
+			ScoreId:   score.Id,
+			Version:   analytics.Version,
+			Analytics: data,
+		}
+
+		return stored.Save()
+	})
+
+	if err != nil {
+		return APIErrorServerError("Error getting score timing analytics", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"analytics": analytics})
+	return nil
+}
+
+// Retrieves the score in the request that the virtual replay player can be used on
+func getReplayPlayerScore(c *gin.Context) (*db.Score, *APIError) {
+	id, err := strconv.Atoi(c.Param("md5")) // Have to name the id as MD5 due to gin limitation...
+
+	if err != nil {
+		return nil, APIErrorBadRequest("Invalid id")
+	}
+
+	score, err := db.GetScoreById(id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, APIErrorServerError("Error retrieving score from db", err)
+	}
+
+	if score == nil {
+		return nil, APIErrorNotFound("Score")
+	}
+
+	if score.Failed {
+		return nil, APIErrorBadRequest("Failed scores do not have replay data.")
+	}
+
+	return score, nil
+}
+
+// Caches the map & replay of a score and plays it with the virtual replay player
+func playScoreReplay(score *db.Score) (*replay.VirtualPlayer, error) {
+	quaPath, err := files.CacheQuaFile(score.Map)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to cache qua file: %v", err)
+	}
+
+	replayPath, err := files.CacheReplay(score.Id)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to cache replay file: %v", err)
+	}
+
+	return replay.PlayVirtually(quaPath, replayPath, score.Modifiers, true)
+}
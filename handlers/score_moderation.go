@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/moderation"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// The maximum amount of scores that can be deleted or restored in a single request
+const scoreModerationLimit = 1000
+
+type scoreModerationBody struct {
+	db.ScoreModerationFilter
+	Notes string `json:"notes"`
+}
+
+// DeleteScore Deletes a single score
+// Endpoint: POST /v2/scores/:id/delete
+func DeleteScore(c *gin.Context) *APIError {
+	id, body, apiErr := parseSingleScoreModerationRequest(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	return deleteScores(c, &db.ScoreModerationFilter{ScoreIds: []int{id}}, body.Notes)
+}
+
+// RestoreScore Restores a single deleted score
+// Endpoint: POST /v2/scores/:id/restore
+func RestoreScore(c *gin.Context) *APIError {
+	id, body, apiErr := parseSingleScoreModerationRequest(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	return restoreScores(c, &db.ScoreModerationFilter{ScoreIds: []int{id}}, body.Notes)
+}
+
+// DeleteScoresBulk Deletes every score that matches a filter, such as all of a user's scores on a map or in a time range
+// Endpoint: POST /v2/scores/bulk/delete
+func DeleteScoresBulk(c *gin.Context) *APIError {
+	body, apiErr := parseBulkScoreModerationRequest(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	return deleteScores(c, &body.ScoreModerationFilter, body.Notes)
+}
+
+// RestoreScoresBulk Restores every deleted score that matches a filter
+// Endpoint: POST /v2/scores/bulk/restore
+func RestoreScoresBulk(c *gin.Context) *APIError {
+	body, apiErr := parseBulkScoreModerationRequest(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	return restoreScores(c, &body.ScoreModerationFilter, body.Notes)
+}
+
+func deleteScores(c *gin.Context, filter *db.ScoreModerationFilter, notes string) *APIError {
+	scores, err := db.GetScoresForDeletion(filter, scoreModerationLimit+1)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving scores to delete", err)
+	}
+
+	if len(scores) == 0 {
+		return APIErrorNotFound("Score")
+	}
+
+	if len(scores) > scoreModerationLimit {
+		return APIErrorBadRequest(fmt.Sprintf("You cannot delete more than %v scores at once.", scoreModerationLimit))
+	}
+
+	deletions, err := moderation.DeleteScores(getAuthedUser(c), scores, notes)
+
+	if err != nil {
+		return APIErrorServerError("Error deleting scores", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   fmt.Sprintf("%v score(s) have been deleted.", len(deletions)),
+		"deletions": deletions,
+	})
+
+	return nil
+}
+
+func restoreScores(c *gin.Context, filter *db.ScoreModerationFilter, notes string) *APIError {
+	deletions, err := db.GetScoreDeletionsForRestore(filter, scoreModerationLimit+1)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving scores to restore", err)
+	}
+
+	if len(deletions) == 0 {
+		return APIErrorNotFound("Deleted score")
+	}
+
+	if len(deletions) > scoreModerationLimit {
+		return APIErrorBadRequest(fmt.Sprintf("You cannot restore more than %v scores at once.", scoreModerationLimit))
+	}
+
+	if err := moderation.RestoreScores(getAuthedUser(c), deletions, notes); err != nil {
+		return APIErrorServerError("Error restoring scores", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   fmt.Sprintf("%v score(s) have been restored.", len(deletions)),
+		"deletions": deletions,
+	})
+
+	return nil
+}
+
+// Parses the score id and optional notes of a request to moderate a single score
+func parseSingleScoreModerationRequest(c *gin.Context) (int, *scoreModerationBody, *APIError) {
+	if !canUserAccessAdminRoute(c) {
+		return 0, nil, APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return 0, nil, APIErrorBadRequest("Invalid id")
+	}
+
+	body := &scoreModerationBody{}
+
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(body); err != nil {
+			return 0, nil, APIErrorBadRequest("Invalid request body")
+		}
+	}
+
+	return id, body, nil
+}
+
+// Parses the filter & notes of a request to moderate scores in bulk
+func parseBulkScoreModerationRequest(c *gin.Context) (*scoreModerationBody, *APIError) {
+	if !canUserAccessAdminRoute(c) {
+		return nil, APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	body := &scoreModerationBody{}
+
+	if err := c.ShouldBindJSON(body); err != nil {
+		return nil, APIErrorBadRequest("Invalid request body")
+	}
+
+	if !body.IsValid() {
+		return nil, APIErrorBadRequest("You must provide either score ids or a user.")
+	}
+
+	if body.After > 0 && body.Before > 0 && body.Before <= body.After {
+		return nil, APIErrorBadRequest("The end of the time range must be after its start.")
+	}
+
+	return body, nil
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+// GetSeasons Returns every ranked season
+// Endpoint: GET /v2/seasons
+func GetSeasons(c *gin.Context) *APIError {
+	seasons, err := db.GetSeasons()
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving seasons from db", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"seasons": seasons})
+	return nil
+}
+
+// CreateSeason Creates a new ranked season
+// Endpoint: POST /v2/seasons
+func CreateSeason(c *gin.Context) *APIError {
+	if !canUserAccessAdminRoute(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	body := struct {
+		Name               string `form:"name" json:"name" binding:"required"`
+		StartsAt           int64  `form:"starts_at" json:"starts_at" binding:"required"`
+		EndsAt             int64  `form:"ends_at" json:"ends_at" binding:"required"`
+		BadgeId            *int   `form:"badge_id" json:"badge_id"`
+		BadgeRankThreshold int    `form:"badge_rank_threshold" json:"badge_rank_threshold"`
+	}{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	if body.EndsAt <= body.StartsAt {
+		return APIErrorBadRequest("The season must end after it starts.")
+	}
+
+	if body.BadgeRankThreshold < 0 {
+		return APIErrorBadRequest("The badge rank threshold must not be negative.")
+	}
+
+	overlapping, err := db.IsSeasonOverlapping(body.StartsAt, body.EndsAt)
+
+	if err != nil {
+		return APIErrorServerError("Error checking for overlapping seasons", err)
+	}
+
+	if overlapping {
+		return APIErrorBadRequest("The season overlaps with an existing season.")
+	}
+
+	season := &db.Season{
+		Name:               body.Name,
+		StartsAt:           body.StartsAt,
+		EndsAt:             body.EndsAt,
+		BadgeId:            body.BadgeId,
+		BadgeRankThreshold: body.BadgeRankThreshold,
+	}
+
+	if err := season.Insert(); err != nil {
+		return APIErrorServerError("Error inserting season into db", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The season has been created.",
+		"season":  season,
+	})
+
+	return nil
+}
+
+// GetSeasonLeaderboard Returns the leaderboard of a season. Seasons that have ended return their final standings.
+// Endpoint: GET /v2/leaderboard/season/:id?mode=&country=&page=
+func GetSeasonLeaderboard(c *gin.Context) *APIError {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid id")
+	}
+
+	mode, err := strconv.Atoi(c.Query("mode"))
+
+	if err != nil {
+		return APIErrorBadRequest("You must supply a valid `mode` query parameter.")
+	}
+
+	page, err := strconv.Atoi(c.Query("page"))
+
+	if err != nil {
+		page = 0
+	}
+
+	country := c.Query("country")
+
+	season, err := db.GetSeasonById(id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving season from db", err)
+	}
+
+	if season == nil {
+		return APIErrorNotFound("Season")
+	}
+
+	var stats []*db.SeasonUserStats
+	var count int
+
+	if season.Finalized {
+		if stats, err = db.GetSeasonFinalStandings(season.Id, enums.GameMode(mode), country, page, 50); err != nil {
+			return APIErrorServerError("Error retrieving season standings from db", err)
+		}
+
+		if count, err = db.GetSeasonFinalStandingsCount(season.Id, enums.GameMode(mode), country); err != nil {
+			return APIErrorServerError("Error retrieving season standings count from db", err)
+		}
+	} else {
+		if stats, err = db.GetSeasonLeaderboard(season.Id, enums.GameMode(mode), country, page, 50); err != nil {
+			return APIErrorServerError("Error retrieving season leaderboard", err)
+		}
+
+		if count, err = db.GetSeasonLeaderboardCount(season.Id, enums.GameMode(mode), country); err != nil {
+			return APIErrorServerError("Error retrieving season leaderboard count", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season":      season,
+		"total_users": count,
+		"users":       stats,
+	})
+
+	return nil
+}
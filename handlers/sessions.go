@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+// CreateSession Logs a user in with a Steam session ticket, returning a short-lived access token and a refresh token.
+// Existing tokens & in-game sessions can't be exchanged for a session, so a stolen token can't be used to stay logged in.
+// Endpoint: POST /v2/sessions
+func CreateSession(c *gin.Context) *APIError {
+	steamId, apiErr := authenticateSteamTicket(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	user, err := db.GetUserBySteamId(steamId)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving user by Steam Id", err)
+	}
+
+	if user == nil {
+		return APIErrorNotFound("User")
+	}
+
+	if !user.Allowed {
+		return APIErrorForbidden("You are banned")
+	}
+
+	response, err := sessions.Create(user, c.GetHeader("User-Agent"), getIpFromRequest(c))
+
+	if err != nil {
+		return APIErrorServerError("Error creating session", err)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+	return nil
+}
+
+// RefreshSession Exchanges a refresh token for a new access token and refresh token
+// Endpoint: POST /v2/sessions/refresh
+func RefreshSession(c *gin.Context) *APIError {
+	body := struct {
+		RefreshToken string `form:"refresh_token" json:"refresh_token" binding:"required"`
+	}{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	response, err := sessions.Refresh(body.RefreshToken, getIpFromRequest(c))
+
+	switch err {
+	case nil:
+		break
+	case sessions.ErrInvalidRefreshToken:
+		return APIErrorUnauthorized("Your session has expired or been revoked. Please log in again.")
+	default:
+		return APIErrorServerError("Error refreshing session", err)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+	return nil
+}
+
+// GetSessions Gets the devices the user is logged in on
+// Endpoint: GET /v2/sessions
+func GetSessions(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	userSessions, err := db.GetUserSessions(user.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving sessions", err)
+	}
+
+	currentSessionId := getAuthedSessionId(c)
+
+	for _, session := range userSessions {
+		session.Current = session.Id == currentSessionId
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": userSessions})
+	return nil
+}
+
+// RevokeSession Logs the user out of one of their sessions
+// Endpoint: DELETE /v2/sessions/:id
+func RevokeSession(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid id")
+	}
+
+	session, err := db.GetUserSessionById(user.Id, id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving session", err)
+	}
+
+	if session == nil {
+		return APIErrorNotFound("Session")
+	}
+
+	if err := sessions.Revoke(session); err != nil {
+		return APIErrorServerError("Error revoking session", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "You have been logged out of the session."})
+	return nil
+}
+
+// RevokeAllSessions Logs the user out everywhere. Every session is revoked, including the current one,
+// and every JWT issued until now is rejected.
+// Endpoint: DELETE /v2/sessions
+func RevokeAllSessions(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	if err := sessions.RevokeAll(user.Id); err != nil {
+		return APIErrorServerError("Error revoking sessions", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "You have been logged out of all of your sessions."})
+	return nil
+}
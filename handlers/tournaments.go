@@ -0,0 +1,708 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/tournaments"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetTournaments Retrieves a page of tournaments
+// Endpoint: GET /v2/tournaments?page=
+func GetTournaments(c *gin.Context) *APIError {
+	page, err := strconv.Atoi(c.Query("page"))
+
+	if err != nil {
+		page = 0
+	}
+
+	const limit = 20
+
+	list, err := db.GetTournaments(limit, page)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving tournaments from db", err)
+	}
+
+	count, err := db.GetTournamentCount()
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving tournament count from db", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tournaments": list,
+		"total_count": count,
+	})
+
+	return nil
+}
+
+// GetTournament Retrieves a tournament along with its registered teams
+// Endpoint: GET /v2/tournaments/:id
+func GetTournament(c *gin.Context) *APIError {
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	teams, err := db.GetTournamentTeams(tournament.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving tournament teams from db", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tournament": tournament,
+		"teams":      teams,
+	})
+
+	return nil
+}
+
+// GetTournamentMappool Retrieves the mappools of every stage of a tournament
+// Endpoint: GET /v2/tournaments/:id/mappool
+func GetTournamentMappool(c *gin.Context) *APIError {
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	mappool, err := db.GetTournamentMappool(tournament.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving tournament mappool from db", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mappool": mappool})
+	return nil
+}
+
+// GetTournamentMatches Retrieves the bracket of a tournament
+// Endpoint: GET /v2/tournaments/:id/matches
+func GetTournamentMatches(c *gin.Context) *APIError {
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	matches, err := db.GetTournamentMatches(tournament.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving tournament matches from db", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"matches": matches})
+	return nil
+}
+
+// RegisterTournamentTeam Registers a team for a tournament, with the authenticated user as its captain
+// Endpoint: POST /v2/tournaments/:id/register
+func RegisterTournamentTeam(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	body := struct {
+		Name      string `form:"name" json:"name"`
+		MemberIds []int  `form:"member_ids" json:"member_ids"`
+	}{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	if !tournament.IsRegistrationOpen(time.Now()) {
+		return APIErrorBadRequest("Registration for this tournament is not open.")
+	}
+
+	// Solo tournaments use the player's name as the team name
+	if tournament.TeamSize == 1 {
+		body.Name = user.Username
+		body.MemberIds = nil
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+
+	if body.Name == "" || len(body.Name) > 50 {
+		return APIErrorBadRequest("Your team name must be between 1 and 50 characters.")
+	}
+
+	memberIds := []int{user.Id}
+
+	for _, id := range body.MemberIds {
+		if !slices.Contains(memberIds, id) {
+			memberIds = append(memberIds, id)
+		}
+	}
+
+	if len(memberIds) > tournament.TeamSize {
+		return APIErrorBadRequest(fmt.Sprintf("Teams can have at most %v players.", tournament.TeamSize))
+	}
+
+	for _, id := range memberIds[1:] {
+		if _, apiErr := getUserById(id, false); apiErr != nil {
+			return apiErr
+		}
+	}
+
+	registered, err := db.GetRegisteredTournamentUsers(tournament.Id, memberIds)
+
+	if err != nil {
+		return APIErrorServerError("Error checking registered tournament users", err)
+	}
+
+	if len(registered) > 0 {
+		return APIErrorBadRequest("One or more players are already registered for this tournament.")
+	}
+
+	exists, err := db.DoesTournamentTeamExistByName(tournament.Id, body.Name)
+
+	if err != nil {
+		return APIErrorServerError("Error checking if tournament team exists", err)
+	}
+
+	if exists {
+		return APIErrorBadRequest("A team with this name is already registered.")
+	}
+
+	team := &db.TournamentTeam{
+		TournamentId: tournament.Id,
+		Name:         body.Name,
+		CaptainId:    user.Id,
+	}
+
+	if err := db.InsertTournamentTeam(team, memberIds); err != nil {
+		return APIErrorServerError("Error inserting tournament team", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "You have successfully registered for the tournament.",
+		"team":    team,
+	})
+
+	return nil
+}
+
+// WithdrawTournamentTeam Removes the team the authenticated user is the captain of from a tournament
+// Endpoint: POST /v2/tournaments/:id/withdraw
+func WithdrawTournamentTeam(c *gin.Context) *APIError {
+	user := getAuthedUser(c)
+
+	if user == nil {
+		return nil
+	}
+
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if !tournament.IsRegistrationOpen(time.Now()) {
+		return APIErrorBadRequest("You can only withdraw while registration is open.")
+	}
+
+	team, err := db.GetTournamentTeamByCaptain(tournament.Id, user.Id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving tournament team from db", err)
+	}
+
+	if team == nil {
+		return APIErrorBadRequest("You are not the captain of a team in this tournament.")
+	}
+
+	if err := db.DeleteTournamentTeam(team); err != nil {
+		return APIErrorServerError("Error deleting tournament team", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Your team has been withdrawn from the tournament."})
+	return nil
+}
+
+type tournamentBody struct {
+	Name                 string                   `form:"name" json:"name" binding:"required"`
+	Description          string                   `form:"description" json:"description"`
+	Mode                 enums.GameMode           `form:"mode" json:"mode" binding:"required"`
+	Status               db.TournamentStatus      `form:"status" json:"status"`
+	BracketType          db.TournamentBracketType `form:"bracket_type" json:"bracket_type" binding:"required"`
+	TeamSize             int                      `form:"team_size" json:"team_size" binding:"required"`
+	RegistrationStartsAt int64                    `form:"registration_starts_at" json:"registration_starts_at" binding:"required"`
+	RegistrationEndsAt   int64                    `form:"registration_ends_at" json:"registration_ends_at" binding:"required"`
+}
+
+// CreateTournament Creates a new tournament
+// Endpoint: POST /v2/tournaments
+func CreateTournament(c *gin.Context) *APIError {
+	if !canUserManageTournaments(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	tournament := &db.Tournament{CreatedBy: getAuthedUser(c).Id, Status: db.TournamentStatusUpcoming}
+
+	if apiErr := parseTournamentBody(c, tournament); apiErr != nil {
+		return apiErr
+	}
+
+	if err := tournament.Insert(); err != nil {
+		return APIErrorServerError("Error inserting tournament", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "The tournament has been successfully created.",
+		"tournament": tournament,
+	})
+
+	return nil
+}
+
+// UpdateTournament Updates the details & status of a tournament
+// Endpoint: POST /v2/tournaments/:id
+func UpdateTournament(c *gin.Context) *APIError {
+	if !canUserManageTournaments(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if apiErr := parseTournamentBody(c, tournament); apiErr != nil {
+		return apiErr
+	}
+
+	if err := tournament.Save(); err != nil {
+		return APIErrorServerError("Error updating tournament", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "The tournament has been successfully updated.",
+		"tournament": tournament,
+	})
+
+	return nil
+}
+
+// AddTournamentMappoolMap Adds a map to the mappool of a tournament stage, replacing the map in the same slot
+// Endpoint: POST /v2/tournaments/:id/mappool
+func AddTournamentMappoolMap(c *gin.Context) *APIError {
+	if !canUserManageTournaments(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	body := struct {
+		Stage string `form:"stage" json:"stage" binding:"required"`
+		Slot  string `form:"slot" json:"slot" binding:"required"`
+		MapId int    `form:"map_id" json:"map_id" binding:"required"`
+		Mods  int64  `form:"mods" json:"mods"`
+	}{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	if len(body.Stage) > 32 || len(body.Slot) > 8 {
+		return APIErrorBadRequest("The stage must be at most 32 characters, and the slot at most 8.")
+	}
+
+	mapQua, err := db.GetMapById(body.MapId)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving map from db", err)
+	}
+
+	if mapQua == nil {
+		return APIErrorNotFound("Map")
+	}
+
+	if mapQua.GameMode != tournament.Mode {
+		return APIErrorBadRequest("The map must be for the same game mode as the tournament.")
+	}
+
+	poolMap := &db.TournamentMappoolMap{
+		TournamentId: tournament.Id,
+		Stage:        body.Stage,
+		Slot:         strings.ToUpper(body.Slot),
+		MapId:        mapQua.Id,
+		Mods:         body.Mods,
+	}
+
+	if err := poolMap.Save(); err != nil {
+		return APIErrorServerError("Error saving tournament mappool map", err)
+	}
+
+	poolMap.Map = mapQua
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The map has been added to the mappool.",
+		"map":     poolMap,
+	})
+
+	return nil
+}
+
+// RemoveTournamentMappoolMap Removes a map from a tournament's mappool
+// Endpoint: DELETE /v2/tournaments/:id/mappool/:pool_map_id
+func RemoveTournamentMappoolMap(c *gin.Context) *APIError {
+	if !canUserManageTournaments(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	id, err := strconv.Atoi(c.Param("pool_map_id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid pool_map_id")
+	}
+
+	deleted, err := db.DeleteTournamentMappoolMap(tournament.Id, id)
+
+	if err != nil {
+		return APIErrorServerError("Error deleting tournament mappool map", err)
+	}
+
+	if !deleted {
+		return APIErrorNotFound("Mappool map")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The map has been removed from the mappool."})
+	return nil
+}
+
+// AddTournamentQualifierLobby Links a multiplayer game to a tournament, so its scores are used for seeding
+// Endpoint: POST /v2/tournaments/:id/qualifiers
+func AddTournamentQualifierLobby(c *gin.Context) *APIError {
+	if !canUserManageTournaments(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	body := struct {
+		GameId int `form:"game_id" json:"game_id" binding:"required"`
+	}{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	if apiErr := validateMultiplayerGameExists(body.GameId); apiErr != nil {
+		return apiErr
+	}
+
+	if err := db.InsertTournamentQualifierLobby(tournament.Id, body.GameId); err != nil {
+		return APIErrorServerError("Error inserting tournament qualifier lobby", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The game has been added as a qualifier lobby."})
+	return nil
+}
+
+// CalculateTournamentSeeding Seeds the teams of a tournament from their qualifier scores
+// Endpoint: POST /v2/tournaments/:id/seeding
+func CalculateTournamentSeeding(c *gin.Context) *APIError {
+	if !canUserManageTournaments(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	teams, err := db.GetTournamentTeams(tournament.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving tournament teams from db", err)
+	}
+
+	scores, err := db.GetTournamentQualifierScores(tournament.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving tournament qualifier scores from db", err)
+	}
+
+	seeded := tournaments.CalculateSeeds(teams, scores)
+	seeds := make(map[int]int, len(seeded))
+
+	for i, team := range seeded {
+		seed := i + 1
+		seeds[team.Id] = seed
+		team.Seed = &seed
+	}
+
+	if err := db.UpdateTournamentTeamSeeds(tournament.Id, seeds); err != nil {
+		return APIErrorServerError("Error updating tournament team seeds", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The teams have been seeded.",
+		"teams":   seeded,
+	})
+
+	return nil
+}
+
+// GenerateTournamentBracket Generates the bracket of a tournament from its seeded teams,
+// replacing the current bracket if none of its matches have been played
+// Endpoint: POST /v2/tournaments/:id/bracket
+func GenerateTournamentBracket(c *gin.Context) *APIError {
+	if !canUserManageTournaments(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	played, err := db.HasCompletedTournamentMatches(tournament.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error checking for completed tournament matches", err)
+	}
+
+	if played {
+		return APIErrorBadRequest("The bracket can't be regenerated after matches have been played.")
+	}
+
+	teams, err := db.GetTournamentTeams(tournament.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving tournament teams from db", err)
+	}
+
+	teamIds := make([]int, 0, len(teams))
+
+	for _, team := range teams {
+		if team.Seed == nil {
+			return APIErrorBadRequest("Every team must be seeded before the bracket is generated.")
+		}
+
+		teamIds = append(teamIds, team.Id)
+	}
+
+	matches, links, err := tournaments.GenerateBracket(tournament.Id, tournament.BracketType, teamIds)
+
+	switch err {
+	case nil:
+		break
+	case tournaments.ErrNotEnoughTeams, tournaments.ErrInvalidBracket:
+		return APIErrorBadRequest(err.Error())
+	default:
+		return APIErrorServerError("Error generating tournament bracket", err)
+	}
+
+	if err := db.ReplaceTournamentMatches(tournament.Id, matches, links); err != nil {
+		return APIErrorServerError("Error inserting tournament bracket", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The bracket has been generated.",
+		"matches": matches,
+	})
+
+	return nil
+}
+
+// ReportTournamentMatchResult Sets the result of a bracket match and advances the teams
+// Endpoint: POST /v2/tournaments/:id/matches/:match_id
+func ReportTournamentMatchResult(c *gin.Context) *APIError {
+	if !canUserManageTournaments(c) {
+		return APIErrorForbidden("You do not have permission to access this endpoint.")
+	}
+
+	tournament, apiErr := getTournamentFromParam(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	matchId, err := strconv.Atoi(c.Param("match_id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid match_id")
+	}
+
+	body := struct {
+		Team1Score int  `form:"team1_score" json:"team1_score"`
+		Team2Score int  `form:"team2_score" json:"team2_score"`
+		GameId     *int `form:"game_id" json:"game_id"`
+	}{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	if body.GameId != nil {
+		if apiErr := validateMultiplayerGameExists(*body.GameId); apiErr != nil {
+			return apiErr
+		}
+	}
+
+	matches, err := db.GetTournamentMatches(tournament.Id)
+
+	if err != nil {
+		return APIErrorServerError("Error retrieving tournament matches from db", err)
+	}
+
+	changed, decided, err := tournaments.ReportMatchResult(matches, matchId, body.Team1Score, body.Team2Score, body.GameId)
+
+	switch err {
+	case nil:
+		break
+	case tournaments.ErrMatchNotFound:
+		return APIErrorNotFound("Match")
+	case tournaments.ErrMatchAlreadyCompleted, tournaments.ErrMatchNotReady, tournaments.ErrMatchTied:
+		return APIErrorBadRequest(err.Error())
+	default:
+		return APIErrorServerError("Error reporting tournament match result", err)
+	}
+
+	if err := db.SaveTournamentMatches(changed); err != nil {
+		return APIErrorServerError("Error saving tournament matches", err)
+	}
+
+	if decided {
+		tournament.Status = db.TournamentStatusCompleted
+
+		if err := tournament.Save(); err != nil {
+			return APIErrorServerError("Error completing tournament", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The match result has been reported.",
+		"matches": changed,
+	})
+
+	return nil
+}
+
+// Retrieves the tournament in the id parameter of the request
+func getTournamentFromParam(c *gin.Context) (*db.Tournament, *APIError) {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		return nil, APIErrorBadRequest("Invalid id")
+	}
+
+	tournament, err := db.GetTournamentById(id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, APIErrorServerError("Error retrieving tournament from db", err)
+	}
+
+	if tournament == nil {
+		return nil, APIErrorNotFound("Tournament")
+	}
+
+	return tournament, nil
+}
+
+// Parses & validates the details of a tournament that is being created or updated
+func parseTournamentBody(c *gin.Context, tournament *db.Tournament) *APIError {
+	body := tournamentBody{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		return APIErrorBadRequest("Invalid request body")
+	}
+
+	if len(body.Name) > 100 {
+		return APIErrorBadRequest("The name must be at most 100 characters.")
+	}
+
+	if body.Mode != enums.GameModeKeys4 && body.Mode != enums.GameModeKeys7 {
+		return APIErrorBadRequest("Invalid mode")
+	}
+
+	if body.BracketType != db.TournamentSingleElimination && body.BracketType != db.TournamentDoubleElimination {
+		return APIErrorBadRequest("Invalid bracket type")
+	}
+
+	if body.TeamSize < 1 || body.TeamSize > 8 {
+		return APIErrorBadRequest("The team size must be between 1 and 8.")
+	}
+
+	if body.RegistrationEndsAt <= body.RegistrationStartsAt {
+		return APIErrorBadRequest("Registration must end after it starts.")
+	}
+
+	if body.Status != "" {
+		statuses := []db.TournamentStatus{
+			db.TournamentStatusUpcoming,
+			db.TournamentStatusRegistration,
+			db.TournamentStatusQualifiers,
+			db.TournamentStatusBracket,
+			db.TournamentStatusCompleted,
+		}
+
+		if !slices.Contains(statuses, body.Status) {
+			return APIErrorBadRequest("Invalid status")
+		}
+
+		tournament.Status = body.Status
+	}
+
+	tournament.Name = body.Name
+	tournament.Description = body.Description
+	tournament.Mode = body.Mode
+	tournament.BracketType = body.BracketType
+	tournament.TeamSize = body.TeamSize
+	tournament.RegistrationStartsAt = body.RegistrationStartsAt
+	tournament.RegistrationEndsAt = body.RegistrationEndsAt
+
+	return nil
+}
+
+func validateMultiplayerGameExists(id int) *APIError {
+	game, err := db.GetMultiplayerGame(id)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return APIErrorServerError("Error retrieving multiplayer game", err)
+	}
+
+	if game == nil {
+		return APIErrorNotFound("Multiplayer Game")
+	}
+
+	return nil
+}
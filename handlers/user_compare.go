@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// The amount of best relative maps returned for each player
+const userComparisonBestRelativeLimit = 5
+
+// CompareUsers Compares the personal bests of two users on the maps they've both played
+// Endpoint: GET /v2/user/:id/compare/:other_id/:mode?page=
+func CompareUsers(c *gin.Context) *APIError {
+	query, apiErr := parseUserScoreParams(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	otherId, err := strconv.Atoi(c.Param("other_id"))
+
+	if err != nil {
+		return APIErrorBadRequest("Invalid other_id")
+	}
+
+	if otherId == query.Id {
+		return APIErrorBadRequest("You cannot compare a user with themselves.")
+	}
+
+	other, apiErr := getUserById(otherId, canAuthedUserViewBannedUsers(c))
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	summary, err := db.GetUserComparisonSummary(query.Id, otherId, query.Mode)
+
+	if err != nil {
+		return APIErrorServerError("Error getting user comparison summary", err)
+	}
+
+	const limit = 50
+
+	maps, err := db.GetUserComparisonMaps(query.Id, otherId, query.Mode, limit, query.Page)
+
+	if err != nil {
+		return APIErrorServerError("Error getting user comparison maps", err)
+	}
+
+	userBest, err := db.GetUserComparisonBestRelativeMaps(query.Id, otherId, query.Mode, userComparisonBestRelativeLimit)
+
+	if err != nil {
+		return APIErrorServerError("Error getting user best relative maps", err)
+	}
+
+	otherBest, err := db.GetUserComparisonBestRelativeMaps(otherId, query.Id, query.Mode, userComparisonBestRelativeLimit)
+
+	if err != nil {
+		return APIErrorServerError("Error getting other user best relative maps", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":                query.User,
+		"other_user":          other,
+		"summary":             summary,
+		"maps":                maps,
+		"user_best_relative":  userBest,
+		"other_best_relative": otherBest,
+		"total_pages":         (summary.SharedMaps + limit - 1) / limit,
+	})
+
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/Quaver/api2/db"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // GetUserRankStatisticsForMode Gets a user's rank statistics for a given game mode
@@ -24,3 +26,42 @@ func GetUserRankStatisticsForMode(c *gin.Context) *APIError {
 	c.JSON(http.StatusOK, gin.H{"ranks": ranks})
 	return nil
 }
+
+// GetUserRankHistoryForMode Gets a single metric of a user's rank statistics as a time series
+// Endpoint: GET /v2/user/:id/statistics/:mode/history?metric=&days=
+func GetUserRankHistoryForMode(c *gin.Context) *APIError {
+	query, apiErr := parseUserScoreParams(c)
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	metric := c.Query("metric")
+
+	if metric == "" {
+		metric = "rank"
+	}
+
+	if _, ok := db.UserRankHistoryMetrics[metric]; !ok {
+		return APIErrorBadRequest("Invalid metric")
+	}
+
+	since := time.Time{}
+
+	if days, err := strconv.Atoi(c.Query("days")); err == nil && days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+
+	points, err := db.GetUserRankHistory(query.Id, query.Mode, metric, since)
+
+	if err != nil {
+		return APIErrorServerError("Error getting rank history", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"metric": metric,
+		"points": points,
+	})
+
+	return nil
+}
@@ -1,35 +1,50 @@
 package middleware
 
 import (
-	"errors"
 	"fmt"
-	"github.com/Quaver/api2/config"
 	"github.com/Quaver/api2/db"
 	"github.com/Quaver/api2/handlers"
+	"github.com/Quaver/api2/oauth"
+	"github.com/Quaver/api2/sessions"
+	"github.com/Quaver/api2/stringutil"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"net/http"
 	"strconv"
 	"strings"
 )
 
-type JWTClaims struct {
-	UserId   int    `json:"user_id"`
-	Username string `json:"username"`
-	jwt.RegisteredClaims
-}
-
 const (
 	messageNoHeader       = "You must provide a valid `Authorization` or `auth` header."
 	messageNoAuthOrSecret = "You must provide a valid `Authorization`, `auth`, or `client_secret`."
+	messageTokenForbidden = "This endpoint can only be accessed when logged in, not with an OAuth or personal access token."
+)
+
+const (
+	// The context key of the OAuth or personal access token that a request was authenticated with
+	scopedTokenKey = "scoped_token"
+
+	// The context key of the session that a request's JWT belongs to
+	sessionIdKey = "session_id"
 )
 
-// RequireAuth Middleware authentication function
+// A token that only grants access to the endpoints of its scopes
+type scopedToken interface {
+	HasScope(scope db.OAuthScope) bool
+}
+
+// RequireAuth Middleware authentication function. OAuth and personal access tokens are rejected,
+// so they can't be used to manage the account's tokens, applications, sessions or payments.
+// Every other endpoint should use RequireScope, so tokens can access it.
 func RequireAuth(c *gin.Context) {
 	user, apiErr := authenticateUser(c)
 
+	if apiErr == nil && isScopedTokenRequest(c) {
+		apiErr = handlers.APIErrorForbidden(messageTokenForbidden)
+	}
+
 	if apiErr != nil {
 		handlers.CreateHandler(func(ctx *gin.Context) *handlers.APIError {
 			return apiErr
@@ -43,10 +58,39 @@ func RequireAuth(c *gin.Context) {
 	c.Next()
 }
 
+// RequireScope Requires user authentication. Requests authenticated with an OAuth or personal access token
+// must have been granted every one of the scopes.
+func RequireScope(scopes ...db.OAuthScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, apiErr := authenticateUser(c)
+
+		if apiErr == nil {
+			apiErr = checkTokenScopes(c, scopes)
+		}
+
+		if apiErr != nil {
+			handlers.CreateHandler(func(ctx *gin.Context) *handlers.APIError {
+				return apiErr
+			})(c)
+
+			c.Abort()
+			return
+		}
+
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
 // RequireAuthOrClientSecret requires either user authentication or a valid application client secret.
+// Requests with an OAuth or personal access token must have been granted the download scope.
 func RequireAuthOrClientSecret(c *gin.Context) {
 	user, authErr := authenticateUser(c)
 
+	if authErr == nil {
+		authErr = checkTokenScopes(c, []db.OAuthScope{db.OAuthScopeDownload})
+	}
+
 	if authErr == nil {
 		c.Set("user", user)
 		c.Next()
@@ -76,10 +120,27 @@ func RequireAuthOrClientSecret(c *gin.Context) {
 }
 
 // AllowAuth Allows user authentication but does not require it. This middleware fails
-// in the event that the user passes in an invalid token OR some other error
+// in the event that the user passes in an invalid token OR some other error.
+// Requests with an OAuth or personal access token are treated as unauthenticated.
 func AllowAuth(c *gin.Context) {
+	allowAuth(c, nil, false)
+}
+
+// AllowScope Allows user authentication but does not require it, like AllowAuth.
+// Requests with an OAuth or personal access token are only authenticated if it has been granted every one of the scopes.
+func AllowScope(scopes ...db.OAuthScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowAuth(c, scopes, true)
+	}
+}
+
+func allowAuth(c *gin.Context, scopes []db.OAuthScope, allowScopedTokens bool) {
 	user, apiErr := authenticateUser(c)
 
+	if apiErr == nil && isScopedTokenRequest(c) && (!allowScopedTokens || checkTokenScopes(c, scopes) != nil) {
+		user = nil
+	}
+
 	if apiErr != nil && apiErr.Error != gorm.ErrRecordNotFound && apiErr.Message != messageNoHeader {
 		handlers.CreateHandler(func(ctx *gin.Context) *handlers.APIError {
 			return apiErr
@@ -104,15 +165,21 @@ func authenticateUser(c *gin.Context) (*db.User, *handlers.APIError) {
 	var user *db.User
 	var err error
 
-	if authorizationHeader != "" {
-		user, err = authenticateJWT(authorizationHeader)
+	bearerToken := getBearerToken(authorizationHeader)
+
+	if oauth.IsAccessToken(bearerToken) {
+		user, err = authenticateOAuth(c, bearerToken)
+	} else if db.IsPersonalAccessToken(bearerToken) {
+		user, err = authenticatePersonalAccessToken(c, bearerToken)
+	} else if authorizationHeader != "" {
+		user, err = authenticateJWT(c, authorizationHeader)
 	} else if inGameToken != "" {
 		user, err = authenticateInGame(c, inGameToken)
 	} else {
 		return nil, &handlers.APIError{Status: http.StatusUnauthorized, Message: messageNoHeader}
 	}
 
-	if err != nil && err != gorm.ErrRecordNotFound && !strings.Contains(err.Error(), jwt.ErrTokenSignatureInvalid.Error()) {
+	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, handlers.APIErrorServerError("Error occurred while authenticating user", err)
 	}
 
@@ -159,37 +226,117 @@ func AuthenticateInGameRequest(c *gin.Context) (*db.User, error) {
 	return authenticateInGame(c, token)
 }
 
-// Authenticates a user by their JWT token.
-// Header - {Authorization: 'Bearer Token`}
-func authenticateJWT(header string) (*db.User, error) {
+// Returns the token of an Authorization header
+func getBearerToken(header string) string {
 	header = strings.Replace(header, "Bearer", "", -1)
-	header = strings.TrimSpace(header)
+	return strings.TrimSpace(header)
+}
+
+// Authenticates a user by an OAuth access token, which is stored in the context so its scopes can be checked.
+// Header - {Authorization: 'Bearer Token`}
+func authenticateOAuth(c *gin.Context, accessToken string) (*db.User, error) {
+	token, err := oauth.AuthenticateAccessToken(accessToken)
+
+	if err != nil || token == nil {
+		return nil, err
+	}
+
+	user, err := db.GetUserById(token.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Allowed {
+		return nil, nil
+	}
+
+	c.Set(scopedTokenKey, token)
+	return user, nil
+}
+
+// Authenticates a user by a personal access token, which is stored in the context so its scopes can be checked.
+// Header - {Authorization: 'Bearer Token`}
+func authenticatePersonalAccessToken(c *gin.Context, value string) (*db.User, error) {
+	token, err := db.GetPersonalAccessToken(stringutil.HashToken(value))
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	user, err := db.GetUserById(token.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Allowed {
+		return nil, nil
+	}
+
+	if err := token.MarkUsed(); err != nil {
+		logrus.Error("Error updating personal access token last used time: ", err)
+	}
+
+	c.Set(scopedTokenKey, token)
+	return user, nil
+}
+
+// Returns if the request was authenticated with an OAuth or personal access token
+func isScopedTokenRequest(c *gin.Context) bool {
+	_, exists := c.Get(scopedTokenKey)
+	return exists
+}
+
+// Checks that the token the request was authenticated with has been granted every one of the scopes
+func checkTokenScopes(c *gin.Context, scopes []db.OAuthScope) *handlers.APIError {
+	value, exists := c.Get(scopedTokenKey)
+
+	if !exists {
+		return nil
+	}
+
+	token := value.(scopedToken)
+
+	for _, scope := range scopes {
+		if !token.HasScope(scope) {
+			return handlers.APIErrorForbidden(fmt.Sprintf("Your token is missing the `%v` scope.", scope))
+		}
+	}
+
+	return nil
+}
+
+// Authenticates a user by their JWT token. Tokens that belong to a session are rejected once their id (jti) is revoked,
+// and every token issued before the user logged out everywhere is rejected.
+// Header - {Authorization: 'Bearer Token`}
+func authenticateJWT(c *gin.Context, header string) (*db.User, error) {
+	header = getBearerToken(header)
 
 	if header == "" {
 		return nil, nil
 	}
 
-	jwtToken, err := jwt.ParseWithClaims(header, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.Instance.JWTSecret), nil
-	})
+	claims, err := sessions.ParseAccessToken(header)
 
-	switch err {
-	case nil:
-		break
-	// Return without any error, which will give them the message that they can't access resource.
-	case jwt.ErrSignatureInvalid:
-	case jwt.ErrTokenSignatureInvalid:
-	case jwt.ErrTokenExpired:
+	if err != nil {
 		return nil, nil
-	// Any other internal errors which should be logged up the call stack.
-	default:
-		return nil, err
 	}
 
-	claims, ok := jwtToken.Claims.(*JWTClaims)
+	if !claims.IsLegacy() {
+		revoked, err := sessions.IsAccessTokenRevoked(claims.ID)
+
+		if err != nil {
+			return nil, err
+		}
 
-	if !ok {
-		return nil, errors.New("unknown claims type, cannot proceed")
+		if revoked {
+			return nil, nil
+		}
 	}
 
 	user, err := db.GetUserById(claims.UserId)
@@ -198,10 +345,14 @@ func authenticateJWT(header string) (*db.User, error) {
 		return nil, err
 	}
 
-	if !user.Allowed {
+	if !user.Allowed || sessions.IsIssuedBeforeRevocation(user, claims) {
 		return nil, nil
 	}
 
+	if !claims.IsLegacy() {
+		c.Set(sessionIdKey, claims.SessionId)
+	}
+
 	return user, nil
 }
 
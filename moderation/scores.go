@@ -0,0 +1,236 @@
+package moderation
+
+import (
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A score that was deleted or restored, and needs everything derived from it recalculated
+type affectedScore struct {
+	ScoreId int
+	UserId  int
+	MapMD5  string
+	Mode    enums.GameMode
+	ClanId  *int
+}
+
+type userMap struct {
+	UserId int
+	MapMD5 string
+}
+
+type userMode struct {
+	UserId int
+	Mode   enums.GameMode
+}
+
+type clanMap struct {
+	ClanId int
+	MapMD5 string
+	Mode   enums.GameMode
+}
+
+// DeleteScores Deletes scores, logs the action for each user and recalculates everything the scores were a part of
+func DeleteScores(author *db.User, scores []*db.Score, notes string) ([]*db.ScoreDeletion, error) {
+	deletions, err := db.DeleteScores(scores, author.Id, notes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	affected := make([]*affectedScore, 0, len(deletions))
+
+	for _, deletion := range deletions {
+		affected = append(affected, newAffectedScore(deletion))
+	}
+
+	if err := logAction(author, db.AdminActionScoresDeleted, "Deleted", affected, notes); err != nil {
+		return nil, err
+	}
+
+	if err := recalculate(affected, db.ScoreboardInvalidationScoreDeleted); err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+// RestoreScores Restores deleted scores, logs the action for each user and recalculates everything the scores are a part of
+func RestoreScores(author *db.User, deletions []*db.ScoreDeletion, notes string) error {
+	if err := db.RestoreScoreDeletions(deletions, author.Id); err != nil {
+		return err
+	}
+
+	affected := make([]*affectedScore, 0, len(deletions))
+
+	for _, deletion := range deletions {
+		affected = append(affected, newAffectedScore(deletion))
+	}
+
+	if err := logAction(author, db.AdminActionScoresRestored, "Restored", affected, notes); err != nil {
+		return err
+	}
+
+	return recalculate(affected, db.ScoreboardInvalidationScoreRestored)
+}
+
+func newAffectedScore(deletion *db.ScoreDeletion) *affectedScore {
+	return &affectedScore{
+		ScoreId: deletion.ScoreId,
+		UserId:  deletion.UserId,
+		MapMD5:  deletion.MapMD5,
+		Mode:    deletion.Mode,
+		ClanId:  deletion.ClanId,
+	}
+}
+
+// Inserts an admin action log for each user whose scores were affected
+func logAction(author *db.User, action db.AdminActionLogType, verb string, affected []*affectedScore, notes string) error {
+	scoreIds := map[int][]string{}
+	userIds := make([]int, 0)
+
+	for _, score := range affected {
+		if _, ok := scoreIds[score.UserId]; !ok {
+			userIds = append(userIds, score.UserId)
+		}
+
+		scoreIds[score.UserId] = append(scoreIds[score.UserId], fmt.Sprintf("#%v", score.ScoreId))
+	}
+
+	for _, userId := range userIds {
+		user, err := db.GetUserById(userId)
+
+		if err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("%v %v score(s): %v", verb, len(scoreIds[userId]), strings.Join(scoreIds[userId], ", "))
+
+		if notes != "" {
+			message += fmt.Sprintf(" (%v)", notes)
+		}
+
+		log := db.AdminActionLog{
+			AuthorId:       author.Id,
+			AuthorUsername: author.Username,
+			TargetId:       user.Id,
+			TargetUsername: user.Username,
+			Action:         action,
+			Notes:          message,
+			Timestamp:      time.Now().UnixMilli(),
+		}
+
+		if err := log.Insert(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Recalculates the personal bests, first places, clan scores, user stats & leaderboards of the affected scores
+func recalculate(affected []*affectedScore, reason db.ScoreboardInvalidationReason) error {
+	userMaps := map[userMap]bool{}
+	userModes := map[userMode]bool{}
+	clanMaps := map[clanMap]bool{}
+	md5s := make([]string, 0)
+
+	for _, score := range affected {
+		userMaps[userMap{score.UserId, score.MapMD5}] = true
+		userModes[userMode{score.UserId, score.Mode}] = true
+
+		if !slices.Contains(md5s, score.MapMD5) {
+			md5s = append(md5s, score.MapMD5)
+		}
+
+		if score.ClanId != nil {
+			clanMaps[clanMap{*score.ClanId, score.MapMD5, score.Mode}] = true
+		}
+	}
+
+	for um := range userMaps {
+		if err := db.RecalculatePersonalBest(um.UserId, um.MapMD5); err != nil {
+			return err
+		}
+	}
+
+	for _, md5 := range md5s {
+		if err := db.RecalculateFirstPlace(md5); err != nil {
+			return err
+		}
+	}
+
+	if err := recalculateClans(clanMaps); err != nil {
+		return err
+	}
+
+	for um := range userModes {
+		user, err := db.GetUserById(um.UserId)
+
+		if err != nil {
+			return err
+		}
+
+		if err := db.RecalculateUserStatsRatings(user, um.Mode); err != nil {
+			return err
+		}
+
+		if !user.Allowed || user.ShadowBanned {
+			continue
+		}
+
+		for _, leaderboard := range db.GetEnabledModLeaderboards() {
+			if err := leaderboard.UpdateUser(user.Id, um.Mode); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := db.InvalidateScoreboards(reason, md5s...); err != nil {
+		logrus.Error("Error invalidating scoreboards after score moderation: ", err)
+	}
+
+	return nil
+}
+
+// Recalculates the clan scores on each map, followed by the stats & leaderboards of the clans
+func recalculateClans(clanMaps map[clanMap]bool) error {
+	clanModes := map[clanMap]bool{}
+
+	for cm := range clanMaps {
+		if err := db.RecalculateClanScore(cm.MapMD5, cm.ClanId, cm.Mode); err != nil {
+			return err
+		}
+
+		clanModes[clanMap{ClanId: cm.ClanId, Mode: cm.Mode}] = true
+	}
+
+	for cm := range clanModes {
+		clan, err := db.GetClanById(cm.ClanId)
+
+		if err != nil {
+			// The clan has been deleted since the score was set
+			if err == gorm.ErrRecordNotFound {
+				continue
+			}
+
+			return err
+		}
+
+		if err := db.RecalculateClanStats(clan.Id, cm.Mode); err != nil {
+			return err
+		}
+
+		if err := db.UpdateClanLeaderboard(clan, cm.Mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package multiplayer
+
+import "math"
+
+const (
+	DefaultRating     = 1500.0
+	DefaultDeviation  = 350.0
+	DefaultVolatility = 0.06
+
+	// Constrains how much the volatility of a rating can change between matches
+	tau = 0.5
+
+	// Converts ratings from the Glicko scale to the Glicko-2 scale
+	glicko2Scale = 173.7178
+
+	volatilityTolerance = 0.000001
+)
+
+// Rating A player's Glicko-2 rating
+type Rating struct {
+	Rating     float64
+	Deviation  float64
+	Volatility float64
+}
+
+// Result The outcome of a game against a single opponent.
+// Score is 1 for a win, 0.5 for a draw and 0 for a loss.
+// Weight scales how much the game counts, so a match against many opponents counts roughly the same as a 1v1.
+type Result struct {
+	Opponent Rating
+	Score    float64
+	Weight   float64
+}
+
+// NewRating Returns the rating of a player who hasn't played yet
+func NewRating() Rating {
+	return Rating{
+		Rating:     DefaultRating,
+		Deviation:  DefaultDeviation,
+		Volatility: DefaultVolatility,
+	}
+}
+
+// Update Returns the rating after playing games with the given results, treating them as a single rating period
+func (r Rating) Update(results []Result) Rating {
+	if len(results) == 0 {
+		return r
+	}
+
+	mu := (r.Rating - DefaultRating) / glicko2Scale
+	phi := r.Deviation / glicko2Scale
+
+	vInverse := 0.0
+	improvement := 0.0
+
+	for _, result := range results {
+		opponentMu := (result.Opponent.Rating - DefaultRating) / glicko2Scale
+		g := glicko2G(result.Opponent.Deviation / glicko2Scale)
+		e := glicko2E(mu, opponentMu, g)
+
+		vInverse += result.Weight * g * g * e * (1 - e)
+		improvement += result.Weight * g * (result.Score - e)
+	}
+
+	if vInverse == 0 {
+		return r
+	}
+
+	v := 1 / vInverse
+	delta := v * improvement
+	sigma := newVolatility(phi, r.Volatility, v, delta)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*improvement
+
+	return Rating{
+		Rating:     newMu*glicko2Scale + DefaultRating,
+		Deviation:  math.Min(newPhi*glicko2Scale, DefaultDeviation),
+		Volatility: sigma,
+	}
+}
+
+func glicko2G(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func glicko2E(mu float64, opponentMu float64, g float64) float64 {
+	return 1 / (1 + math.Exp(-g*(mu-opponentMu)))
+}
+
+// Finds the new volatility using the Illinois algorithm, as described in step 5 of the Glicko-2 paper
+func newVolatility(phi float64, sigma float64, v float64, delta float64) float64 {
+	alpha := math.Log(sigma * sigma)
+
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-alpha)/(tau*tau)
+	}
+
+	a := alpha
+	var b float64
+
+	if delta*delta > phi*phi+v {
+		b = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+
+		for f(alpha-k*tau) < 0 {
+			k++
+		}
+
+		b = alpha - k*tau
+	}
+
+	fA, fB := f(a), f(b)
+
+	for math.Abs(b-a) > volatilityTolerance {
+		c := a + (a-b)*fA/(fB-fA)
+		fc := f(c)
+
+		if fc*fB <= 0 {
+			a, fA = b, fB
+		} else {
+			fA /= 2
+		}
+
+		b, fB = c, fc
+	}
+
+	return math.Exp(a / 2)
+}
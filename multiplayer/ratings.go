@@ -0,0 +1,266 @@
+package multiplayer
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"time"
+)
+
+const (
+	// The amount of matches that are loaded at a time when rating
+	ratingBatchSize = 500
+
+	// How long to wait after a match has been played before rating it, so its scores have finished being inserted
+	ratingDelay = 5 * time.Minute
+)
+
+// RateNewMatches Updates ratings with every match that has been played since the last processed match.
+// Returns the amount of matches that were rated.
+func RateNewMatches() (int, error) {
+	afterId, err := db.GetLastProcessedMultiplayerMatchId()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return rateMatchesAfter(afterId)
+}
+
+// Backfill Deletes every multiplayer rating and rates all matches again from the start
+func Backfill() (int, error) {
+	if err := db.ResetMultiplayerRatings(); err != nil {
+		return 0, err
+	}
+
+	return rateMatchesAfter(0)
+}
+
+func rateMatchesAfter(afterId int) (int, error) {
+	cutoff := time.Now().Add(-ratingDelay)
+	rated := 0
+
+	for {
+		matches, err := db.GetMultiplayerMatchesForRating(afterId, ratingBatchSize)
+
+		if err != nil {
+			return rated, err
+		}
+
+		if len(matches) == 0 {
+			return rated, nil
+		}
+
+		for _, match := range matches {
+			// Matches are processed in order, so stop at the first one that may still be having scores inserted
+			if match.TimePlayed >= cutoff.UnixMilli() {
+				return rated, nil
+			}
+
+			ok, err := RateMatch(match)
+
+			if err != nil {
+				return rated, err
+			}
+
+			if ok {
+				rated++
+			} else if err := db.SetLastProcessedMultiplayerMatchId(match.Id); err != nil {
+				return rated, err
+			}
+
+			afterId = match.Id
+		}
+	}
+}
+
+// RateMatch Updates the ratings of the players of a match. The scores of the match must be loaded.
+// Returns false if the match couldn't be rated, such as when there was only one player.
+func RateMatch(match *db.MultiplayerGameMatches) (bool, error) {
+	scores := getUniqueScores(match.Scores)
+
+	if len(scores) < 2 {
+		return false, nil
+	}
+
+	userIds := make([]int, 0, len(scores))
+
+	for _, score := range scores {
+		userIds = append(userIds, score.UserId)
+	}
+
+	existing, err := db.GetMultiplayerRatings(userIds, match.GameMode)
+
+	if err != nil {
+		return false, err
+	}
+
+	ratings := make(map[int]Rating, len(scores))
+
+	for _, score := range scores {
+		if rating, ok := existing[score.UserId]; ok {
+			ratings[score.UserId] = Rating{rating.Rating, rating.Deviation, rating.Volatility}
+		} else {
+			ratings[score.UserId] = NewRating()
+		}
+	}
+
+	results := getMatchResults(enums.MultiplayerRuleset(match.Ruleset), scores, ratings)
+
+	if len(results) == 0 {
+		return false, nil
+	}
+
+	updated := make([]*db.MultiplayerRating, 0, len(results))
+	history := make([]*db.MultiplayerRatingHistory, 0, len(results))
+
+	for _, score := range scores {
+		userResults, ok := results[score.UserId]
+
+		if !ok {
+			continue
+		}
+
+		previous := ratings[score.UserId]
+		next := previous.Update(userResults)
+
+		rating, ok := existing[score.UserId]
+
+		if !ok {
+			rating = &db.MultiplayerRating{UserId: score.UserId, Mode: match.GameMode}
+		}
+
+		rating.Rating = next.Rating
+		rating.Deviation = next.Deviation
+		rating.Volatility = next.Volatility
+		rating.MatchesPlayed++
+		rating.UpdatedAt = match.TimePlayed
+
+		if score.Won {
+			rating.Wins++
+		}
+
+		updated = append(updated, rating)
+
+		history = append(history, &db.MultiplayerRatingHistory{
+			UserId:    score.UserId,
+			Mode:      match.GameMode,
+			MatchId:   match.Id,
+			Rating:    next.Rating,
+			Deviation: next.Deviation,
+			Change:    next.Rating - previous.Rating,
+			Timestamp: match.TimePlayed,
+		})
+	}
+
+	if err := db.SaveMultiplayerMatchRatings(match.Id, updated, history); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Returns the results of each player against their opponents, keyed by user id.
+// Every opponent is weighted equally, so that a whole match counts as much as a single game.
+func getMatchResults(ruleset enums.MultiplayerRuleset, scores []*db.MultiplayerMatchScore, ratings map[int]Rating) map[int][]Result {
+	results := make(map[int][]Result, len(scores))
+
+	for _, score := range scores {
+		playerResults := make([]Result, 0, len(scores)-1)
+
+		for _, opponent := range scores {
+			if opponent == score {
+				continue
+			}
+
+			outcome, ok := getOutcome(ruleset, score, opponent)
+
+			if !ok {
+				continue
+			}
+
+			playerResults = append(playerResults, Result{
+				Opponent: ratings[opponent.UserId],
+				Score:    outcome,
+			})
+		}
+
+		if len(playerResults) == 0 {
+			continue
+		}
+
+		for i := range playerResults {
+			playerResults[i].Weight = 1 / float64(len(playerResults))
+		}
+
+		results[score.UserId] = playerResults
+	}
+
+	return results
+}
+
+// Returns the outcome of a player against an opponent, or false if they weren't playing against each other
+func getOutcome(ruleset enums.MultiplayerRuleset, score *db.MultiplayerMatchScore, opponent *db.MultiplayerMatchScore) (float64, bool) {
+	switch ruleset {
+	case enums.MultiplayerRulesetTeam:
+		// Players on the same team aren't opponents, and the winning team beats every player on the other team
+		if score.Team == opponent.Team {
+			return 0, false
+		}
+
+		return compareOutcome(boolToInt(score.Won), boolToInt(opponent.Won)), true
+	case enums.MultiplayerRulesetBattleRoyale:
+		// A lower place is better. Players without a place were still alive at the end.
+		return compareOutcome(getBattleRoyaleRank(opponent), getBattleRoyaleRank(score)), true
+	default:
+		// The winner beats everyone else, and the other players are placed by their score
+		if score.Won != opponent.Won {
+			return compareOutcome(boolToInt(score.Won), boolToInt(opponent.Won)), true
+		}
+
+		return compareOutcome(score.Score, opponent.Score), true
+	}
+}
+
+func compareOutcome(a int, b int) float64 {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return 0
+	default:
+		return 0.5
+	}
+}
+
+func getBattleRoyaleRank(score *db.MultiplayerMatchScore) int {
+	if score.BattleRoyalePlace <= 0 {
+		return 0
+	}
+
+	return score.BattleRoyalePlace
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// Returns the scores of a match, ignoring any duplicate scores by the same user
+func getUniqueScores(scores []*db.MultiplayerMatchScore) []*db.MultiplayerMatchScore {
+	seen := map[int]bool{}
+	unique := make([]*db.MultiplayerMatchScore, 0, len(scores))
+
+	for _, score := range scores {
+		if seen[score.UserId] {
+			continue
+		}
+
+		seen[score.UserId] = true
+		unique = append(unique, score)
+	}
+
+	return unique
+}
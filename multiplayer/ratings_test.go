@@ -0,0 +1,86 @@
+package multiplayer
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"math"
+	"testing"
+)
+
+func TestRatingUpdate(t *testing.T) {
+	// The example from the Glicko-2 paper
+	rating := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+
+	updated := rating.Update([]Result{
+		{Opponent: Rating{Rating: 1400, Deviation: 30}, Score: 1, Weight: 1},
+		{Opponent: Rating{Rating: 1550, Deviation: 100}, Score: 0, Weight: 1},
+		{Opponent: Rating{Rating: 1700, Deviation: 300}, Score: 0, Weight: 1},
+	})
+
+	if math.Abs(updated.Rating-1464.06) > 0.01 {
+		t.Fatalf("expected rating 1464.06, got %v", updated.Rating)
+	}
+
+	if math.Abs(updated.Deviation-151.52) > 0.01 {
+		t.Fatalf("expected deviation 151.52, got %v", updated.Deviation)
+	}
+
+	if math.Abs(updated.Volatility-0.05999) > 0.00001 {
+		t.Fatalf("expected volatility 0.05999, got %v", updated.Volatility)
+	}
+}
+
+func TestGetMatchResults(t *testing.T) {
+	ratings := map[int]Rating{1: NewRating(), 2: NewRating(), 3: NewRating(), 4: NewRating()}
+
+	team := []*db.MultiplayerMatchScore{
+		{UserId: 1, Team: int8(enums.MultiplayerTeamRed), Won: true},
+		{UserId: 2, Team: int8(enums.MultiplayerTeamRed), Won: true},
+		{UserId: 3, Team: int8(enums.MultiplayerTeamBlue)},
+		{UserId: 4, Team: int8(enums.MultiplayerTeamBlue)},
+	}
+
+	results := getMatchResults(enums.MultiplayerRulesetTeam, team, ratings)
+
+	if len(results[1]) != 2 || results[1][0].Score != 1 || results[1][0].Weight != 0.5 {
+		t.Fatalf("expected red players to beat both blue players, got %+v", results[1])
+	}
+
+	if len(results[3]) != 2 || results[3][0].Score != 0 {
+		t.Fatalf("expected blue players to lose to both red players, got %+v", results[3])
+	}
+
+	battleRoyale := []*db.MultiplayerMatchScore{
+		{UserId: 1, BattleRoyalePlace: 3},
+		{UserId: 2, BattleRoyalePlace: 2},
+		{UserId: 3, BattleRoyalePlace: 1},
+	}
+
+	results = getMatchResults(enums.MultiplayerRulesetBattleRoyale, battleRoyale, ratings)
+
+	if getTotalScore(results[3]) != 2 || getTotalScore(results[2]) != 1 || getTotalScore(results[1]) != 0 {
+		t.Fatalf("expected players to be ranked by place, got %+v", results)
+	}
+
+	freeForAll := []*db.MultiplayerMatchScore{
+		{UserId: 1, Score: 900000},
+		{UserId: 2, Score: 800000, Won: true},
+		{UserId: 3, Score: 900000},
+	}
+
+	results = getMatchResults(enums.MultiplayerRulesetFreeForAll, freeForAll, ratings)
+
+	if getTotalScore(results[2]) != 2 || getTotalScore(results[1]) != 0.5 || getTotalScore(results[3]) != 0.5 {
+		t.Fatalf("expected the winner to beat everyone and tied scores to draw, got %+v", results)
+	}
+}
+
+func getTotalScore(results []Result) float64 {
+	total := 0.0
+
+	for _, result := range results {
+		total += result.Score
+	}
+
+	return total
+}
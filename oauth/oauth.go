@@ -0,0 +1,233 @@
+package oauth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/stringutil"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const (
+	AccessTokenPrefix  = "qoa_"
+	RefreshTokenPrefix = "qor_"
+
+	CodeChallengeMethodS256 = "S256"
+
+	codeLifetime         = 10 * time.Minute
+	accessTokenLifetime  = time.Hour
+	refreshTokenLifetime = 30 * 24 * time.Hour
+)
+
+var ErrInvalidGrant = errors.New("the authorization code or refresh token is invalid, expired, or was issued to another client")
+
+// TokenResponse The tokens that are given to an application, in the format described by RFC 6749
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// IsAccessToken Returns if a bearer token is an OAuth access token, rather than a JWT
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// AuthenticateClient Returns if the secret a client provided matches its application.
+// Only applications registered as public clients, which are secured by PKCE alone, can leave out their secret.
+func AuthenticateClient(app *db.Application, secret string) bool {
+	if secret == "" {
+		return app.PublicClient
+	}
+
+	if app.ClientSecret == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(app.ClientSecret), []byte(secret)) == 1
+}
+
+// CreateAuthorizationCode Creates a code that the application can exchange for tokens with the verifier of the code challenge
+func CreateAuthorizationCode(app *db.Application, userId int, redirectURL string, scopes []db.OAuthScope, codeChallenge string) (string, error) {
+	if err := db.DeleteExpiredOAuthAuthorizationCodes(); err != nil {
+		logrus.Error("Error deleting expired oauth authorization codes: ", err)
+	}
+
+	code, err := stringutil.GenerateToken(32)
+
+	if err != nil {
+		return "", err
+	}
+
+	authorizationCode := &db.OAuthAuthorizationCode{
+		CodeHash:            stringutil.HashToken(code),
+		ApplicationId:       app.Id,
+		UserId:              userId,
+		RedirectURL:         redirectURL,
+		ScopeList:           scopes,
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: CodeChallengeMethodS256,
+		ExpiresAt:           time.Now().Add(codeLifetime).UnixMilli(),
+	}
+
+	if err := authorizationCode.Insert(); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// ExchangeAuthorizationCode Exchanges an authorization code for an access & refresh token
+func ExchangeAuthorizationCode(app *db.Application, code string, redirectURL string, codeVerifier string) (*TokenResponse, error) {
+	authorizationCode, err := db.ConsumeOAuthAuthorizationCode(stringutil.HashToken(code))
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidGrant
+		}
+
+		return nil, err
+	}
+
+	if authorizationCode.ApplicationId != app.Id ||
+		authorizationCode.RedirectURL != redirectURL ||
+		authorizationCode.ExpiresAt < time.Now().UnixMilli() ||
+		!VerifyCodeChallenge(codeVerifier, authorizationCode.CodeChallenge) {
+		return nil, ErrInvalidGrant
+	}
+
+	token, response, err := newToken(app.Id, authorizationCode.UserId, authorizationCode.ScopeList)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := token.Insert(); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// RefreshAccessToken Exchanges a refresh token for a new access & refresh token, revoking the previous ones.
+// If a refresh token is used after it has been replaced, every token of the user is revoked, as it may have been stolen.
+func RefreshAccessToken(app *db.Application, refreshToken string) (*TokenResponse, error) {
+	previous, err := db.GetOAuthTokenByRefreshToken(stringutil.HashToken(refreshToken))
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidGrant
+		}
+
+		return nil, err
+	}
+
+	if previous.ApplicationId != app.Id || previous.RefreshTokenExpiresAt < time.Now().UnixMilli() {
+		return nil, ErrInvalidGrant
+	}
+
+	if previous.RevokedAt != nil {
+		if err := db.RevokeUserOAuthTokens(previous.UserId, app.Id); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrInvalidGrant
+	}
+
+	token, response, err := newToken(app.Id, previous.UserId, previous.ScopeList)
+
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed, err := db.RefreshOAuthToken(previous, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !refreshed {
+		return nil, ErrInvalidGrant
+	}
+
+	return response, nil
+}
+
+// RevokeToken Revokes an access or refresh token that was issued to an application.
+// Tokens that don't exist or belong to another application are ignored, as described by RFC 7009.
+func RevokeToken(app *db.Application, value string) error {
+	token, err := db.GetOAuthTokenByAnyToken(stringutil.HashToken(value))
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	if token.ApplicationId != app.Id || token.RevokedAt != nil {
+		return nil
+	}
+
+	return token.Revoke()
+}
+
+// AuthenticateAccessToken Retrieves the token of an access token. Returns nil if it's invalid, expired or revoked.
+func AuthenticateAccessToken(accessToken string) (*db.OAuthToken, error) {
+	token, err := db.GetOAuthTokenByAccessToken(stringutil.HashToken(accessToken))
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Generates a new access & refresh token for a user
+func newToken(applicationId int, userId int, scopes []db.OAuthScope) (*db.OAuthToken, *TokenResponse, error) {
+	accessToken, err := stringutil.GenerateToken(32)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := stringutil.GenerateToken(32)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessToken = AccessTokenPrefix + accessToken
+	refreshToken = RefreshTokenPrefix + refreshToken
+	now := time.Now()
+
+	token := &db.OAuthToken{
+		ApplicationId:         applicationId,
+		UserId:                userId,
+		AccessTokenHash:       stringutil.HashToken(accessToken),
+		RefreshTokenHash:      stringutil.HashToken(refreshToken),
+		ScopeList:             scopes,
+		AccessTokenExpiresAt:  now.Add(accessTokenLifetime).UnixMilli(),
+		RefreshTokenExpiresAt: now.Add(refreshTokenLifetime).UnixMilli(),
+	}
+
+	response := &TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTokenLifetime.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        db.FormatOAuthScopes(scopes),
+	}
+
+	return token, response, nil
+}
@@ -0,0 +1,32 @@
+package oauth
+
+import (
+	"github.com/Quaver/api2/db"
+	"testing"
+)
+
+func TestAuthenticateClient(t *testing.T) {
+	app := &db.Application{ClientSecret: "secret"}
+
+	if AuthenticateClient(app, "") {
+		t.Fatalf("expected a confidential client without its secret to be rejected")
+	}
+
+	if AuthenticateClient(app, "wrong") {
+		t.Fatalf("expected a confidential client with the wrong secret to be rejected")
+	}
+
+	if !AuthenticateClient(app, "secret") {
+		t.Fatalf("expected a confidential client with its secret to be accepted")
+	}
+
+	app.PublicClient = true
+
+	if !AuthenticateClient(app, "") {
+		t.Fatalf("expected a public client without a secret to be accepted")
+	}
+
+	if AuthenticateClient(app, "wrong") {
+		t.Fatalf("expected a public client with the wrong secret to be rejected")
+	}
+}
@@ -0,0 +1,28 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// Code verifiers & challenges are 43-128 unreserved characters, as described by RFC 7636
+var pkceRegex = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// IsValidCodeChallenge Returns if a code challenge is in a valid format
+func IsValidCodeChallenge(challenge string) bool {
+	return pkceRegex.MatchString(challenge)
+}
+
+// VerifyCodeChallenge Returns if a code verifier matches an S256 code challenge
+func VerifyCodeChallenge(verifier string, challenge string) bool {
+	if !pkceRegex.MatchString(verifier) {
+		return false
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(hash[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
@@ -0,0 +1,25 @@
+package oauth
+
+import "testing"
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// The example from appendix B of RFC 7636
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if !IsValidCodeChallenge(challenge) {
+		t.Fatalf("expected the code challenge to be valid")
+	}
+
+	if !VerifyCodeChallenge(verifier, challenge) {
+		t.Fatalf("expected the code verifier to match the challenge")
+	}
+
+	if VerifyCodeChallenge(verifier[:len(verifier)-1]+"a", challenge) {
+		t.Fatalf("expected a different code verifier not to match the challenge")
+	}
+
+	if VerifyCodeChallenge("too-short", challenge) {
+		t.Fatalf("expected a code verifier that is too short to be rejected")
+	}
+}
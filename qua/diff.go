@@ -0,0 +1,261 @@
+package qua
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// The maximum distance in milliseconds that an object can be moved in the same lane
+// for it to count as a moved object instead of a removed one and an added one.
+const diffMoveToleranceMs int = 100
+
+type DiffHitObject struct {
+	StartTime int `json:"start_time"`
+	Lane      int `json:"lane"`
+	EndTime   int `json:"end_time"`
+}
+
+type MovedHitObject struct {
+	From DiffHitObject `json:"from"`
+	To   DiffHitObject `json:"to"`
+}
+
+type DiffTimingPoint struct {
+	StartTime     float32 `json:"start_time"`
+	BPM           float32 `json:"bpm"`
+	TimeSignature string  `json:"time_signature"`
+	Hidden        bool    `json:"hidden"`
+}
+
+type ChangedTimingPoint struct {
+	From DiffTimingPoint `json:"from"`
+	To   DiffTimingPoint `json:"to"`
+}
+
+type DiffScrollVelocity struct {
+	StartTime  float32 `json:"start_time"`
+	Multiplier float32 `json:"multiplier"`
+}
+
+type ChangedScrollVelocity struct {
+	From DiffScrollVelocity `json:"from"`
+	To   DiffScrollVelocity `json:"to"`
+}
+
+type MetadataChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type HitObjectDiff struct {
+	Added   []DiffHitObject  `json:"added"`
+	Removed []DiffHitObject  `json:"removed"`
+	Moved   []MovedHitObject `json:"moved"`
+}
+
+type TimingPointDiff struct {
+	Added   []DiffTimingPoint    `json:"added"`
+	Removed []DiffTimingPoint    `json:"removed"`
+	Changed []ChangedTimingPoint `json:"changed"`
+}
+
+type ScrollVelocityDiff struct {
+	Added   []DiffScrollVelocity    `json:"added"`
+	Removed []DiffScrollVelocity    `json:"removed"`
+	Changed []ChangedScrollVelocity `json:"changed"`
+}
+
+type Diff struct {
+	HasChanges       bool               `json:"has_changes"`
+	Metadata         []MetadataChange   `json:"metadata"`
+	HitObjects       HitObjectDiff      `json:"hit_objects"`
+	TimingPoints     TimingPointDiff    `json:"timing_points"`
+	ScrollVelocities ScrollVelocityDiff `json:"scroll_velocities"`
+}
+
+// Diff Compares two revisions of a map and returns everything that changed between them
+func (q *Qua) Diff(other *Qua) *Diff {
+	diff := &Diff{
+		Metadata:         diffMetadata(q, other),
+		HitObjects:       diffHitObjects(q.HitObjects, other.HitObjects),
+		TimingPoints:     diffTimingPoints(q.TimingPoints, other.TimingPoints),
+		ScrollVelocities: diffScrollVelocities(q.ScrollVelocities, other.ScrollVelocities),
+	}
+
+	diff.HasChanges = len(diff.Metadata) > 0 ||
+		len(diff.HitObjects.Added) > 0 || len(diff.HitObjects.Removed) > 0 || len(diff.HitObjects.Moved) > 0 ||
+		len(diff.TimingPoints.Added) > 0 || len(diff.TimingPoints.Removed) > 0 || len(diff.TimingPoints.Changed) > 0 ||
+		len(diff.ScrollVelocities.Added) > 0 || len(diff.ScrollVelocities.Removed) > 0 || len(diff.ScrollVelocities.Changed) > 0
+
+	return diff
+}
+
+// Compares every metadata field of the maps
+func diffMetadata(from *Qua, to *Qua) []MetadataChange {
+	fields := []struct {
+		Name string
+		From any
+		To   any
+	}{
+		{"AudioFile", from.AudioFile, to.AudioFile},
+		{"SongPreviewTime", from.SongPreviewTime, to.SongPreviewTime},
+		{"BackgroundFile", from.BackgroundFile, to.BackgroundFile},
+		{"BannerFile", from.BannerFile, to.BannerFile},
+		{"Mode", from.modeString(), to.modeString()},
+		{"Title", from.Title, to.Title},
+		{"Artist", from.Artist, to.Artist},
+		{"Source", from.Source, to.Source},
+		{"Tags", from.Tags, to.Tags},
+		{"Creator", from.Creator, to.Creator},
+		{"DifficultyName", from.DifficultyName, to.DifficultyName},
+		{"Description", from.Description, to.Description},
+		{"Genre", from.Genre, to.Genre},
+		{"LegacyLNRendering", from.LegacyLNRendering, to.LegacyLNRendering},
+		{"BPMDoesNotAffectScrollVelocity", from.BPMDoesNotAffectScrollVelocity, to.BPMDoesNotAffectScrollVelocity},
+		{"InitialScrollVelocity", from.InitialScrollVelocity, to.InitialScrollVelocity},
+		{"HasScratchKey", from.HasScratchKey, to.HasScratchKey},
+	}
+
+	changes := []MetadataChange{}
+
+	for _, field := range fields {
+		if field.From != field.To {
+			changes = append(changes, MetadataChange{
+				Field: field.Name,
+				From:  fmt.Sprint(field.From),
+				To:    fmt.Sprint(field.To),
+			})
+		}
+	}
+
+	return changes
+}
+
+// Finds the added, removed & moved hit objects.
+// Objects that were removed and added at the same time or in the same lane close by are counted as moved.
+func diffHitObjects(from []HitObject, to []HitObject) HitObjectDiff {
+	convert := func(h HitObject) DiffHitObject {
+		return DiffHitObject{StartTime: h.StartTime, Lane: h.Lane, EndTime: h.EndTime}
+	}
+
+	removed, added := diffSlices(from, to, convert)
+
+	diff := HitObjectDiff{Added: []DiffHitObject{}, Removed: []DiffHitObject{}, Moved: []MovedHitObject{}}
+
+	matchers := []func(a DiffHitObject, b DiffHitObject) bool{
+		// Same object with a different length
+		func(a DiffHitObject, b DiffHitObject) bool { return a.StartTime == b.StartTime && a.Lane == b.Lane },
+		// Same time in a different lane
+		func(a DiffHitObject, b DiffHitObject) bool { return a.StartTime == b.StartTime },
+		// Same lane at a slightly different time
+		func(a DiffHitObject, b DiffHitObject) bool {
+			return a.Lane == b.Lane && int(math.Abs(float64(a.StartTime-b.StartTime))) <= diffMoveToleranceMs
+		},
+	}
+
+	for _, matches := range matchers {
+		for i := 0; i < len(removed); i++ {
+			index := slices.IndexFunc(added, func(h DiffHitObject) bool { return matches(removed[i], h) })
+
+			if index == -1 {
+				continue
+			}
+
+			diff.Moved = append(diff.Moved, MovedHitObject{From: removed[i], To: added[index]})
+			removed = slices.Delete(removed, i, i+1)
+			added = slices.Delete(added, index, index+1)
+			i--
+		}
+	}
+
+	diff.Added = append(diff.Added, added...)
+	diff.Removed = append(diff.Removed, removed...)
+
+	sort.SliceStable(diff.Moved, func(i, j int) bool { return diff.Moved[i].From.StartTime < diff.Moved[j].From.StartTime })
+	return diff
+}
+
+// Finds the added, removed & changed timing points. Timing points at the same time are counted as changed.
+func diffTimingPoints(from []TimingPoint, to []TimingPoint) TimingPointDiff {
+	convert := func(tp TimingPoint) DiffTimingPoint {
+		return DiffTimingPoint{StartTime: tp.StartTime, BPM: tp.BPM, TimeSignature: tp.TimeSignature, Hidden: tp.Hidden}
+	}
+
+	removed, added := diffSlices(from, to, convert)
+	diff := TimingPointDiff{Added: []DiffTimingPoint{}, Removed: []DiffTimingPoint{}, Changed: []ChangedTimingPoint{}}
+
+	for _, tp := range removed {
+		index := slices.IndexFunc(added, func(a DiffTimingPoint) bool { return a.StartTime == tp.StartTime })
+
+		if index == -1 {
+			diff.Removed = append(diff.Removed, tp)
+			continue
+		}
+
+		diff.Changed = append(diff.Changed, ChangedTimingPoint{From: tp, To: added[index]})
+		added = slices.Delete(added, index, index+1)
+	}
+
+	diff.Added = append(diff.Added, added...)
+	return diff
+}
+
+// Finds the added, removed & changed scroll velocities. Scroll velocities at the same time are counted as changed.
+func diffScrollVelocities(from []ScrollVelocity, to []ScrollVelocity) ScrollVelocityDiff {
+	convert := func(sv ScrollVelocity) DiffScrollVelocity {
+		return DiffScrollVelocity{StartTime: sv.StartTime, Multiplier: sv.Multiplier}
+	}
+
+	removed, added := diffSlices(from, to, convert)
+	diff := ScrollVelocityDiff{Added: []DiffScrollVelocity{}, Removed: []DiffScrollVelocity{}, Changed: []ChangedScrollVelocity{}}
+
+	for _, sv := range removed {
+		index := slices.IndexFunc(added, func(a DiffScrollVelocity) bool { return a.StartTime == sv.StartTime })
+
+		if index == -1 {
+			diff.Removed = append(diff.Removed, sv)
+			continue
+		}
+
+		diff.Changed = append(diff.Changed, ChangedScrollVelocity{From: sv, To: added[index]})
+		added = slices.Delete(added, index, index+1)
+	}
+
+	diff.Added = append(diff.Added, added...)
+	return diff
+}
+
+// Returns the elements that are only in from (removed) and only in to (added), treating both slices as multisets.
+// The results keep the order of the original slices.
+func diffSlices[T any, K comparable](from []T, to []T, convert func(T) K) (removed []K, added []K) {
+	counts := map[K]int{}
+
+	for _, element := range from {
+		counts[convert(element)]++
+	}
+
+	for _, element := range to {
+		key := convert(element)
+
+		if counts[key] > 0 {
+			counts[key]--
+			continue
+		}
+
+		added = append(added, key)
+	}
+
+	for i := len(from) - 1; i >= 0; i-- {
+		key := convert(from[i])
+
+		if counts[key] > 0 {
+			counts[key]--
+			removed = append([]K{key}, removed...)
+		}
+	}
+
+	return removed, added
+}
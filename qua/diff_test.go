@@ -0,0 +1,83 @@
+package qua
+
+import (
+	"github.com/Quaver/api2/enums"
+	"reflect"
+	"testing"
+)
+
+func diffTestMap() *Qua {
+	return &Qua{
+		Mode:             enums.GameModeKeys4,
+		Title:            "Title",
+		DifficultyName:   "Hard",
+		TimingPoints:     []TimingPoint{{StartTime: 0, BPM: 120}, {StartTime: 5000, BPM: 180}},
+		ScrollVelocities: []ScrollVelocity{{StartTime: 1000, Multiplier: 2}},
+		HitObjects: []HitObject{
+			{StartTime: 1000, Lane: 1},
+			{StartTime: 1000, Lane: 2},
+			{StartTime: 1500, Lane: 3, EndTime: 2000},
+			{StartTime: 2000, Lane: 4},
+			{StartTime: 3000, Lane: 1},
+		},
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	diff := diffTestMap().Diff(diffTestMap())
+
+	if diff.HasChanges {
+		t.Fatalf("expected no changes, got %+v", diff)
+	}
+}
+
+func TestDiffChanges(t *testing.T) {
+	to := diffTestMap()
+	to.DifficultyName = "Insane"
+	to.TimingPoints[1].BPM = 200
+	to.TimingPoints = append(to.TimingPoints, TimingPoint{StartTime: 8000, BPM: 90})
+	to.ScrollVelocities = nil
+	to.HitObjects = []HitObject{
+		{StartTime: 1000, Lane: 1},
+		{StartTime: 1000, Lane: 3},                // Moved from lane 2
+		{StartTime: 1500, Lane: 3, EndTime: 2250}, // Long note extended
+		{StartTime: 2050, Lane: 4},                // Snapped later
+		{StartTime: 4000, Lane: 2},                // Added, the object at 3000 was removed
+	}
+
+	diff := diffTestMap().Diff(to)
+
+	if !diff.HasChanges {
+		t.Fatal("expected changes")
+	}
+
+	expectedMetadata := []MetadataChange{{Field: "DifficultyName", From: "Hard", To: "Insane"}}
+
+	if !reflect.DeepEqual(diff.Metadata, expectedMetadata) {
+		t.Errorf("unexpected metadata changes: %+v", diff.Metadata)
+	}
+
+	expectedMoved := []MovedHitObject{
+		{From: DiffHitObject{StartTime: 1000, Lane: 2}, To: DiffHitObject{StartTime: 1000, Lane: 3}},
+		{From: DiffHitObject{StartTime: 1500, Lane: 3, EndTime: 2000}, To: DiffHitObject{StartTime: 1500, Lane: 3, EndTime: 2250}},
+		{From: DiffHitObject{StartTime: 2000, Lane: 4}, To: DiffHitObject{StartTime: 2050, Lane: 4}},
+	}
+
+	if !reflect.DeepEqual(diff.HitObjects.Moved, expectedMoved) {
+		t.Errorf("unexpected moved objects: %+v", diff.HitObjects.Moved)
+	}
+
+	if !reflect.DeepEqual(diff.HitObjects.Added, []DiffHitObject{{StartTime: 4000, Lane: 2}}) ||
+		!reflect.DeepEqual(diff.HitObjects.Removed, []DiffHitObject{{StartTime: 3000, Lane: 1}}) {
+		t.Errorf("unexpected added/removed objects: %+v", diff.HitObjects)
+	}
+
+	if len(diff.TimingPoints.Changed) != 1 || diff.TimingPoints.Changed[0].To.BPM != 200 ||
+		len(diff.TimingPoints.Added) != 1 || len(diff.TimingPoints.Removed) != 0 {
+		t.Errorf("unexpected timing point changes: %+v", diff.TimingPoints)
+	}
+
+	if len(diff.ScrollVelocities.Removed) != 1 || len(diff.ScrollVelocities.Added) != 0 {
+		t.Errorf("unexpected scroll velocity changes: %+v", diff.ScrollVelocities)
+	}
+}
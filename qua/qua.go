@@ -8,36 +8,38 @@ import (
 	"math"
 	"os"
 	"regexp"
+	"strings"
 )
 
 type Qua struct {
-	RawBytes                       []byte           `yaml:"-"`
-	AudioFile                      string           `yaml:"AudioFile"`
-	SongPreviewTime                int              `yaml:"SongPreviewTime"`
-	BackgroundFile                 string           `yaml:"BackgroundFile"`
-	BannerFile                     string           `yaml:"BannerFile"`
-	MapId                          int              `yaml:"MapId"`
-	MapSetId                       int              `yaml:"MapSetId"`
-	RawMode                        string           `yaml:"Mode"`
-	Mode                           enums.GameMode   `yaml:"-"`
-	Title                          string           `yaml:"Title"`
-	Artist                         string           `yaml:"Artist"`
-	Source                         string           `yaml:"Source"`
-	Tags                           string           `yaml:"Tags"`
-	Creator                        string           `yaml:"Creator"`
-	DifficultyName                 string           `yaml:"DifficultyName"`
-	Description                    string           `yaml:"Description"`
-	Genre                          string           `yaml:"Genre"`
-	LegacyLNRendering              bool             `yaml:"LegacyLNRendering"`
-	BPMDoesNotAffectScrollVelocity bool             `yaml:"BPMDoesNotAffectScrollVelocity"`
-	InitialScrollVelocity          float32          `yaml:"InitialScrollVelocity"`
-	HasScratchKey                  bool             `yaml:"HasScratchKey"`
-	EditorLayers                   []EditorLayer    `yaml:"EditorLayers"`
-	Bookmarks                      []Bookmark       `yaml:"Bookmarks"`
-	SoundEffects                   []SoundEffect    `yaml:"SoundEffects"`
-	TimingPoints                   []TimingPoint    `yaml:"TimingPoints"`
-	ScrollVelocities               []ScrollVelocity `yaml:"SliderVelocities"`
-	HitObjects                     []HitObject      `yaml:"HitObjects"`
+	RawBytes                       []byte              `yaml:"-"`
+	AudioFile                      string              `yaml:"AudioFile"`
+	SongPreviewTime                int                 `yaml:"SongPreviewTime"`
+	BackgroundFile                 string              `yaml:"BackgroundFile"`
+	BannerFile                     string              `yaml:"BannerFile"`
+	MapId                          int                 `yaml:"MapId"`
+	MapSetId                       int                 `yaml:"MapSetId"`
+	RawMode                        string              `yaml:"Mode"`
+	Mode                           enums.GameMode      `yaml:"-"`
+	Title                          string              `yaml:"Title"`
+	Artist                         string              `yaml:"Artist"`
+	Source                         string              `yaml:"Source"`
+	Tags                           string              `yaml:"Tags"`
+	Creator                        string              `yaml:"Creator"`
+	DifficultyName                 string              `yaml:"DifficultyName"`
+	Description                    string              `yaml:"Description"`
+	Genre                          string              `yaml:"Genre"`
+	LegacyLNRendering              bool                `yaml:"LegacyLNRendering"`
+	BPMDoesNotAffectScrollVelocity bool                `yaml:"BPMDoesNotAffectScrollVelocity"`
+	InitialScrollVelocity          float32             `yaml:"InitialScrollVelocity"`
+	HasScratchKey                  bool                `yaml:"HasScratchKey"`
+	EditorLayers                   []EditorLayer       `yaml:"EditorLayers"`
+	Bookmarks                      []Bookmark          `yaml:"Bookmarks"`
+	CustomAudioSamples             []CustomAudioSample `yaml:"CustomAudioSamples"`
+	SoundEffects                   []SoundEffect       `yaml:"SoundEffects"`
+	TimingPoints                   []TimingPoint       `yaml:"TimingPoints"`
+	ScrollVelocities               []ScrollVelocity    `yaml:"SliderVelocities"`
+	HitObjects                     []HitObject         `yaml:"HitObjects"`
 }
 
 // Parse Parses and returns a Qua file
@@ -138,30 +140,76 @@ func (q *Qua) CountHitObjectLong() int {
 	return count
 }
 
+// KeyCount Returns the amount of keys in the map's game mode
+func (q *Qua) KeyCount(includeScratch bool) int {
+	var count int
+
+	switch q.Mode {
+	case enums.GameModeKeys1:
+		count = 1
+	case enums.GameModeKeys2:
+		count = 2
+	case enums.GameModeKeys3:
+		count = 3
+	case enums.GameModeKeys4:
+		count = 4
+	case enums.GameModeKeys5:
+		count = 5
+	case enums.GameModeKeys6:
+		count = 6
+	case enums.GameModeKeys7:
+		count = 7
+	case enums.GameModeKeys8:
+		count = 8
+	case enums.GameModeKeys9:
+		count = 9
+	case enums.GameModeKeys10:
+		count = 10
+	}
+
+	if includeScratch && q.HasScratchKey {
+		count++
+	}
+
+	return count
+}
+
 // MaxCombo Returns the max combo achievable in the map
 func (q *Qua) MaxCombo() int {
 	return q.CountHitObjectLong()*2 + q.CountHitObjectNormal()
 }
 
 // ReplaceIds Replaces the ids of the map and sets Qua.RawBytes. Returns a string of the new file.
+// Only the id lines are patched, so keys that Qua doesn't model are kept as they were uploaded.
 func (q *Qua) ReplaceIds(mapsetId int, mapId int) string {
 	q.MapSetId = mapsetId
 	q.MapId = mapId
 
 	fileStr := string(q.RawBytes)
-
-	fileStr = regexp.MustCompile(`MapSetId:\s*-?\d+`).ReplaceAllStringFunc(fileStr, func(match string) string {
-		return fmt.Sprintf("MapSetId: %v", mapsetId)
-	})
-
-	fileStr = regexp.MustCompile(`MapId:\s*-?\d+`).ReplaceAllStringFunc(fileStr, func(match string) string {
-		return fmt.Sprintf("MapId: %v", mapId)
-	})
+	fileStr = replaceTopLevelInt(fileStr, "MapSetId", mapsetId)
+	fileStr = replaceTopLevelInt(fileStr, "MapId", mapId)
 
 	q.RawBytes = []byte(fileStr)
 	return fileStr
 }
 
+// Replaces the value of a top-level integer key, or adds the key if the file doesn't have it.
+// Keys are matched at the start of a line, so values of other keys (e.g. a title containing "MapId: -1") are left alone.
+func replaceTopLevelInt(file string, key string, value int) string {
+	pattern := regexp.MustCompile(fmt.Sprintf(`(?m)^%v:[ \t]*-?\d*`, key))
+	line := fmt.Sprintf("%v: %v", key, value)
+
+	if pattern.MatchString(file) {
+		return pattern.ReplaceAllLiteralString(file, line)
+	}
+
+	if file != "" && !strings.HasSuffix(file, "\n") {
+		file += "\n"
+	}
+
+	return file + line + "\n"
+}
+
 // Writes the .qua to a file
 func (q *Qua) Write(path string) error {
 	return os.WriteFile(path, q.RawBytes, 0644)
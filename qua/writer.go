@@ -0,0 +1,220 @@
+package qua
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Serialize Writes the Qua in the canonical .qua format, using the same key order as Quaver.
+// Values inside of lists that are left at their defaults are omitted, the same way that Quaver does.
+func (q *Qua) Serialize() []byte {
+	w := &quaWriter{}
+
+	w.field("AudioFile", formatString(q.AudioFile))
+	w.field("SongPreviewTime", strconv.Itoa(q.SongPreviewTime))
+	w.field("BackgroundFile", formatString(q.BackgroundFile))
+	w.optionalField("BannerFile", formatString(q.BannerFile), q.BannerFile == "")
+	w.field("MapId", strconv.Itoa(q.MapId))
+	w.field("MapSetId", strconv.Itoa(q.MapSetId))
+	w.field("Mode", formatString(q.modeString()))
+	w.field("Title", formatString(q.Title))
+	w.field("Artist", formatString(q.Artist))
+	w.field("Source", formatString(q.Source))
+	w.field("Tags", formatString(q.Tags))
+	w.field("Creator", formatString(q.Creator))
+	w.field("DifficultyName", formatString(q.DifficultyName))
+	w.field("Description", formatString(q.Description))
+	w.optionalField("Genre", formatString(q.Genre), q.Genre == "")
+	w.optionalField("LegacyLNRendering", "true", !q.LegacyLNRendering)
+	w.optionalField("BPMDoesNotAffectScrollVelocity", "true", !q.BPMDoesNotAffectScrollVelocity)
+	w.optionalField("InitialScrollVelocity", formatFloat(q.InitialScrollVelocity), q.InitialScrollVelocity == 0)
+	w.optionalField("HasScratchKey", "true", !q.HasScratchKey)
+
+	w.list("EditorLayers", len(q.EditorLayers), func(i int) {
+		layer := q.EditorLayers[i]
+
+		w.item("Name", formatString(layer.Name), false)
+		w.item("Hidden", "true", !layer.Hidden)
+		w.item("ColorRgb", formatString(layer.ColorRGB), layer.ColorRGB == "")
+	})
+
+	w.list("Bookmarks", len(q.Bookmarks), func(i int) {
+		bookmark := q.Bookmarks[i]
+
+		w.item("StartTime", strconv.Itoa(bookmark.StartTime), false)
+		w.item("Note", formatString(bookmark.Note), false)
+	})
+
+	w.list("CustomAudioSamples", len(q.CustomAudioSamples), func(i int) {
+		sample := q.CustomAudioSamples[i]
+
+		w.item("Path", formatString(sample.Path), false)
+		w.item("UnaffectedByRate", "true", !sample.UnaffectedByRate)
+	})
+
+	w.list("SoundEffects", len(q.SoundEffects), func(i int) {
+		effect := q.SoundEffects[i]
+
+		w.item("StartTime", formatFloat(effect.StartTime), false)
+		w.item("Sample", strconv.Itoa(effect.Sample), false)
+		w.item("Volume", strconv.Itoa(effect.Volume), false)
+	})
+
+	w.list("TimingPoints", len(q.TimingPoints), func(i int) {
+		point := q.TimingPoints[i]
+
+		w.item("StartTime", formatFloat(point.StartTime), point.StartTime == 0)
+		w.item("Bpm", formatFloat(point.BPM), false)
+		w.item("TimeSignature", formatString(point.TimeSignature), point.TimeSignature == "")
+		w.item("Hidden", "true", !point.Hidden)
+	})
+
+	w.list("SliderVelocities", len(q.ScrollVelocities), func(i int) {
+		sv := q.ScrollVelocities[i]
+
+		w.item("StartTime", formatFloat(sv.StartTime), sv.StartTime == 0)
+		w.item("Multiplier", formatFloat(sv.Multiplier), sv.Multiplier == 0)
+	})
+
+	w.list("HitObjects", len(q.HitObjects), func(i int) {
+		hitObject := q.HitObjects[i]
+
+		w.item("StartTime", strconv.Itoa(hitObject.StartTime), hitObject.StartTime == 0)
+		w.item("Lane", strconv.Itoa(hitObject.Lane), false)
+		w.item("EndTime", strconv.Itoa(hitObject.EndTime), hitObject.EndTime == 0)
+		w.item("HitSound", formatString(hitObject.HitSound), hitObject.HitSound == "")
+		w.nestedList("KeySounds", len(hitObject.KeySounds), func(j int) {
+			keySound := hitObject.KeySounds[j]
+
+			w.nestedItem("Sample", strconv.Itoa(keySound.Sample))
+			w.nestedItem("Volume", strconv.Itoa(keySound.Volume))
+		})
+		w.item("EditorLayer", strconv.Itoa(hitObject.EditorLayer), hitObject.EditorLayer == 0)
+	})
+
+	return []byte(w.String())
+}
+
+// Returns the name of the game mode how it's written in a .qua file (Keys4, Keys7, etc.)
+func (q *Qua) modeString() string {
+	if keyCount := q.KeyCount(false); keyCount > 0 {
+		return fmt.Sprintf("Keys%v", keyCount)
+	}
+
+	return q.RawMode
+}
+
+// Builds a .qua file line by line
+type quaWriter struct {
+	strings.Builder
+	firstKey       bool
+	firstNestedKey bool
+}
+
+// Writes a top-level key and value
+func (w *quaWriter) field(key string, value string) {
+	w.WriteString(fmt.Sprintf("%v: %v\n", key, value))
+}
+
+// Writes a top-level key and value, unless it is the default value
+func (w *quaWriter) optionalField(key string, value string, isDefault bool) {
+	if !isDefault {
+		w.field(key, value)
+	}
+}
+
+// Writes a top-level list. Each element is written by calling writeItem with its index.
+func (w *quaWriter) list(key string, length int, writeItem func(i int)) {
+	if length == 0 {
+		w.field(key, "[]")
+		return
+	}
+
+	w.WriteString(key + ":\n")
+
+	for i := 0; i < length; i++ {
+		w.firstKey = true
+		writeItem(i)
+
+		// Every key in the item was omitted, so it has to be written as an empty mapping.
+		if w.firstKey {
+			w.WriteString("- {}\n")
+		}
+	}
+}
+
+// Writes a key and value of the current list element, unless it is the default value
+func (w *quaWriter) item(key string, value string, isDefault bool) {
+	if isDefault {
+		return
+	}
+
+	w.WriteString(w.itemPrefix() + fmt.Sprintf("%v: %v\n", key, value))
+}
+
+// Writes a list that belongs to the current list element
+func (w *quaWriter) nestedList(key string, length int, writeItem func(i int)) {
+	if length == 0 {
+		w.WriteString(w.itemPrefix() + key + ": []\n")
+		return
+	}
+
+	w.WriteString(w.itemPrefix() + key + ":\n")
+
+	for i := 0; i < length; i++ {
+		w.firstNestedKey = true
+		writeItem(i)
+	}
+}
+
+// Writes a key and value of the current nested list element
+func (w *quaWriter) nestedItem(key string, value string) {
+	prefix := "    "
+
+	if w.firstNestedKey {
+		prefix = "  - "
+		w.firstNestedKey = false
+	}
+
+	w.WriteString(prefix + fmt.Sprintf("%v: %v\n", key, value))
+}
+
+// Returns the prefix of a key in a list element. The first key of each element starts the element with a dash.
+func (w *quaWriter) itemPrefix() string {
+	if w.firstKey {
+		w.firstKey = false
+		return "- "
+	}
+
+	return "  "
+}
+
+// Formats a string as a YAML scalar, quoting it only if needed.
+func formatString(str string) string {
+	out, err := yaml.Marshal(str)
+	formatted := strings.TrimSuffix(string(out), "\n")
+
+	// Multi-line strings are emitted as block scalars, which can't be written inline.
+	if err != nil || strings.Contains(formatted, "\n") || strings.HasPrefix(formatted, "|") || strings.HasPrefix(formatted, ">") {
+		return strconv.Quote(str)
+	}
+
+	return formatted
+}
+
+// Formats a float using the shortest representation that parses back into the same float32
+func formatFloat(f float32) string {
+	switch {
+	case math.IsNaN(float64(f)):
+		return ".nan"
+	case math.IsInf(float64(f), 1):
+		return ".inf"
+	case math.IsInf(float64(f), -1):
+		return "-.inf"
+	}
+
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
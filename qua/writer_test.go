@@ -0,0 +1,299 @@
+package qua
+
+import (
+	"bytes"
+	"github.com/Quaver/api2/enums"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// Strings that need to be quoted or escaped to survive being written
+var trickyStrings = []string{
+	"", "true", "null", "~", "123", "-1.5", "a: b", "#comment", "- dash", "'single'", "\"double\"",
+	" leading", "trailing ", "multi\nline", "tab\there", "[list]", "{map}", "ünïcödé ♪", "日本語", "@at", "%percent",
+	"\n", "back\\slash", "carriage\r\nreturn", "*alias", "&anchor", "!tag", "|", ">", "?", "yes", "NO", "0x1F", ".inf",
+}
+
+var gameModes = []enums.GameMode{
+	enums.GameModeKeys1, enums.GameModeKeys2, enums.GameModeKeys3, enums.GameModeKeys4, enums.GameModeKeys5,
+	enums.GameModeKeys6, enums.GameModeKeys7, enums.GameModeKeys8, enums.GameModeKeys9, enums.GameModeKeys10,
+}
+
+func randomString(r *rand.Rand) string {
+	if r.Intn(2) == 0 {
+		return trickyStrings[r.Intn(len(trickyStrings))]
+	}
+
+	const chars = "abcdefghijklmnopqrstuvwxyz ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.:#'\"!?[]{}\\/\n\t♪é"
+	runes := []rune(chars)
+	var sb strings.Builder
+
+	for i := 0; i < r.Intn(20); i++ {
+		sb.WriteRune(runes[r.Intn(len(runes))])
+	}
+
+	return sb.String()
+}
+
+func randomFloat(r *rand.Rand) float32 {
+	switch r.Intn(4) {
+	case 0:
+		return 0
+	case 1:
+		return float32(r.Intn(500000))
+	case 2:
+		return r.Float32() * 1000
+	default:
+		return (r.Float32() - 0.5) * float32(r.Intn(1_000_000))
+	}
+}
+
+// Generates a list with a random length, including empty lists
+func randomList[T any](r *rand.Rand, generate func() T) []T {
+	list := make([]T, r.Intn(5))
+
+	for i := range list {
+		list[i] = generate()
+	}
+
+	return list
+}
+
+func randomQua(r *rand.Rand) *Qua {
+	mode := gameModes[r.Intn(len(gameModes))]
+
+	q := &Qua{
+		AudioFile:                      randomString(r),
+		SongPreviewTime:                r.Intn(200000) - 1000,
+		BackgroundFile:                 randomString(r),
+		BannerFile:                     randomString(r),
+		MapId:                          r.Intn(200000) - 1,
+		MapSetId:                       r.Intn(200000) - 1,
+		Mode:                           mode,
+		Title:                          randomString(r),
+		Artist:                         randomString(r),
+		Source:                         randomString(r),
+		Tags:                           randomString(r),
+		Creator:                        randomString(r),
+		DifficultyName:                 randomString(r),
+		Description:                    randomString(r),
+		Genre:                          randomString(r),
+		LegacyLNRendering:              r.Intn(2) == 0,
+		BPMDoesNotAffectScrollVelocity: r.Intn(2) == 0,
+		InitialScrollVelocity:          randomFloat(r),
+		HasScratchKey:                  r.Intn(2) == 0,
+	}
+
+	q.RawMode = q.modeString()
+
+	q.EditorLayers = randomList(r, func() EditorLayer {
+		return EditorLayer{Name: randomString(r), Hidden: r.Intn(2) == 0, ColorRGB: randomString(r)}
+	})
+
+	q.Bookmarks = randomList(r, func() Bookmark {
+		return Bookmark{StartTime: r.Intn(100000), Note: randomString(r)}
+	})
+
+	q.CustomAudioSamples = randomList(r, func() CustomAudioSample {
+		return CustomAudioSample{Path: randomString(r), UnaffectedByRate: r.Intn(2) == 0}
+	})
+
+	q.SoundEffects = randomList(r, func() SoundEffect {
+		return SoundEffect{StartTime: randomFloat(r), Sample: r.Intn(10), Volume: r.Intn(101)}
+	})
+
+	q.TimingPoints = randomList(r, func() TimingPoint {
+		return TimingPoint{StartTime: randomFloat(r), BPM: randomFloat(r), TimeSignature: randomString(r), Hidden: r.Intn(2) == 0}
+	})
+
+	q.ScrollVelocities = randomList(r, func() ScrollVelocity {
+		return ScrollVelocity{StartTime: randomFloat(r), Multiplier: randomFloat(r)}
+	})
+
+	q.HitObjects = randomList(r, func() HitObject {
+		hitObject := HitObject{
+			StartTime:   r.Intn(100000),
+			Lane:        r.Intn(q.KeyCount(true)) + 1,
+			HitSound:    randomString(r),
+			EditorLayer: r.Intn(3),
+			KeySounds: randomList(r, func() KeySound {
+				return KeySound{Sample: r.Intn(10), Volume: r.Intn(101)}
+			}),
+		}
+
+		if r.Intn(2) == 0 {
+			hitObject.EndTime = hitObject.StartTime + r.Intn(5000)
+		}
+
+		return hitObject
+	})
+
+	return q
+}
+
+// Generate Implements quick.Generator, so random maps can be used as property test inputs
+func (q *Qua) Generate(r *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(randomQua(r))
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	property := func(q *Qua) bool {
+		written := q.Serialize()
+		parsed, err := Parse(written)
+
+		if err != nil {
+			t.Logf("failed to parse written file: %v\n%s", err, written)
+			return false
+		}
+
+		parsed.RawBytes = nil
+
+		if !reflect.DeepEqual(q, parsed) {
+			t.Logf("parsed map does not match original\n%s", written)
+			return false
+		}
+
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSerializeIsStable(t *testing.T) {
+	property := func(q *Qua) bool {
+		written := q.Serialize()
+		parsed, err := Parse(written)
+
+		if err != nil {
+			return false
+		}
+
+		return bytes.Equal(written, parsed.Serialize())
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSerializeCanonicalFormat(t *testing.T) {
+	q := &Qua{
+		AudioFile:                      "audio.mp3",
+		SongPreviewTime:                1500,
+		BackgroundFile:                 "bg.jpg",
+		MapId:                          -1,
+		MapSetId:                       -1,
+		Mode:                           enums.GameModeKeys4,
+		Title:                          "Title",
+		Artist:                         "Artist",
+		Creator:                        "Creator",
+		DifficultyName:                 "Hard",
+		BPMDoesNotAffectScrollVelocity: true,
+		InitialScrollVelocity:          1,
+		TimingPoints:                   []TimingPoint{{BPM: 120.5}},
+		ScrollVelocities:               []ScrollVelocity{{StartTime: 1000, Multiplier: 0.75}},
+		HitObjects: []HitObject{
+			{StartTime: 1000, Lane: 1},
+			{StartTime: 1500, Lane: 4, EndTime: 2000, KeySounds: []KeySound{{Sample: 1, Volume: 100}}},
+		},
+	}
+
+	expected := `AudioFile: audio.mp3
+SongPreviewTime: 1500
+BackgroundFile: bg.jpg
+MapId: -1
+MapSetId: -1
+Mode: Keys4
+Title: Title
+Artist: Artist
+Source: ""
+Tags: ""
+Creator: Creator
+DifficultyName: Hard
+Description: ""
+BPMDoesNotAffectScrollVelocity: true
+InitialScrollVelocity: 1
+EditorLayers: []
+Bookmarks: []
+CustomAudioSamples: []
+SoundEffects: []
+TimingPoints:
+- Bpm: 120.5
+SliderVelocities:
+- StartTime: 1000
+  Multiplier: 0.75
+HitObjects:
+- StartTime: 1000
+  Lane: 1
+  KeySounds: []
+- StartTime: 1500
+  Lane: 4
+  EndTime: 2000
+  KeySounds:
+  - Sample: 1
+    Volume: 100
+`
+
+	if written := string(q.Serialize()); written != expected {
+		t.Fatalf("expected:\n%v\ngot:\n%v", expected, written)
+	}
+}
+
+func TestReplaceIds(t *testing.T) {
+	q, err := Parse([]byte("Mode: Keys7\nMapId: -1\nMapSetId: -1\nTitle: \"MapId: -1\"\nHitObjects:\n- StartTime: 5\n  Lane: 7\n"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.ReplaceIds(10, 20)
+	parsed, err := Parse(q.RawBytes)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.MapSetId != 10 || parsed.MapId != 20 || parsed.Title != "MapId: -1" || len(parsed.HitObjects) != 1 {
+		t.Fatalf("unexpected map after replacing ids: %+v", parsed)
+	}
+}
+
+func TestReplaceIdsKeepsUnknownKeys(t *testing.T) {
+	file := "AudioFile: audio.mp3\r\nMapId: 5\r\nMapSetId: 6\r\nMode: Keys4\r\nUnknownKey: kept\r\nHitObjects: []\r\n"
+
+	q, err := Parse([]byte(file))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replaced := q.ReplaceIds(10, 20)
+	expected := "AudioFile: audio.mp3\r\nMapId: 20\r\nMapSetId: 10\r\nMode: Keys4\r\nUnknownKey: kept\r\nHitObjects: []\r\n"
+
+	if replaced != expected {
+		t.Fatalf("expected %q, got %q", expected, replaced)
+	}
+}
+
+func TestReplaceIdsAddsMissingIds(t *testing.T) {
+	q, err := Parse([]byte("Mode: Keys4\nHitObjects:\n- StartTime: 5\n  Lane: 1"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse([]byte(q.ReplaceIds(10, 20)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.MapSetId != 10 || parsed.MapId != 20 || len(parsed.HitObjects) != 1 {
+		t.Fatalf("unexpected map after adding ids: %+v", parsed)
+	}
+}
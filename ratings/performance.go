@@ -0,0 +1,16 @@
+package ratings
+
+import "math"
+
+// PerformanceVersion The version of the performance processor. This must be bumped whenever the algorithm changes.
+const PerformanceVersion string = "0.0.1"
+
+// CalculatePerformanceRating Calculates the performance rating of a score from the difficulty rating of the map
+// (with the mods of the score) and the accuracy the score was achieved with
+func CalculatePerformanceRating(difficultyRating float64, accuracy float64, failed bool) float64 {
+	if failed {
+		return 0
+	}
+
+	return difficultyRating * math.Pow(accuracy/98, 6)
+}
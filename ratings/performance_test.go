@@ -0,0 +1,31 @@
+package ratings
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculatePerformanceRating(t *testing.T) {
+	tests := []struct {
+		name       string
+		difficulty float64
+		accuracy   float64
+		failed     bool
+		expected   float64
+	}{
+		{name: "98% is worth the difficulty", difficulty: 30, accuracy: 98, expected: 30},
+		{name: "higher accuracy is worth more", difficulty: 30, accuracy: 100, expected: 30 * math.Pow(100.0/98, 6)},
+		{name: "lower accuracy is worth less", difficulty: 30, accuracy: 90, expected: 30 * math.Pow(90.0/98, 6)},
+		{name: "failed scores are worth nothing", difficulty: 30, accuracy: 99, failed: true, expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rating := CalculatePerformanceRating(test.difficulty, test.accuracy, test.failed)
+
+			if math.Abs(rating-test.expected) > 1e-9 {
+				t.Errorf("expected %v, got %v", test.expected, rating)
+			}
+		})
+	}
+}
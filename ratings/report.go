@@ -0,0 +1,129 @@
+package ratings
+
+import (
+	"encoding/json"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"strconv"
+)
+
+// The amount of players with the largest rank changes that are included in a report
+const reportMovementCount = 25
+
+// Report The rank movement of players between the live and shadow leaderboards of a game mode
+type Report struct {
+	Mode          enums.GameMode  `json:"mode"`
+	UsersCompared int             `json:"users_compared"`
+	UsersImproved int             `json:"users_improved"`
+	UsersDropped  int             `json:"users_dropped"`
+	LargestGains  []*RankMovement `json:"largest_gains"`
+	LargestLosses []*RankMovement `json:"largest_losses"`
+}
+
+// RankMovement The rank & rating of a player before and after reprocessing.
+// A previous rank of 0 means the player wasn't on the live leaderboard.
+type RankMovement struct {
+	UserId         int     `json:"user_id"`
+	PreviousRank   int64   `json:"previous_rank"`
+	NewRank        int64   `json:"new_rank"`
+	PreviousRating float64 `json:"previous_rating"`
+	NewRating      float64 `json:"new_rating"`
+}
+
+// Change Returns how many ranks the player has gained. Negative if they've lost ranks.
+func (m *RankMovement) Change() int64 {
+	if m.PreviousRank == 0 {
+		return 0
+	}
+
+	return m.PreviousRank - m.NewRank
+}
+
+// Compares the shadow leaderboards with the live ones for every game mode
+func buildReport() (json.RawMessage, error) {
+	reports := make([]*Report, 0, 2)
+
+	for _, mode := range []enums.GameMode{enums.GameModeKeys4, enums.GameModeKeys7} {
+		report, err := buildModeReport(mode)
+
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	return json.Marshal(reports)
+}
+
+func buildModeReport(mode enums.GameMode) (*Report, error) {
+	shadow, err := db.Redis.ZRevRangeWithScores(db.RedisCtx, shadowLeaderboardKey(mode), 0, -1).Result()
+
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	movements := make([]*RankMovement, 0, len(shadow))
+
+	for i, entry := range shadow {
+		member := entry.Member.(string)
+		userId, err := strconv.Atoi(member)
+
+		if err != nil {
+			return nil, err
+		}
+
+		movement := &RankMovement{
+			UserId:    userId,
+			NewRank:   int64(i) + 1,
+			NewRating: entry.Score,
+		}
+
+		previous, err := db.Redis.ZRevRankWithScore(db.RedisCtx, leaderboardKey(mode), member).Result()
+
+		switch {
+		case err == redis.Nil:
+		case err != nil:
+			return nil, err
+		default:
+			movement.PreviousRank = previous.Rank + 1
+			movement.PreviousRating = previous.Score
+		}
+
+		movements = append(movements, movement)
+	}
+
+	return newReport(mode, movements), nil
+}
+
+func newReport(mode enums.GameMode, movements []*RankMovement) *Report {
+	report := &Report{
+		Mode:          mode,
+		UsersCompared: len(movements),
+		LargestGains:  []*RankMovement{},
+		LargestLosses: []*RankMovement{},
+	}
+
+	var gains, losses []*RankMovement
+
+	for _, movement := range movements {
+		switch change := movement.Change(); {
+		case change > 0:
+			report.UsersImproved++
+			gains = append(gains, movement)
+		case change < 0:
+			report.UsersDropped++
+			losses = append(losses, movement)
+		}
+	}
+
+	slices.SortStableFunc(gains, func(a, b *RankMovement) int { return int(b.Change() - a.Change()) })
+	slices.SortStableFunc(losses, func(a, b *RankMovement) int { return int(a.Change() - b.Change()) })
+
+	report.LargestGains = append(report.LargestGains, gains[:min(len(gains), reportMovementCount)]...)
+	report.LargestLosses = append(report.LargestLosses, losses[:min(len(losses), reportMovementCount)]...)
+
+	return report
+}
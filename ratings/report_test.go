@@ -0,0 +1,51 @@
+package ratings
+
+import (
+	"github.com/Quaver/api2/enums"
+	"testing"
+)
+
+func TestNewReport(t *testing.T) {
+	movements := []*RankMovement{
+		{UserId: 1, PreviousRank: 3, NewRank: 1},
+		{UserId: 2, PreviousRank: 1, NewRank: 2},
+		{UserId: 3, PreviousRank: 2, NewRank: 3},
+		{UserId: 4, PreviousRank: 0, NewRank: 4},
+		{UserId: 5, PreviousRank: 10, NewRank: 5},
+		{UserId: 6, PreviousRank: 6, NewRank: 6},
+	}
+
+	report := newReport(enums.GameModeKeys4, movements)
+
+	if report.UsersCompared != 6 || report.UsersImproved != 2 || report.UsersDropped != 2 {
+		t.Fatalf("unexpected counts: %+v", report)
+	}
+
+	if len(report.LargestGains) != 2 || report.LargestGains[0].UserId != 5 || report.LargestGains[1].UserId != 1 {
+		t.Errorf("unexpected gains: %+v", report.LargestGains)
+	}
+
+	if len(report.LargestLosses) != 2 || report.LargestLosses[0].UserId != 2 || report.LargestLosses[1].UserId != 3 {
+		t.Errorf("unexpected losses: %+v", report.LargestLosses)
+	}
+}
+
+func TestLeaderboardKeys(t *testing.T) {
+	shadow := shadowCountryLeaderboardKey("US", enums.GameModeKeys7)
+
+	if shadow != "quaver:shadow:country_leaderboard:us:2" {
+		t.Fatalf("unexpected shadow key: %v", shadow)
+	}
+
+	if live := liveLeaderboardKey(shadow); live != countryLeaderboardKey("US", enums.GameModeKeys7) {
+		t.Errorf("unexpected live key: %v", live)
+	}
+
+	if live := liveLeaderboardKey(shadowLeaderboardKey(enums.GameModeKeys4)); live != "quaver:leaderboard:1" {
+		t.Errorf("unexpected live key: %v", live)
+	}
+
+	if previous := previousLeaderboardKey(leaderboardKey(enums.GameModeKeys4)); previous != "quaver:shadow_previous:leaderboard:1" {
+		t.Errorf("unexpected previous key: %v", previous)
+	}
+}
@@ -0,0 +1,566 @@
+package ratings
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/difficulty"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/files"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	mapBatchSize  = 100
+	userBatchSize = 1000
+
+	// The amount of best scores that make up the overall rating of a user
+	overallRatingScoreCount = 500
+
+	// How many times a promotion catches up with new scores before giving up
+	promoteAttempts = 3
+
+	shadowKeyPrefix = "quaver:shadow:"
+
+	// Live leaderboards are kept under this prefix while a job is being promoted, so they can be restored if it fails
+	previousKeyPrefix = "quaver:shadow_previous:"
+)
+
+// Reprocess Re-rates every ranked score with the current difficulty and performance processors.
+// Ratings are written to the shadow tables & leaderboards and the job can be stopped and resumed at any point.
+// Returns nil if every score is already up-to-date.
+func Reprocess() (*db.RatingReprocessJob, error) {
+	job, err := db.GetActiveRatingReprocessJob()
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if job == nil {
+		outdated, err := db.HasOutdatedScoreRatings(difficulty.Version, PerformanceVersion)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !outdated {
+			return nil, nil
+		}
+
+		if job, err = startJob(); err != nil {
+			return nil, err
+		}
+
+		logrus.Infof("Started rating reprocess job #%v", job.Id)
+	} else {
+		logrus.Infof("Resuming rating reprocess job #%v (%v, cursor: %v)", job.Id, job.Phase, job.CursorId)
+	}
+
+	if job.Status != db.RatingReprocessProcessing {
+		return job, nil
+	}
+
+	if job.Phase == db.RatingReprocessPhaseMaps {
+		if err := reprocessMaps(job); err != nil {
+			return nil, err
+		}
+	}
+
+	if job.Phase == db.RatingReprocessPhaseUsers {
+		if err := reprocessUsers(job); err != nil {
+			return nil, err
+		}
+	}
+
+	report, err := buildReport()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := job.MarkReady(report); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Rating reprocess job #%v is ready to be promoted", job.Id)
+	return job, nil
+}
+
+// Promote Swaps the shadow ratings & leaderboards of a ready job with the live ones.
+// Scores that were submitted while the job was running are rated first, so none of them are lost or overwritten.
+func Promote(job *db.RatingReprocessJob) error {
+	if job.Status != db.RatingReprocessReady {
+		return fmt.Errorf("rating reprocess job #%v is not ready to be promoted", job.Id)
+	}
+
+	if err := recoverInterruptedPromotion(); err != nil {
+		return err
+	}
+
+	md5s, err := db.GetShadowRatedMapMD5s()
+
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := catchUp(job); err != nil {
+			return err
+		}
+
+		err = promote(job)
+
+		if !errors.Is(err, db.ErrRatingReprocessBehind) || attempt == promoteAttempts {
+			break
+		}
+
+		logrus.Infof("New scores were submitted while promoting rating reprocess job #%v, catching up again", job.Id)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := db.InvalidateScoreboards(db.ScoreboardInvalidationRatings, md5s...); err != nil {
+		logrus.Error("Error invalidating scoreboards after promoting ratings: ", err)
+	}
+
+	logrus.Infof("Promoted rating reprocess job #%v", job.Id)
+	return nil
+}
+
+// Promotes the ratings & leaderboards of a job. The live leaderboards are only deleted once the ratings are committed,
+// and are restored if the transaction fails after they were swapped.
+func promote(job *db.RatingReprocessJob) error {
+	var promotedKeys []string
+
+	err := db.PromoteRatingReprocessJob(job, func() error {
+		keys, err := promoteShadowLeaderboards()
+
+		if err != nil {
+			return err
+		}
+
+		promotedKeys = keys
+		return nil
+	})
+
+	if err != nil {
+		if len(promotedKeys) > 0 {
+			if err := restorePreviousLeaderboards(promotedKeys); err != nil {
+				logrus.Error("Error restoring leaderboards after failing to promote ratings: ", err)
+			}
+		}
+
+		return err
+	}
+
+	return deletePreviousLeaderboards()
+}
+
+// Restores the live leaderboards if a previous promotion stopped before its ratings were committed,
+// so the shadow leaderboards aren't lost.
+func recoverInterruptedPromotion() error {
+	previousKeys, err := db.ScanRedisKeys(previousKeyPrefix + "*")
+
+	if err != nil || len(previousKeys) == 0 {
+		return err
+	}
+
+	promotedKeys, err := db.ScanRedisKeys("quaver:country_leaderboard:*")
+
+	if err != nil {
+		return err
+	}
+
+	for _, mode := range []enums.GameMode{enums.GameModeKeys4, enums.GameModeKeys7} {
+		promotedKeys = append(promotedKeys, leaderboardKey(mode))
+	}
+
+	logrus.Warn("Restoring the leaderboards of an interrupted rating promotion")
+	return restorePreviousLeaderboards(promotedKeys)
+}
+
+// Rates the scores that were submitted and the maps that were ranked since the job last caught up,
+// and recalculates the overall rating of the users who submitted them.
+func catchUp(job *db.RatingReprocessJob) error {
+	lastScoreId, err := db.GetLatestScoreId()
+
+	if err != nil {
+		return err
+	}
+
+	maps, err := db.GetRankedMapsToCatchUp(job.LastScoreId, lastScoreId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, mapQua := range maps {
+		if err := reprocessMap(mapQua); err != nil {
+			return fmt.Errorf("map #%v: %w", mapQua.Id, err)
+		}
+	}
+
+	userIds, err := db.GetUserIdsWithScoresBetween(job.LastScoreId, lastScoreId)
+
+	if err != nil {
+		return err
+	}
+
+	// Newly ranked maps can change the overall rating of anyone who played them
+	for _, mapQua := range maps {
+		scores, err := db.GetPassedScoresForMap(mapQua.MD5)
+
+		if err != nil {
+			return err
+		}
+
+		for _, score := range scores {
+			if !slices.Contains(userIds, score.UserId) {
+				userIds = append(userIds, score.UserId)
+			}
+		}
+	}
+
+	for _, userId := range userIds {
+		user, err := db.GetUserById(userId)
+
+		if err != nil {
+			return err
+		}
+
+		if err := reprocessUser(user); err != nil {
+			return fmt.Errorf("user #%v: %w", user.Id, err)
+		}
+	}
+
+	if len(maps) > 0 || len(userIds) > 0 {
+		logrus.Infof("Caught up with %v maps and %v users for rating reprocess job #%v", len(maps), len(userIds), job.Id)
+	}
+
+	return job.UpdateLastScoreId(lastScoreId)
+}
+
+// Clears out the shadow & previous leaderboards of a previous job and starts a new one
+func startJob() (*db.RatingReprocessJob, error) {
+	keys, err := db.ScanRedisKeys(shadowKeyPrefix + "*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	previousKeys, err := db.ScanRedisKeys(previousKeyPrefix + "*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys = append(keys, previousKeys...)
+
+	if len(keys) > 0 {
+		if err := db.Redis.Del(db.RedisCtx, keys...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return db.StartRatingReprocessJob(difficulty.Version, PerformanceVersion)
+}
+
+// Recalculates the difficulty rating of every ranked map and the performance rating of its scores
+func reprocessMaps(job *db.RatingReprocessJob) error {
+	for {
+		maps, err := db.GetRankedMapsAfterId(job.CursorId, mapBatchSize)
+
+		if err != nil {
+			return err
+		}
+
+		if len(maps) == 0 {
+			break
+		}
+
+		for _, mapQua := range maps {
+			if err := reprocessMap(mapQua); err != nil {
+				return fmt.Errorf("map #%v: %w", mapQua.Id, err)
+			}
+		}
+
+		if err := job.UpdateProgress(db.RatingReprocessPhaseMaps, maps[len(maps)-1].Id); err != nil {
+			return err
+		}
+
+		logrus.Infof("Reprocessed maps up to #%v", job.CursorId)
+	}
+
+	return job.UpdateProgress(db.RatingReprocessPhaseUsers, 0)
+}
+
+func reprocessMap(mapQua *db.MapQua) error {
+	path, err := files.CacheQuaFile(mapQua)
+
+	if err != nil {
+		return err
+	}
+
+	// Difficulty ratings by mod combination, as scores on a map share only a handful of them
+	ratings := map[enums.Mods]float64{}
+
+	calculate := func(mods enums.Mods) (float64, error) {
+		if rating, ok := ratings[mods]; ok {
+			return rating, nil
+		}
+
+		result, err := difficulty.CalculateFromFile(path, mods)
+
+		if err != nil {
+			return 0, err
+		}
+
+		ratings[mods] = result.OverallDifficulty
+		return result.OverallDifficulty, nil
+	}
+
+	mapRating, err := calculate(0)
+
+	if err != nil {
+		return err
+	}
+
+	err = db.InsertMapShadowRatings([]*db.MapShadowRating{
+		{MapId: mapQua.Id, MD5: mapQua.MD5, DifficultyRating: mapRating},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	scores, err := db.GetPassedScoresForMap(mapQua.MD5)
+
+	if err != nil {
+		return err
+	}
+
+	shadowScores := make([]*db.ScoreShadowRating, 0, len(scores))
+
+	for _, score := range scores {
+		mods := enums.Mods(score.Modifiers)
+
+		if !enums.IsModComboRanked(mods) {
+			continue
+		}
+
+		rating, err := calculate(mods)
+
+		if err != nil {
+			return err
+		}
+
+		shadowScores = append(shadowScores, &db.ScoreShadowRating{
+			ScoreId:           score.Id,
+			UserId:            score.UserId,
+			MapMD5:            score.MapMD5,
+			Mode:              score.Mode,
+			PerformanceRating: CalculatePerformanceRating(rating, score.Accuracy, score.Failed),
+		})
+	}
+
+	return db.InsertScoreShadowRatings(shadowScores)
+}
+
+// Recalculates the overall rating of every user from their shadow scores and builds the shadow leaderboards
+func reprocessUsers(job *db.RatingReprocessJob) error {
+	for {
+		users, err := db.GetUsersAfterId(job.CursorId, userBatchSize)
+
+		if err != nil {
+			return err
+		}
+
+		if len(users) == 0 {
+			break
+		}
+
+		for _, user := range users {
+			if err := reprocessUser(user); err != nil {
+				return fmt.Errorf("user #%v: %w", user.Id, err)
+			}
+		}
+
+		if err := job.UpdateProgress(db.RatingReprocessPhaseUsers, users[len(users)-1].Id); err != nil {
+			return err
+		}
+
+		logrus.Infof("Reprocessed users up to #%v", job.CursorId)
+	}
+
+	return job.UpdateProgress(db.RatingReprocessPhaseReport, 0)
+}
+
+func reprocessUser(user *db.User) error {
+	stats := make([]*db.UserStatsShadowRating, 0, 2)
+
+	for _, mode := range []enums.GameMode{enums.GameModeKeys4, enums.GameModeKeys7} {
+		scores, err := db.GetUserBestShadowScores(user.Id, mode, overallRatingScoreCount)
+
+		if err != nil {
+			return err
+		}
+
+		rating := db.CalculateOverallRating(scores)
+
+		stats = append(stats, &db.UserStatsShadowRating{
+			UserId:                   user.Id,
+			Mode:                     mode,
+			OverallPerformanceRating: rating,
+		})
+
+		if !user.Allowed {
+			continue
+		}
+
+		member := redis.Z{Score: rating, Member: strconv.Itoa(user.Id)}
+
+		if err := db.Redis.ZAdd(db.RedisCtx, shadowLeaderboardKey(mode), member).Err(); err != nil {
+			return err
+		}
+
+		if user.Country == "XX" {
+			continue
+		}
+
+		if err := db.Redis.ZAdd(db.RedisCtx, shadowCountryLeaderboardKey(user.Country, mode), member).Err(); err != nil {
+			return err
+		}
+	}
+
+	return db.InsertUserStatsShadowRatings(stats)
+}
+
+// Renames every shadow leaderboard to its live key in a single transaction. The live leaderboards are moved to
+// the previous prefix, so they can be restored if the ratings couldn't be committed.
+// Live country leaderboards without a shadow counterpart no longer have any players, so they're only kept as previous ones.
+// Returns the live keys of the promoted leaderboards.
+func promoteShadowLeaderboards() ([]string, error) {
+	shadowKeys, err := db.ScanRedisKeys(shadowKeyPrefix + "*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The leaderboards have already been promoted
+	if len(shadowKeys) == 0 {
+		return nil, nil
+	}
+
+	liveKeys, err := db.ScanRedisKeys("quaver:country_leaderboard:*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mode := range []enums.GameMode{enums.GameModeKeys4, enums.GameModeKeys7} {
+		liveKeys = append(liveKeys, leaderboardKey(mode))
+	}
+
+	pipeline := db.Redis.TxPipeline()
+
+	for _, key := range liveKeys {
+		pipeline.Rename(db.RedisCtx, key, previousLeaderboardKey(key))
+	}
+
+	promotedKeys := make([]string, 0, len(shadowKeys))
+
+	for _, key := range shadowKeys {
+		liveKey := liveLeaderboardKey(key)
+		promotedKeys = append(promotedKeys, liveKey)
+		pipeline.Rename(db.RedisCtx, key, liveKey)
+	}
+
+	if err := execLeaderboardPipeline(pipeline); err != nil {
+		return nil, err
+	}
+
+	return promotedKeys, nil
+}
+
+// Moves the promoted leaderboards back to their shadow keys and the previous leaderboards back to their live keys
+func restorePreviousLeaderboards(promotedKeys []string) error {
+	previousKeys, err := db.ScanRedisKeys(previousKeyPrefix + "*")
+
+	if err != nil {
+		return err
+	}
+
+	pipeline := db.Redis.TxPipeline()
+
+	for _, key := range promotedKeys {
+		pipeline.Rename(db.RedisCtx, key, shadowKeyPrefix+strings.TrimPrefix(key, "quaver:"))
+	}
+
+	for _, key := range previousKeys {
+		pipeline.Rename(db.RedisCtx, key, "quaver:"+strings.TrimPrefix(key, previousKeyPrefix))
+	}
+
+	return execLeaderboardPipeline(pipeline)
+}
+
+// Deletes the previous leaderboards once a job has been promoted
+func deletePreviousLeaderboards() error {
+	previousKeys, err := db.ScanRedisKeys(previousKeyPrefix + "*")
+
+	if err != nil || len(previousKeys) == 0 {
+		return err
+	}
+
+	return db.Redis.Del(db.RedisCtx, previousKeys...).Err()
+}
+
+// Runs a pipeline of renames. Renaming a key that doesn't exist fails without aborting the transaction,
+// which only happens for leaderboards that have no players, so those errors are ignored.
+func execLeaderboardPipeline(pipeline redis.Pipeliner) error {
+	cmds, err := pipeline.Exec(db.RedisCtx)
+
+	if err == nil {
+		return nil
+	}
+
+	for _, cmd := range cmds {
+		if cmd.Err() != nil && !strings.Contains(cmd.Err().Error(), "no such key") {
+			return cmd.Err()
+		}
+	}
+
+	return nil
+}
+
+func leaderboardKey(mode enums.GameMode) string {
+	return fmt.Sprintf("quaver:leaderboard:%v", mode)
+}
+
+func countryLeaderboardKey(country string, mode enums.GameMode) string {
+	return fmt.Sprintf("quaver:country_leaderboard:%v:%v", strings.ToLower(country), mode)
+}
+
+func shadowLeaderboardKey(mode enums.GameMode) string {
+	return shadowKeyPrefix + strings.TrimPrefix(leaderboardKey(mode), "quaver:")
+}
+
+func shadowCountryLeaderboardKey(country string, mode enums.GameMode) string {
+	return shadowKeyPrefix + strings.TrimPrefix(countryLeaderboardKey(country, mode), "quaver:")
+}
+
+// Returns the live key of a shadow leaderboard
+func liveLeaderboardKey(shadowKey string) string {
+	return "quaver:" + strings.TrimPrefix(shadowKey, shadowKeyPrefix)
+}
+
+// Returns the key that a live leaderboard is kept under while a job is being promoted
+func previousLeaderboardKey(liveKey string) string {
+	return previousKeyPrefix + strings.TrimPrefix(liveKey, "quaver:")
+}
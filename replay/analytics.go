@@ -0,0 +1,234 @@
+package replay
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	// TimingAnalyticsVersion Should be incremented when the analytics change, so stored ones are recalculated
+	TimingAnalyticsVersion = 1
+
+	// The width of each bucket of the hit error histogram in milliseconds
+	hitErrorBucketSize = 5
+
+	// The amount of sections the judgement timeline is split into
+	judgementTimelineSections = 50
+)
+
+// TimingAnalytics The timing of every hit in a replay, summarized so it can be charted.
+// Deviations are in real time rather than song time, so plays on different rates can be compared.
+// Negative deviations are early, and positive ones are late.
+type TimingAnalytics struct {
+	Version      int                    `json:"version"`
+	Hits         int                    `json:"hits"`
+	Mean         float64                `json:"mean"`
+	UnstableRate float64                `json:"unstable_rate"`
+	Histogram    []*HitErrorBucket      `json:"histogram"`
+	Releases     *ReleaseTiming         `json:"releases"`
+	Lanes        []*LaneTiming          `json:"lanes"`
+	Timeline     []*JudgementTimeWindow `json:"timeline"`
+}
+
+// HitErrorBucket The amount of presses with a deviation of at least Min and below Max
+type HitErrorBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// ReleaseTiming The timing of long note releases, which are kept separate as they have wider windows
+type ReleaseTiming struct {
+	Hits         int     `json:"hits"`
+	Mean         float64 `json:"mean"`
+	UnstableRate float64 `json:"unstable_rate"`
+}
+
+// LaneTiming How early or late the presses in a lane were
+type LaneTiming struct {
+	Lane       int     `json:"lane"`
+	Hits       int     `json:"hits"`
+	Early      int     `json:"early"`
+	Late       int     `json:"late"`
+	EarlyRatio float64 `json:"early_ratio"`
+	LateRatio  float64 `json:"late_ratio"`
+	Mean       float64 `json:"mean"`
+}
+
+// JudgementTimeWindow The judgements given between StartTime and EndTime in the song
+type JudgementTimeWindow struct {
+	StartTime      int `json:"start_time"`
+	EndTime        int `json:"end_time"`
+	CountMarvelous int `json:"count_marvelous"`
+	CountPerfect   int `json:"count_perfect"`
+	CountGreat     int `json:"count_great"`
+	CountGood      int `json:"count_good"`
+	CountOkay      int `json:"count_okay"`
+	CountMiss      int `json:"count_miss"`
+}
+
+// TimingAnalytics Calculates the timing analytics of the hits that were judged while playing
+func (p *VirtualPlayer) TimingAnalytics() *TimingAnalytics {
+	return calculateTimingAnalytics(p.Hits, p.rate, p.window(JudgementMiss, false)/p.rate)
+}
+
+func calculateTimingAnalytics(hits []HitStat, rate float32, maxDeviation float32) *TimingAnalytics {
+	analytics := &TimingAnalytics{
+		Version:   TimingAnalyticsVersion,
+		Histogram: newHitErrorHistogram(int(math.Ceil(float64(maxDeviation)))),
+		Lanes:     []*LaneTiming{},
+		Timeline:  newJudgementTimeline(hits),
+	}
+
+	var presses, releases []float64
+	lanes := map[int]*LaneTiming{}
+
+	for _, hit := range hits {
+		addToJudgementTimeline(analytics.Timeline, hit)
+
+		// Objects that were never hit don't have a deviation
+		if hit.Judgement == JudgementMiss && hit.Deviation == 0 {
+			continue
+		}
+
+		deviation := float64(hit.Deviation / rate)
+
+		if hit.IsRelease {
+			releases = append(releases, deviation)
+			continue
+		}
+
+		presses = append(presses, deviation)
+		addToHitErrorHistogram(analytics.Histogram, deviation)
+
+		lane, ok := lanes[hit.Lane]
+
+		if !ok {
+			lane = &LaneTiming{Lane: hit.Lane}
+			lanes[hit.Lane] = lane
+			analytics.Lanes = append(analytics.Lanes, lane)
+		}
+
+		lane.Hits++
+		lane.Mean += deviation
+
+		switch {
+		case deviation < 0:
+			lane.Early++
+		case deviation > 0:
+			lane.Late++
+		}
+	}
+
+	for _, lane := range analytics.Lanes {
+		lane.Mean /= float64(lane.Hits)
+		lane.EarlyRatio = float64(lane.Early) / float64(lane.Hits)
+		lane.LateRatio = float64(lane.Late) / float64(lane.Hits)
+	}
+
+	slices.SortFunc(analytics.Lanes, func(a, b *LaneTiming) int { return a.Lane - b.Lane })
+
+	analytics.Hits = len(presses)
+	analytics.Mean, analytics.UnstableRate = calculateMeanAndUnstableRate(presses)
+
+	analytics.Releases = &ReleaseTiming{Hits: len(releases)}
+	analytics.Releases.Mean, analytics.Releases.UnstableRate = calculateMeanAndUnstableRate(releases)
+
+	return analytics
+}
+
+// Returns the mean deviation and the unstable rate (10x the standard deviation)
+func calculateMeanAndUnstableRate(deviations []float64) (float64, float64) {
+	if len(deviations) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+
+	for _, deviation := range deviations {
+		sum += deviation
+	}
+
+	mean := sum / float64(len(deviations))
+
+	var variance float64
+
+	for _, deviation := range deviations {
+		variance += math.Pow(deviation-mean, 2)
+	}
+
+	variance /= float64(len(deviations))
+	return mean, math.Sqrt(variance) * 10
+}
+
+// Returns empty histogram buckets that cover deviations from -maxDeviation to maxDeviation
+func newHitErrorHistogram(maxDeviation int) []*HitErrorBucket {
+	bound := int(math.Ceil(float64(maxDeviation)/hitErrorBucketSize)) * hitErrorBucketSize
+	buckets := make([]*HitErrorBucket, 0, bound*2/hitErrorBucketSize)
+
+	for min := -bound; min < bound; min += hitErrorBucketSize {
+		buckets = append(buckets, &HitErrorBucket{Min: min, Max: min + hitErrorBucketSize})
+	}
+
+	return buckets
+}
+
+func addToHitErrorHistogram(buckets []*HitErrorBucket, deviation float64) {
+	if len(buckets) == 0 {
+		return
+	}
+
+	index := int(math.Floor((deviation - float64(buckets[0].Min)) / hitErrorBucketSize))
+	index = max(0, min(index, len(buckets)-1))
+
+	buckets[index].Count++
+}
+
+// Returns empty timeline sections that evenly split the time between the first and last judged objects
+func newJudgementTimeline(hits []HitStat) []*JudgementTimeWindow {
+	timeline := make([]*JudgementTimeWindow, 0, judgementTimelineSections)
+
+	if len(hits) == 0 {
+		return timeline
+	}
+
+	start, end := hits[0].HitObjectTime, hits[0].HitObjectTime
+
+	for _, hit := range hits {
+		start = min(start, hit.HitObjectTime)
+		end = max(end, hit.HitObjectTime)
+	}
+
+	size := max(int(math.Ceil(float64(end-start+1)/judgementTimelineSections)), 1)
+
+	for time := start; time <= end; time += size {
+		timeline = append(timeline, &JudgementTimeWindow{StartTime: time, EndTime: time + size})
+	}
+
+	return timeline
+}
+
+func addToJudgementTimeline(timeline []*JudgementTimeWindow, hit HitStat) {
+	if len(timeline) == 0 {
+		return
+	}
+
+	size := timeline[0].EndTime - timeline[0].StartTime
+	index := min((hit.HitObjectTime-timeline[0].StartTime)/size, len(timeline)-1)
+	window := timeline[index]
+
+	switch hit.Judgement {
+	case JudgementMarvelous:
+		window.CountMarvelous++
+	case JudgementPerfect:
+		window.CountPerfect++
+	case JudgementGreat:
+		window.CountGreat++
+	case JudgementGood:
+		window.CountGood++
+	case JudgementOkay:
+		window.CountOkay++
+	case JudgementMiss:
+		window.CountMiss++
+	}
+}
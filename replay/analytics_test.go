@@ -0,0 +1,83 @@
+package replay
+
+import (
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"testing"
+)
+
+func TestTimingAnalytics(t *testing.T) {
+	q := testMap()
+
+	frames := []Frame{
+		{Time: 0, Keys: 0},
+		{Time: 990, Keys: KeyPressStateFromLanes(1)},
+		{Time: 1050, Keys: 0},
+		{Time: 1260, Keys: KeyPressStateFromLanes(2)},
+		{Time: 1300, Keys: 0},
+		{Time: 1500, Keys: KeyPressStateFromLanes(3, 4)},
+		{Time: 1550, Keys: KeyPressStateFromLanes(3)},
+		{Time: 2020, Keys: 0},
+	}
+
+	player := NewVirtualPlayer(q, frames, 0)
+	player.PlayAll()
+
+	analytics := player.TimingAnalytics()
+
+	// The last object is never hit, so it doesn't have a deviation
+	if analytics.Hits != 4 || analytics.Mean != 0 {
+		t.Fatalf("expected 4 hits with a mean of 0, got %+v", analytics)
+	}
+
+	if analytics.Releases.Hits != 1 || analytics.Releases.Mean != 20 {
+		t.Fatalf("expected a release 20ms late, got %+v", analytics.Releases)
+	}
+
+	if len(analytics.Lanes) != 4 || analytics.Lanes[0].Early != 1 || analytics.Lanes[1].Late != 1 ||
+		analytics.Lanes[0].EarlyRatio != 1 {
+		t.Fatalf("unexpected lane timing: %+v", analytics.Lanes)
+	}
+
+	var histogramCount, missCount int
+
+	for _, bucket := range analytics.Histogram {
+		histogramCount += bucket.Count
+
+		if bucket.Min == -10 && bucket.Count != 1 {
+			t.Errorf("expected the early hit in the -10ms bucket, got %+v", bucket)
+		}
+	}
+
+	for _, window := range analytics.Timeline {
+		missCount += window.CountMiss
+	}
+
+	if histogramCount != 4 || missCount != 1 {
+		t.Fatalf("expected 4 hits in the histogram and 1 miss in the timeline, got %v and %v", histogramCount, missCount)
+	}
+}
+
+func TestTimingAnalyticsRate(t *testing.T) {
+	q := &qua.Qua{Mode: enums.GameModeKeys4, HitObjects: []qua.HitObject{
+		{StartTime: 1000, Lane: 1},
+		{StartTime: 2000, Lane: 1},
+	}}
+
+	frames := []Frame{
+		{Time: 980, Keys: KeyPressStateFromLanes(1)},
+		{Time: 1100, Keys: 0},
+		{Time: 2040, Keys: KeyPressStateFromLanes(1)},
+		{Time: 2100, Keys: 0},
+	}
+
+	player := NewVirtualPlayer(q, frames, enums.ModSpeed20X)
+	player.PlayAll()
+
+	analytics := player.TimingAnalytics()
+
+	// Deviations are in real time, so they're halved at 2.0x
+	if analytics.Mean != 5 || analytics.UnstableRate != 150 {
+		t.Fatalf("expected a mean of 5 and unstable rate of 150, got %v and %v", analytics.Mean, analytics.UnstableRate)
+	}
+}
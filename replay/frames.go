@@ -0,0 +1,35 @@
+package replay
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// KeyPressState A bitmask of the lanes that are held down during a frame. Lane 1 is the first bit.
+type KeyPressState int
+
+type Frame struct {
+	Time float32
+	Keys KeyPressState
+}
+
+// IsLaneHeld Returns if a given lane (starting at 1) is held down
+func (k KeyPressState) IsLaneHeld(lane int) bool {
+	return k&(1<<(lane-1)) != 0
+}
+
+// KeyPressStateFromLanes Returns the key press state of a set of held lanes
+func KeyPressStateFromLanes(lanes ...int) KeyPressState {
+	var state KeyPressState
+
+	for _, lane := range lanes {
+		state |= 1 << (lane - 1)
+	}
+
+	return state
+}
+
+// String Returns the frame in the format it is stored in replays (time|keys)
+func (f Frame) String() string {
+	return fmt.Sprintf("%v|%v", strconv.FormatFloat(float64(f.Time), 'f', -1, 32), int(f.Keys))
+}
@@ -0,0 +1,67 @@
+package replay
+
+type Judgement int
+
+const (
+	JudgementMarvelous Judgement = iota
+	JudgementPerfect
+	JudgementGreat
+	JudgementGood
+	JudgementOkay
+	JudgementMiss
+)
+
+// Judgements in the order they are checked in when a key is pressed
+var Judgements = []Judgement{
+	JudgementMarvelous,
+	JudgementPerfect,
+	JudgementGreat,
+	JudgementGood,
+	JudgementOkay,
+	JudgementMiss,
+}
+
+// The "Standard" judgement windows in milliseconds at 1.0x
+var judgementWindows = map[Judgement]float32{
+	JudgementMarvelous: 18,
+	JudgementPerfect:   43,
+	JudgementGreat:     76,
+	JudgementGood:      106,
+	JudgementOkay:      127,
+	JudgementMiss:      164,
+}
+
+// Long note releases are judged more leniently than presses
+const releaseWindowMultiplier float32 = 1.5
+
+var judgementAccuracyWeights = map[Judgement]float64{
+	JudgementMarvelous: 100,
+	JudgementPerfect:   98.25,
+	JudgementGreat:     65,
+	JudgementGood:      25,
+	JudgementOkay:      -100,
+	JudgementMiss:      -50,
+}
+
+var judgementScoreWeights = map[Judgement]int{
+	JudgementMarvelous: 100,
+	JudgementPerfect:   50,
+	JudgementGreat:     25,
+	JudgementGood:      10,
+	JudgementOkay:      5,
+	JudgementMiss:      0,
+}
+
+var judgementStrings = map[Judgement]string{
+	JudgementMarvelous: "Marv",
+	JudgementPerfect:   "Perf",
+	JudgementGreat:     "Great",
+	JudgementGood:      "Good",
+	JudgementOkay:      "Okay",
+	JudgementMiss:      "Miss",
+}
+
+// String Returns the stringified name of the judgement
+func (j Judgement) String() string {
+	return judgementStrings[j]
+}
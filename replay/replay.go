@@ -0,0 +1,368 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/ulikunitz/xz/lzma"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Replays recorded on this version of the game store the mods as an int32 instead of an int64.
+const legacyQuaverVersion string = "None"
+
+type Header struct {
+	QuaverVersion         string
+	MapMD5                string
+	ReplayMD5             string
+	PlayerName            string
+	Date                  string
+	TimePlayed            int64
+	Mode                  enums.GameMode
+	Mods                  enums.Mods
+	Score                 int32
+	Accuracy              float32
+	MaxCombo              int32
+	CountMarvelous        int32
+	CountPerfect          int32
+	CountGreat            int32
+	CountGood             int32
+	CountOkay             int32
+	CountMiss             int32
+	PauseCount            int32
+	RandomizeModifierSeed int32
+}
+
+type Replay struct {
+	Header *Header
+	Frames []Frame
+}
+
+// Parse Parses a full replay (header + frames)
+func Parse(data []byte) (*Replay, error) {
+	reader := bytes.NewReader(data)
+
+	header, err := readHeader(reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading replay header: %v", err)
+	}
+
+	frames, err := readFrames(reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replay{Header: header, Frames: frames}, nil
+}
+
+// ParseHeaderless Parses a replay that only contains the compressed frames (as uploaded by the game)
+func ParseHeaderless(data []byte) (*Replay, error) {
+	frames, err := readFrames(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replay{Frames: frames}, nil
+}
+
+// ParseFile Reads and parses a replay file on disk
+func ParseFile(path string, headerless bool) (*Replay, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if headerless {
+		return ParseHeaderless(data)
+	}
+
+	return Parse(data)
+}
+
+// NewHeader Creates a replay header from a score
+func NewHeader(user *db.User, score *db.Score) *Header {
+	version := score.QuaverVersion
+
+	if version == "" {
+		version = "0.0.1"
+	}
+
+	mode := score.Mode
+
+	if score.Map != nil {
+		mode = score.Map.GameMode
+	}
+
+	return &Header{
+		QuaverVersion:  version,
+		MapMD5:         score.MapMD5,
+		ReplayMD5:      score.ReplayMD5,
+		PlayerName:     user.Username,
+		Date:           time.UnixMilli(score.TimePlayEnd).UTC().Format("01/02/2006 15:04:05"),
+		TimePlayed:     score.TimePlayEnd,
+		Mode:           mode,
+		Mods:           enums.Mods(score.Modifiers),
+		Score:          int32(score.TotalScore),
+		Accuracy:       float32(score.Accuracy),
+		MaxCombo:       int32(score.MaxCombo),
+		CountMarvelous: int32(score.CountMarvelous),
+		CountPerfect:   int32(score.CountPerfect),
+		CountGreat:     int32(score.CountGreat),
+		CountGood:      int32(score.CountGood),
+		CountOkay:      int32(score.CountOkay),
+		CountMiss:      int32(score.CountMiss),
+		PauseCount:     int32(score.PauseCount),
+	}
+}
+
+// BuildFull Builds a full replay file from a score and the headerless replay that was uploaded with it
+func BuildFull(user *db.User, score *db.Score, headerless []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	if err := writeHeader(buf, NewHeader(user, score)); err != nil {
+		return nil, err
+	}
+
+	// The headerless replay is already the compressed frame stream, so it can be copied as is.
+	buf.Write(headerless)
+	return buf.Bytes(), nil
+}
+
+// BuildFullFile Builds a full replay from a headerless replay on disk and writes it to outputPath
+func BuildFullFile(user *db.User, score *db.Score, headerlessPath string, outputPath string) error {
+	headerless, err := os.ReadFile(headerlessPath)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := BuildFull(user, score, headerless)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(outputPath, data, 0644)
+}
+
+// Bytes Serializes the replay. If there's no header, a headerless replay is written.
+func (r *Replay) Bytes() ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	if r.Header != nil {
+		if err := writeHeader(buf, r.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeFrames(buf, r.Frames); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Reads the replay header
+func readHeader(r *bytes.Reader) (*Header, error) {
+	var err error
+	h := &Header{}
+
+	readString := func(dest *string) {
+		if err == nil {
+			*dest, err = readDotNetString(r)
+		}
+	}
+
+	readValue := func(dest any) {
+		if err == nil {
+			err = binary.Read(r, binary.LittleEndian, dest)
+		}
+	}
+
+	readString(&h.QuaverVersion)
+	readString(&h.MapMD5)
+	readString(&h.ReplayMD5)
+	readString(&h.PlayerName)
+	readString(&h.Date)
+	readValue(&h.TimePlayed)
+
+	var mode int32
+	readValue(&mode)
+	h.Mode = enums.GameMode(mode)
+
+	if h.QuaverVersion == legacyQuaverVersion {
+		var mods int32
+		readValue(&mods)
+		h.Mods = enums.Mods(mods)
+	} else {
+		var mods int64
+		readValue(&mods)
+		h.Mods = enums.Mods(mods)
+	}
+
+	readValue(&h.Score)
+	readValue(&h.Accuracy)
+	readValue(&h.MaxCombo)
+	readValue(&h.CountMarvelous)
+	readValue(&h.CountPerfect)
+	readValue(&h.CountGreat)
+	readValue(&h.CountGood)
+	readValue(&h.CountOkay)
+	readValue(&h.CountMiss)
+	readValue(&h.PauseCount)
+
+	if h.QuaverVersion != legacyQuaverVersion {
+		readValue(&h.RandomizeModifierSeed)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Writes the replay header
+func writeHeader(w io.Writer, h *Header) error {
+	for _, str := range []string{h.QuaverVersion, h.MapMD5, h.ReplayMD5, h.PlayerName, h.Date} {
+		if err := writeDotNetString(w, str); err != nil {
+			return err
+		}
+	}
+
+	var mods any = int64(h.Mods)
+
+	if h.QuaverVersion == legacyQuaverVersion {
+		mods = int32(h.Mods)
+	}
+
+	values := []any{h.TimePlayed, int32(h.Mode), mods, h.Score, h.Accuracy, h.MaxCombo,
+		h.CountMarvelous, h.CountPerfect, h.CountGreat, h.CountGood, h.CountOkay, h.CountMiss, h.PauseCount}
+
+	if h.QuaverVersion != legacyQuaverVersion {
+		values = append(values, h.RandomizeModifierSeed)
+	}
+
+	for _, value := range values {
+		if err := binary.Write(w, binary.LittleEndian, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reads and decompresses the replay frames
+func readFrames(r io.Reader) ([]Frame, error) {
+	reader, err := lzma.NewReader(r)
+
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing replay frames: %v", err)
+	}
+
+	data, err := io.ReadAll(reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing replay frames: %v", err)
+	}
+
+	frames := make([]Frame, 0)
+
+	for _, frameStr := range strings.Split(string(data), ",") {
+		frameStr = strings.TrimSpace(frameStr)
+
+		if frameStr == "" {
+			continue
+		}
+
+		split := strings.Split(frameStr, "|")
+
+		if len(split) < 2 {
+			return nil, fmt.Errorf("invalid replay frame: %v", frameStr)
+		}
+
+		frameTime, err := strconv.ParseFloat(split[0], 32)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay frame time: %v", frameStr)
+		}
+
+		keys, err := strconv.Atoi(split[1])
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay frame keys: %v", frameStr)
+		}
+
+		frames = append(frames, Frame{Time: float32(frameTime), Keys: KeyPressState(keys)})
+	}
+
+	return frames, nil
+}
+
+// Compresses and writes the replay frames
+func writeFrames(w io.Writer, frames []Frame) error {
+	frameStrs := make([]string, len(frames))
+
+	for i, frame := range frames {
+		frameStrs[i] = frame.String()
+	}
+
+	data := []byte(strings.Join(frameStrs, ","))
+
+	writer, err := lzma.WriterConfig{SizeInHeader: true, Size: int64(len(data))}.NewWriter(w)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// Reads a length-prefixed string written by .NET's BinaryWriter
+func readDotNetString(r io.ByteReader) (string, error) {
+	length, err := binary.ReadUvarint(r)
+
+	if err != nil {
+		return "", err
+	}
+
+	if length > math.MaxInt16 {
+		return "", errors.New("string length exceeds limit")
+	}
+
+	str := make([]byte, length)
+
+	for i := range str {
+		if str[i], err = r.ReadByte(); err != nil {
+			return "", err
+		}
+	}
+
+	return string(str), nil
+}
+
+// Writes a length-prefixed string in the format of .NET's BinaryWriter
+func writeDotNetString(w io.Writer, str string) error {
+	if _, err := w.Write(binary.AppendUvarint(nil, uint64(len(str)))); err != nil {
+		return err
+	}
+
+	_, err := w.Write([]byte(str))
+	return err
+}
@@ -0,0 +1,151 @@
+package replay
+
+import (
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"reflect"
+	"testing"
+)
+
+func testMap() *qua.Qua {
+	return &qua.Qua{
+		Mode: enums.GameModeKeys4,
+		HitObjects: []qua.HitObject{
+			{StartTime: 1000, Lane: 1},
+			{StartTime: 1250, Lane: 2},
+			{StartTime: 1500, Lane: 3, EndTime: 2000},
+			{StartTime: 1500, Lane: 4},
+			{StartTime: 2250, Lane: 1},
+		},
+	}
+}
+
+// Returns frames that press every object exactly on time
+func perfectFrames(q *qua.Qua) []Frame {
+	return []Frame{
+		{Time: 0, Keys: 0},
+		{Time: 1000, Keys: KeyPressStateFromLanes(1)},
+		{Time: 1050, Keys: 0},
+		{Time: 1250, Keys: KeyPressStateFromLanes(2)},
+		{Time: 1300, Keys: 0},
+		{Time: 1500, Keys: KeyPressStateFromLanes(3, 4)},
+		{Time: 1550, Keys: KeyPressStateFromLanes(3)},
+		{Time: 2000, Keys: 0},
+		{Time: 2250, Keys: KeyPressStateFromLanes(1)},
+		{Time: 2300, Keys: 0},
+	}
+}
+
+func TestReplayRoundTrip(t *testing.T) {
+	r := &Replay{
+		Header: &Header{
+			QuaverVersion:  "1.0.0",
+			MapMD5:         "map",
+			ReplayMD5:      "replay",
+			PlayerName:     "QuaverBot",
+			Date:           "01/01/2024 00:00:00",
+			TimePlayed:     1704067200000,
+			Mode:           enums.GameModeKeys4,
+			Mods:           enums.ModMirror,
+			Score:          1000000,
+			Accuracy:       100,
+			MaxCombo:       6,
+			CountMarvelous: 6,
+		},
+		Frames: perfectFrames(testMap()),
+	}
+
+	data, err := r.Bytes()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(r, parsed) {
+		t.Fatalf("expected %+v, got %+v", r.Header, parsed.Header)
+	}
+}
+
+func TestBuildFullFromHeaderless(t *testing.T) {
+	headerless, err := (&Replay{Frames: perfectFrames(testMap())}).Bytes()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &db.User{Username: "QuaverBot"}
+	score := &db.Score{MapMD5: "map", Mode: enums.GameModeKeys4, Modifiers: int64(enums.ModSpeed12X), TotalScore: 5}
+
+	data, err := BuildFull(user, score, headerless)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	full, err := Parse(data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if full.Header.PlayerName != user.Username || full.Header.Mods != enums.ModSpeed12X || full.Header.Score != 5 {
+		t.Fatalf("unexpected header: %+v", full.Header)
+	}
+
+	if len(full.Frames) != len(perfectFrames(testMap())) {
+		t.Fatalf("expected %v frames, got %v", len(perfectFrames(testMap())), len(full.Frames))
+	}
+}
+
+func TestVirtualPlayerPerfectPlay(t *testing.T) {
+	q := testMap()
+
+	player := NewVirtualPlayer(q, perfectFrames(q), 0)
+	player.PlayAll()
+
+	if player.CountMarvelous != q.MaxCombo() || player.CountMiss != 0 {
+		t.Fatalf("expected %v marvelous, got %+v", q.MaxCombo(), player)
+	}
+
+	if player.MaxCombo != q.MaxCombo() || player.Accuracy != 100 || player.Score != standardizedMaxScore {
+		t.Fatalf("unexpected result: %+v", player)
+	}
+}
+
+func TestVirtualPlayerNoInput(t *testing.T) {
+	q := testMap()
+
+	player := NewVirtualPlayer(q, []Frame{}, 0)
+	player.PlayAll()
+
+	if player.CountMiss != q.MaxCombo() || player.MaxCombo != 0 || player.Score != 0 || player.Accuracy != 0 {
+		t.Fatalf("unexpected result: %+v", player)
+	}
+}
+
+func TestVirtualPlayerRateWindows(t *testing.T) {
+	q := &qua.Qua{Mode: enums.GameModeKeys4, HitObjects: []qua.HitObject{{StartTime: 1000, Lane: 1}}}
+	frames := []Frame{{Time: 1030, Keys: KeyPressStateFromLanes(1)}, {Time: 1100, Keys: 0}}
+
+	normal := NewVirtualPlayer(q, frames, 0)
+	normal.PlayAll()
+
+	if normal.CountPerfect != 1 {
+		t.Fatalf("expected a perfect at 1.0x, got %+v", normal.Hits)
+	}
+
+	fast := NewVirtualPlayer(q, frames, enums.ModSpeed20X)
+	fast.PlayAll()
+
+	// Windows are scaled by the rate, so the same deviation is judged more leniently at 2.0x
+	if fast.CountMarvelous != 1 || fast.Hits[0].Deviation != 30 {
+		t.Fatalf("expected a marvelous at 2.0x, got %+v", fast.Hits)
+	}
+}
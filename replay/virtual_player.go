@@ -0,0 +1,383 @@
+package replay
+
+import (
+	"github.com/Quaver/api2/enums"
+	"github.com/Quaver/api2/qua"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+const (
+	maxMultiplierCount             int = 150
+	multiplierCountToIncreaseIndex int = 10
+	standardizedMaxScore           int = 1_000_000
+)
+
+// HitStat A single judgement that was given while playing the replay
+type HitStat struct {
+	Lane          int       `json:"lane"`
+	HitObjectTime int       `json:"hit_object_time"`
+	SongPosition  float32   `json:"song_position"`
+	Deviation     float32   `json:"deviation"`
+	Judgement     Judgement `json:"judgement"`
+	IsRelease     bool      `json:"is_release"`
+}
+
+// String Returns the hit's deviation in milliseconds, or Miss if the object was never hit.
+func (h HitStat) String() string {
+	if h.Judgement == JudgementMiss && h.Deviation == 0 {
+		return JudgementMiss.String()
+	}
+
+	return strconv.Itoa(int(math.Round(float64(h.Deviation))))
+}
+
+// A hit object and its state during playback
+type playerHitObject struct {
+	qua.HitObject
+	HeadJudged bool
+	TailJudged bool
+	Held       bool
+}
+
+type VirtualPlayer struct {
+	Map            *qua.Qua   `json:"-"`
+	Mods           enums.Mods `json:"-"`
+	Frames         []Frame    `json:"-"`
+	Score          int        `json:"score"`
+	Accuracy       float64    `json:"accuracy"`
+	Combo          int        `json:"-"`
+	MaxCombo       int        `json:"max_combo"`
+	CountMarvelous int        `json:"count_marvelous"`
+	CountPerfect   int        `json:"count_perfect"`
+	CountGreat     int        `json:"count_great"`
+	CountGood      int        `json:"count_good"`
+	CountOkay      int        `json:"count_okay"`
+	CountMiss      int        `json:"count_miss"`
+	CountMineHit   int        `json:"count_minehit"`
+	Hits           []HitStat  `json:"hits"`
+
+	rate            float32
+	lanes           map[int][]*playerHitObject
+	totalJudgements int
+	multiplierCount int
+	scoreCount      int
+	summedScore     int
+}
+
+// NewVirtualPlayer Creates a virtual player that plays the frames of a replay on a map
+func NewVirtualPlayer(q *qua.Qua, frames []Frame, mods enums.Mods) *VirtualPlayer {
+	p := &VirtualPlayer{
+		Map:    q,
+		Mods:   mods,
+		Frames: frames,
+		Hits:   []HitStat{},
+		rate:   enums.GetRateFromMods(mods),
+		lanes:  map[int][]*playerHitObject{},
+	}
+
+	keyCount := q.KeyCount(false)
+
+	for _, hitObject := range q.HitObjects {
+		if enums.IsModActivated(mods, enums.ModNoLongNotes) {
+			hitObject.EndTime = 0
+		}
+
+		if enums.IsModActivated(mods, enums.ModMirror) && hitObject.Lane <= keyCount {
+			hitObject.Lane = keyCount - hitObject.Lane + 1
+		}
+
+		p.lanes[hitObject.Lane] = append(p.lanes[hitObject.Lane], &playerHitObject{HitObject: hitObject})
+
+		p.totalJudgements++
+
+		if hitObject.IsLongNote() {
+			p.totalJudgements++
+		}
+	}
+
+	for _, objects := range p.lanes {
+		sort.SliceStable(objects, func(i, j int) bool { return objects[i].StartTime < objects[j].StartTime })
+	}
+
+	p.summedScore = calculateSummedScore(p.totalJudgements)
+	return p
+}
+
+// PlayVirtually Plays a replay file on a .qua file and returns the result
+func PlayVirtually(quaPath string, replayPath string, mods int64, headerless bool) (*VirtualPlayer, error) {
+	quaBytes, err := os.ReadFile(quaPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := qua.Parse(quaBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := ParseFile(replayPath, headerless)
+
+	if err != nil {
+		return nil, err
+	}
+
+	player := NewVirtualPlayer(q, r.Frames, enums.Mods(mods))
+	player.PlayAll()
+
+	return player, nil
+}
+
+// PlayAll Plays every frame of the replay and judges any objects that were left over
+func (p *VirtualPlayer) PlayAll() {
+	var previousKeys KeyPressState
+
+	for _, frame := range p.Frames {
+		p.handleMissedObjects(frame.Time)
+
+		for lane := 1; lane <= p.Map.KeyCount(true); lane++ {
+			wasHeld := previousKeys.IsLaneHeld(lane)
+			isHeld := frame.Keys.IsLaneHeld(lane)
+
+			switch {
+			case isHeld && !wasHeld:
+				p.handleKeyPress(lane, frame.Time)
+			case !isHeld && wasHeld:
+				p.handleKeyRelease(lane, frame.Time)
+			}
+		}
+
+		previousKeys = frame.Keys
+	}
+
+	p.handleMissedObjects(math.MaxFloat32)
+}
+
+// Deviance Returns the stringified deviation of every hit
+func (p *VirtualPlayer) Deviance() []string {
+	deviance := make([]string, len(p.Hits))
+
+	for i, hit := range p.Hits {
+		deviance[i] = hit.String()
+	}
+
+	return deviance
+}
+
+// Returns the judgement window scaled by the rate of the play
+func (p *VirtualPlayer) window(judgement Judgement, isRelease bool) float32 {
+	window := judgementWindows[judgement] * p.rate
+
+	if isRelease {
+		window *= releaseWindowMultiplier
+	}
+
+	return window
+}
+
+// Judges the first object in the lane that hasn't had its head judged yet
+func (p *VirtualPlayer) handleKeyPress(lane int, time float32) {
+	index := slices.IndexFunc(p.lanes[lane], func(h *playerHitObject) bool { return !h.HeadJudged })
+
+	if index == -1 {
+		return
+	}
+
+	hitObject := p.lanes[lane][index]
+	deviation := time - float32(hitObject.StartTime)
+
+	if float32(math.Abs(float64(deviation))) > p.window(JudgementMiss, false) {
+		return
+	}
+
+	judgement := JudgementMiss
+
+	for _, j := range Judgements {
+		if float32(math.Abs(float64(deviation))) <= p.window(j, false) {
+			judgement = j
+			break
+		}
+	}
+
+	hitObject.HeadJudged = true
+	p.addJudgement(hitObject, time, deviation, judgement, false)
+
+	if !hitObject.IsLongNote() {
+		return
+	}
+
+	// Missing the head of a long note also misses its tail
+	if judgement == JudgementMiss {
+		hitObject.TailJudged = true
+		p.addJudgement(hitObject, time, 0, JudgementMiss, true)
+		return
+	}
+
+	hitObject.Held = true
+}
+
+// Judges the release of the long note that is currently held in the lane
+func (p *VirtualPlayer) handleKeyRelease(lane int, time float32) {
+	index := slices.IndexFunc(p.lanes[lane], func(h *playerHitObject) bool { return h.Held })
+
+	if index == -1 {
+		return
+	}
+
+	hitObject := p.lanes[lane][index]
+	deviation := time - float32(hitObject.EndTime)
+
+	judgement := JudgementMiss
+
+	for _, j := range Judgements[:len(Judgements)-1] {
+		if float32(math.Abs(float64(deviation))) <= p.window(j, true) {
+			judgement = j
+			break
+		}
+	}
+
+	hitObject.Held = false
+	hitObject.TailJudged = true
+	p.addJudgement(hitObject, time, deviation, judgement, true)
+}
+
+// Judges every object whose windows have passed at the given time
+func (p *VirtualPlayer) handleMissedObjects(time float32) {
+	for lane := 1; lane <= p.Map.KeyCount(true); lane++ {
+		for _, hitObject := range p.lanes[lane] {
+			if hitObject.HeadJudged && !hitObject.Held {
+				continue
+			}
+
+			// Objects are sorted, so none of the following ones can be missed yet.
+			if !hitObject.HeadJudged && time <= float32(hitObject.StartTime)+p.window(JudgementOkay, false) {
+				break
+			}
+
+			if !hitObject.HeadJudged {
+				missTime := float32(hitObject.StartTime) + p.window(JudgementOkay, false)
+
+				hitObject.HeadJudged = true
+				p.addJudgement(hitObject, missTime, 0, JudgementMiss, false)
+
+				if hitObject.IsLongNote() {
+					hitObject.TailJudged = true
+					p.addJudgement(hitObject, missTime, 0, JudgementMiss, true)
+				}
+
+				continue
+			}
+
+			// Holding a long note past its release window counts as a late release
+			releaseTime := float32(hitObject.EndTime) + p.window(JudgementOkay, true)
+
+			if time > releaseTime {
+				hitObject.Held = false
+				hitObject.TailJudged = true
+				p.addJudgement(hitObject, releaseTime, p.window(JudgementOkay, true), JudgementOkay, true)
+			}
+		}
+	}
+}
+
+// Adds a judgement to the player's stats and recalculates the score, combo & accuracy
+func (p *VirtualPlayer) addJudgement(hitObject *playerHitObject, time float32, deviation float32,
+	judgement Judgement, isRelease bool) {
+	hitObjectTime := hitObject.StartTime
+
+	if isRelease {
+		hitObjectTime = hitObject.EndTime
+	}
+
+	p.Hits = append(p.Hits, HitStat{
+		Lane:          hitObject.Lane,
+		HitObjectTime: hitObjectTime,
+		SongPosition:  time,
+		Deviation:     deviation,
+		Judgement:     judgement,
+		IsRelease:     isRelease,
+	})
+
+	switch judgement {
+	case JudgementMarvelous:
+		p.CountMarvelous++
+	case JudgementPerfect:
+		p.CountPerfect++
+	case JudgementGreat:
+		p.CountGreat++
+	case JudgementGood:
+		p.CountGood++
+	case JudgementOkay:
+		p.CountOkay++
+	case JudgementMiss:
+		p.CountMiss++
+	}
+
+	if judgement == JudgementMiss {
+		p.Combo = 0
+	} else {
+		p.Combo++
+		p.MaxCombo = max(p.MaxCombo, p.Combo)
+	}
+
+	switch judgement {
+	case JudgementGood:
+		p.multiplierCount -= multiplierCountToIncreaseIndex
+	case JudgementOkay, JudgementMiss:
+		p.multiplierCount -= multiplierCountToIncreaseIndex * 2
+	default:
+		p.multiplierCount = min(p.multiplierCount+1, maxMultiplierCount)
+	}
+
+	p.multiplierCount = max(p.multiplierCount, 0)
+	p.scoreCount += judgementScoreWeights[judgement] + (p.multiplierCount/multiplierCountToIncreaseIndex)*10
+
+	if p.summedScore > 0 {
+		p.Score = int(float64(standardizedMaxScore) * (float64(p.scoreCount) / float64(p.summedScore)))
+	}
+
+	p.Accuracy = p.calculateAccuracy()
+}
+
+// Calculates the accuracy of all the judgements so far
+func (p *VirtualPlayer) calculateAccuracy() float64 {
+	counts := map[Judgement]int{
+		JudgementMarvelous: p.CountMarvelous,
+		JudgementPerfect:   p.CountPerfect,
+		JudgementGreat:     p.CountGreat,
+		JudgementGood:      p.CountGood,
+		JudgementOkay:      p.CountOkay,
+		JudgementMiss:      p.CountMiss,
+	}
+
+	var total int
+	var weighted float64
+
+	for judgement, count := range counts {
+		total += count
+		weighted += float64(count) * judgementAccuracyWeights[judgement]
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return math.Max(weighted/float64(total*100), 0) * 100
+}
+
+// Returns the score that is achieved by getting a Marvelous on every judgement
+func calculateSummedScore(totalJudgements int) int {
+	var summed int
+
+	for i := 1; i <= totalJudgements; i++ {
+		multiplierCount := min(i, maxMultiplierCount)
+		summed += judgementScoreWeights[JudgementMarvelous] + (multiplierCount/multiplierCountToIncreaseIndex)*10
+	}
+
+	return summed
+}
@@ -0,0 +1,195 @@
+package seasons
+
+import (
+	"fmt"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/enums"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"strconv"
+)
+
+const (
+	// The amount of best scores that make up the overall rating of a user in a season
+	overallRatingScoreCount = 500
+
+	finalizeBatchSize = 1000
+)
+
+// AddScore Adds a newly submitted score to the stats & leaderboards of the season it was set in.
+// Stats are recalculated from every score the user has submitted in the season rather than incremented,
+// so handling the same score again doesn't count it twice.
+func AddScore(season *db.Season, score *db.RedisScore, dbScore *db.Score) error {
+	stats, err := db.GetSeasonUserStats(season.Id, score.User.Id, dbScore.Mode)
+
+	if err != nil {
+		return err
+	}
+
+	totals, err := db.GetUserSeasonTotals(season, score.User.Id, dbScore.Mode)
+
+	if err != nil {
+		return err
+	}
+
+	stats.Country = score.User.Country
+	stats.PlayCount = totals.PlayCount
+	stats.FailCount = totals.FailCount
+	stats.MaxCombo = totals.MaxCombo
+	stats.TotalScore = totals.TotalScore
+	stats.TotalMarvelous = totals.TotalMarvelous
+	stats.TotalPerfect = totals.TotalPerfect
+	stats.TotalGreat = totals.TotalGreat
+	stats.TotalGood = totals.TotalGood
+	stats.TotalOkay = totals.TotalOkay
+	stats.TotalMiss = totals.TotalMiss
+
+	if dbScore.Failed || score.Map.RankedStatus != enums.RankedStatusRanked {
+		return stats.Save()
+	}
+
+	scores, err := db.GetUserSeasonScores(season, score.User.Id, dbScore.Mode)
+
+	if err != nil {
+		return err
+	}
+
+	best := db.GetBestScorePerMap(scores, overallRatingScoreCount)
+
+	stats.OverallPerformanceRating = db.CalculateOverallRating(best)
+	stats.OverallAccuracy = db.CalculateOverallAccuracy(best)
+	stats.RankedScore = 0
+
+	for _, s := range best {
+		stats.RankedScore += int64(s.TotalScore)
+	}
+
+	if err := stats.Save(); err != nil {
+		return err
+	}
+
+	if score.User.ShadowBanned {
+		return nil
+	}
+
+	member := redis.Z{Score: stats.OverallPerformanceRating, Member: strconv.Itoa(score.User.Id)}
+
+	if err := db.Redis.ZAdd(db.RedisCtx, db.SeasonLeaderboardRedisKey(season.Id, dbScore.Mode), member).Err(); err != nil {
+		return err
+	}
+
+	if score.User.Country == "XX" {
+		return nil
+	}
+
+	key := db.SeasonCountryLeaderboardRedisKey(season.Id, score.User.Country, dbScore.Mode)
+	return db.Redis.ZAdd(db.RedisCtx, key, member).Err()
+}
+
+// Finalize Archives the final standings of a season that has ended, awards its badge and removes its leaderboards.
+// It is safe to run again if it was interrupted, as ranks are overwritten and badges aren't given twice.
+func Finalize(season *db.Season) error {
+	for _, mode := range []enums.GameMode{enums.GameModeKeys4, enums.GameModeKeys7} {
+		if err := archiveStandings(season, mode); err != nil {
+			return err
+		}
+	}
+
+	if err := db.UpdateSeasonFinalized(season.Id); err != nil {
+		return err
+	}
+
+	keys, err := db.ScanRedisKeys(fmt.Sprintf("quaver:season:%v:*", season.Id))
+
+	if err != nil {
+		return err
+	}
+
+	if len(keys) > 0 {
+		if err := db.Redis.Del(db.RedisCtx, keys...).Err(); err != nil {
+			return err
+		}
+	}
+
+	logrus.Infof("Finalized season #%v (%v)", season.Id, season.Name)
+	return nil
+}
+
+// Stores the final global & country rank of every user on a season leaderboard
+func archiveStandings(season *db.Season, mode enums.GameMode) error {
+	key := db.SeasonLeaderboardRedisKey(season.Id, mode)
+	countryRanks := map[string]int{}
+
+	for offset := int64(0); ; offset += finalizeBatchSize {
+		members, err := db.Redis.ZRevRange(db.RedisCtx, key, offset, offset+finalizeBatchSize-1).Result()
+
+		if err != nil {
+			return err
+		}
+
+		if len(members) == 0 {
+			break
+		}
+
+		userIds := make([]int, 0, len(members))
+
+		for _, member := range members {
+			id, err := strconv.Atoi(member)
+
+			if err != nil {
+				return err
+			}
+
+			userIds = append(userIds, id)
+		}
+
+		stats, err := db.GetSeasonUserStatsForUsers(season.Id, mode, userIds)
+
+		if err != nil {
+			return err
+		}
+
+		countries := make(map[int]string, len(stats))
+
+		for _, s := range stats {
+			countries[s.UserId] = s.Country
+		}
+
+		for i, userId := range userIds {
+			rank := int(offset) + i + 1
+			countryRank := 0
+
+			if country, ok := countries[userId]; ok && country != "XX" {
+				countryRanks[country]++
+				countryRank = countryRanks[country]
+			}
+
+			if err := db.UpdateSeasonUserFinalRank(season.Id, userId, mode, rank, countryRank); err != nil {
+				return err
+			}
+
+			if season.BadgeId != nil && rank <= season.BadgeRankThreshold {
+				if err := awardBadge(userId, *season.BadgeId); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func awardBadge(userId int, badgeId int) error {
+	hasBadge, err := db.UserHasBadge(userId, badgeId)
+
+	if err != nil {
+		return err
+	}
+
+	if hasBadge {
+		return nil
+	}
+
+	badge := &db.UserBadge{UserId: userId, BadgeId: badgeId}
+	return badge.Insert()
+}
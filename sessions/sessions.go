@@ -0,0 +1,218 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Quaver/api2/config"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/stringutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	RefreshTokenPrefix = "qsr_"
+
+	maxUserAgentLength = 255
+)
+
+var ErrInvalidRefreshToken = errors.New("the refresh token is invalid, expired or has been revoked")
+
+// TokenResponse The tokens of a session. The refresh token can be exchanged for new tokens before the session expires.
+type TokenResponse struct {
+	AccessToken  string          `json:"access_token"`
+	TokenType    string          `json:"token_type"`
+	ExpiresIn    int             `json:"expires_in"`
+	RefreshToken string          `json:"refresh_token"`
+	Session      *db.UserSession `json:"session"`
+}
+
+// Create Starts a new session for a user on a device
+func Create(user *db.User, userAgent string, ip string) (*TokenResponse, error) {
+	if getSigningKey(config.Instance.JWT.CurrentKeyId) == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	refreshToken, err := generateRefreshToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	tokenId, err := generateTokenId()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	session := &db.UserSession{
+		UserId:           user.Id,
+		RefreshTokenHash: stringutil.HashToken(refreshToken),
+		AccessTokenId:    tokenId,
+		UserAgent:        userAgent,
+		IPAddress:        ip,
+		ExpiresAt:        time.Now().Add(refreshTokenLifetime()).UnixMilli(),
+	}
+
+	if err := session.Insert(); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := signAccessToken(user, session.Id, tokenId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session.Current = true
+	return newTokenResponse(accessToken, refreshToken, session), nil
+}
+
+// Refresh Exchanges a refresh token for a new access token and refresh token. The session's previous access token is revoked.
+// If a refresh token that has already been exchanged is used again, it has likely been stolen, so the whole session is revoked.
+func Refresh(refreshToken string, ip string) (*TokenResponse, error) {
+	hash := stringutil.HashToken(refreshToken)
+	session, err := db.GetUserSessionByRefreshToken(hash)
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	if session == nil {
+		reused, err := db.GetUserSessionByPreviousRefreshToken(hash)
+
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+
+		if reused != nil {
+			logrus.Warnf("Session #%v refresh token was reused, revoking the session", reused.Id)
+
+			if err := Revoke(reused); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := db.GetUserById(session.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Allowed {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := generateRefreshToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	tokenId, err := generateTokenId()
+
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := signAccessToken(user, session.Id, tokenId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	previousTokenId := session.AccessTokenId
+	expiresAt := time.Now().Add(refreshTokenLifetime()).UnixMilli()
+
+	if err := session.Rotate(stringutil.HashToken(newRefreshToken), tokenId, ip, expiresAt); err != nil {
+		return nil, err
+	}
+
+	if err := revokeAccessToken(previousTokenId); err != nil {
+		return nil, err
+	}
+
+	session.Current = true
+	return newTokenResponse(accessToken, newRefreshToken, session), nil
+}
+
+// Revoke Revokes a session and its current access token
+func Revoke(session *db.UserSession) error {
+	if err := session.Revoke(); err != nil {
+		return err
+	}
+
+	return revokeAccessToken(session.AccessTokenId)
+}
+
+// RevokeAll Logs a user out everywhere. Every session is revoked, and every JWT issued until now is rejected,
+// including legacy tokens that don't belong to a session.
+func RevokeAll(userId int) error {
+	if err := db.UpdateUserTokensRevokedAt(userId, time.Now().UnixMilli()); err != nil {
+		return err
+	}
+
+	sessions, err := db.GetUserSessions(userId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := Revoke(session); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked Returns if an access token's id (jti) is in the revocation list
+func IsAccessTokenRevoked(tokenId string) (bool, error) {
+	exists, err := db.Redis.Exists(db.RedisCtx, revokedAccessTokenKey(tokenId)).Result()
+
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+
+	return exists > 0, nil
+}
+
+// Adds an access token's id to the revocation list. Access tokens are short-lived,
+// so the id only needs to be kept until the token would have expired.
+func revokeAccessToken(tokenId string) error {
+	return db.Redis.Set(db.RedisCtx, revokedAccessTokenKey(tokenId), 1, accessTokenLifetime()).Err()
+}
+
+func revokedAccessTokenKey(tokenId string) string {
+	return fmt.Sprintf("quaver:jwt:revoked:%v", tokenId)
+}
+
+func generateRefreshToken() (string, error) {
+	token, err := stringutil.GenerateToken(32)
+
+	if err != nil {
+		return "", err
+	}
+
+	return RefreshTokenPrefix + token, nil
+}
+
+func newTokenResponse(accessToken string, refreshToken string, session *db.UserSession) *TokenResponse {
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTokenLifetime().Seconds()),
+		RefreshToken: refreshToken,
+		Session:      session,
+	}
+}
@@ -0,0 +1,135 @@
+package sessions
+
+import (
+	"errors"
+	"github.com/Quaver/api2/config"
+	"github.com/Quaver/api2/db"
+	"github.com/Quaver/api2/stringutil"
+	"github.com/golang-jwt/jwt/v5"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("the access token is invalid or has expired")
+	ErrNoSigningKey = errors.New("the current jwt signing key is not configured")
+)
+
+// Claims The claims of a JWT access token. Tokens issued before sessions existed have no session id or token id (jti).
+type Claims struct {
+	UserId    int    `json:"user_id"`
+	Username  string `json:"username"`
+	SessionId int    `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IsLegacy Returns if the token was issued without a session, so it can't be revoked
+func (claims *Claims) IsLegacy() bool {
+	return claims.SessionId == 0
+}
+
+// IsIssuedBeforeRevocation Returns if a token was issued before the user logged out everywhere.
+// Tokens without an issue time can't be checked, so they're treated as revoked once the user has logged out everywhere.
+func IsIssuedBeforeRevocation(user *db.User, claims *Claims) bool {
+	if user.TokensRevokedAt == nil {
+		return false
+	}
+
+	if claims.IssuedAt == nil {
+		return true
+	}
+
+	return claims.IssuedAt.UnixMilli() <= *user.TokensRevokedAt
+}
+
+// Returns how long access tokens are valid for
+func accessTokenLifetime() time.Duration {
+	return time.Duration(config.Instance.JWT.AccessTokenMinutes) * time.Minute
+}
+
+// Returns how long a session can go without being refreshed
+func refreshTokenLifetime() time.Duration {
+	return time.Duration(config.Instance.JWT.RefreshTokenDays) * 24 * time.Hour
+}
+
+// Returns the signing key with a given id
+func getSigningKey(id string) *config.JWTKey {
+	for _, key := range config.Instance.JWT.Keys {
+		if key.Id == id && key.Secret != "" {
+			return &key
+		}
+	}
+
+	return nil
+}
+
+// Signs a new access token for a session with the current key, whose id is put in the `kid` header
+func signAccessToken(user *db.User, sessionId int, tokenId string) (string, error) {
+	key := getSigningKey(config.Instance.JWT.CurrentKeyId)
+
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		UserId:    user.Id,
+		Username:  user.Username,
+		SessionId: sessionId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenId,
+			Subject:   strconv.Itoa(user.Id),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenLifetime())),
+		},
+	})
+
+	token.Header["kid"] = key.Id
+	return token.SignedString([]byte(key.Secret))
+}
+
+// Generates the id (jti) of a new access token
+func generateTokenId() (string, error) {
+	return stringutil.GenerateToken(16)
+}
+
+// ParseAccessToken Verifies an access token with the key in its `kid` header, and returns its claims.
+// Tokens without a `kid` are legacy tokens signed with the jwt secret, which are accepted until their sunset time, if one is configured.
+func ParseAccessToken(value string) (*Claims, error) {
+	claims := &Claims{}
+	isLegacy := false
+
+	_, err := jwt.ParseWithClaims(value, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+
+		if !ok {
+			isLegacy = true
+
+			if !config.Instance.JWT.AcceptsLegacyTokens() || config.Instance.JWTSecret == "" {
+				return nil, ErrInvalidToken
+			}
+
+			return []byte(config.Instance.JWTSecret), nil
+		}
+
+		key := getSigningKey(kid)
+
+		if key == nil {
+			return nil, ErrInvalidToken
+		}
+
+		return []byte(key.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Tokens signed with a session key must belong to a session, so they can be revoked
+	if !isLegacy && (claims.IsLegacy() || claims.ID == "") {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,108 @@
+package sessions
+
+import (
+	"github.com/Quaver/api2/config"
+	"github.com/Quaver/api2/db"
+	"github.com/golang-jwt/jwt/v5"
+	"testing"
+	"time"
+)
+
+func TestParseAccessToken(t *testing.T) {
+	config.Instance = &config.Config{JWTSecret: "legacy-secret"}
+	config.Instance.JWT.Keys = []config.JWTKey{{Id: "2024-01", Secret: "first-secret"}}
+	config.Instance.JWT.CurrentKeyId = "2024-01"
+	config.Instance.JWT.AccessTokenMinutes = 15
+
+	user := &db.User{Id: 1, Username: "QuaverBot"}
+	token, err := signAccessToken(user, 5, "token-id")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := ParseAccessToken(token)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.UserId != 1 || claims.SessionId != 5 || claims.ID != "token-id" || claims.IsLegacy() {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	// Tokens signed with the previous key are still valid after rotating, until the key is removed
+	config.Instance.JWT.Keys = append(config.Instance.JWT.Keys, config.JWTKey{Id: "2024-02", Secret: "second-secret"})
+	config.Instance.JWT.CurrentKeyId = "2024-02"
+
+	if _, err := ParseAccessToken(token); err != nil {
+		t.Fatalf("expected a token signed with the previous key to be valid: %v", err)
+	}
+
+	config.Instance.JWT.Keys = config.Instance.JWT.Keys[1:]
+
+	if _, err := ParseAccessToken(token); err != ErrInvalidToken {
+		t.Fatalf("expected a token signed with a removed key to be invalid")
+	}
+
+	// Legacy tokens have no kid or session, and are signed with the jwt secret
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		UserId: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte("legacy-secret"))
+
+	// Legacy tokens are accepted without a sunset time, and only refused once it has passed
+	if claims, err := ParseAccessToken(legacy); err != nil || !claims.IsLegacy() {
+		t.Fatalf("expected the legacy token to be valid without a sunset time")
+	}
+
+	config.Instance.JWT.LegacyTokensSunset = time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	if claims, err := ParseAccessToken(legacy); err != nil || !claims.IsLegacy() {
+		t.Fatalf("expected the legacy token to be valid before its sunset time")
+	}
+
+	config.Instance.JWT.LegacyTokensSunset = time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	if _, err := ParseAccessToken(legacy); err != ErrInvalidToken {
+		t.Fatalf("expected the legacy token to be invalid after its sunset time")
+	}
+
+	// Tokens signed with a session key must belong to a session
+	sessionless := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserId: 1})
+	sessionless.Header["kid"] = "2024-02"
+	value, _ := sessionless.SignedString([]byte("second-secret"))
+
+	if _, err := ParseAccessToken(value); err != ErrInvalidToken {
+		t.Fatalf("expected a token without a session to be invalid")
+	}
+}
+
+func TestIsIssuedBeforeRevocation(t *testing.T) {
+	issuedAt := time.Now()
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issuedAt)}}
+	user := &db.User{Id: 1}
+
+	if IsIssuedBeforeRevocation(user, claims) {
+		t.Fatalf("expected the token to be valid when the user hasn't logged out everywhere")
+	}
+
+	revokedAt := issuedAt.Add(time.Minute).UnixMilli()
+	user.TokensRevokedAt = &revokedAt
+
+	if !IsIssuedBeforeRevocation(user, claims) {
+		t.Fatalf("expected a token issued before logging out everywhere to be revoked")
+	}
+
+	if !IsIssuedBeforeRevocation(user, &Claims{}) {
+		t.Fatalf("expected a token without an issue time to be revoked")
+	}
+
+	claims.IssuedAt = jwt.NewNumericDate(issuedAt.Add(time.Hour))
+
+	if IsIssuedBeforeRevocation(user, claims) {
+		t.Fatalf("expected a token issued after logging out everywhere to be valid")
+	}
+}
@@ -2,6 +2,7 @@ package stringutil
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 )
 
@@ -17,3 +18,9 @@ func GenerateToken(size int) (string, error) {
 
 	return hex.EncodeToString(randomBytes), nil
 }
+
+// HashToken Returns the SHA-256 hash of a token, so it can be stored and looked up without storing the token itself
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,260 @@
+package tournaments
+
+import (
+	"errors"
+	"github.com/Quaver/api2/db"
+	"math/bits"
+	"time"
+)
+
+var (
+	ErrNotEnoughTeams = errors.New("there are not enough teams to generate a bracket")
+	ErrInvalidBracket = errors.New("invalid bracket type")
+)
+
+// A slot of a match in a bracket that is being generated.
+// A slot is resolved once it's known which team (or no team, for byes) will be in it.
+type bracketSlot struct {
+	team     *int
+	resolved bool
+}
+
+type bracketSlotRef struct {
+	match int
+	slot  int
+}
+
+var noSlot = bracketSlotRef{match: -1}
+
+type bracketMatch struct {
+	match     *db.TournamentMatch
+	slots     [2]bracketSlot
+	winnerTo  bracketSlotRef
+	loserTo   bracketSlotRef
+	loserSent bool
+	done      bool
+}
+
+type bracket struct {
+	tournamentId int
+	matches      []*bracketMatch
+}
+
+// GenerateBracket Creates the matches of a bracket for teams ordered by seed.
+// Teams are placed so the top seeds meet as late as possible, and get byes if the amount of teams
+// isn't a power of two. Matches are returned along with where their winners & losers advance to.
+func GenerateBracket(tournamentId int, bracketType db.TournamentBracketType, seededTeamIds []int) ([]*db.TournamentMatch, []*db.TournamentMatchLink, error) {
+	switch bracketType {
+	case db.TournamentSingleElimination:
+		if len(seededTeamIds) < 2 {
+			return nil, nil, ErrNotEnoughTeams
+		}
+	case db.TournamentDoubleElimination:
+		if len(seededTeamIds) < 4 {
+			return nil, nil, ErrNotEnoughTeams
+		}
+	default:
+		return nil, nil, ErrInvalidBracket
+	}
+
+	size := 1 << bits.Len(uint(len(seededTeamIds)-1))
+	rounds := bits.Len(uint(size)) - 1
+
+	b := &bracket{tournamentId: tournamentId}
+	winners := b.addWinnersBracket(size, rounds)
+
+	if bracketType == db.TournamentDoubleElimination {
+		b.addLosersBracket(size, rounds, winners)
+	}
+
+	order := getSeedOrder(size)
+
+	for i, match := range winners[0] {
+		for slot := 0; slot < 2; slot++ {
+			seed := order[i*2+slot]
+			b.matches[match].slots[slot].resolved = true
+
+			if seed <= len(seededTeamIds) {
+				teamId := seededTeamIds[seed-1]
+				b.matches[match].slots[slot].team = &teamId
+			}
+		}
+	}
+
+	b.resolveByes()
+	return b.build()
+}
+
+// Returns the seed that is placed in each position of the first round, so that seeds 1 & 2 can only meet in the final
+func getSeedOrder(size int) []int {
+	order := []int{1}
+
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+
+		for _, seed := range order {
+			next = append(next, seed, len(order)*2+1-seed)
+		}
+
+		order = next
+	}
+
+	return order
+}
+
+func (b *bracket) add(bracketType db.TournamentBracket, round int, position int) int {
+	b.matches = append(b.matches, &bracketMatch{
+		match: &db.TournamentMatch{
+			TournamentId: b.tournamentId,
+			Bracket:      bracketType,
+			Round:        round,
+			Position:     position,
+		},
+		winnerTo: noSlot,
+		loserTo:  noSlot,
+	})
+
+	return len(b.matches) - 1
+}
+
+// Adds the winners bracket and returns the indexes of the matches in each round
+func (b *bracket) addWinnersBracket(size int, rounds int) [][]int {
+	winners := make([][]int, rounds)
+
+	for r := 0; r < rounds; r++ {
+		for i := 0; i < size>>(r+1); i++ {
+			winners[r] = append(winners[r], b.add(db.TournamentBracketWinners, r+1, i+1))
+
+			if r > 0 {
+				b.matches[winners[r-1][i*2]].winnerTo = bracketSlotRef{winners[r][i], 0}
+				b.matches[winners[r-1][i*2+1]].winnerTo = bracketSlotRef{winners[r][i], 1}
+			}
+		}
+	}
+
+	return winners
+}
+
+// Adds the losers bracket & grand finals. Losers of the first winners round play each other, after which
+// losers of each following winners round drop down to play the survivors of the losers bracket.
+func (b *bracket) addLosersBracket(size int, rounds int, winners [][]int) {
+	var previous []int
+
+	for r := 1; r <= 2*(rounds-1); r++ {
+		var current []int
+
+		switch {
+		case r == 1:
+			for i := 0; i < size/4; i++ {
+				current = append(current, b.add(db.TournamentBracketLosers, r, i+1))
+				b.matches[winners[0][i*2]].loserTo = bracketSlotRef{current[i], 0}
+				b.matches[winners[0][i*2+1]].loserTo = bracketSlotRef{current[i], 1}
+			}
+		case r%2 == 0:
+			// Teams dropping down are placed in reverse, to avoid rematches from the winners bracket
+			dropping := winners[r/2]
+
+			for i := range previous {
+				current = append(current, b.add(db.TournamentBracketLosers, r, i+1))
+				b.matches[previous[i]].winnerTo = bracketSlotRef{current[i], 0}
+				b.matches[dropping[len(dropping)-1-i]].loserTo = bracketSlotRef{current[i], 1}
+			}
+		default:
+			for i := 0; i < len(previous)/2; i++ {
+				current = append(current, b.add(db.TournamentBracketLosers, r, i+1))
+				b.matches[previous[i*2]].winnerTo = bracketSlotRef{current[i], 0}
+				b.matches[previous[i*2+1]].winnerTo = bracketSlotRef{current[i], 1}
+			}
+		}
+
+		previous = current
+	}
+
+	grandFinals := b.add(db.TournamentBracketGrandFinals, 1, 1)
+	b.matches[winners[rounds-1][0]].winnerTo = bracketSlotRef{grandFinals, 0}
+	b.matches[previous[0]].winnerTo = bracketSlotRef{grandFinals, 1}
+}
+
+func (b *bracket) send(ref bracketSlotRef, team *int) {
+	if ref.match == -1 {
+		return
+	}
+
+	b.matches[ref.match].slots[ref.slot] = bracketSlot{team: team, resolved: true}
+}
+
+// Advances teams through matches they have no opponent in, until every remaining match needs to be played
+func (b *bracket) resolveByes() {
+	now := time.Now().UnixMilli()
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, m := range b.matches {
+			if m.done {
+				continue
+			}
+
+			first, second := m.slots[0], m.slots[1]
+
+			if first.resolved && second.resolved {
+				if first.team != nil && second.team != nil {
+					continue
+				}
+
+				winner := first.team
+
+				if winner == nil {
+					winner = second.team
+				}
+
+				m.match.Bye = true
+				m.match.WinnerId = winner
+				m.match.CompletedAt = &now
+				m.done = true
+
+				b.send(m.winnerTo, winner)
+
+				if !m.loserSent {
+					b.send(m.loserTo, nil)
+					m.loserSent = true
+				}
+
+				changed = true
+				continue
+			}
+
+			// The team that arrives in this match will advance without playing, so there won't be a loser
+			isEmpty := (first.resolved && first.team == nil) || (second.resolved && second.team == nil)
+
+			if isEmpty && !m.loserSent {
+				m.match.Bye = true
+				b.send(m.loserTo, nil)
+				m.loserSent = true
+				changed = true
+			}
+		}
+	}
+}
+
+// Returns the generated matches with the teams that have been placed in them
+func (b *bracket) build() ([]*db.TournamentMatch, []*db.TournamentMatchLink, error) {
+	matches := make([]*db.TournamentMatch, 0, len(b.matches))
+	links := make([]*db.TournamentMatchLink, 0, len(b.matches))
+
+	for _, m := range b.matches {
+		m.match.Team1Id = m.slots[0].team
+		m.match.Team2Id = m.slots[1].team
+
+		matches = append(matches, m.match)
+
+		links = append(links, &db.TournamentMatchLink{
+			NextMatch:      m.winnerTo.match,
+			NextSlot:       m.winnerTo.slot + 1,
+			LoserNextMatch: m.loserTo.match,
+			LoserNextSlot:  m.loserTo.slot + 1,
+		})
+	}
+
+	return matches, links, nil
+}
@@ -0,0 +1,149 @@
+package tournaments
+
+import (
+	"github.com/Quaver/api2/db"
+	"reflect"
+	"testing"
+)
+
+func TestGetSeedOrder(t *testing.T) {
+	expected := []int{1, 8, 4, 5, 2, 7, 3, 6}
+
+	if order := getSeedOrder(8); !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+}
+
+// Generates a bracket and links its matches the same way they are when inserted into the database
+func generateTestBracket(t *testing.T, bracketType db.TournamentBracketType, teams int) []*db.TournamentMatch {
+	teamIds := make([]int, 0, teams)
+
+	for i := 1; i <= teams; i++ {
+		teamIds = append(teamIds, i)
+	}
+
+	matches, links, err := GenerateBracket(1, bracketType, teamIds)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, match := range matches {
+		match.Id = i + 1
+	}
+
+	for i, link := range links {
+		if link.NextMatch != -1 {
+			matches[i].NextMatchId = &matches[link.NextMatch].Id
+			matches[i].NextMatchSlot = &link.NextSlot
+		}
+
+		if link.LoserNextMatch != -1 {
+			matches[i].LoserNextMatchId = &matches[link.LoserNextMatch].Id
+			matches[i].LoserNextMatchSlot = &link.LoserNextSlot
+		}
+	}
+
+	return matches
+}
+
+// Plays every match in the bracket, with the lower team id always winning. Returns the winner of the tournament.
+func playTestBracket(t *testing.T, matches []*db.TournamentMatch) int {
+	for played := 0; played < len(matches); played++ {
+		for _, match := range matches {
+			if match.CompletedAt != nil || match.Team1Id == nil || match.Team2Id == nil {
+				continue
+			}
+
+			team1Score, team2Score := 1, 0
+
+			if *match.Team2Id < *match.Team1Id {
+				team1Score, team2Score = 0, 1
+			}
+
+			_, decided, err := ReportMatchResult(matches, match.Id, team1Score, team2Score, nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if decided {
+				for _, m := range matches {
+					if m.CompletedAt == nil {
+						t.Fatalf("match #%v wasn't completed: %+v", m.Id, m)
+					}
+				}
+
+				return *match.WinnerId
+			}
+
+			break
+		}
+	}
+
+	t.Fatal("the tournament was never decided")
+	return 0
+}
+
+func TestGenerateSingleEliminationBracketWithByes(t *testing.T) {
+	matches := generateTestBracket(t, db.TournamentSingleElimination, 5)
+
+	if len(matches) != 7 {
+		t.Fatalf("expected 7 matches, got %v", len(matches))
+	}
+
+	byes := 0
+
+	for _, match := range matches[:4] {
+		if match.Bye {
+			byes++
+		}
+	}
+
+	if byes != 3 {
+		t.Fatalf("expected 3 byes in the first round, got %v", byes)
+	}
+
+	// Seeds 2 & 3 both had byes, so they meet in the second round
+	second := matches[5]
+
+	if second.Team1Id == nil || second.Team2Id == nil || *second.Team1Id != 2 || *second.Team2Id != 3 {
+		t.Fatalf("expected seeds 2 & 3 to be placed in the second round, got %+v", second)
+	}
+
+	if winner := playTestBracket(t, matches); winner != 1 {
+		t.Fatalf("expected seed 1 to win, got %v", winner)
+	}
+}
+
+func TestGenerateDoubleEliminationBracket(t *testing.T) {
+	for _, teams := range []int{4, 5, 6, 8, 11, 16} {
+		matches := generateTestBracket(t, db.TournamentDoubleElimination, teams)
+
+		if winner := playTestBracket(t, matches); winner != 1 {
+			t.Fatalf("expected seed 1 to win with %v teams, got %v", teams, winner)
+		}
+	}
+}
+
+func TestReportMatchResultAdvancesLoser(t *testing.T) {
+	matches := generateTestBracket(t, db.TournamentDoubleElimination, 4)
+
+	changed, decided, err := ReportMatchResult(matches, matches[0].Id, 2, 3, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decided || len(changed) != 3 {
+		t.Fatalf("expected the match, winners & losers matches to change, got %v", len(changed))
+	}
+
+	if *matches[0].WinnerId != 4 || *matches[2].Team1Id != 4 || *matches[3].Team1Id != 1 {
+		t.Fatalf("expected seed 4 to advance and seed 1 to drop to the losers bracket")
+	}
+
+	if _, _, err := ReportMatchResult(matches, matches[0].Id, 1, 0, nil); err != ErrMatchAlreadyCompleted {
+		t.Fatalf("expected the match to already be completed, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package tournaments
+
+import (
+	"errors"
+	"github.com/Quaver/api2/db"
+	"slices"
+	"time"
+)
+
+var (
+	ErrMatchNotFound         = errors.New("match not found")
+	ErrMatchAlreadyCompleted = errors.New("this match has already been completed")
+	ErrMatchNotReady         = errors.New("both teams must be known before the match can be played")
+	ErrMatchTied             = errors.New("a match can't end in a tie")
+)
+
+// ReportMatchResult Sets the result of a match in a bracket and advances the winner & loser to their next matches.
+// Returns the matches that were changed, and if the tournament has been decided.
+func ReportMatchResult(matches []*db.TournamentMatch, matchId int, team1Score int, team2Score int,
+	gameId *int) ([]*db.TournamentMatch, bool, error) {
+	match := findMatch(matches, matchId)
+
+	if match == nil {
+		return nil, false, ErrMatchNotFound
+	}
+
+	if match.CompletedAt != nil {
+		return nil, false, ErrMatchAlreadyCompleted
+	}
+
+	if match.Team1Id == nil || match.Team2Id == nil {
+		return nil, false, ErrMatchNotReady
+	}
+
+	if team1Score == team2Score {
+		return nil, false, ErrMatchTied
+	}
+
+	now := time.Now().UnixMilli()
+	winner, loser := *match.Team1Id, *match.Team2Id
+
+	if team2Score > team1Score {
+		winner, loser = loser, winner
+	}
+
+	match.Team1Score = team1Score
+	match.Team2Score = team2Score
+	match.WinnerId = &winner
+	match.GameId = gameId
+	match.CompletedAt = &now
+
+	changed := []*db.TournamentMatch{match}
+	changed = advance(matches, changed, winner, match.NextMatchId, match.NextMatchSlot, now)
+	changed = advance(matches, changed, loser, match.LoserNextMatchId, match.LoserNextMatchSlot, now)
+
+	return changed, match.NextMatchId == nil, nil
+}
+
+// Places a team in the slot of the next match. If the team has no opponent there, it keeps advancing.
+func advance(matches []*db.TournamentMatch, changed []*db.TournamentMatch, teamId int, matchId *int,
+	slot *int, now int64) []*db.TournamentMatch {
+	if matchId == nil || slot == nil {
+		return changed
+	}
+
+	next := findMatch(matches, *matchId)
+
+	if next == nil {
+		return changed
+	}
+
+	if *slot == 1 {
+		next.Team1Id = &teamId
+	} else {
+		next.Team2Id = &teamId
+	}
+
+	if !slices.Contains(changed, next) {
+		changed = append(changed, next)
+	}
+
+	if !next.Bye || next.CompletedAt != nil {
+		return changed
+	}
+
+	next.WinnerId = &teamId
+	next.CompletedAt = &now
+
+	return advance(matches, changed, teamId, next.NextMatchId, next.NextMatchSlot, now)
+}
+
+func findMatch(matches []*db.TournamentMatch, id int) *db.TournamentMatch {
+	index := slices.IndexFunc(matches, func(m *db.TournamentMatch) bool { return m.Id == id })
+
+	if index == -1 {
+		return nil
+	}
+
+	return matches[index]
+}
@@ -0,0 +1,106 @@
+package tournaments
+
+import (
+	"github.com/Quaver/api2/db"
+	"slices"
+	"sort"
+)
+
+type teamQualifierResult struct {
+	team       *db.TournamentTeam
+	rankSum    int
+	totalScore int64
+}
+
+// CalculateSeeds Orders teams by their qualifier results. Each team's score on a map is the sum of its
+// members' best scores, and teams are seeded by their average placement across every qualifier map.
+// Teams that didn't play a map are placed last on it. Ties are broken by the total score.
+func CalculateSeeds(teams []*db.TournamentTeam, scores []*db.TournamentQualifierScore) []*db.TournamentTeam {
+	userTeams := map[int]int{}
+
+	for _, team := range teams {
+		for _, member := range team.Members {
+			userTeams[member.UserId] = team.Id
+		}
+	}
+
+	mapScores := map[string]map[int]int64{}
+	md5s := make([]string, 0)
+
+	for _, score := range scores {
+		teamId, ok := userTeams[score.UserId]
+
+		if !ok {
+			continue
+		}
+
+		if _, ok := mapScores[score.MapMD5]; !ok {
+			mapScores[score.MapMD5] = map[int]int64{}
+			md5s = append(md5s, score.MapMD5)
+		}
+
+		mapScores[score.MapMD5][teamId] += int64(score.Score)
+	}
+
+	results := make([]*teamQualifierResult, 0, len(teams))
+	resultsByTeam := map[int]*teamQualifierResult{}
+
+	for _, team := range teams {
+		result := &teamQualifierResult{team: team}
+		results = append(results, result)
+		resultsByTeam[team.Id] = result
+	}
+
+	slices.Sort(md5s)
+
+	for _, md5 := range md5s {
+		ranked := make([]int, 0, len(mapScores[md5]))
+
+		for teamId := range mapScores[md5] {
+			ranked = append(ranked, teamId)
+		}
+
+		sort.Slice(ranked, func(i, j int) bool {
+			if mapScores[md5][ranked[i]] != mapScores[md5][ranked[j]] {
+				return mapScores[md5][ranked[i]] > mapScores[md5][ranked[j]]
+			}
+
+			return ranked[i] < ranked[j]
+		})
+
+		for _, result := range results {
+			index := slices.Index(ranked, result.team.Id)
+
+			if index == -1 {
+				result.rankSum += len(teams)
+				continue
+			}
+
+			result.rankSum += index + 1
+			result.totalScore += mapScores[md5][result.team.Id]
+		}
+	}
+
+	// The amount of maps is the same for every team, so the rank sum orders teams the same as the average
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+
+		if a.rankSum != b.rankSum {
+			return a.rankSum < b.rankSum
+		}
+
+		if a.totalScore != b.totalScore {
+			return a.totalScore > b.totalScore
+		}
+
+		return a.team.Id < b.team.Id
+	})
+
+	seeded := make([]*db.TournamentTeam, 0, len(results))
+
+	for _, result := range results {
+		seeded = append(seeded, result.team)
+	}
+
+	return seeded
+}
@@ -0,0 +1,33 @@
+package tournaments
+
+import (
+	"github.com/Quaver/api2/db"
+	"testing"
+)
+
+func TestCalculateSeeds(t *testing.T) {
+	teams := []*db.TournamentTeam{
+		{Id: 1, Members: []*db.TournamentTeamMember{{UserId: 10}, {UserId: 11}}},
+		{Id: 2, Members: []*db.TournamentTeamMember{{UserId: 20}}},
+		{Id: 3, Members: []*db.TournamentTeamMember{{UserId: 30}}},
+	}
+
+	scores := []*db.TournamentQualifierScore{
+		{UserId: 10, MapMD5: "a", Score: 400_000},
+		{UserId: 11, MapMD5: "a", Score: 400_000},
+		{UserId: 20, MapMD5: "a", Score: 900_000},
+		{UserId: 10, MapMD5: "b", Score: 300_000},
+		{UserId: 20, MapMD5: "b", Score: 900_000},
+		{UserId: 30, MapMD5: "b", Score: 950_000},
+		{UserId: 99, MapMD5: "b", Score: 1_000_000},
+	}
+
+	seeded := CalculateSeeds(teams, scores)
+
+	// Team 2 places 1st & 2nd, team 1 places 2nd & 3rd, and team 3 is placed last on the map it didn't play
+	for i, id := range []int{2, 3, 1} {
+		if seeded[i].Id != id {
+			t.Errorf("expected team #%v to be seed %v, got #%v", id, i+1, seeded[i].Id)
+		}
+	}
+}
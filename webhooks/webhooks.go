@@ -308,6 +308,35 @@ func SendErrorEventWebhook(title string, message string) error {
 	return nil
 }
 
+// SendAntiCheatWebhook Sends a webhook displaying that a score was flagged by the anti-cheat
+func SendAntiCheatWebhook(score *db.RedisScore, mapQua *db.MapQua, review *db.ScoreReview, flags []string) error {
+	if events == nil {
+		return nil
+	}
+
+	embed := discord.NewEmbedBuilder().
+		SetTitle("🚩 Score Flagged By Anti-Cheat").
+		SetDescription(strings.Join(flags, "\n")).
+		AddField("User", fmt.Sprintf("[%v](https://quavergame.com/user/%v)", score.User.Username, score.User.Id), true).
+		AddField("Score", fmt.Sprintf("#%v (%.2f%%)", score.Score.Id, score.Score.Accuracy), true).
+		AddField("Review", fmt.Sprintf("#%v", review.Id), true).
+		AddField("Map", fmt.Sprintf("[%v](https://quavergame.com/mapset/map/%v)", mapQua, mapQua.Id), false).
+		SetThumbnail(QuaverLogo).
+		SetFooter("Quaver", QuaverLogo).
+		SetTimestamp(time.Now()).
+		SetColor(0xFFA500).
+		Build()
+
+	_, err := events.CreateEmbeds([]discord.Embed{embed})
+
+	if err != nil {
+		logrus.Error("Failed to send anti-cheat webhook: ", err)
+		return err
+	}
+
+	return nil
+}
+
 func SendSupervisorActivityWebhook(results map[*db.User]int, timeStart int64, timeEnd int64) error {
 	if teamAnnounce == nil {
 		return nil